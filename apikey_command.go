@@ -0,0 +1,113 @@
+package main
+
+import (
+	"books-api/app/models"
+	"books-api/app/service"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// apiKeyUsage describes the apikey subcommand
+const apiKeyUsage = `usage:
+  books-api apikey issue -name NAME -scopes books:read,books:write,admin [-tenant SLUG] [-expires 720h]
+  books-api apikey list
+  books-api apikey revoke ID`
+
+// runAPIKeyCommand issues, lists and revokes API keys from the command line.
+// It is the way to bootstrap the first admin key.
+func runAPIKeyCommand(args []string, keyService service.APIKeyService, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing apikey command\n%s", apiKeyUsage)
+	}
+
+	switch args[0] {
+	case "issue":
+		flags := flag.NewFlagSet("apikey issue", flag.ContinueOnError)
+		flags.SetOutput(out)
+		name := flags.String("name", "", "name describing who uses the key")
+		scopes := flags.String("scopes", string(models.ScopeBooksRead), "comma separated scopes")
+		tenant := flags.String("tenant", "", "slug of the tenant the key is bound to, empty for a platform key")
+		expires := flags.Duration("expires", 0, "lifetime of the key, 0 for no expiry")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		var scopeList []models.Scope
+		for _, scope := range strings.Split(*scopes, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				scopeList = append(scopeList, models.Scope(scope))
+			}
+		}
+		var expiresAt *time.Time
+		if *expires > 0 {
+			at := time.Now().Add(*expires)
+			expiresAt = &at
+		}
+
+		issued, err := keyService.IssueKey(context.Background(), *name, *tenant, scopeList, expiresAt)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Issued API key %d (%s) with scopes %s\n", issued.ID, issued.Name, joinScopes(issued.Scopes))
+		fmt.Fprintf(out, "Key: %s\n", issued.Key)
+		fmt.Fprintln(out, "Store it now, it cannot be shown again.")
+		return nil
+
+	case "list":
+		keys, err := keyService.ListKeys(context.Background())
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tTENANT\tEXPIRES\tLAST USED\tREVOKED")
+		for _, key := range keys {
+			tenant := key.Tenant
+			if tenant == "" {
+				tenant = "-"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Prefix, joinScopes(key.Scopes), tenant,
+				formatOptionalTime(key.ExpiresAt), formatOptionalTime(key.LastUsedAt), formatOptionalTime(key.RevokedAt))
+		}
+		return w.Flush()
+
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf("revoke takes exactly one key ID\n%s", apiKeyUsage)
+		}
+		id, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid API key ID: %s", args[1])
+		}
+		key, err := keyService.RevokeKey(context.Background(), uint(id))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Revoked API key %d (%s)\n", key.ID, key.Name)
+		return nil
+	}
+
+	return fmt.Errorf("unknown apikey command %q\n%s", args[0], apiKeyUsage)
+}
+
+// joinScopes renders scopes as a comma separated list
+func joinScopes(scopes []models.Scope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, ",")
+}
+
+// formatOptionalTime renders a nullable timestamp for tabular output
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
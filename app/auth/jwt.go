@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"books-api/app/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig describes which bearer tokens are accepted
+type JWTConfig struct {
+	// Issuer must match the iss claim when set
+	Issuer string
+	// Audience must be contained in the aud claim when set
+	Audience string
+	// Algorithms lists the accepted signing algorithms; defaults to RS256,
+	// ES256 and HS256
+	Algorithms []string
+	// Leeway tolerates clock skew when checking exp and nbf
+	Leeway time.Duration
+}
+
+// TokenVerifier turns a bearer token into a principal
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*Principal, error)
+}
+
+// JWTVerifier validates signed JWTs against a key source
+type JWTVerifier struct {
+	config JWTConfig
+	keys   KeySource
+	parser *jwt.Parser
+}
+
+// NewJWTVerifier creates a verifier that checks signatures with keys and
+// validates the standard claims configured in config
+func NewJWTVerifier(config JWTConfig, keys KeySource) *JWTVerifier {
+	if len(config.Algorithms) == 0 {
+		config.Algorithms = []string{"RS256", "ES256", "HS256"}
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(config.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+
+	return &JWTVerifier{
+		config: config,
+		keys:   keys,
+		parser: jwt.NewParser(options...),
+	}
+}
+
+// tokenClaims are the registered claims plus the authorization claims the
+// API understands
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	// Scope is the space separated OAuth 2.0 scope claim
+	Scope string `json:"scope,omitempty"`
+	// Scp is the list form of the scope claim used by some issuers
+	Scp   []string `json:"scp,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+	// Tenant is the slug of the tenant the token was issued for
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Verify checks the token signature and claims. The key type returned by
+// the key source must match the signing method, so an HS256 token cannot be
+// verified with a public key.
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	var claims tokenClaims
+	_, err := v.parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid, t.Method.Alg())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("invalid token: missing subject")
+	}
+
+	scopeNames := claims.Scp
+	if claims.Scope != "" {
+		scopeNames = append(scopeNames, strings.Fields(claims.Scope)...)
+	}
+	var scopes []models.Scope
+	for _, name := range scopeNames {
+		if scope := models.Scope(name); scope.IsValid() {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &Principal{
+		Subject: claims.Subject,
+		Issuer:  claims.Issuer,
+		Method:  MethodJWT,
+		Scopes:  scopes,
+		Roles:   claims.Roles,
+		Tenant:  strings.ToLower(claims.Tenant),
+	}, nil
+}
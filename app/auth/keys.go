@@ -0,0 +1,282 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// KeySource resolves the key that verifies a token signed with alg. kid is
+// the key ID from the token header and may be empty.
+type KeySource interface {
+	Key(ctx context.Context, kid, alg string) (interface{}, error)
+}
+
+// StaticKeySource verifies every token with a single configured key
+type StaticKeySource struct {
+	key interface{}
+}
+
+// NewHMACKeySource creates a key source for HS256 tokens
+func NewHMACKeySource(secret []byte) *StaticKeySource {
+	return &StaticKeySource{key: secret}
+}
+
+// NewPublicKeySource creates a key source from a PEM encoded RSA or ECDSA
+// public key or certificate
+func NewPublicKeySource(pemData []byte) (*StaticKeySource, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		key = cert.PublicKey
+	case "RSA PUBLIC KEY":
+		rsaKey, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA public key: %w", err)
+		}
+		key = rsaKey
+	default:
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		key = parsed
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return &StaticKeySource{key: key}, nil
+	}
+	return nil, fmt.Errorf("unsupported public key type %T", key)
+}
+
+// Key returns the configured key regardless of kid
+func (s *StaticKeySource) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	return s.key, nil
+}
+
+// JWKSKeySource fetches signing keys from a JWKS endpoint. Keys are cached
+// for the refresh interval; a token naming an unknown kid triggers an early
+// refresh so that rotated keys are picked up, at most once per
+// minRefreshInterval. Concurrent refreshes share one fetch, which runs
+// without holding the lock on the key set.
+type JWKSKeySource struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	fetches            singleflight.Group
+	logger             *slog.Logger
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// NewJWKSKeySource creates a key source backed by the JWKS document at url.
+// Fetches are logged through logger.
+func NewJWKSKeySource(url string, refreshInterval time.Duration, logger *slog.Logger) *JWKSKeySource {
+	if refreshInterval <= 0 {
+		refreshInterval = time.Hour
+	}
+	return &JWKSKeySource{
+		url:                url,
+		client:             &http.Client{Timeout: 10 * time.Second},
+		refreshInterval:    refreshInterval,
+		minRefreshInterval: 10 * time.Second,
+		logger:             logger,
+	}
+}
+
+// Key returns the cached key with the given kid, refreshing the key set when
+// it is stale or does not know the kid
+func (s *JWKSKeySource) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	keys, fetchedAt := s.snapshot()
+	if keys == nil || time.Since(fetchedAt) >= s.refreshInterval {
+		err := s.refresh(ctx)
+		if keys, fetchedAt = s.snapshot(); err != nil && keys == nil {
+			return nil, err
+		}
+	}
+
+	if key, ok := lookup(keys, kid); ok {
+		return key, nil
+	}
+
+	if time.Since(fetchedAt) >= s.minRefreshInterval {
+		s.logger.InfoContext(ctx, "Unknown JWKS key, refreshing key set", "kid", kid)
+		if err := s.refresh(ctx); err != nil {
+			return nil, err
+		}
+		keys, _ = s.snapshot()
+		if key, ok := lookup(keys, kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// snapshot returns the current key set and when it was fetched
+func (s *JWKSKeySource) snapshot() (map[string]interface{}, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys, s.fetchedAt
+}
+
+// lookup finds a key by kid; without a kid it only succeeds for a
+// single-key set
+func lookup(keys map[string]interface{}, kid string) (interface{}, bool) {
+	if kid == "" {
+		if len(keys) == 1 {
+			for _, key := range keys {
+				return key, true
+			}
+		}
+		return nil, false
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+// refresh replaces the key set with a freshly fetched one. Callers refreshing
+// at the same time wait for the same fetch. Failed fetches keep the previous
+// keys.
+func (s *JWKSKeySource) refresh(ctx context.Context) error {
+	_, err, _ := s.fetches.Do(s.url, func() (interface{}, error) {
+		keys, err := s.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.keys = keys
+		s.fetchedAt = time.Now()
+		s.mu.Unlock()
+		return nil, nil
+	})
+	return err
+}
+
+// fetch downloads and parses the key set. ctx is only used for logging:
+// the fetch is shared with other callers and must not end with this one.
+func (s *JWKSKeySource) fetch(ctx context.Context) (map[string]interface{}, error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch JWKS", "url", s.url, "error", err)
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		s.logger.ErrorContext(ctx, "JWKS endpoint returned an error", "url", s.url, "status", resp.StatusCode)
+		return nil, fmt.Errorf("failed to fetch JWKS: %s", resp.Status)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			s.logger.WarnContext(ctx, "Skipping JWKS key", "kid", jwk.Kid, "error", err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	s.logger.InfoContext(ctx, "Loaded signing keys from JWKS", "keys", len(keys))
+	return keys, nil
+}
+
+// jwkSet is a JSON Web Key Set document (RFC 7517)
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwk is a single JSON Web Key; only the members needed for RSA, EC and
+// symmetric verification keys are decoded
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// publicKey converts the JWK into a verification key
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key parameter: %w", err)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"books-api/app/models"
+	"context"
+)
+
+const (
+	MethodAPIKey     = "api_key"
+	MethodJWT        = "jwt"
+	MethodClientCert = "client_cert"
+)
+
+// Principal is the authenticated caller of a request, independent of how it
+// authenticated
+type Principal struct {
+	Subject string         `json:"subject"`
+	Issuer  string         `json:"issuer,omitempty"`
+	Method  string         `json:"method"`
+	Scopes  []models.Scope `json:"scopes"`
+	Roles   []string       `json:"roles,omitempty"`
+	// Tenant is the slug of the only tenant the principal may act for;
+	// empty for platform credentials
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// HasScope reports whether the principal holds scope, directly or through admin
+func (p *Principal) HasScope(scope models.Scope) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope || granted == models.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// principalKey is the context key under which the principal is stored
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored in ctx, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
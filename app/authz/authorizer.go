@@ -0,0 +1,112 @@
+package authz
+
+import (
+	"books-api/app/auth"
+	"books-api/app/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ForbiddenError is returned when the policy denies an operation
+type ForbiddenError struct {
+	Subject string
+	Action  Action
+}
+
+func (e *ForbiddenError) Error() string {
+	subject := e.Subject
+	if subject == "" {
+		subject = "anonymous"
+	}
+	return fmt.Sprintf("forbidden: %s may not %s", subject, e.Action)
+}
+
+// IsForbidden reports whether err is or wraps a ForbiddenError
+func IsForbidden(err error) bool {
+	var forbidden *ForbiddenError
+	return errors.As(err, &forbidden)
+}
+
+// Authorizer decides whether the principal in ctx may perform an action.
+// owner is the subject that created the resource, or empty when the action
+// does not target an owned resource.
+type Authorizer interface {
+	Authorize(ctx context.Context, action Action, owner string) error
+}
+
+// policyAuthorizer implements Authorizer on top of a Policy
+type policyAuthorizer struct {
+	policy *Policy
+	logger *slog.Logger
+}
+
+// NewAuthorizer creates an authorizer enforcing policy. Denials are logged
+// through logger.
+func NewAuthorizer(policy *Policy, logger *slog.Logger) Authorizer {
+	return &policyAuthorizer{
+		policy: policy,
+		logger: logger,
+	}
+}
+
+// Authorize allows the action when any role of the principal allows it
+func (a *policyAuthorizer) Authorize(ctx context.Context, action Action, owner string) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		if a.policy.AnonymousRole != "" && a.policy.allows(a.policy.AnonymousRole, action, false) {
+			return nil
+		}
+		a.logger.WarnContext(ctx, "Denied anonymous request", "action", action)
+		return &ForbiddenError{Action: action}
+	}
+
+	isOwner := owner != "" && owner == principal.Subject
+	for _, role := range a.rolesOf(principal) {
+		if a.policy.allows(role, action, isOwner) {
+			return nil
+		}
+	}
+
+	a.logger.WarnContext(ctx, "Denied request", "action", action, "subject", principal.Subject)
+	return &ForbiddenError{Subject: principal.Subject, Action: action}
+}
+
+// rolesOf returns the policy roles of a principal. Explicit roles come from
+// token claims; otherwise API key scopes map onto the closest role.
+func (a *policyAuthorizer) rolesOf(principal *auth.Principal) []Role {
+	var roles []Role
+	for _, name := range principal.Roles {
+		if _, ok := a.policy.Roles[Role(name)]; ok {
+			roles = append(roles, Role(name))
+		}
+	}
+	if len(roles) > 0 {
+		return roles
+	}
+
+	switch {
+	case principal.HasScope(models.ScopeAdmin):
+		return []Role{RoleAdmin}
+	case principal.Method == auth.MethodAPIKey && principal.HasScope(models.ScopeBooksWrite):
+		return []Role{RoleLibrarian}
+	}
+	if a.policy.DefaultRole != "" {
+		return []Role{a.policy.DefaultRole}
+	}
+	return nil
+}
+
+// allowAll implements Authorizer without any checks
+type allowAll struct{}
+
+// AllowAll returns an authorizer that permits everything. It is meant for
+// trusted in-process callers and tests.
+func AllowAll() Authorizer {
+	return allowAll{}
+}
+
+func (allowAll) Authorize(ctx context.Context, action Action, owner string) error {
+	return nil
+}
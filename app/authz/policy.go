@@ -0,0 +1,139 @@
+package authz
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Role is a named set of permissions
+type Role string
+
+const (
+	RoleViewer    Role = "viewer"
+	RoleEditor    Role = "editor"
+	RoleLibrarian Role = "librarian"
+	RoleAdmin     Role = "admin"
+)
+
+// Action is an operation checked by the policy
+type Action string
+
+const (
+	ActionBookRead   Action = "book:read"
+	ActionBookCreate Action = "book:create"
+	ActionBookUpdate Action = "book:update"
+	ActionBookDelete Action = "book:delete"
+
+	ActionReviewCreate Action = "review:create"
+	ActionReviewUpdate Action = "review:update"
+	ActionReviewDelete Action = "review:delete"
+	// ActionReviewModerate approves and rejects reviews, and reads those
+	// not approved
+	ActionReviewModerate Action = "review:moderate"
+)
+
+// AllActions matches every action in a role's allow list
+const AllActions Action = "*"
+
+// RolePolicy lists what a role may do. Actions in AllowOwn are only allowed
+// on resources the principal created.
+type RolePolicy struct {
+	Allow    []Action `json:"allow"`
+	AllowOwn []Action `json:"allow_own,omitempty"`
+}
+
+// Policy maps roles to permissions
+type Policy struct {
+	// AnonymousRole applies to calls without a principal, e.g. public reads.
+	// Empty means anonymous calls are denied.
+	AnonymousRole Role `json:"anonymous_role"`
+	// DefaultRole applies to principals that carry no known role
+	DefaultRole Role                `json:"default_role"`
+	Roles       map[Role]RolePolicy `json:"roles"`
+}
+
+// DefaultPolicy is used when no policy file is configured
+func DefaultPolicy() *Policy {
+	return &Policy{
+		AnonymousRole: RoleViewer,
+		DefaultRole:   RoleViewer,
+		Roles: map[Role]RolePolicy{
+			RoleViewer: {
+				Allow:    []Action{ActionBookRead, ActionReviewCreate},
+				AllowOwn: []Action{ActionReviewUpdate, ActionReviewDelete},
+			},
+			RoleEditor: {
+				Allow:    []Action{ActionBookRead, ActionBookCreate, ActionReviewCreate},
+				AllowOwn: []Action{ActionBookUpdate, ActionBookDelete, ActionReviewUpdate, ActionReviewDelete},
+			},
+			RoleLibrarian: {
+				Allow: []Action{
+					ActionBookRead, ActionBookCreate, ActionBookUpdate, ActionBookDelete,
+					ActionReviewCreate, ActionReviewDelete, ActionReviewModerate,
+				},
+				AllowOwn: []Action{ActionReviewUpdate},
+			},
+			RoleAdmin: {
+				Allow: []Action{AllActions},
+			},
+		},
+	}
+}
+
+// LoadPolicy reads a JSON policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate checks that the roles the policy refers to are defined
+func (p *Policy) Validate() error {
+	if len(p.Roles) == 0 {
+		return fmt.Errorf("policy defines no roles")
+	}
+	if p.AnonymousRole != "" {
+		if _, ok := p.Roles[p.AnonymousRole]; !ok {
+			return fmt.Errorf("anonymous role %q is not defined", p.AnonymousRole)
+		}
+	}
+	if p.DefaultRole != "" {
+		if _, ok := p.Roles[p.DefaultRole]; !ok {
+			return fmt.Errorf("default role %q is not defined", p.DefaultRole)
+		}
+	}
+	return nil
+}
+
+// allows reports whether role may perform action on a resource, given
+// whether the principal owns it
+func (p *Policy) allows(role Role, action Action, owner bool) bool {
+	rolePolicy, ok := p.Roles[role]
+	if !ok {
+		return false
+	}
+	if containsAction(rolePolicy.Allow, action) {
+		return true
+	}
+	return owner && containsAction(rolePolicy.AllowOwn, action)
+}
+
+func containsAction(actions []Action, action Action) bool {
+	for _, candidate := range actions {
+		if candidate == action || candidate == AllActions {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,319 @@
+package backup
+
+import (
+	"books-api/app/migrations"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrUnsupported is returned for databases other than SQLite, which have
+// their own tools such as pg_dump
+var ErrUnsupported = errors.New("backups are only supported on SQLite")
+
+// nameLayout timestamps snapshot names so they sort by age
+const nameLayout = "20060102T150405.000Z"
+
+// manifestSuffix is appended to a snapshot's file name to name its manifest
+const manifestSuffix = ".json"
+
+// Snapshot describes a backup, as recorded in the manifest next to it
+type Snapshot struct {
+	Name          string    `json:"name" example:"books-20261018T021500.000Z.db"`
+	Size          int64     `json:"size" example:"49152"`
+	SHA256        string    `json:"sha256"`
+	SchemaVersion int       `json:"schema_version" example:"1"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Manager creates and lists snapshots of a live database
+type Manager struct {
+	db         *gorm.DB
+	config     Config
+	migrations migrations.MigrationManager
+	// mu serializes backups, which rotate the snapshots of each other
+	mu sync.Mutex
+}
+
+// NewManager creates a manager writing snapshots of db to config.Dir
+func NewManager(db *gorm.DB, config Config, migrationManager migrations.MigrationManager) *Manager {
+	return &Manager{
+		db:         db,
+		config:     config,
+		migrations: migrationManager,
+	}
+}
+
+// Create writes a consistent snapshot with VACUUM INTO while the database
+// stays in use, records its checksum and schema version in a manifest and
+// removes the oldest snapshots beyond Keep
+func (m *Manager) Create(ctx context.Context) (*Snapshot, error) {
+	if m.db.Dialector.Name() != "sqlite" {
+		return nil, ErrUnsupported
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.config.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+	db := m.db.WithContext(ctx)
+	version, err := m.migrations.AppliedVersion(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	// Backups within the same millisecond get the next free name
+	createdAt := time.Now().UTC()
+	name := "books-" + createdAt.Format(nameLayout) + ".db"
+	path := filepath.Join(m.config.Dir, name)
+	for _, err := os.Stat(path); err == nil; _, err = os.Stat(path) {
+		createdAt = createdAt.Add(time.Millisecond)
+		name = "books-" + createdAt.Format(nameLayout) + ".db"
+		path = filepath.Join(m.config.Dir, name)
+	}
+	partial := path + ".partial"
+	os.Remove(partial)
+	if err := vacuumInto(db, partial); err != nil {
+		os.Remove(partial)
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	sum, size, err := checksum(partial)
+	if err == nil {
+		err = os.Rename(partial, path)
+	}
+	if err != nil {
+		os.Remove(partial)
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	snapshot := Snapshot{Name: name, Size: size, SHA256: sum, SchemaVersion: version, CreatedAt: createdAt}
+	if err := writeManifest(path, snapshot); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+	if err := m.rotate(); err != nil {
+		return nil, fmt.Errorf("failed to rotate backups: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// vacuumInto copies the last committed state of db to path over a read-only
+// connection of its own, so that writers do not queue behind the single
+// write connection while the copy runs. In-memory databases, which no other
+// connection can open, are copied over db.
+func vacuumInto(db *gorm.DB, path string) error {
+	var file string
+	if err := db.Raw("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file).Error; err != nil {
+		return err
+	}
+	if file == "" {
+		return db.Exec("VACUUM INTO ?", path).Error
+	}
+
+	source, err := gorm.Open(sqlite.Open("file:"+file+"?mode=ro&_busy_timeout=5000"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := source.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	return source.WithContext(db.Statement.Context).Exec("VACUUM INTO ?", path).Error
+}
+
+// List returns the snapshots with a manifest, newest first
+func (m *Manager) List() ([]Snapshot, error) {
+	manifests, err := filepath.Glob(filepath.Join(m.config.Dir, "books-*.db"+manifestSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []Snapshot{}
+	for _, manifest := range manifests {
+		snapshot, err := readManifest(strings.TrimSuffix(manifest, manifestSuffix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backups: %w", err)
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name > snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+// rotate removes the snapshots beyond Keep, oldest first
+func (m *Manager) rotate() error {
+	if m.config.Keep == 0 {
+		return nil
+	}
+	snapshots, err := m.List()
+	if err != nil {
+		return err
+	}
+	for i := m.config.Keep; i < len(snapshots); i++ {
+		path := filepath.Join(m.config.Dir, snapshots[i].Name)
+		if err := os.Remove(path + manifestSuffix); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify checks a snapshot against its manifest and SQLite's integrity
+// check, and returns it with the schema version stored in the file
+func Verify(path string, migrationManager migrations.MigrationManager) (*Snapshot, error) {
+	snapshot, err := readManifest(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	sum, size, err := checksum(path)
+	if err != nil {
+		return nil, err
+	}
+	if sum != snapshot.SHA256 || size != snapshot.Size {
+		return nil, fmt.Errorf("checksum mismatch: %s is damaged", snapshot.Name)
+	}
+
+	db, err := gorm.Open(sqlite.Open("file:"+path+"?mode=ro"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDB.Close()
+
+	var result string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	if result != "ok" {
+		return nil, fmt.Errorf("integrity check failed: %s", result)
+	}
+	if snapshot.SchemaVersion, err = migrationManager.AppliedVersion(db); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Restore replaces the SQLite database file target with a verified
+// snapshot. Snapshots of a newer schema than migrationManager migrates to
+// are refused; older ones are migrated when the API next starts. Nothing
+// may have target open, so the API has to be stopped.
+func Restore(path, target string, migrationManager migrations.MigrationManager) (*Snapshot, error) {
+	snapshot, err := Verify(path, migrationManager)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case snapshot.SchemaVersion == 0:
+		return nil, fmt.Errorf("snapshot %s has no schema version", snapshot.Name)
+	case snapshot.SchemaVersion > migrationManager.Version():
+		return nil, fmt.Errorf("snapshot schema version %d is newer than %d", snapshot.SchemaVersion, migrationManager.Version())
+	}
+
+	restoring := target + ".restoring"
+	if err := copyFile(path, restoring); err != nil {
+		os.Remove(restoring)
+		return nil, err
+	}
+	// A write-ahead log left next to target belongs to the old database
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(target + suffix); err != nil && !os.IsNotExist(err) {
+			os.Remove(restoring)
+			return nil, err
+		}
+	}
+	if err := os.Rename(restoring, target); err != nil {
+		os.Remove(restoring)
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// checksum returns the hex SHA-256 and size of the file at path
+func checksum(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// writeManifest atomically writes the manifest of the snapshot at path
+func writeManifest(path string, snapshot Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	partial := path + manifestSuffix + ".partial"
+	if err := os.WriteFile(partial, data, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(partial, path+manifestSuffix)
+}
+
+// readManifest reads the manifest of the snapshot at path
+func readManifest(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path + manifestSuffix)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// copyFile copies src to dst and flushes it to disk
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
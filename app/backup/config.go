@@ -0,0 +1,39 @@
+package backup
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Config selects where snapshots are written and how many are kept
+type Config struct {
+	// Dir holds the snapshots and their checksum files
+	Dir string
+	// Keep is the number of snapshots kept after each backup; 0 keeps all
+	Keep int
+}
+
+// DefaultConfig returns the config used unless configured otherwise
+func DefaultConfig() Config {
+	return Config{Dir: "backups", Keep: 7}
+}
+
+// LoadConfig builds a config from environment style lookups:
+//
+//	BACKUP_DIR    directory of the snapshots, backups unless set
+//	BACKUP_KEEP   number of snapshots kept, 7 unless set; 0 keeps all
+func LoadConfig(lookup func(string) (string, bool)) (Config, error) {
+	config := DefaultConfig()
+
+	if value, ok := lookup("BACKUP_DIR"); ok && value != "" {
+		config.Dir = value
+	}
+	if value, ok := lookup("BACKUP_KEEP"); ok && value != "" {
+		keep, err := strconv.Atoi(value)
+		if err != nil || keep < 0 {
+			return Config{}, fmt.Errorf("BACKUP_KEEP: must be a number of snapshots")
+		}
+		config.Keep = keep
+	}
+	return config, nil
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Store holds encoded values under string keys until they expire. Values
+// are bytes so that a shared backend such as Redis can implement it, and so
+// callers never share a cached object.
+type Store interface {
+	// Get returns the value under key, or false when it is missing or
+	// expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key if present
+	Delete(ctx context.Context, key string) error
+}
+
+// Stats counts the lookups of a cache
+type Stats struct {
+	Hits   uint64 `json:"hits" example:"1200"`
+	Misses uint64 `json:"misses" example:"35"`
+	// Loads counts the queries made for misses; concurrent misses of the
+	// same entry share one
+	Loads uint64 `json:"loads" example:"31"`
+}
+
+// Config sizes the in-memory book cache
+type Config struct {
+	// Size is the number of entries kept; 0 disables the cache
+	Size int
+	// TTL bounds how long an entry is served. Changes made outside the
+	// cached repository and units of work, or whose invalidation failed,
+	// show after at most this long.
+	TTL time.Duration
+}
+
+// DefaultConfig returns the config used unless configured otherwise
+func DefaultConfig() Config {
+	return Config{Size: 1000, TTL: 30 * time.Second}
+}
+
+// Enabled reports whether the cache is used
+func (c Config) Enabled() bool {
+	return c.Size > 0 && c.TTL > 0
+}
+
+// LoadConfig builds a config from environment style lookups:
+//
+//	BOOK_CACHE_SIZE   books kept in memory, 1000 unless set; 0 disables
+//	BOOK_CACHE_TTL    how long a book is served from memory, 30s unless set
+func LoadConfig(lookup func(string) (string, bool)) (Config, error) {
+	config := DefaultConfig()
+
+	if value, ok := lookup("BOOK_CACHE_SIZE"); ok && value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return Config{}, fmt.Errorf("BOOK_CACHE_SIZE: must be a number of books")
+		}
+		config.Size = size
+	}
+	if value, ok := lookup("BOOK_CACHE_TTL"); ok && value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl < 0 {
+			return Config{}, fmt.Errorf("BOOK_CACHE_TTL: invalid duration %s", value)
+		}
+		config.TTL = ttl
+	}
+	return config, nil
+}
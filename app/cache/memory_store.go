@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// entry is a value in the recency list
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// memoryStore implements Store in process memory as an LRU with expiry
+type memoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	// recency holds the entries, most recently used first
+	recency *list.List
+	entries map[string]*list.Element
+}
+
+// NewMemoryStore creates a store that keeps up to capacity values in
+// memory, evicting the least recently used. Each instance of the API has
+// its own.
+func NewMemoryStore(capacity int) Store {
+	return &memoryStore{
+		capacity: capacity,
+		recency:  list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value under key and marks it as recently used
+func (s *memoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := element.Value.(*entry)
+	if !time.Now().Before(e.expires) {
+		s.remove(element)
+		return nil, false, nil
+	}
+	s.recency.MoveToFront(element)
+	return e.value, true, nil
+}
+
+// Set stores value under key, evicting the least recently used value when
+// the store is full
+func (s *memoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expires := time.Now().Add(ttl)
+	if element, ok := s.entries[key]; ok {
+		e := element.Value.(*entry)
+		e.value, e.expires = value, expires
+		s.recency.MoveToFront(element)
+		return nil
+	}
+
+	s.entries[key] = s.recency.PushFront(&entry{key: key, value: value, expires: expires})
+	for s.recency.Len() > s.capacity {
+		s.remove(s.recency.Back())
+	}
+	return nil
+}
+
+// Delete removes key if present
+func (s *memoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+	return nil
+}
+
+// remove drops element from the list and the index
+func (s *memoryStore) remove(element *list.Element) {
+	s.recency.Remove(element)
+	delete(s.entries, element.Value.(*entry).key)
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"books-api/app/database"
+	"books-api/app/models"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyController handles HTTP requests for API key administration
+type APIKeyController struct {
+	keyService service.APIKeyService
+}
+
+// NewAPIKeyController creates a new instance of API key controller
+func NewAPIKeyController(keyService service.APIKeyService) *APIKeyController {
+	return &APIKeyController{
+		keyService: keyService,
+	}
+}
+
+// IssueAPIKeyRequest is the payload for issuing an API key
+type IssueAPIKeyRequest struct {
+	Name      string         `json:"name" binding:"required"`
+	Scopes    []models.Scope `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time     `json:"expires_at"`
+	// Tenant binds the key to one tenant by slug; empty issues a platform key
+	Tenant string `json:"tenant"`
+}
+
+// apiKeyErrorStatus maps API key service errors to HTTP status codes
+func apiKeyErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	switch {
+	case err.Error() == "API key not found", err.Error() == "tenant not found":
+		return http.StatusNotFound
+	case err.Error() == "API key already revoked":
+		return http.StatusConflict
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// IssueAPIKey godoc
+// @Summary      Issue an API key
+// @Description  Creates an API key with the given scopes; the plaintext key is only returned in this response
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        key body IssueAPIKeyRequest true "Key name, scopes, optional tenant and expiry"
+// @Success      201 {object} models.IssuedAPIKey
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/api-keys [post]
+func (ctrl *APIKeyController) IssueAPIKey(c *gin.Context) {
+	var req IssueAPIKeyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	issued, err := ctrl.keyService.IssueKey(c.Request.Context(), req.Name, req.Tenant, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, issued)
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  Get all API keys, including revoked ones, without their secrets
+// @Tags         admin
+// @Produce      json
+// @Success      200 {array} models.APIKey
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/api-keys [get]
+func (ctrl *APIKeyController) ListAPIKeys(c *gin.Context) {
+	keys, err := ctrl.keyService.ListKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Permanently disables an API key
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "API key ID"
+// @Success      200 {object} models.APIKey
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/api-keys/{id} [delete]
+func (ctrl *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID"})
+		return
+	}
+
+	key, err := ctrl.keyService.RevokeKey(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"books-api/app/backup"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BackupController handles HTTP requests for database snapshots
+type BackupController struct {
+	backups *backup.Manager
+}
+
+// NewBackupController creates a new instance of backup controller
+func NewBackupController(backups *backup.Manager) *BackupController {
+	return &BackupController{
+		backups: backups,
+	}
+}
+
+// backupErrorStatus maps backup errors to HTTP status codes
+func backupErrorStatus(err error) int {
+	if errors.Is(err, backup.ErrUnsupported) {
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
+
+// CreateBackup godoc
+// @Summary      Create a backup
+// @Description  Writes a consistent snapshot of the SQLite database to BACKUP_DIR with a checksum manifest, removing the oldest snapshots beyond BACKUP_KEEP
+// @Tags         admin
+// @Produce      json
+// @Success      201 {object} backup.Snapshot
+// @Failure      500 {object} map[string]string
+// @Failure      501 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/backups [post]
+func (ctrl *BackupController) CreateBackup(c *gin.Context) {
+	snapshot, err := ctrl.backups.Create(c.Request.Context())
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, snapshot)
+}
+
+// ListBackups godoc
+// @Summary      List backups
+// @Description  Returns the snapshots in BACKUP_DIR, newest first
+// @Tags         admin
+// @Produce      json
+// @Success      200 {array} backup.Snapshot
+// @Failure      500 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/backups [get]
+func (ctrl *BackupController) ListBackups(c *gin.Context) {
+	snapshots, err := ctrl.backups.List()
+	if err != nil {
+		c.JSON(backupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshots)
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindJSON decodes the request body into obj. It answers 413 when the body
+// is over the size limit and 400 for any other problem, and reports
+// whether the handler may go on.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	return bindError(c, c.ShouldBindJSON(obj))
+}
+
+// bindStrictJSON is bindJSON for payloads where misspelt or unsupported
+// fields must be reported instead of silently dropped: unknown fields and
+// trailing data are rejected with 400.
+func bindStrictJSON(c *gin.Context, obj interface{}) bool {
+	if c.Request.Body == nil {
+		return bindError(c, errors.New("request body is empty"))
+	}
+
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("request body is empty")
+		}
+		return bindError(c, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after JSON body")
+		}
+		return bindError(c, err)
+	}
+	return bindError(c, binding.Validator.ValidateStruct(obj))
+}
+
+// bindError writes the response for a failed bind
+func bindError(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	return false
+}
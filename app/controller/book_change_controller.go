@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"books-api/app/authz"
+	"books-api/app/database"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BookChangeController handles HTTP requests for the incremental sync of
+// books
+type BookChangeController struct {
+	changeService service.BookChangeService
+}
+
+// NewBookChangeController creates a new instance of book change controller
+func NewBookChangeController(changeService service.BookChangeService) *BookChangeController {
+	return &BookChangeController{
+		changeService: changeService,
+	}
+}
+
+// bookChangeErrorStatus maps book change service errors to HTTP status
+// codes
+func bookChangeErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	switch {
+	case authz.IsForbidden(err):
+		return http.StatusForbidden
+	case err.Error() == "sync token expired":
+		return http.StatusGone
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// ListBookChanges godoc
+// @Summary      List book changes since a sync token
+// @Description  Returns the books written and deleted since the sync token, oldest change first: upserts carry the current book, deletes only its ID. Without a token every book is returned. Pass next_token as since to continue; has_more tells that more changes are waiting. A token older than the tombstone retention is answered with 410 and the client has to sync from scratch.
+// @Tags         books
+// @Produce      json
+// @Param        since query string false "Sync token from the previous response"
+// @Param        limit query int    false "Maximum number of changes (1-1000, default 100)"
+// @Success      200 {object} models.BookChangePage
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      410 {object} map[string]string
+// @Router       /books/changes [get]
+func (ctrl *BookChangeController) ListBookChanges(c *gin.Context) {
+	limit := service.DefaultChangeLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+
+	page, err := ctrl.changeService.GetChanges(c.Request.Context(), c.Query("since"), limit)
+	if err != nil {
+		c.JSON(bookChangeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"books-api/app/authz"
+	"books-api/app/database"
 	"books-api/app/service"
 	"books-api/app/models"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,23 +26,32 @@ func NewBookController(bookService service.BookService) *BookController {
 
 // CreateBook godoc
 // @Summary      Create a new book
-// @Description  Adds a new book to the database
+// @Description  Adds a new book to the catalogue of the request's tenant; 403 once the tenant's book quota is reached. Unknown fields are rejected
 // @Tags         books
 // @Accept       json
 // @Produce      json
 // @Param        book body models.Book true "Book data"
 // @Success      201 {object} models.Book
 // @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      413 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /books [post]
 func (ctrl *BookController) CreateBook(c *gin.Context) {
 	var book models.Book
-	if err := c.ShouldBindJSON(&book); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindStrictJSON(c, &book) {
 		return
 	}
 
-	if err := ctrl.bookService.CreateBook(&book); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := ctrl.bookService.CreateBook(c.Request.Context(), &book); err != nil {
+		if authz.IsForbidden(err) || err.Error() == "book quota exceeded" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if database.IsBusy(err) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -48,15 +60,40 @@ func (ctrl *BookController) CreateBook(c *gin.Context) {
 
 // ListBooks godoc
 // @Summary      List all books
-// @Description  Get all books
+// @Description  Get all books, optionally filtered by tag or by category (including its subcategories)
 // @Tags         books
 // @Produce      json
+// @Param        tag      query string false "Tag name"
+// @Param        category query int    false "Category ID"
+// @Param        sort     query string false "Sort field (id, title, rating); prefix with - for descending"
 // @Success      200 {array} models.Book
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
 // @Router       /books [get]
 func (ctrl *BookController) ListBooks(c *gin.Context) {
-	books, err := ctrl.bookService.GetAllBooks()
+	filter := models.BookFilter{Tag: c.Query("tag"), Sort: c.Query("sort")}
+
+	if categoryStr := c.Query("category"); categoryStr != "" {
+		categoryID, err := strconv.ParseUint(categoryStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+			return
+		}
+		id := uint(categoryID)
+		filter.CategoryID = &id
+	}
+
+	books, err := ctrl.bookService.GetAllBooks(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if authz.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if database.IsBusy(err) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		} else if strings.HasPrefix(err.Error(), "invalid sort field") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -65,11 +102,12 @@ func (ctrl *BookController) ListBooks(c *gin.Context) {
 
 // GetBook godoc
 // @Summary      Get a book by ID
-// @Description  Returns a single book
+// @Description  Returns a single book with the availability of its copies
 // @Tags         books
 // @Produce      json
 // @Param        id path int true "Book ID"
 // @Success      200 {object} models.Book
+// @Failure      403 {object} map[string]string
 // @Failure      404 {object} map[string]string
 // @Router       /books/{id} [get]
 func (ctrl *BookController) GetBook(c *gin.Context) {
@@ -80,9 +118,15 @@ func (ctrl *BookController) GetBook(c *gin.Context) {
 		return
 	}
 
-	book, err := ctrl.bookService.GetBookByID(uint(id))
+	book, err := ctrl.bookService.GetBookByID(c.Request.Context(), uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if authz.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if database.IsBusy(err) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -91,7 +135,7 @@ func (ctrl *BookController) GetBook(c *gin.Context) {
 
 // UpdateBook godoc
 // @Summary      Update a book
-// @Description  Updates book fields by ID
+// @Description  Updates book fields by ID. Unknown fields are rejected
 // @Tags         books
 // @Accept       json
 // @Produce      json
@@ -99,6 +143,10 @@ func (ctrl *BookController) GetBook(c *gin.Context) {
 // @Param        book body models.Book true "Book data"
 // @Success      200 {object} models.Book
 // @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      413 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /books/{id} [put]
 func (ctrl *BookController) UpdateBook(c *gin.Context) {
 	idStr := c.Param("id")
@@ -109,14 +157,17 @@ func (ctrl *BookController) UpdateBook(c *gin.Context) {
 	}
 
 	var updateData models.Book
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindStrictJSON(c, &updateData) {
 		return
 	}
 
-	book, err := ctrl.bookService.UpdateBook(uint(id), updateData)
+	book, err := ctrl.bookService.UpdateBook(c.Request.Context(), uint(id), updateData)
 	if err != nil {
-		if err.Error() == "book not found" {
+		if authz.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if database.IsBusy(err) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		} else if err.Error() == "book not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -129,11 +180,16 @@ func (ctrl *BookController) UpdateBook(c *gin.Context) {
 
 // DeleteBook godoc
 // @Summary      Delete a book
-// @Description  Deletes a book by ID
+// @Description  Deletes a book by ID; books with copies cannot be deleted
 // @Tags         books
 // @Produce      json
 // @Param        id path int true "Book ID"
 // @Success      200 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
 // @Router       /books/{id} [delete]
 func (ctrl *BookController) DeleteBook(c *gin.Context) {
 	idStr := c.Param("id")
@@ -143,9 +199,15 @@ func (ctrl *BookController) DeleteBook(c *gin.Context) {
 		return
 	}
 
-	if err := ctrl.bookService.DeleteBook(uint(id)); err != nil {
-		if err.Error() == "book not found" {
+	if err := ctrl.bookService.DeleteBook(c.Request.Context(), uint(id)); err != nil {
+		if authz.IsForbidden(err) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if database.IsBusy(err) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		} else if err.Error() == "book not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if err.Error() == "book has copies" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"books-api/app/repository"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheController handles HTTP requests for the book cache metrics
+type CacheController struct {
+	books *repository.CachedBookRepository
+}
+
+// NewCacheController creates a new instance of cache controller. books is
+// nil when the cache is disabled.
+func NewCacheController(books *repository.CachedBookRepository) *CacheController {
+	return &CacheController{
+		books: books,
+	}
+}
+
+// GetCacheStats godoc
+// @Summary      Get book cache metrics
+// @Description  Returns the hits and misses of the book cache since the API started, and how many database queries the misses took
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} cache.Stats
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/cache [get]
+func (ctrl *CacheController) GetCacheStats(c *gin.Context) {
+	if ctrl.books == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "book cache is disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.books.Stats())
+}
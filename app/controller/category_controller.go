@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"books-api/app/authz"
+	"books-api/app/database"
+	"books-api/app/models"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryController handles HTTP requests for category operations
+type CategoryController struct {
+	categoryService service.CategoryService
+}
+
+// NewCategoryController creates a new instance of category controller
+func NewCategoryController(categoryService service.CategoryService) *CategoryController {
+	return &CategoryController{
+		categoryService: categoryService,
+	}
+}
+
+// BookCategoriesRequest is the payload for replacing the categories of a book
+type BookCategoriesRequest struct {
+	CategoryIDs []uint `json:"category_ids"`
+}
+
+// categoryErrorStatus maps category service errors to HTTP status codes
+func categoryErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	if authz.IsForbidden(err) {
+		return http.StatusForbidden
+	}
+	switch err.Error() {
+	case "category not found", "book not found":
+		return http.StatusNotFound
+	case "category has subcategories":
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// CreateCategory godoc
+// @Summary      Create a new category
+// @Description  Adds a new category, optionally below a parent category
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        category body models.Category true "Category data"
+// @Success      201 {object} models.Category
+// @Failure      400 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /categories [post]
+func (ctrl *CategoryController) CreateCategory(c *gin.Context) {
+	var category models.Category
+	if !bindJSON(c, &category) {
+		return
+	}
+
+	if err := ctrl.categoryService.CreateCategory(c.Request.Context(), &category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// ListCategories godoc
+// @Summary      Get the category tree
+// @Description  Get all categories nested under their parents
+// @Tags         categories
+// @Produce      json
+// @Success      200 {array} models.Category
+// @Router       /categories [get]
+func (ctrl *CategoryController) ListCategories(c *gin.Context) {
+	categories, err := ctrl.categoryService.GetCategoryTree(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// GetCategory godoc
+// @Summary      Get a category by ID
+// @Description  Returns a single category with its direct subcategories
+// @Tags         categories
+// @Produce      json
+// @Param        id path int true "Category ID"
+// @Success      200 {object} models.Category
+// @Failure      404 {object} map[string]string
+// @Router       /categories/{id} [get]
+func (ctrl *CategoryController) GetCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	category, err := ctrl.categoryService.GetCategoryByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// UpdateCategory godoc
+// @Summary      Update a category
+// @Description  Renames or moves a category; parent_id 0 moves it to the root
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Category ID"
+// @Param        category body models.Category true "Category data"
+// @Success      200 {object} models.Category
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /categories/{id} [put]
+func (ctrl *CategoryController) UpdateCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	var updateData models.Category
+	if !bindJSON(c, &updateData) {
+		return
+	}
+
+	category, err := ctrl.categoryService.UpdateCategory(c.Request.Context(), uint(id), updateData)
+	if err != nil {
+		c.JSON(categoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory godoc
+// @Summary      Delete a category
+// @Description  Deletes a category without subcategories and removes it from all books
+// @Tags         categories
+// @Produce      json
+// @Param        id path int true "Category ID"
+// @Success      200 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /categories/{id} [delete]
+func (ctrl *CategoryController) DeleteCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category ID"})
+		return
+	}
+
+	if err := ctrl.categoryService.DeleteCategory(c.Request.Context(), uint(id)); err != nil {
+		switch err.Error() {
+		case "category not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "category has subcategories":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "category deleted successfully"})
+}
+
+// SetBookCategories godoc
+// @Summary      Replace the categories of a book
+// @Description  Sets the book's categories to the given category IDs
+// @Tags         books
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Book ID"
+// @Param        categories body BookCategoriesRequest true "Category IDs"
+// @Success      200 {object} models.Book
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /books/{id}/categories [put]
+func (ctrl *CategoryController) SetBookCategories(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	var req BookCategoriesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	book, err := ctrl.categoryService.SetBookCategories(c.Request.Context(), uint(id), req.CategoryIDs)
+	if err != nil {
+		c.JSON(categoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, book)
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"books-api/app/database"
+	"books-api/app/models"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CopyController handles HTTP requests for physical copy operations
+type CopyController struct {
+	copyService service.CopyService
+}
+
+// NewCopyController creates a new instance of copy controller
+func NewCopyController(copyService service.CopyService) *CopyController {
+	return &CopyController{
+		copyService: copyService,
+	}
+}
+
+// copyErrorStatus maps copy service errors to HTTP status codes
+func copyErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	switch err.Error() {
+	case "copy not found", "book not found":
+		return http.StatusNotFound
+	case "barcode already exists", "copy is checked out", "copy is reserved for a hold":
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// CreateCopy godoc
+// @Summary      Add a copy of a book
+// @Description  Registers a physical copy of a book at a branch
+// @Tags         copies
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Book ID"
+// @Param        copy body models.Copy true "Copy data"
+// @Success      201 {object} models.Copy
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /books/{id}/copies [post]
+func (ctrl *CopyController) CreateCopy(c *gin.Context) {
+	idStr := c.Param("id")
+	bookID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	var bookCopy models.Copy
+	if !bindJSON(c, &bookCopy) {
+		return
+	}
+
+	if err := ctrl.copyService.CreateCopy(c.Request.Context(), uint(bookID), &bookCopy); err != nil {
+		c.JSON(copyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bookCopy)
+}
+
+// ListBookCopies godoc
+// @Summary      List the copies of a book
+// @Description  Get all physical copies of a book
+// @Tags         copies
+// @Produce      json
+// @Param        id path int true "Book ID"
+// @Success      200 {array} models.Copy
+// @Failure      404 {object} map[string]string
+// @Router       /books/{id}/copies [get]
+func (ctrl *CopyController) ListBookCopies(c *gin.Context) {
+	idStr := c.Param("id")
+	bookID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	copies, err := ctrl.copyService.GetBookCopies(c.Request.Context(), uint(bookID))
+	if err != nil {
+		if err.Error() == "book not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, copies)
+}
+
+// GetCopy godoc
+// @Summary      Get a copy by ID
+// @Description  Returns a single physical copy
+// @Tags         copies
+// @Produce      json
+// @Param        id path int true "Copy ID"
+// @Success      200 {object} models.Copy
+// @Failure      404 {object} map[string]string
+// @Router       /copies/{id} [get]
+func (ctrl *CopyController) GetCopy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid copy ID"})
+		return
+	}
+
+	bookCopy, err := ctrl.copyService.GetCopyByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bookCopy)
+}
+
+// UpdateCopy godoc
+// @Summary      Update a copy
+// @Description  Updates the barcode, branch or condition of a copy
+// @Tags         copies
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Copy ID"
+// @Param        copy body models.Copy true "Copy data"
+// @Success      200 {object} models.Copy
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /copies/{id} [put]
+func (ctrl *CopyController) UpdateCopy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid copy ID"})
+		return
+	}
+
+	var updateData models.Copy
+	if !bindJSON(c, &updateData) {
+		return
+	}
+
+	bookCopy, err := ctrl.copyService.UpdateCopy(c.Request.Context(), uint(id), updateData)
+	if err != nil {
+		c.JSON(copyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bookCopy)
+}
+
+// DeleteCopy godoc
+// @Summary      Delete a copy
+// @Description  Withdraws a copy that is neither checked out nor set aside for a hold
+// @Tags         copies
+// @Produce      json
+// @Param        id path int true "Copy ID"
+// @Success      200 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /copies/{id} [delete]
+func (ctrl *CopyController) DeleteCopy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid copy ID"})
+		return
+	}
+
+	if err := ctrl.copyService.DeleteCopy(c.Request.Context(), uint(id)); err != nil {
+		switch err.Error() {
+		case "copy not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "copy is checked out", "copy is reserved for a hold":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "copy deleted successfully"})
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"books-api/app/graph"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQLController handles GraphQL requests over HTTP
+type GraphQLController struct {
+	server *graph.Server
+}
+
+// NewGraphQLController creates a new instance of GraphQL controller
+func NewGraphQLController(server *graph.Server) *GraphQLController {
+	return &GraphQLController{
+		server: server,
+	}
+}
+
+// graphQLRequest documents the body of POST /graphql
+type graphQLRequest struct {
+	Query         string                 `json:"query" example:"{ books(first: 10) { nodes { id title } } }"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphQLResponse documents the body of GraphQL responses
+type graphQLResponse struct {
+	Data   interface{}   `json:"data,omitempty"`
+	Errors []interface{} `json:"errors,omitempty"`
+}
+
+// Query godoc
+// @Summary      Run a GraphQL query or mutation
+// @Description  Runs a GraphQL operation over books: book(id), books(first, after, tag, category, sort) with cursor pagination, and the createBook, updateBook and deleteBook mutations, which need the books:write scope in addition to books:read. The reviews of all books in a result are read in one batch. Queries deeper or costlier than the configured limits are rejected before they run. Errors are reported in the errors array with a code in their extensions; the response status is 200 whenever the request could be read.
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        request body graphQLRequest true "GraphQL request"
+// @Success      200 {object} graphQLResponse
+// @Failure      400 {object} map[string]string
+// @Failure      413 {object} map[string]string
+// @Router       /graphql [post]
+func (ctrl *GraphQLController) Query(c *gin.Context) {
+	var req graph.Request
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	c.JSON(http.StatusOK, ctrl.server.Execute(c.Request.Context(), req))
+}
+
+// QueryByGet godoc
+// @Summary      Run a GraphQL query given in the URL
+// @Description  Runs the GraphQL query given in the query parameters, for clients and caches that prefer GET. Mutations must be sent with POST.
+// @Tags         graphql
+// @Produce      json
+// @Param        query         query string true  "GraphQL query"
+// @Param        operationName query string false "Operation to run"
+// @Param        variables     query string false "Variables as a JSON object"
+// @Success      200 {object} graphQLResponse
+// @Failure      400 {object} map[string]string
+// @Router       /graphql [get]
+func (ctrl *GraphQLController) QueryByGet(c *gin.Context) {
+	req := graph.Request{Query: c.Query("query"), OperationName: c.Query("operationName"), ReadOnly: true}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+	if variables := c.Query("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "variables must be a JSON object"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, ctrl.server.Execute(c.Request.Context(), req))
+}
+
+// Playground godoc
+// @Summary      GraphQL playground
+// @Description  An in-browser page for trying out queries against /graphql, unless disabled with GRAPHQL_PLAYGROUND=false. The page itself needs no credentials; queries run with the Authorization header entered on it.
+// @Tags         graphql
+// @Produce      html
+// @Success      200 {string} string "HTML page"
+// @Failure      404 {object} map[string]string
+// @Router       /graphql/playground [get]
+func (ctrl *GraphQLController) Playground(c *gin.Context) {
+	if !ctrl.server.Config().Playground {
+		c.JSON(http.StatusNotFound, gin.H{"error": "playground is disabled"})
+		return
+	}
+
+	// The page runs an inline script the API-wide policy forbids
+	c.Header("Content-Security-Policy", graph.PlaygroundCSP)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(graph.PlaygroundHTML))
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"books-api/app/database"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HoldController handles HTTP requests for hold queue operations
+type HoldController struct {
+	holdService service.HoldService
+}
+
+// NewHoldController creates a new instance of hold controller
+func NewHoldController(holdService service.HoldService) *HoldController {
+	return &HoldController{
+		holdService: holdService,
+	}
+}
+
+// PlaceHoldRequest is the payload for placing a hold on a book
+type PlaceHoldRequest struct {
+	MemberID uint `json:"member_id" binding:"required"`
+}
+
+// holdErrorStatus maps hold service errors to HTTP status codes
+func holdErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	switch {
+	case err.Error() == "book not found", err.Error() == "hold not found", err.Error() == "member not found":
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	}
+	return http.StatusConflict
+}
+
+// PlaceHold godoc
+// @Summary      Place a hold on a book
+// @Description  Queues a member for the next returned copy when every copy of the book is out
+// @Tags         holds
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Book ID"
+// @Param        hold body PlaceHoldRequest true "Member placing the hold"
+// @Success      201 {object} models.Hold
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /books/{id}/holds [post]
+func (ctrl *HoldController) PlaceHold(c *gin.Context) {
+	idStr := c.Param("id")
+	bookID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	var req PlaceHoldRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	hold, err := ctrl.holdService.PlaceHold(c.Request.Context(), uint(bookID), req.MemberID)
+	if err != nil {
+		c.JSON(holdErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+// ListMemberHolds godoc
+// @Summary      List the holds of a member
+// @Description  Get all holds a member has placed, newest first
+// @Tags         holds
+// @Produce      json
+// @Param        id path int true "Member ID"
+// @Success      200 {array} models.Hold
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /members/{id}/holds [get]
+func (ctrl *HoldController) ListMemberHolds(c *gin.Context) {
+	idStr := c.Param("id")
+	memberID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid member ID"})
+		return
+	}
+
+	holds, err := ctrl.holdService.GetMemberHolds(c.Request.Context(), uint(memberID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, holds)
+}
+
+// CancelHold godoc
+// @Summary      Cancel a hold
+// @Description  Cancels a waiting or ready hold; a copy set aside for it goes to the next member in line
+// @Tags         holds
+// @Produce      json
+// @Param        id path int true "Hold ID"
+// @Success      200 {object} models.Hold
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /holds/{id} [delete]
+func (ctrl *HoldController) CancelHold(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid hold ID"})
+		return
+	}
+
+	hold, err := ctrl.holdService.CancelHold(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(holdErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
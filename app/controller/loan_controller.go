@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"books-api/app/database"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoanController handles HTTP requests for circulation operations
+type LoanController struct {
+	loanService service.LoanService
+}
+
+// NewLoanController creates a new instance of loan controller
+func NewLoanController(loanService service.LoanService) *LoanController {
+	return &LoanController{
+		loanService: loanService,
+	}
+}
+
+// CheckoutRequest is the payload for checking out a copy
+type CheckoutRequest struct {
+	MemberID uint `json:"member_id" binding:"required"`
+}
+
+// loanErrorStatus maps loan service errors to HTTP status codes
+func loanErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	switch {
+	case err.Error() == "copy not found", err.Error() == "member not found":
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	}
+	return http.StatusConflict
+}
+
+// Checkout godoc
+// @Summary      Check out a copy
+// @Description  Lends a copy to a member, enforcing the member's loan limit
+// @Tags         circulation
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Copy ID"
+// @Param        checkout body CheckoutRequest true "Borrowing member"
+// @Success      201 {object} models.Loan
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /copies/{id}/checkout [post]
+func (ctrl *LoanController) Checkout(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid copy ID"})
+		return
+	}
+
+	var req CheckoutRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	loan, err := ctrl.loanService.Checkout(c.Request.Context(), uint(id), req.MemberID)
+	if err != nil {
+		c.JSON(loanErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, loan)
+}
+
+// Return godoc
+// @Summary      Return a copy
+// @Description  Closes the active loan of a copy; the loan reports whether it was returned late
+// @Tags         circulation
+// @Produce      json
+// @Param        id path int true "Copy ID"
+// @Success      200 {object} models.Loan
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /copies/{id}/return [post]
+func (ctrl *LoanController) Return(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid copy ID"})
+		return
+	}
+
+	loan, err := ctrl.loanService.Return(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(loanErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+// Renew godoc
+// @Summary      Renew a loan
+// @Description  Extends the due date of a copy's active loan by one loan period
+// @Tags         circulation
+// @Produce      json
+// @Param        id path int true "Copy ID"
+// @Success      200 {object} models.Loan
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /copies/{id}/renew [post]
+func (ctrl *LoanController) Renew(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid copy ID"})
+		return
+	}
+
+	loan, err := ctrl.loanService.Renew(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(loanErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+// ListOverdueLoans godoc
+// @Summary      List overdue loans
+// @Description  Get all unreturned loans past their due date
+// @Tags         circulation
+// @Produce      json
+// @Success      200 {array} models.Loan
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /loans/overdue [get]
+func (ctrl *LoanController) ListOverdueLoans(c *gin.Context) {
+	loans, err := ctrl.loanService.GetOverdueLoans(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loans)
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"books-api/app/logging"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoggingController handles HTTP requests for the runtime logging settings
+type LoggingController struct {
+	switches *logging.Switches
+	logger   *slog.Logger
+}
+
+// NewLoggingController creates a new instance of logging controller. Changes
+// are logged through logger.
+func NewLoggingController(switches *logging.Switches, logger *slog.Logger) *LoggingController {
+	return &LoggingController{
+		switches: switches,
+		logger:   logger,
+	}
+}
+
+// GetLoggingSettings godoc
+// @Summary      Get logging settings
+// @Description  Returns the log level, whether SQL statements are logged and the slow query threshold
+// @Tags         admin
+// @Produce      json
+// @Success      200 {object} logging.Settings
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/logging [get]
+func (ctrl *LoggingController) GetLoggingSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.switches.Settings())
+}
+
+// UpdateLoggingSettings godoc
+// @Summary      Update logging settings
+// @Description  Changes the given logging settings without a restart. SQL statements are logged at debug level, so they only show with level debug
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        settings body logging.SettingsUpdate true "Settings to change"
+// @Success      200 {object} logging.Settings
+// @Failure      400 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/logging [put]
+func (ctrl *LoggingController) UpdateLoggingSettings(c *gin.Context) {
+	var update logging.SettingsUpdate
+	if !bindJSON(c, &update) {
+		return
+	}
+
+	settings, err := ctrl.switches.Apply(update)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctrl.logger.WarnContext(c.Request.Context(), "Logging settings changed",
+		slog.String("level", settings.Level),
+		slog.Bool("sql_statements", settings.SQLStatements),
+		slog.String("slow_query_threshold", settings.SlowQueryThreshold),
+	)
+	c.JSON(http.StatusOK, settings)
+}
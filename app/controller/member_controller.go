@@ -0,0 +1,344 @@
+package controller
+
+import (
+	"books-api/app/database"
+	"books-api/app/models"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MemberController handles HTTP requests for member account operations
+type MemberController struct {
+	memberService service.MemberService
+}
+
+// NewMemberController creates a new instance of member controller
+func NewMemberController(memberService service.MemberService) *MemberController {
+	return &MemberController{
+		memberService: memberService,
+	}
+}
+
+// SuspendMemberRequest is the payload for suspending a member
+type SuspendMemberRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// memberErrorStatus maps member service errors to HTTP status codes
+func memberErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	switch {
+	case err.Error() == "member not found", err.Error() == "fine not found":
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	}
+	switch err.Error() {
+	case "email already exists", "card number already exists",
+		"member has active loans", "member has unpaid fines",
+		"member is already suspended", "member is not suspended",
+		"fine already paid":
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// parseMemberID reads the member ID path parameter
+func parseMemberID(c *gin.Context) (uint, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid member ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// CreateMember godoc
+// @Summary      Register a member
+// @Description  Creates a library member account; email and card number must be unique
+// @Tags         members
+// @Accept       json
+// @Produce      json
+// @Param        member body models.Member true "Member data"
+// @Success      201 {object} models.Member
+// @Failure      400 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /members [post]
+func (ctrl *MemberController) CreateMember(c *gin.Context) {
+	var member models.Member
+	if !bindJSON(c, &member) {
+		return
+	}
+
+	if err := ctrl.memberService.CreateMember(c.Request.Context(), &member); err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// ListMembers godoc
+// @Summary      Search members
+// @Description  List members, optionally matching name, email or card number and filtered by status
+// @Tags         members
+// @Produce      json
+// @Param        q query string false "Search text"
+// @Param        status query string false "Member status (active, suspended)"
+// @Success      200 {array} models.Member
+// @Failure      400 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /members [get]
+func (ctrl *MemberController) ListMembers(c *gin.Context) {
+	filter := models.MemberFilter{
+		Query:  c.Query("q"),
+		Status: models.MemberStatus(c.Query("status")),
+	}
+
+	members, err := ctrl.memberService.SearchMembers(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// GetMember godoc
+// @Summary      Get a member
+// @Description  Get a member account by ID
+// @Tags         members
+// @Produce      json
+// @Param        id path int true "Member ID"
+// @Success      200 {object} models.Member
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /members/{id} [get]
+func (ctrl *MemberController) GetMember(c *gin.Context) {
+	id, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	member, err := ctrl.memberService.GetMemberByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// UpdateMember godoc
+// @Summary      Update a member
+// @Description  Update the contact details or loan limit of a member
+// @Tags         members
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Member ID"
+// @Param        member body models.Member true "Updated member data"
+// @Success      200 {object} models.Member
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /members/{id} [put]
+func (ctrl *MemberController) UpdateMember(c *gin.Context) {
+	id, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	var updateData models.Member
+	if !bindJSON(c, &updateData) {
+		return
+	}
+
+	member, err := ctrl.memberService.UpdateMember(c.Request.Context(), id, updateData)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// DeleteMember godoc
+// @Summary      Delete a member
+// @Description  Delete a member who has no active loans and no unpaid fines; their holds are cancelled
+// @Tags         members
+// @Param        id path int true "Member ID"
+// @Success      200 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /members/{id} [delete]
+func (ctrl *MemberController) DeleteMember(c *gin.Context) {
+	id, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.memberService.DeleteMember(c.Request.Context(), id); err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member deleted successfully"})
+}
+
+// SuspendMember godoc
+// @Summary      Suspend a member
+// @Description  Blocks a member from checking out copies and placing holds
+// @Tags         members
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Member ID"
+// @Param        suspension body SuspendMemberRequest true "Reason for the suspension"
+// @Success      200 {object} models.Member
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /members/{id}/suspend [post]
+func (ctrl *MemberController) SuspendMember(c *gin.Context) {
+	id, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	var req SuspendMemberRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	member, err := ctrl.memberService.SuspendMember(c.Request.Context(), id, req.Reason)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// ReinstateMember godoc
+// @Summary      Reinstate a member
+// @Description  Lifts the suspension of a member
+// @Tags         members
+// @Produce      json
+// @Param        id path int true "Member ID"
+// @Success      200 {object} models.Member
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /members/{id}/reinstate [post]
+func (ctrl *MemberController) ReinstateMember(c *gin.Context) {
+	id, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	member, err := ctrl.memberService.ReinstateMember(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// ListMemberLoans godoc
+// @Summary      List the loans of a member
+// @Description  Get the loan history of a member, newest first, with overdue loans flagged
+// @Tags         members
+// @Produce      json
+// @Param        id path int true "Member ID"
+// @Success      200 {array} models.Loan
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /members/{id}/loans [get]
+func (ctrl *MemberController) ListMemberLoans(c *gin.Context) {
+	id, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	loans, err := ctrl.memberService.GetMemberLoans(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loans)
+}
+
+// ListMemberFines godoc
+// @Summary      List the fines of a member
+// @Description  Get all overdue fines charged to a member, newest first
+// @Tags         members
+// @Produce      json
+// @Param        id path int true "Member ID"
+// @Success      200 {array} models.Fine
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /members/{id}/fines [get]
+func (ctrl *MemberController) ListMemberFines(c *gin.Context) {
+	id, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	fines, err := ctrl.memberService.GetMemberFines(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, fines)
+}
+
+// PayFine godoc
+// @Summary      Pay a fine
+// @Description  Marks an overdue fine as paid
+// @Tags         members
+// @Produce      json
+// @Param        id path int true "Fine ID"
+// @Success      200 {object} models.Fine
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /fines/{id}/pay [post]
+func (ctrl *MemberController) PayFine(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fine ID"})
+		return
+	}
+
+	fine, err := ctrl.memberService.PayFine(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, fine)
+}
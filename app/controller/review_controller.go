@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"books-api/app/authz"
+	"books-api/app/database"
+	"books-api/app/models"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewController handles HTTP requests for book review operations
+type ReviewController struct {
+	reviewService service.ReviewService
+}
+
+// NewReviewController creates a new instance of review controller
+func NewReviewController(reviewService service.ReviewService) *ReviewController {
+	return &ReviewController{
+		reviewService: reviewService,
+	}
+}
+
+// ModerateReviewRequest is the payload for changing a review's status
+type ModerateReviewRequest struct {
+	Status models.ReviewStatus `json:"status" binding:"required"`
+}
+
+// reviewErrorStatus maps review service errors to HTTP status codes
+func reviewErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	switch {
+	case authz.IsForbidden(err):
+		return http.StatusForbidden
+	case err.Error() == "book not found", err.Error() == "review not found":
+		return http.StatusNotFound
+	case err.Error() == "review already exists":
+		return http.StatusConflict
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// parseReviewPath parses the book and review IDs from the request path
+func parseReviewPath(c *gin.Context) (bookID, reviewID uint, ok bool) {
+	book, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return 0, 0, false
+	}
+	review, err := strconv.ParseUint(c.Param("reviewId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
+		return 0, 0, false
+	}
+	return uint(book), uint(review), true
+}
+
+// CreateReview godoc
+// @Summary      Review a book
+// @Description  Adds a review (rating 1-5) by the caller for a book; one review per reviewer and book. New reviews await moderation.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Book ID"
+// @Param        review body models.Review true "Review data"
+// @Success      201 {object} models.Review
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /books/{id}/reviews [post]
+func (ctrl *ReviewController) CreateReview(c *gin.Context) {
+	idStr := c.Param("id")
+	bookID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	var review models.Review
+	if !bindJSON(c, &review) {
+		return
+	}
+
+	if err := ctrl.reviewService.CreateReview(c.Request.Context(), uint(bookID), &review); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// ListReviews godoc
+// @Summary      List the reviews of a book
+// @Description  Get approved reviews of a book, or, for moderators, reviews with the given moderation status
+// @Tags         reviews
+// @Produce      json
+// @Param        id     path  int    true  "Book ID"
+// @Param        status query string false "Moderation status (pending, approved, rejected)"
+// @Success      200 {array} models.Review
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /books/{id}/reviews [get]
+func (ctrl *ReviewController) ListReviews(c *gin.Context) {
+	idStr := c.Param("id")
+	bookID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	status := models.ReviewApproved
+	if statusStr := c.Query("status"); statusStr != "" {
+		status = models.ReviewStatus(statusStr)
+	}
+
+	reviews, err := ctrl.reviewService.GetBookReviews(c.Request.Context(), uint(bookID), &status)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// GetReview godoc
+// @Summary      Get a review
+// @Description  Returns a single review of a book; reviews awaiting or failing moderation are only visible to moderators
+// @Tags         reviews
+// @Produce      json
+// @Param        id       path int true "Book ID"
+// @Param        reviewId path int true "Review ID"
+// @Success      200 {object} models.Review
+// @Failure      404 {object} map[string]string
+// @Router       /books/{id}/reviews/{reviewId} [get]
+func (ctrl *ReviewController) GetReview(c *gin.Context) {
+	bookID, reviewID, ok := parseReviewPath(c)
+	if !ok {
+		return
+	}
+
+	review, err := ctrl.reviewService.GetReview(c.Request.Context(), bookID, reviewID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// UpdateReview godoc
+// @Summary      Update a review
+// @Description  Updates the rating or text of the caller's review; the review goes back to moderation
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id       path int true "Book ID"
+// @Param        reviewId path int true "Review ID"
+// @Param        review body models.Review true "Review data"
+// @Success      200 {object} models.Review
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /books/{id}/reviews/{reviewId} [put]
+func (ctrl *ReviewController) UpdateReview(c *gin.Context) {
+	bookID, reviewID, ok := parseReviewPath(c)
+	if !ok {
+		return
+	}
+
+	var updateData models.Review
+	if !bindJSON(c, &updateData) {
+		return
+	}
+
+	review, err := ctrl.reviewService.UpdateReview(c.Request.Context(), bookID, reviewID, updateData)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// ModerateReview godoc
+// @Summary      Moderate a review
+// @Description  Approves or rejects a review; only approved reviews count towards the book rating
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id       path int true "Book ID"
+// @Param        reviewId path int true "Review ID"
+// @Param        moderation body ModerateReviewRequest true "New status"
+// @Success      200 {object} models.Review
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /books/{id}/reviews/{reviewId}/moderation [put]
+func (ctrl *ReviewController) ModerateReview(c *gin.Context) {
+	bookID, reviewID, ok := parseReviewPath(c)
+	if !ok {
+		return
+	}
+
+	var req ModerateReviewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	review, err := ctrl.reviewService.ModerateReview(c.Request.Context(), bookID, reviewID, req.Status)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// DeleteReview godoc
+// @Summary      Delete a review
+// @Description  Deletes a review of a book; reviewers may delete their own reviews, moderators any
+// @Tags         reviews
+// @Produce      json
+// @Param        id       path int true "Book ID"
+// @Param        reviewId path int true "Review ID"
+// @Success      200 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /books/{id}/reviews/{reviewId} [delete]
+func (ctrl *ReviewController) DeleteReview(c *gin.Context) {
+	bookID, reviewID, ok := parseReviewPath(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.reviewService.DeleteReview(c.Request.Context(), bookID, reviewID); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "review deleted successfully"})
+}
@@ -0,0 +1,321 @@
+package controller
+
+import (
+	"books-api/app/authz"
+	"books-api/app/events"
+	"books-api/app/models"
+	"books-api/app/service"
+	"books-api/app/stream"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamWriteTimeout bounds a single write to a streaming client
+	streamWriteTimeout = 10 * time.Second
+	// streamRetry is how long SSE clients wait before reconnecting
+	streamRetry = 3 * time.Second
+	// Notices sent to streaming clients besides the book events
+	streamReset  = "stream.reset"
+	streamLagged = "stream.lagged"
+)
+
+// StreamController handles the live change feed of books over Server-Sent
+// Events and WebSocket
+type StreamController struct {
+	feedService service.BookFeedService
+	heartbeat   time.Duration
+	upgrader    websocket.Upgrader
+}
+
+// NewStreamController creates a new instance of stream controller. Idle
+// connections are pinged every heartbeat. WebSocket connections from
+// another origin are only accepted if allowOrigin accepts it.
+func NewStreamController(feedService service.BookFeedService, heartbeat time.Duration, allowOrigin func(origin string) bool) *StreamController {
+	return &StreamController{
+		feedService: feedService,
+		heartbeat:   heartbeat,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				if origin == "" {
+					return true
+				}
+				parsed, err := url.Parse(origin)
+				if err == nil && strings.EqualFold(parsed.Host, r.Host) {
+					return true
+				}
+				return allowOrigin(origin)
+			},
+		},
+	}
+}
+
+// streamNotice tells a client about the stream itself rather than a book
+type streamNotice struct {
+	Type   string `json:"type" example:"stream.reset"`
+	Reason string `json:"reason"`
+}
+
+// resetNotice is sent to resuming clients that may have missed events
+var resetNotice = streamNotice{Type: streamReset, Reason: "events since the last event ID are no longer available; reload the books"}
+
+// lagNotice is sent before disconnecting a client that fell behind
+var lagNotice = streamNotice{Type: streamLagged, Reason: "client too slow; reconnect to resume"}
+
+// parseStreamRequest reads the filter and the event to resume after from
+// the query and the Last-Event-ID header
+func parseStreamRequest(c *gin.Context) (stream.Filter, uint, bool) {
+	filter := stream.Filter{Author: strings.TrimSpace(c.Query("author"))}
+
+	if value := c.Query("type"); value != "" {
+		for _, name := range strings.Split(value, ",") {
+			eventType := events.Type(strings.TrimSpace(name))
+			if !eventType.IsValid() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event type: " + string(eventType)})
+				return stream.Filter{}, 0, false
+			}
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+	if value := c.Query("color"); value != "" {
+		filter.Color = models.Color(value)
+		if !filter.Color.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid color"})
+			return stream.Filter{}, 0, false
+		}
+	}
+	if value := c.Query("book_id"); value != "" {
+		bookID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+			return stream.Filter{}, 0, false
+		}
+		filter.BookID = uint(bookID)
+	}
+
+	// Browsers cannot set headers on WebSocket requests, so the query works
+	// as well
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var after uint64
+	if lastEventID != "" {
+		var err error
+		if after, err = strconv.ParseUint(lastEventID, 10, 32); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid last event ID"})
+			return stream.Filter{}, 0, false
+		}
+	}
+	return filter, uint(after), true
+}
+
+// subscribe starts the subscription of a streaming request, answering the
+// request itself if that fails
+func (ctrl *StreamController) subscribe(c *gin.Context) (*stream.Subscription, bool) {
+	filter, lastEventID, ok := parseStreamRequest(c)
+	if !ok {
+		return nil, false
+	}
+
+	subscription, err := ctrl.feedService.Subscribe(c.Request.Context(), filter, lastEventID)
+	if err != nil {
+		switch {
+		case authz.IsForbidden(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, stream.ErrClosed):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil, false
+	}
+	return subscription, true
+}
+
+// StreamBooks godoc
+// @Summary      Stream book changes
+// @Description  Pushes book.created, book.updated and book.deleted events as Server-Sent Events, with the event ID as SSE id. Reconnecting with Last-Event-ID replays the missed events while they are buffered, or sends stream.reset if they are not. Clients that fall behind get stream.lagged and are disconnected.
+// @Tags         books
+// @Produce      text/event-stream
+// @Param        type          query  string false "Comma separated event types"
+// @Param        author        query  string false "Only books by this author"
+// @Param        color         query  string false "Only books of this color"
+// @Param        book_id       query  int    false "Only this book"
+// @Param        Last-Event-ID header int    false "Resume after this event"
+// @Success      200 {object} events.Event
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Router       /books/stream [get]
+func (ctrl *StreamController) StreamBooks(c *gin.Context) {
+	subscription, ok := ctrl.subscribe(c)
+	if !ok {
+		return
+	}
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	// Keeps reverse proxies such as nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	writer := http.NewResponseController(c.Writer)
+	send := func(event sse.Event) bool {
+		writer.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if err := sse.Encode(c.Writer, event); err != nil {
+			return false
+		}
+		return writer.Flush() == nil
+	}
+
+	// Sending the reconnect delay right away also flushes the headers, so
+	// clients see the stream open before the first event
+	writer.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds()); err != nil || writer.Flush() != nil {
+		return
+	}
+	if subscription.Reset && !send(sse.Event{Event: streamReset, Data: resetNotice}) {
+		return
+	}
+	for _, event := range subscription.Replay {
+		if !send(bookSSEvent(event)) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(ctrl.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, open := <-subscription.Events():
+			if !open {
+				if errors.Is(subscription.Err(), stream.ErrLagged) {
+					send(sse.Event{Event: streamLagged, Data: lagNotice})
+				}
+				return
+			}
+			if !send(bookSSEvent(event)) {
+				return
+			}
+		case <-heartbeat.C:
+			writer.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil || writer.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+// bookSSEvent encodes a book event for SSE
+func bookSSEvent(event events.Event) sse.Event {
+	return sse.Event{
+		Id:    strconv.FormatUint(uint64(event.ID), 10),
+		Event: string(event.Type),
+		Data:  event,
+	}
+}
+
+// StreamBooksWebSocket godoc
+// @Summary      Stream book changes over WebSocket
+// @Description  Upgrades to a WebSocket that receives every book event as a JSON text message, filtered like /books/stream. last_event_id resumes after an event; a stream.reset message means missed events are no longer buffered. Clients that fall behind are closed with code 1013.
+// @Tags         books
+// @Param        type          query string false "Comma separated event types"
+// @Param        author        query string false "Only books by this author"
+// @Param        color         query string false "Only books of this color"
+// @Param        book_id       query int    false "Only this book"
+// @Param        last_event_id query int    false "Resume after this event"
+// @Success      101 {object} events.Event
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Router       /books/stream/ws [get]
+func (ctrl *StreamController) StreamBooksWebSocket(c *gin.Context) {
+	subscription, ok := ctrl.subscribe(c)
+	if !ok {
+		return
+	}
+	defer subscription.Close()
+
+	conn, err := ctrl.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has answered the request
+		return
+	}
+	defer conn.Close()
+
+	// Clients only send pongs and close messages; reading notices when
+	// they are gone
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * ctrl.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * ctrl.heartbeat))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(message interface{}) bool {
+		conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		return conn.WriteJSON(message) == nil
+	}
+	closeWith := func(code int, reason string) {
+		message := websocket.FormatCloseMessage(code, reason)
+		conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(streamWriteTimeout))
+	}
+
+	if subscription.Reset && !send(resetNotice) {
+		return
+	}
+	for _, event := range subscription.Replay {
+		if !send(event) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(ctrl.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, open := <-subscription.Events():
+			if !open {
+				switch err := subscription.Err(); {
+				case errors.Is(err, stream.ErrLagged):
+					send(lagNotice)
+					closeWith(websocket.CloseTryAgainLater, err.Error())
+				case errors.Is(err, stream.ErrClosed):
+					closeWith(websocket.CloseGoingAway, err.Error())
+				}
+				return
+			}
+			if !send(event) {
+				return
+			}
+		case <-heartbeat.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)) != nil {
+				return
+			}
+		}
+	}
+}
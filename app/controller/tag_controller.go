@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"books-api/app/authz"
+	"books-api/app/database"
+	"books-api/app/models"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagController handles HTTP requests for tag operations
+type TagController struct {
+	tagService service.TagService
+}
+
+// NewTagController creates a new instance of tag controller
+func NewTagController(tagService service.TagService) *TagController {
+	return &TagController{
+		tagService: tagService,
+	}
+}
+
+// MergeTagsRequest is the payload for merging a duplicate tag into another
+type MergeTagsRequest struct {
+	TargetID uint `json:"target_id" binding:"required"`
+}
+
+// BookTagsRequest is the payload for replacing the tags of a book
+type BookTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// tagErrorStatus maps tag service errors to HTTP status codes
+func tagErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	if authz.IsForbidden(err) {
+		return http.StatusForbidden
+	}
+	switch err.Error() {
+	case "tag not found", "book not found":
+		return http.StatusNotFound
+	case "tag already exists":
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// CreateTag godoc
+// @Summary      Create a new tag
+// @Description  Adds a new tag; the name is lowercased and whitespace-normalized
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        tag body models.Tag true "Tag data"
+// @Success      201 {object} models.Tag
+// @Failure      400 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tags [post]
+func (ctrl *TagController) CreateTag(c *gin.Context) {
+	var tag models.Tag
+	if !bindJSON(c, &tag) {
+		return
+	}
+
+	if err := ctrl.tagService.CreateTag(c.Request.Context(), &tag); err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// ListTags godoc
+// @Summary      List all tags
+// @Description  Get all tags with the number of books carrying each tag
+// @Tags         tags
+// @Produce      json
+// @Success      200 {array} models.TagCount
+// @Router       /tags [get]
+func (ctrl *TagController) ListTags(c *gin.Context) {
+	tags, err := ctrl.tagService.GetAllTags(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// GetTag godoc
+// @Summary      Get a tag by ID
+// @Description  Returns a single tag
+// @Tags         tags
+// @Produce      json
+// @Param        id path int true "Tag ID"
+// @Success      200 {object} models.Tag
+// @Failure      404 {object} map[string]string
+// @Router       /tags/{id} [get]
+func (ctrl *TagController) GetTag(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag ID"})
+		return
+	}
+
+	tag, err := ctrl.tagService.GetTagByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// UpdateTag godoc
+// @Summary      Rename a tag
+// @Description  Renames a tag by ID; renaming onto an existing tag is rejected
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Tag ID"
+// @Param        tag body models.Tag true "Tag data"
+// @Success      200 {object} models.Tag
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tags/{id} [put]
+func (ctrl *TagController) UpdateTag(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag ID"})
+		return
+	}
+
+	var updateData models.Tag
+	if !bindJSON(c, &updateData) {
+		return
+	}
+
+	tag, err := ctrl.tagService.UpdateTag(c.Request.Context(), uint(id), updateData)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag godoc
+// @Summary      Delete a tag
+// @Description  Deletes a tag by ID and removes it from all books
+// @Tags         tags
+// @Produce      json
+// @Param        id path int true "Tag ID"
+// @Success      200 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tags/{id} [delete]
+func (ctrl *TagController) DeleteTag(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag ID"})
+		return
+	}
+
+	if err := ctrl.tagService.DeleteTag(c.Request.Context(), uint(id)); err != nil {
+		if err.Error() == "tag not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "tag deleted successfully"})
+}
+
+// MergeTag godoc
+// @Summary      Merge a duplicate tag
+// @Description  Moves all books from the tag to the target tag and deletes the tag
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Tag ID to merge away"
+// @Param        merge body MergeTagsRequest true "Target tag"
+// @Success      200 {object} models.Tag
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /tags/{id}/merge [post]
+func (ctrl *TagController) MergeTag(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag ID"})
+		return
+	}
+
+	var req MergeTagsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tag, err := ctrl.tagService.MergeTags(c.Request.Context(), uint(id), req.TargetID)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// SetBookTags godoc
+// @Summary      Replace the tags of a book
+// @Description  Sets the book's tags to the given names, creating missing tags
+// @Tags         books
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Book ID"
+// @Param        tags body BookTagsRequest true "Tag names"
+// @Success      200 {object} models.Book
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /books/{id}/tags [put]
+func (ctrl *TagController) SetBookTags(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	var req BookTagsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	book, err := ctrl.tagService.SetBookTags(c.Request.Context(), uint(id), req.Tags)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, book)
+}
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"books-api/app/database"
+	"books-api/app/models"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantController handles HTTP requests for tenant administration
+type TenantController struct {
+	tenantService service.TenantService
+}
+
+// NewTenantController creates a new instance of tenant controller
+func NewTenantController(tenantService service.TenantService) *TenantController {
+	return &TenantController{
+		tenantService: tenantService,
+	}
+}
+
+// tenantErrorStatus maps tenant service errors to HTTP status codes
+func tenantErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	switch {
+	case err.Error() == "tenant not found":
+		return http.StatusNotFound
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	case strings.HasPrefix(err.Error(), "tenant has "):
+		return http.StatusConflict
+	}
+	switch err.Error() {
+	case "slug already exists", "default tenant cannot be deleted":
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// parseTenantID reads the tenant ID path parameter
+func parseTenantID(c *gin.Context) (uint, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// CreateTenant godoc
+// @Summary      Create a tenant
+// @Description  Registers an organization with its own catalogue; the slug must be a DNS label and cannot be changed later
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        tenant body models.Tenant true "Tenant data"
+// @Success      201 {object} models.Tenant
+// @Failure      400 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/tenants [post]
+func (ctrl *TenantController) CreateTenant(c *gin.Context) {
+	var tenant models.Tenant
+	if !bindJSON(c, &tenant) {
+		return
+	}
+
+	if err := ctrl.tenantService.CreateTenant(c.Request.Context(), &tenant); err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, tenant)
+}
+
+// ListTenants godoc
+// @Summary      List tenants
+// @Description  Get all tenants
+// @Tags         admin
+// @Produce      json
+// @Success      200 {array} models.Tenant
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/tenants [get]
+func (ctrl *TenantController) ListTenants(c *gin.Context) {
+	tenants, err := ctrl.tenantService.GetAllTenants(c.Request.Context())
+	if err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tenants)
+}
+
+// GetTenant godoc
+// @Summary      Get a tenant
+// @Description  Get a tenant by ID together with its quota usage
+// @Tags         admin
+// @Produce      json
+// @Param        id path int true "Tenant ID"
+// @Success      200 {object} models.Tenant
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/tenants/{id} [get]
+func (ctrl *TenantController) GetTenant(c *gin.Context) {
+	id, ok := parseTenantID(c)
+	if !ok {
+		return
+	}
+
+	tenant, err := ctrl.tenantService.GetTenantByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// UpdateTenant godoc
+// @Summary      Update a tenant
+// @Description  Rename a tenant or change its quotas
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Tenant ID"
+// @Param        tenant body models.Tenant true "Updated tenant data"
+// @Success      200 {object} models.Tenant
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/tenants/{id} [put]
+func (ctrl *TenantController) UpdateTenant(c *gin.Context) {
+	id, ok := parseTenantID(c)
+	if !ok {
+		return
+	}
+
+	var updateData models.Tenant
+	if !bindJSON(c, &updateData) {
+		return
+	}
+
+	tenant, err := ctrl.tenantService.UpdateTenant(c.Request.Context(), id, updateData)
+	if err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// DeleteTenant godoc
+// @Summary      Delete a tenant
+// @Description  Delete a tenant that owns no books, members, circulation, taxonomy or webhooks and revoke its API keys; the default tenant cannot be deleted
+// @Tags         admin
+// @Param        id path int true "Tenant ID"
+// @Success      200 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /admin/tenants/{id} [delete]
+func (ctrl *TenantController) DeleteTenant(c *gin.Context) {
+	id, ok := parseTenantID(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.tenantService.DeleteTenant(c.Request.Context(), id); err != nil {
+		c.JSON(tenantErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "tenant deleted successfully"})
+}
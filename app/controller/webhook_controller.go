@@ -0,0 +1,294 @@
+package controller
+
+import (
+	"books-api/app/database"
+	"books-api/app/models"
+	"books-api/app/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookController handles HTTP requests for webhook management and the
+// delivery log
+type WebhookController struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookController creates a new instance of webhook controller
+func NewWebhookController(webhookService service.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhookRequest is the payload for registering a webhook
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required" example:"https://partner.example/hooks/books"`
+	// Events limits the webhook to these event types; empty receives all
+	Events      []string `json:"events" example:"book.created,book.deleted"`
+	Description string   `json:"description"`
+	// Secret signs the deliveries; one is generated when empty
+	Secret string `json:"secret"`
+}
+
+// webhookErrorStatus maps webhook service errors to HTTP status codes
+func webhookErrorStatus(err error) int {
+	if database.IsBusy(err) {
+		return http.StatusServiceUnavailable
+	}
+	switch {
+	case err.Error() == "webhook not found", err.Error() == "delivery not found":
+		return http.StatusNotFound
+	case err.Error() == "webhook is disabled":
+		return http.StatusConflict
+	case strings.HasPrefix(err.Error(), "failed to"):
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// parseWebhookID parses the webhook ID from the request path
+func parseWebhookID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseDeliveryPath parses the webhook and delivery IDs from the request
+// path
+func parseDeliveryPath(c *gin.Context) (webhookID, deliveryID uint, ok bool) {
+	webhookID, ok = parseWebhookID(c)
+	if !ok {
+		return 0, 0, false
+	}
+	delivery, err := strconv.ParseUint(c.Param("deliveryId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery ID"})
+		return 0, 0, false
+	}
+	return webhookID, uint(delivery), true
+}
+
+// CreateWebhook godoc
+// @Summary      Register a webhook
+// @Description  Registers a URL that book events are posted to, signed with the webhook secret; the secret is only returned in this response
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        webhook body CreateWebhookRequest true "Target URL, event filter and optional secret"
+// @Success      201 {object} models.WebhookWithSecret
+// @Failure      400 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /webhooks [post]
+func (ctrl *WebhookController) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+
+	webhook := models.Webhook{
+		URL:         req.URL,
+		Events:      req.Events,
+		Description: req.Description,
+		Secret:      req.Secret,
+	}
+	created, err := ctrl.webhookService.CreateWebhook(c.Request.Context(), &webhook)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// ListWebhooks godoc
+// @Summary      List webhooks
+// @Description  Get all webhooks, including disabled ones, without their secrets
+// @Tags         webhooks
+// @Produce      json
+// @Success      200 {array} models.Webhook
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /webhooks [get]
+func (ctrl *WebhookController) ListWebhooks(c *gin.Context) {
+	webhooks, err := ctrl.webhookService.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook godoc
+// @Summary      Get a webhook
+// @Description  Get a webhook by its ID, without its secret
+// @Tags         webhooks
+// @Produce      json
+// @Param        id path int true "Webhook ID"
+// @Success      200 {object} models.Webhook
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /webhooks/{id} [get]
+func (ctrl *WebhookController) GetWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := ctrl.webhookService.GetWebhook(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary      Update a webhook
+// @Description  Changes the given fields of a webhook. Setting active re-enables a webhook disabled after failing deliveries; rotate_secret replaces the secret, which is returned once.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Webhook ID"
+// @Param        webhook body models.WebhookUpdate true "Fields to change"
+// @Success      200 {object} models.WebhookWithSecret
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /webhooks/{id} [put]
+func (ctrl *WebhookController) UpdateWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var update models.WebhookUpdate
+	if !bindStrictJSON(c, &update) {
+		return
+	}
+
+	webhook, err := ctrl.webhookService.UpdateWebhook(c.Request.Context(), id, update)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete a webhook
+// @Description  Deletes a webhook together with its delivery log
+// @Tags         webhooks
+// @Produce      json
+// @Param        id path int true "Webhook ID"
+// @Success      200 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /webhooks/{id} [delete]
+func (ctrl *WebhookController) DeleteWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.webhookService.DeleteWebhook(c.Request.Context(), id); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook deleted successfully"})
+}
+
+// ListDeliveries godoc
+// @Summary      List the deliveries of a webhook
+// @Description  Get the latest 100 deliveries of a webhook, newest first, with the outcome of their last attempt
+// @Tags         webhooks
+// @Produce      json
+// @Param        id path int true "Webhook ID"
+// @Success      200 {array} models.WebhookDelivery
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /webhooks/{id}/deliveries [get]
+func (ctrl *WebhookController) ListDeliveries(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := ctrl.webhookService.ListDeliveries(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// GetDelivery godoc
+// @Summary      Get a delivery
+// @Description  Get a delivery of a webhook with its payload and the response to its last attempt
+// @Tags         webhooks
+// @Produce      json
+// @Param        id         path int true "Webhook ID"
+// @Param        deliveryId path int true "Delivery ID"
+// @Success      200 {object} models.WebhookDelivery
+// @Failure      404 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /webhooks/{id}/deliveries/{deliveryId} [get]
+func (ctrl *WebhookController) GetDelivery(c *gin.Context) {
+	webhookID, deliveryID, ok := parseDeliveryPath(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := ctrl.webhookService.GetDelivery(c.Request.Context(), webhookID, deliveryID)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Redeliver godoc
+// @Summary      Redeliver a delivery
+// @Description  Queues a delivery again with its original payload and a fresh set of attempts
+// @Tags         webhooks
+// @Produce      json
+// @Param        id         path int true "Webhook ID"
+// @Param        deliveryId path int true "Delivery ID"
+// @Success      202 {object} models.WebhookDelivery
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Security     ApiKeyAuth
+// @Security     BearerAuth
+// @Router       /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (ctrl *WebhookController) Redeliver(c *gin.Context) {
+	webhookID, deliveryID, ok := parseDeliveryPath(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := ctrl.webhookService.Redeliver(c.Request.Context(), webhookID, deliveryID)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
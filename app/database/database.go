@@ -0,0 +1,31 @@
+package database
+
+import (
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// Open connects to the configured database
+func Open(config Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return dialects[config.Driver].Open(config, gormConfig)
+}
+
+// applyPool sets the pool limits that are configured on sqlDB
+func (c Config) applyPool(sqlDB *sql.DB) {
+	if c.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+}
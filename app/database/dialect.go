@@ -0,0 +1,111 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PartialIndex is a unique index over Column that only covers the rows
+// matching Where
+type PartialIndex struct {
+	Name   string
+	Table  string
+	Column string
+	Where  string
+}
+
+// Dialect isolates the SQL that differs between the supported databases.
+// Repositories and migrations otherwise stick to SQL all of them accept.
+type Dialect interface {
+	// Open connects to the database and sizes its connection pool
+	Open(config Config, gormConfig *gorm.Config) (*gorm.DB, error)
+	// CreatePartialUniqueIndex creates index unless it exists
+	CreatePartialUniqueIndex(db *gorm.DB, index PartialIndex) error
+	// SyncSequence moves the ID sequence of table past its highest ID, after
+	// rows were inserted with explicit IDs
+	SyncSequence(db *gorm.DB, table string) error
+}
+
+// dialects are the supported databases by driver name, which matches the
+// name of their GORM dialector
+var dialects = map[string]Dialect{
+	"sqlite":   sqliteDialect{},
+	"postgres": postgresDialect{},
+	"mysql":    mysqlDialect{},
+}
+
+// DialectOf returns the dialect of an open database
+func DialectOf(db *gorm.DB) (Dialect, error) {
+	dialect, ok := dialects[db.Dialector.Name()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver: %s", db.Dialector.Name())
+	}
+	return dialect, nil
+}
+
+// createIndex runs the CREATE UNIQUE INDEX statement built by columns
+// unless the index exists
+func createIndex(db *gorm.DB, index PartialIndex, columns string, where string) error {
+	if db.Migrator().HasIndex(index.Table, index.Name) {
+		return nil
+	}
+	sql := "CREATE UNIQUE INDEX ? ON ? (" + columns + ")"
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	return db.Exec(sql, clause.Column{Name: index.Name}, clause.Table{Name: index.Table}).Error
+}
+
+// openPool connects through dialector and applies the pool limits of config
+func openPool(dialector gorm.Dialector, config Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, gormConfig)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	config.applyPool(sqlDB)
+	return db, nil
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Open(config Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	return openPool(postgres.Open(config.DSN), config, gormConfig)
+}
+
+func (postgresDialect) CreatePartialUniqueIndex(db *gorm.DB, index PartialIndex) error {
+	return createIndex(db, index, db.Statement.Quote(index.Column), index.Where)
+}
+
+// SyncSequence sets the serial sequence of table, which explicit IDs do not
+// advance
+func (postgresDialect) SyncSequence(db *gorm.DB, table string) error {
+	return db.Exec("SELECT setval(pg_get_serial_sequence(?, 'id'), COALESCE(MAX(id), 1)) FROM ?",
+		table, clause.Table{Name: table}).Error
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Open(config Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	return openPool(mysql.Open(config.DSN), config, gormConfig)
+}
+
+// CreatePartialUniqueIndex indexes an expression that is NULL outside of
+// Where, since MySQL has no partial indexes but allows repeated NULLs in
+// unique ones. It needs MySQL 8.0.13 or later.
+func (mysqlDialect) CreatePartialUniqueIndex(db *gorm.DB, index PartialIndex) error {
+	column := db.Statement.Quote(index.Column)
+	return createIndex(db, index, "(CASE WHEN "+index.Where+" THEN "+column+" END)", "")
+}
+
+// SyncSequence is a no-op: AUTO_INCREMENT continues after explicit IDs
+func (mysqlDialect) SyncSequence(db *gorm.DB, table string) error {
+	return nil
+}
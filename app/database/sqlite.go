@@ -0,0 +1,213 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// ErrBusy is returned when SQLite stays locked past the busy timeout, e.g.
+// while another process writes. The operation can be retried.
+var ErrBusy = errors.New("database is busy")
+
+// IsBusy reports whether err is or wraps ErrBusy
+func IsBusy(err error) bool {
+	return errors.Is(err, ErrBusy)
+}
+
+// SQLiteConfig holds the pragmas applied to every SQLite connection
+type SQLiteConfig struct {
+	// JournalMode is WAL by default, which lets reads run during a write
+	JournalMode string
+	// Synchronous is NORMAL by default, which is durable enough with WAL
+	Synchronous string
+	// BusyTimeout is how long a statement waits for a lock before failing
+	// with ErrBusy
+	BusyTimeout time.Duration
+	// ForeignKeys enforces foreign key constraints
+	ForeignKeys bool
+	// CacheSize is the page cache size in pages or, when negative, in KiB
+	CacheSize int
+}
+
+// DefaultSQLiteConfig returns the pragmas used unless configured otherwise
+func DefaultSQLiteConfig() SQLiteConfig {
+	return SQLiteConfig{
+		JournalMode: "WAL",
+		Synchronous: "NORMAL",
+		BusyTimeout: 5 * time.Second,
+		ForeignKeys: true,
+		CacheSize:   -20000,
+	}
+}
+
+// loadSQLiteConfig reads the pragmas:
+//
+//	SQLITE_JOURNAL_MODE   WAL unless set
+//	SQLITE_SYNCHRONOUS    NORMAL unless set
+//	SQLITE_BUSY_TIMEOUT   5s unless set
+//	SQLITE_FOREIGN_KEYS   false to stop enforcing foreign keys
+//	SQLITE_CACHE_SIZE     pages, or KiB when negative; -20000 unless set
+func loadSQLiteConfig(get func(string) string) (SQLiteConfig, error) {
+	config := DefaultSQLiteConfig()
+
+	if value := get("SQLITE_JOURNAL_MODE"); value != "" {
+		config.JournalMode = strings.ToUpper(value)
+		switch config.JournalMode {
+		case "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF":
+		default:
+			return SQLiteConfig{}, fmt.Errorf("SQLITE_JOURNAL_MODE: unknown mode %s", value)
+		}
+	}
+	if value := get("SQLITE_SYNCHRONOUS"); value != "" {
+		config.Synchronous = strings.ToUpper(value)
+		switch config.Synchronous {
+		case "OFF", "NORMAL", "FULL", "EXTRA":
+		default:
+			return SQLiteConfig{}, fmt.Errorf("SQLITE_SYNCHRONOUS: unknown mode %s", value)
+		}
+	}
+	if value := get("SQLITE_BUSY_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return SQLiteConfig{}, fmt.Errorf("SQLITE_BUSY_TIMEOUT: invalid duration %s", value)
+		}
+		config.BusyTimeout = timeout
+	}
+	if value := get("SQLITE_FOREIGN_KEYS"); value != "" {
+		config.ForeignKeys = value != "false"
+	}
+	if value := get("SQLITE_CACHE_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return SQLiteConfig{}, fmt.Errorf("SQLITE_CACHE_SIZE: must be a number")
+		}
+		config.CacheSize = size
+	}
+	return config, nil
+}
+
+// dsn adds the pragmas and extra to path as go-sqlite3 parameters, which
+// apply them whenever the pool opens a connection
+func (c SQLiteConfig) dsn(path string, extra url.Values) string {
+	params := url.Values{}
+	if c.JournalMode != "" {
+		params.Set("_journal_mode", c.JournalMode)
+	}
+	if c.Synchronous != "" {
+		params.Set("_synchronous", c.Synchronous)
+	}
+	params.Set("_busy_timeout", strconv.FormatInt(c.BusyTimeout.Milliseconds(), 10))
+	params.Set("_foreign_keys", strconv.FormatBool(c.ForeignKeys))
+	if c.CacheSize != 0 {
+		params.Set("_cache_size", strconv.Itoa(c.CacheSize))
+	}
+	for name, values := range extra {
+		params[name] = values
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + params.Encode()
+}
+
+// isMemory reports whether dsn is an in-memory database, which every
+// connection sees a separate copy of
+func isMemory(dsn string) bool {
+	return strings.HasPrefix(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
+
+// SQLiteFile returns the database file of a SQLite config, or false for
+// other drivers and in-memory databases
+func (c Config) SQLiteFile() (string, bool) {
+	if c.Driver != "sqlite" || isMemory(c.DSN) {
+		return "", false
+	}
+	path, _, _ := strings.Cut(strings.TrimPrefix(c.DSN, "file:"), "?")
+	return path, true
+}
+
+type sqliteDialect struct{}
+
+// Open uses a write pool of one connection, so writers queue in the pool
+// instead of failing on the database lock, and a read-only pool sized by
+// the config that dbresolver sends queries outside of transactions to.
+// In-memory databases get the write pool only.
+func (sqliteDialect) Open(config Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	writeDSN := config.SQLite.dsn(config.DSN, url.Values{"_txlock": {"immediate"}})
+	writer, err := sql.Open(sqlite.DriverName, writeDSN)
+	if err != nil {
+		return nil, err
+	}
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
+
+	db, err := gorm.Open(sqlite.New(sqlite.Config{Conn: writer}), gormConfig)
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+	if err := registerBusyCallbacks(db); err != nil {
+		return nil, err
+	}
+	if isMemory(config.DSN) {
+		return db, nil
+	}
+
+	reader, err := sql.Open(sqlite.DriverName, config.SQLite.dsn(config.DSN, url.Values{"_query_only": {"true"}}))
+	if err != nil {
+		return nil, err
+	}
+	config.applyPool(reader)
+	err = db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{sqlite.New(sqlite.Config{Conn: reader})},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (sqliteDialect) CreatePartialUniqueIndex(db *gorm.DB, index PartialIndex) error {
+	return createIndex(db, index, db.Statement.Quote(index.Column), index.Where)
+}
+
+// SyncSequence is a no-op: SQLite continues after the highest rowid
+func (sqliteDialect) SyncSequence(db *gorm.DB, table string) error {
+	return nil
+}
+
+// registerBusyCallbacks wraps lock errors of every statement in ErrBusy
+func registerBusyCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	for _, processor := range []interface {
+		Register(name string, fn func(*gorm.DB)) error
+	}{
+		callbacks.Create(), callbacks.Query(), callbacks.Update(),
+		callbacks.Delete(), callbacks.Row(), callbacks.Raw(),
+	} {
+		if err := processor.Register("database:busy", translateBusy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// translateBusy replaces SQLITE_BUSY and SQLITE_LOCKED errors with ErrBusy
+func translateBusy(db *gorm.DB) {
+	var sqliteErr sqlite3.Error
+	if errors.As(db.Error, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked) {
+		db.Error = fmt.Errorf("%w: %w", ErrBusy, db.Error)
+	}
+}
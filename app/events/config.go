@@ -0,0 +1,107 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Config controls how the dispatcher delivers outbox events
+type Config struct {
+	// PollInterval is how often the outbox is checked for due events
+	PollInterval time.Duration
+	// BatchSize is the number of events read from the outbox at a time
+	BatchSize int
+	// MaxAttempts is the number of failed deliveries after which an event
+	// is marked failed and no longer retried
+	MaxAttempts int
+	// RetryBase is the delay after the first failure; it doubles with
+	// every further failure up to RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Retention is how long delivered events are kept
+	Retention time.Duration
+	// WebhookURL, if set, receives every event
+	WebhookURL string
+	// File, if set, gets every event appended as NDJSON
+	File string
+}
+
+// DefaultConfig returns the config used unless configured otherwise
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Second,
+		BatchSize:    100,
+		MaxAttempts:  10,
+		RetryBase:    time.Second,
+		RetryMax:     10 * time.Minute,
+		Retention:    7 * 24 * time.Hour,
+	}
+}
+
+// LoadConfig builds a config from environment style lookups:
+//
+//	OUTBOX_POLL_INTERVAL   how often events are dispatched, 1s unless set
+//	OUTBOX_MAX_ATTEMPTS    deliveries before an event fails, 10 unless set
+//	OUTBOX_RETENTION       how long delivered events are kept, 168h unless set
+//	OUTBOX_WEBHOOK_URL     URL every event is posted to
+//	OUTBOX_FILE            file every event is appended to as NDJSON
+func LoadConfig(lookup func(string) (string, bool)) (Config, error) {
+	config := DefaultConfig()
+
+	if value, ok := lookup("OUTBOX_POLL_INTERVAL"); ok && value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return Config{}, fmt.Errorf("OUTBOX_POLL_INTERVAL: invalid duration %s", value)
+		}
+		config.PollInterval = interval
+	}
+	if value, ok := lookup("OUTBOX_MAX_ATTEMPTS"); ok && value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts < 1 {
+			return Config{}, fmt.Errorf("OUTBOX_MAX_ATTEMPTS: must be a positive number")
+		}
+		config.MaxAttempts = attempts
+	}
+	if value, ok := lookup("OUTBOX_RETENTION"); ok && value != "" {
+		retention, err := time.ParseDuration(value)
+		if err != nil || retention <= 0 {
+			return Config{}, fmt.Errorf("OUTBOX_RETENTION: invalid duration %s", value)
+		}
+		config.Retention = retention
+	}
+	if value, ok := lookup("OUTBOX_WEBHOOK_URL"); ok && value != "" {
+		parsed, err := url.Parse(value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return Config{}, fmt.Errorf("OUTBOX_WEBHOOK_URL: must be an http or https URL")
+		}
+		config.WebhookURL = value
+	}
+	if value, ok := lookup("OUTBOX_FILE"); ok {
+		config.File = value
+	}
+	return config, nil
+}
+
+// Sinks creates the webhook and file sinks the config enables
+func (c Config) Sinks() []Sink {
+	var sinks []Sink
+	if c.WebhookURL != "" {
+		sinks = append(sinks, NewWebhookSink(c.WebhookURL, 10*time.Second))
+	}
+	if c.File != "" {
+		sinks = append(sinks, NewFileSink(c.File))
+	}
+	return sinks
+}
+
+// backoff is the delay before the next attempt after the given number of
+// failed ones
+func (c Config) backoff(attempts int) time.Duration {
+	delay := c.RetryBase
+	for i := 1; i < attempts && delay < c.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, c.RetryMax)
+}
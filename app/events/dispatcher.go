@@ -0,0 +1,134 @@
+package events
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// purgeInterval is how often delivered events past the retention are
+// removed
+const purgeInterval = time.Hour
+
+// Dispatcher delivers the events in the outbox to the sinks at least once.
+// Failed deliveries are retried with exponential backoff, to the sinks that
+// did not take the event yet; the events of a book are delivered in order,
+// so one waiting for a retry holds back the later events of its book.
+type Dispatcher struct {
+	outbox repository.OutboxRepository
+	sinks  []Sink
+	config Config
+	logger *slog.Logger
+}
+
+// NewDispatcher creates a dispatcher delivering the events of outbox to
+// sinks. Failures are logged through logger.
+func NewDispatcher(outbox repository.OutboxRepository, sinks []Sink, config Config, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		outbox: outbox,
+		sinks:  sinks,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Run dispatches every PollInterval and purges delivered events past the
+// retention every hour, until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	poll := time.NewTicker(d.config.PollInterval)
+	defer poll.Stop()
+	purge := time.NewTicker(purgeInterval)
+	defer purge.Stop()
+
+	for {
+		if _, err := d.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			d.logger.ErrorContext(ctx, "Outbox dispatch failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-purge.C:
+			if _, err := d.outbox.DeleteDeliveredBefore(ctx, time.Now().Add(-d.config.Retention)); err != nil {
+				d.logger.ErrorContext(ctx, "Outbox purge failed", "error", err)
+			}
+		}
+	}
+}
+
+// Dispatch delivers the due events of one batch and returns how many were
+// delivered
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	records, err := d.outbox.GetPending(ctx, time.Now(), d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	// held are books with an earlier event of this batch that failed
+	held := make(map[uint]bool)
+	for i := range records {
+		record := &records[i]
+		if held[record.BookID] {
+			continue
+		}
+
+		if err := d.deliver(ctx, record); err != nil {
+			if ctx.Err() != nil {
+				return delivered, ctx.Err()
+			}
+			held[record.BookID] = true
+			d.fail(ctx, record, err)
+		} else {
+			delivered++
+			deliveredAt := time.Now()
+			record.Status = models.OutboxDelivered
+			record.DeliveredAt = &deliveredAt
+		}
+		if err := d.outbox.Update(ctx, record); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// deliver hands the event to every sink that did not take it yet and
+// records the ones that do
+func (d *Dispatcher) deliver(ctx context.Context, record *models.OutboxEvent) error {
+	event, err := FromRecord(*record)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, sink := range d.sinks {
+		if slices.Contains(record.DeliveredSinks, sink.Name()) {
+			continue
+		}
+		if err := sink.Deliver(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+		record.DeliveredSinks = append(record.DeliveredSinks, sink.Name())
+	}
+	return errors.Join(errs...)
+}
+
+// fail schedules the next attempt or, after MaxAttempts, gives up
+func (d *Dispatcher) fail(ctx context.Context, record *models.OutboxEvent, err error) {
+	record.Attempts++
+	record.LastError = err.Error()
+	if record.Attempts >= d.config.MaxAttempts {
+		record.Status = models.OutboxFailed
+		d.logger.ErrorContext(ctx, "Giving up on outbox event", "event_id", record.ID, "type", record.Type, "attempts", record.Attempts, "error", err)
+		return
+	}
+	record.NextAttemptAt = time.Now().Add(d.config.backoff(record.Attempts))
+	d.logger.WarnContext(ctx, "Outbox delivery failed", "event_id", record.ID, "type", record.Type, "attempts", record.Attempts, "retry_at", record.NextAttemptAt, "error", err)
+}
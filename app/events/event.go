@@ -0,0 +1,127 @@
+package events
+
+import (
+	"books-api/app/models"
+	"encoding/json"
+	"time"
+)
+
+// Type names a kind of domain event
+type Type string
+
+const (
+	BookCreated Type = "book.created"
+	BookUpdated Type = "book.updated"
+	BookDeleted Type = "book.deleted"
+)
+
+// IsValid reports whether t is a known event type
+func (t Type) IsValid() bool {
+	switch t {
+	case BookCreated, BookUpdated, BookDeleted:
+		return true
+	}
+	return false
+}
+
+// Event is a change to a book, as delivered to the sinks. Deliveries are
+// at least once, so consumers should ignore an ID they have seen before.
+type Event struct {
+	// ID increases with every event
+	ID       uint `json:"id" example:"42"`
+	Type     Type `json:"type" example:"book.updated"`
+	TenantID uint `json:"tenant_id" example:"1"`
+	BookID   uint `json:"book_id" example:"7"`
+	// Book is the book after the change; for BookDeleted it is the book as
+	// it was deleted
+	Book *models.Book `json:"book"`
+	// Changed lists the JSON names of the fields a BookUpdated changed
+	Changed    []string  `json:"changed,omitempty" example:"title,pages"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// payload is the part of an event stored in the outbox as JSON
+type payload struct {
+	Book    *models.Book `json:"book"`
+	Changed []string     `json:"changed,omitempty"`
+}
+
+// NewBookCreated describes a created book
+func NewBookCreated(book *models.Book) Event {
+	return newBookEvent(BookCreated, book, nil)
+}
+
+// NewBookUpdated describes an update of a book that changed the given
+// fields
+func NewBookUpdated(book *models.Book, changed []string) Event {
+	return newBookEvent(BookUpdated, book, changed)
+}
+
+// NewBookDeleted describes a deleted book
+func NewBookDeleted(book *models.Book) Event {
+	return newBookEvent(BookDeleted, book, nil)
+}
+
+func newBookEvent(eventType Type, book *models.Book, changed []string) Event {
+	return Event{
+		Type:       eventType,
+		TenantID:   book.TenantID,
+		BookID:     book.ID,
+		Book:       book,
+		Changed:    changed,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// ChangedFields returns the JSON names of the client editable fields that
+// differ between before and after
+func ChangedFields(before, after models.Book) []string {
+	var changed []string
+	if before.Title != after.Title {
+		changed = append(changed, "title")
+	}
+	if before.Author != after.Author {
+		changed = append(changed, "author")
+	}
+	if before.Pages != after.Pages {
+		changed = append(changed, "pages")
+	}
+	if (before.Color == nil) != (after.Color == nil) || (before.Color != nil && *before.Color != *after.Color) {
+		changed = append(changed, "color")
+	}
+	return changed
+}
+
+// Record encodes the event as an outbox row
+func (e Event) Record() (*models.OutboxEvent, error) {
+	data, err := json.Marshal(payload{Book: e.Book, Changed: e.Changed})
+	if err != nil {
+		return nil, err
+	}
+	return &models.OutboxEvent{
+		Type:          string(e.Type),
+		BookID:        e.BookID,
+		Payload:       string(data),
+		TenantID:      e.TenantID,
+		Status:        models.OutboxPending,
+		NextAttemptAt: e.OccurredAt,
+		CreatedAt:     e.OccurredAt,
+	}, nil
+}
+
+// FromRecord decodes an outbox row
+func FromRecord(record models.OutboxEvent) (Event, error) {
+	var body payload
+	if err := json.Unmarshal([]byte(record.Payload), &body); err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:         record.ID,
+		Type:       Type(record.Type),
+		TenantID:   record.TenantID,
+		BookID:     record.BookID,
+		Book:       body.Book,
+		Changed:    body.Changed,
+		OccurredAt: record.CreatedAt.UTC(),
+	}, nil
+}
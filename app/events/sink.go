@@ -0,0 +1,149 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Sink receives the events the dispatcher delivers. An error makes the
+// dispatcher retry the event later, with every sink.
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+	Deliver(ctx context.Context, event Event) error
+}
+
+// Handler processes an event in the same process
+type Handler func(ctx context.Context, event Event) error
+
+// Subscribers is a sink calling handlers registered in the same process
+type Subscribers struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewSubscribers creates a sink without handlers
+func NewSubscribers() *Subscribers {
+	return &Subscribers{}
+}
+
+// Subscribe adds a handler for all events delivered from now on
+func (s *Subscribers) Subscribe(handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+func (s *Subscribers) Name() string {
+	return "subscribers"
+}
+
+// Deliver calls every handler, even when an earlier one fails
+func (s *Subscribers) Deliver(ctx context.Context, event Event) error {
+	s.mu.RLock()
+	handlers := s.handlers
+	s.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WebhookSink posts every event as JSON to a URL. Any status other than 2xx
+// is a failure.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a sink posting to url, giving up on a request
+// after timeout
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Deliver posts the event. The X-Event-ID and X-Event-Type headers repeat
+// its ID and type.
+func (s *WebhookSink) Deliver(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatUint(uint64(event.ID), 10))
+	req.Header.Set("X-Event-Type", string(event.Type))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// FileSink appends every event as a line of JSON (NDJSON) to a file
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileSink creates a sink appending to the file at path, which is
+// created if missing
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// Deliver appends the event and flushes it to disk
+func (s *FileSink) Deliver(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Config controls the GraphQL endpoint
+type Config struct {
+	// MaxDepth is the deepest nesting of fields a query may select
+	MaxDepth int
+	// MaxIntrospectionDepth is the deepest nesting of fields a query may
+	// select with introspection fields counted, which MaxDepth leaves out
+	MaxIntrospectionDepth int
+	// MaxComplexity caps the estimated cost of a query: every field costs
+	// one, and the fields below a list count once per requested item
+	MaxComplexity int
+	// Playground serves the in-browser GraphQL IDE at /graphql/playground
+	Playground bool
+}
+
+// DefaultConfig returns the config used unless configured otherwise
+func DefaultConfig() Config {
+	return Config{
+		MaxDepth:              8,
+		MaxIntrospectionDepth: 15,
+		MaxComplexity:         1000,
+		Playground:            true,
+	}
+}
+
+// LoadConfig builds a config from environment style lookups:
+//
+//	GRAPHQL_MAX_DEPTH                deepest allowed field nesting, 8 unless set
+//	GRAPHQL_MAX_INTROSPECTION_DEPTH  deepest nesting with introspection, 15 unless set
+//	GRAPHQL_MAX_COMPLEXITY           highest allowed query cost, 1000 unless set
+//	GRAPHQL_PLAYGROUND               false disables the IDE at /graphql/playground
+func LoadConfig(lookup func(string) (string, bool)) (Config, error) {
+	config := DefaultConfig()
+
+	limits := []struct {
+		name  string
+		value *int
+	}{
+		{"GRAPHQL_MAX_DEPTH", &config.MaxDepth},
+		{"GRAPHQL_MAX_INTROSPECTION_DEPTH", &config.MaxIntrospectionDepth},
+		{"GRAPHQL_MAX_COMPLEXITY", &config.MaxComplexity},
+	}
+	for _, limit := range limits {
+		if value, ok := lookup(limit.name); ok && value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				return Config{}, fmt.Errorf("%s: must be a positive number", limit.name)
+			}
+			*limit.value = parsed
+		}
+	}
+	if value, ok := lookup("GRAPHQL_PLAYGROUND"); ok && value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("GRAPHQL_PLAYGROUND: must be true or false")
+		}
+		config.Playground = enabled
+	}
+	return config, nil
+}
@@ -0,0 +1,174 @@
+package graph
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// listSizes are the page sizes of the list fields taking a first argument,
+// used to estimate the cost of their items when first is not given
+var listSizes = map[string]int{
+	"books":   defaultBooksFirst,
+	"reviews": defaultReviewsFirst,
+}
+
+// queryShape measures the selected operation of a validated document.
+// Introspection fields are left out of depth and complexity, so tools can
+// load the schema whatever the limits; their nesting is measured on its own.
+// Fragments are measured once and remembered, so fragments spreading each
+// other repeatedly cost no more to measure than to parse.
+type queryShape struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// depths and introspectionDepths hold the nesting of each fragment
+	// measured so far, complexities its cost
+	depths              map[string]int
+	introspectionDepths map[string]int
+	complexities        map[string]int
+}
+
+// newQueryShape collects the fragments of doc
+func newQueryShape(doc *ast.Document, variables map[string]interface{}) queryShape {
+	shape := queryShape{
+		fragments:           make(map[string]*ast.FragmentDefinition),
+		variables:           variables,
+		depths:              make(map[string]int),
+		introspectionDepths: make(map[string]int),
+		complexities:        make(map[string]int),
+	}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			shape.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	return shape
+}
+
+// counted reports whether a field is measured, leaving out introspection
+// fields unless introspection is set
+func counted(field *ast.Field, introspection bool) bool {
+	return introspection || !strings.HasPrefix(field.Name.Value, "__")
+}
+
+// depth returns how deeply the fields of set nest, not counting
+// introspection fields
+func (q queryShape) depth(set *ast.SelectionSet) int {
+	return q.nesting(set, false)
+}
+
+// introspectionDepth returns how deeply the fields of set nest, counting
+// introspection fields too
+func (q queryShape) introspectionDepth(set *ast.SelectionSet) int {
+	return q.nesting(set, true)
+}
+
+// nesting returns how deeply the fields of set nest, with the fragments
+// spread into it. Validation has ruled out fragment cycles.
+func (q queryShape) nesting(set *ast.SelectionSet, introspection bool) int {
+	if set == nil {
+		return 0
+	}
+	deepest := 0
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if counted(selection, introspection) {
+				deepest = max(deepest, 1+q.nesting(selection.SelectionSet, introspection))
+			}
+		case *ast.InlineFragment:
+			deepest = max(deepest, q.nesting(selection.SelectionSet, introspection))
+		case *ast.FragmentSpread:
+			deepest = max(deepest, q.fragmentNesting(selection.Name.Value, introspection))
+		}
+	}
+	return deepest
+}
+
+// fragmentNesting returns the nesting of a named fragment, measuring it on
+// first use
+func (q queryShape) fragmentNesting(name string, introspection bool) int {
+	depths := q.depths
+	if introspection {
+		depths = q.introspectionDepths
+	}
+	if depth, ok := depths[name]; ok {
+		return depth
+	}
+	depth := 0
+	if fragment, ok := q.fragments[name]; ok {
+		depth = q.nesting(fragment.SelectionSet, introspection)
+	}
+	depths[name] = depth
+	return depth
+}
+
+// complexity estimates the cost of set: one per field, with the fields
+// below a list counted once per item it may return
+func (q queryShape) complexity(set *ast.SelectionSet) int {
+	if set == nil {
+		return 0
+	}
+	total := 0
+	for _, selection := range set.Selections {
+		cost := 0
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if counted(selection, false) {
+				cost = 1 + q.listSize(selection)*q.complexity(selection.SelectionSet)
+			}
+		case *ast.InlineFragment:
+			cost = q.complexity(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			cost = q.fragmentComplexity(selection.Name.Value)
+		}
+		// Saturates instead of overflowing on absurd queries
+		total = min(total+cost, math.MaxInt32)
+	}
+	return total
+}
+
+// fragmentComplexity returns the cost of a named fragment, estimating it on
+// first use
+func (q queryShape) fragmentComplexity(name string) int {
+	if cost, ok := q.complexities[name]; ok {
+		return cost
+	}
+	cost := 0
+	if fragment, ok := q.fragments[name]; ok {
+		cost = q.complexity(fragment.SelectionSet)
+	}
+	q.complexities[name] = cost
+	return cost
+}
+
+// listSize returns the number of items a field may return: its first
+// argument, the default page size, or one for other fields
+func (q queryShape) listSize(field *ast.Field) int {
+	size, ok := listSizes[field.Name.Value]
+	if !ok {
+		return 1
+	}
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if first, err := strconv.Atoi(value.Value); err == nil {
+				size = first
+			}
+		case *ast.Variable:
+			// JSON numbers decode as float64
+			switch first := q.variables[value.Name.Value].(type) {
+			case float64:
+				size = int(first)
+			case int:
+				size = first
+			}
+		}
+	}
+	return min(max(size, 1), math.MaxInt16)
+}
@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// playgroundScript posts the query of the playground to the endpoint the
+// page is served under
+const playgroundScript = `
+const $ = (id) => document.getElementById(id);
+$("run").addEventListener("click", async () => {
+  const headers = { "Content-Type": "application/json" };
+  if ($("auth").value) headers["Authorization"] = $("auth").value;
+  let variables = {};
+  try {
+    variables = $("variables").value.trim() ? JSON.parse($("variables").value) : {};
+  } catch (err) {
+    $("result").textContent = "Variables are not valid JSON: " + err.message;
+    return;
+  }
+  $("result").textContent = "Running...";
+  try {
+    const response = await fetch(location.pathname.replace(/\/playground$/, ""), {
+      method: "POST",
+      headers,
+      body: JSON.stringify({ query: $("query").value, variables }),
+    });
+    const text = await response.text();
+    try {
+      $("result").textContent = JSON.stringify(JSON.parse(text), null, 2);
+    } catch (err) {
+      $("result").textContent = response.status + " " + text;
+    }
+  } catch (err) {
+    $("result").textContent = "Request failed: " + err.message;
+  }
+});
+`
+
+// playgroundStyle lays out the playground
+const playgroundStyle = `
+body { font-family: sans-serif; margin: 1rem; display: grid; grid-template-columns: 1fr 1fr; gap: 1rem; }
+textarea, pre { font-family: monospace; font-size: 0.9rem; width: 100%; box-sizing: border-box; }
+pre { background: #f4f4f4; padding: 0.5rem; min-height: 30rem; overflow: auto; margin: 0; }
+label { display: block; margin-top: 0.5rem; }
+`
+
+// PlaygroundHTML is a self-contained page for trying out queries; it loads
+// nothing from elsewhere, so it works offline and under a strict CSP
+var PlaygroundHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Books API GraphQL playground</title>
+<style>` + playgroundStyle + `</style>
+</head>
+<body>
+<div>
+<label for="query">Query</label>
+<textarea id="query" rows="20">{
+  books(first: 5) {
+    nodes { id title author averageRating reviews(first: 2) { reviewer rating } }
+    pageInfo { hasNextPage endCursor }
+  }
+}</textarea>
+<label for="variables">Variables (JSON)</label>
+<textarea id="variables" rows="4">{}</textarea>
+<label for="auth">Authorization header</label>
+<input id="auth" type="text" size="60" placeholder="Bearer ...">
+<p><button id="run" type="button">Run</button></p>
+</div>
+<pre id="result"></pre>
+<script>` + playgroundScript + `</script>
+</body>
+</html>
+`
+
+// PlaygroundCSP is the Content-Security-Policy of the playground. It allows
+// only its own inline script and style, by hash, and requests to this origin.
+var PlaygroundCSP = strings.Join([]string{
+	"default-src 'none'",
+	"script-src '" + cspHash(playgroundScript) + "'",
+	"style-src '" + cspHash(playgroundStyle) + "'",
+	"connect-src 'self'",
+	"frame-ancestors 'none'",
+}, "; ")
+
+// cspHash returns the CSP source expression matching an inline element
+func cspHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256-" + base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,364 @@
+package graph
+
+import (
+	"books-api/app/models"
+	"books-api/app/service"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// defaultBooksFirst is the page size of books unless first is given
+	defaultBooksFirst = 20
+	// maxBooksFirst is the largest page of books
+	maxBooksFirst = 100
+	// defaultReviewsFirst is the number of reviews of a book unless first
+	// is given
+	defaultReviewsFirst = 10
+	// cursorPrefix versions the cursor format
+	cursorPrefix = "offset:"
+)
+
+// resolvers resolves the root fields with the services
+type resolvers struct {
+	books   service.BookService
+	reviews service.ReviewService
+}
+
+// newSchema builds the GraphQL schema of the book domain
+func newSchema(r *resolvers) (graphql.Schema, error) {
+	color := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Color",
+		Values: graphql.EnumValueConfigMap{
+			string(models.Red):   &graphql.EnumValueConfig{Value: models.Red},
+			string(models.Green): &graphql.EnumValueConfig{Value: models.Green},
+			string(models.Blue):  &graphql.EnumValueConfig{Value: models.Blue},
+		},
+	})
+
+	tag := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	category := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Category",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"parentId": &graphql.Field{
+				Type: graphql.ID,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if parentID := p.Source.(models.Category).ParentID; parentID != nil {
+						return *parentID, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	review := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Review",
+		Description: "An approved review",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"reviewer":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"rating":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"text":      &graphql.Field{Type: graphql.String},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+
+	book := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Book",
+		Fields: graphql.Fields{
+			"id":     &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"title":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"author": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"pages":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"color": &graphql.Field{
+				Type: color,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if color := p.Source.(*models.Book).Color; color != nil {
+						return *color, nil
+					}
+					return nil, nil
+				},
+			},
+			"averageRating": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"ratingCount":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			// Loaded together with the book
+			"tags":       &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tag)))},
+			"categories": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(category)))},
+			"reviews": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(review))),
+				Description: "The latest approved reviews",
+				Args: graphql.FieldConfigArgument{
+					"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultReviewsFirst},
+				},
+				Resolve: r.bookReviews,
+			},
+		},
+	})
+
+	pageInfo := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	bookConnection := graphql.NewObject(graphql.ObjectConfig{
+		Name: "BookConnection",
+		Fields: graphql.Fields{
+			"nodes":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(book)))},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfo)},
+		},
+	})
+
+	bookInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "BookInput",
+		Description: "Book fields; on update, omitted fields keep their value",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"author": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"pages":  &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"color":  &graphql.InputObjectFieldConfig{Type: color},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"book": &graphql.Field{
+				Type: book,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.book,
+			},
+			"books": &graphql.Field{
+				Type:        graphql.NewNonNull(bookConnection),
+				Description: "Books by ID, or by the sort field, a page at a time",
+				Args: graphql.FieldConfigArgument{
+					"first":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultBooksFirst},
+					"after":    &graphql.ArgumentConfig{Type: graphql.String},
+					"tag":      &graphql.ArgumentConfig{Type: graphql.String},
+					"category": &graphql.ArgumentConfig{Type: graphql.ID},
+					"sort":     &graphql.ArgumentConfig{Type: graphql.String, Description: "id, title or rating; prefix with - for descending"},
+				},
+				Resolve: r.bookPage,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createBook": &graphql.Field{
+				Type: graphql.NewNonNull(book),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(bookInput)},
+				},
+				Resolve: r.createBook,
+			},
+			"updateBook": &graphql.Field{
+				Type: graphql.NewNonNull(book),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(bookInput)},
+				},
+				Resolve: r.updateBook,
+			},
+			"deleteBook": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.deleteBook,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// parseID parses an ID argument
+func parseID(value interface{}) (uint, error) {
+	text, _ := value.(string)
+	id, err := strconv.ParseUint(text, 10, 32)
+	if err != nil {
+		return 0, badInput("invalid ID: " + text)
+	}
+	return uint(id), nil
+}
+
+// encodeCursor turns a list offset into an opaque cursor
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the list offset of a cursor
+func decodeCursor(cursor string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil && strings.HasPrefix(string(decoded), cursorPrefix) {
+		offset, err := strconv.Atoi(strings.TrimPrefix(string(decoded), cursorPrefix))
+		if err == nil && offset >= 0 {
+			return offset, nil
+		}
+	}
+	return 0, badInput("invalid cursor")
+}
+
+// book resolves Query.book
+func (r *resolvers) book(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	book, err := r.books.GetBookByID(p.Context, id)
+	if err != nil {
+		if err.Error() == "book not found" {
+			return nil, nil
+		}
+		return nil, domainError(err)
+	}
+	return book, nil
+}
+
+// bookPage resolves Query.books. One book more than the page is read to
+// tell whether there is a next page.
+func (r *resolvers) bookPage(p graphql.ResolveParams) (interface{}, error) {
+	first, _ := p.Args["first"].(int)
+	if first < 1 || first > maxBooksFirst {
+		return nil, badInput(fmt.Sprintf("first must be between 1 and %d", maxBooksFirst))
+	}
+	filter := models.BookFilter{Limit: first + 1}
+	if after, ok := p.Args["after"].(string); ok {
+		offset, err := decodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		filter.Offset = offset
+	}
+	filter.Tag, _ = p.Args["tag"].(string)
+	filter.Sort, _ = p.Args["sort"].(string)
+	if value, ok := p.Args["category"]; ok {
+		categoryID, err := parseID(value)
+		if err != nil {
+			return nil, err
+		}
+		filter.CategoryID = &categoryID
+	}
+
+	books, err := r.books.GetAllBooks(p.Context, filter)
+	if err != nil {
+		return nil, domainError(err)
+	}
+
+	hasNextPage := len(books) > first
+	if hasNextPage {
+		books = books[:first]
+	}
+	nodes := make([]*models.Book, len(books))
+	for i := range books {
+		nodes[i] = &books[i]
+	}
+	info := map[string]interface{}{"hasNextPage": hasNextPage}
+	if len(nodes) > 0 {
+		info["endCursor"] = encodeCursor(filter.Offset + len(nodes))
+	}
+	return map[string]interface{}{"nodes": nodes, "pageInfo": info}, nil
+}
+
+// bookReviews resolves Book.reviews through the review loader of the
+// request, so the reviews of all books in a result are read at once
+func (r *resolvers) bookReviews(p graphql.ResolveParams) (interface{}, error) {
+	first, _ := p.Args["first"].(int)
+	if first < 1 {
+		return nil, badInput("first must be positive")
+	}
+	load := loadersFrom(p.Context).reviews.Load(p.Context, p.Source.(*models.Book).ID)
+	return func() (interface{}, error) {
+		reviews, err := load()
+		if err != nil {
+			return nil, domainError(err)
+		}
+		return reviews[:min(first, len(reviews))], nil
+	}, nil
+}
+
+// bookInput reads the input argument of a mutation
+func bookInput(args map[string]interface{}) models.Book {
+	input, _ := args["input"].(map[string]interface{})
+	var book models.Book
+	book.Title, _ = input["title"].(string)
+	book.Author, _ = input["author"].(string)
+	book.Pages, _ = input["pages"].(int)
+	if color, ok := input["color"].(models.Color); ok {
+		book.Color = &color
+	}
+	return book
+}
+
+// createBook resolves Mutation.createBook
+func (r *resolvers) createBook(p graphql.ResolveParams) (interface{}, error) {
+	if err := requireWrite(p.Context); err != nil {
+		return nil, err
+	}
+	book := bookInput(p.Args)
+	if err := r.books.CreateBook(p.Context, &book); err != nil {
+		return nil, domainError(err)
+	}
+	return &book, nil
+}
+
+// updateBook resolves Mutation.updateBook
+func (r *resolvers) updateBook(p graphql.ResolveParams) (interface{}, error) {
+	if err := requireWrite(p.Context); err != nil {
+		return nil, err
+	}
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	book, err := r.books.UpdateBook(p.Context, id, bookInput(p.Args))
+	if err != nil {
+		return nil, domainError(err)
+	}
+	return book, nil
+}
+
+// deleteBook resolves Mutation.deleteBook
+func (r *resolvers) deleteBook(p graphql.ResolveParams) (interface{}, error) {
+	if err := requireWrite(p.Context); err != nil {
+		return nil, err
+	}
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	if err := r.books.DeleteBook(p.Context, id); err != nil {
+		return nil, domainError(err)
+	}
+	return true, nil
+}
+
+// newLoaders creates the loaders of one request
+func (r *resolvers) newLoaders() *loaders {
+	return &loaders{
+		reviews: NewLoader(func(ctx context.Context, bookIDs []uint) (map[uint][]models.Review, error) {
+			return r.reviews.GetApprovedReviews(ctx, bookIDs)
+		}),
+	}
+}
@@ -0,0 +1,215 @@
+package graph
+
+import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/database"
+	"books-api/app/models"
+	"books-api/app/service"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Error codes reported in the extensions of GraphQL errors
+const (
+	CodeParseFailed      = "GRAPHQL_PARSE_FAILED"
+	CodeValidationFailed = "GRAPHQL_VALIDATION_FAILED"
+	CodeQueryTooDeep     = "QUERY_TOO_DEEP"
+	CodeQueryTooComplex  = "QUERY_TOO_COMPLEX"
+	CodeBadUserInput     = "BAD_USER_INPUT"
+	CodeUnauthenticated  = "UNAUTHENTICATED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeNotFound         = "NOT_FOUND"
+	CodeUnavailable      = "UNAVAILABLE"
+	CodeInternal         = "INTERNAL_SERVER_ERROR"
+)
+
+// Request is a GraphQL request as posted by clients
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	// ReadOnly rejects mutations, for requests that must not change state
+	// such as GET requests
+	ReadOnly bool `json:"-"`
+}
+
+// Server executes GraphQL requests against the book services
+type Server struct {
+	schema    graphql.Schema
+	resolvers *resolvers
+	config    Config
+}
+
+// NewServer creates a server resolving books with bookService and their
+// reviews with reviewService
+func NewServer(bookService service.BookService, reviewService service.ReviewService, config Config) (*Server, error) {
+	r := &resolvers{books: bookService, reviews: reviewService}
+	schema, err := newSchema(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build GraphQL schema: %w", err)
+	}
+	return &Server{schema: schema, resolvers: r, config: config}, nil
+}
+
+// Config returns the config of the server
+func (s *Server) Config() Config {
+	return s.config
+}
+
+// Execute parses, validates and runs a request. Queries deeper or costlier
+// than the configured limits are rejected before anything is resolved.
+func (s *Server) Execute(ctx context.Context, req Request) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		formatted := gqlerrors.FormatError(err)
+		formatted.Extensions = map[string]interface{}{"code": CodeParseFailed}
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{formatted}}
+	}
+
+	validation := graphql.ValidateDocument(&s.schema, doc, nil)
+	if !validation.IsValid {
+		for i := range validation.Errors {
+			validation.Errors[i].Extensions = map[string]interface{}{"code": CodeValidationFailed}
+		}
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	// Without a single matching operation Execute reports the problem
+	// before resolving anything
+	if operation := selectOperation(doc, req.OperationName); operation != nil {
+		if req.ReadOnly && operation.Operation == ast.OperationTypeMutation {
+			return errorResult(badRequest(CodeBadUserInput, "mutations must be sent with POST"))
+		}
+		shape := newQueryShape(doc, req.Variables)
+		if depth := shape.depth(operation.SelectionSet); depth > s.config.MaxDepth {
+			return errorResult(badRequest(CodeQueryTooDeep, fmt.Sprintf("query depth %d exceeds the limit of %d", depth, s.config.MaxDepth)))
+		}
+		if depth := shape.introspectionDepth(operation.SelectionSet); depth > s.config.MaxIntrospectionDepth {
+			return errorResult(badRequest(CodeQueryTooDeep, fmt.Sprintf("introspection depth %d exceeds the limit of %d", depth, s.config.MaxIntrospectionDepth)))
+		}
+		if complexity := shape.complexity(operation.SelectionSet); complexity > s.config.MaxComplexity {
+			return errorResult(badRequest(CodeQueryTooComplex, fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, s.config.MaxComplexity)))
+		}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, s.resolvers.newLoaders()),
+	})
+}
+
+// selectOperation returns the operation of doc a request runs: the one
+// named, or the only one
+func selectOperation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var selected *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if selected != nil {
+				return nil
+			}
+			selected = operation
+		} else if operation.Name != nil && operation.Name.Value == name {
+			return operation
+		}
+	}
+	return selected
+}
+
+// errorResult returns a result made of a request error
+func errorResult(err error) *graphql.Result {
+	formatted := gqlerrors.FormatError(err)
+	if extended, ok := err.(gqlerrors.ExtendedError); ok {
+		formatted.Extensions = extended.Extensions()
+	}
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{formatted}}
+}
+
+// codedError is an error reported with a code in its extensions
+type codedError struct {
+	code    string
+	message string
+}
+
+func (e *codedError) Error() string {
+	return e.message
+}
+
+// Extensions implements gqlerrors.ExtendedError
+func (e *codedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// badRequest returns an error with the given code
+func badRequest(code, message string) error {
+	return &codedError{code: code, message: message}
+}
+
+// badInput returns an error about invalid arguments
+func badInput(message string) error {
+	return &codedError{code: CodeBadUserInput, message: message}
+}
+
+// domainError gives a service error the code matching the status the REST
+// controllers answer it with
+func domainError(err error) error {
+	message := err.Error()
+	switch {
+	case database.IsBusy(err):
+		return &codedError{code: CodeUnavailable, message: message}
+	case authz.IsForbidden(err), message == "book quota exceeded":
+		return &codedError{code: CodeForbidden, message: message}
+	case message == "book not found":
+		return &codedError{code: CodeNotFound, message: message}
+	case strings.HasPrefix(message, "failed to"):
+		return &codedError{code: CodeInternal, message: message}
+	default:
+		return &codedError{code: CodeBadUserInput, message: message}
+	}
+}
+
+// requireWrite checks that the caller may change books. Reads are
+// authorized by the services; mutations additionally need the write scope
+// the REST routes require.
+func requireWrite(ctx context.Context) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return &codedError{code: CodeUnauthenticated, message: "authentication required"}
+	}
+	if !principal.HasScope(models.ScopeBooksWrite) {
+		return &codedError{code: CodeForbidden, message: "missing scope " + string(models.ScopeBooksWrite)}
+	}
+	return nil
+}
+
+// loaders are the batching loaders of one request
+type loaders struct {
+	reviews *Loader[uint, []models.Review]
+}
+
+// loadersKey is the context key under which the loaders are stored
+type loadersKey struct{}
+
+// withLoaders returns a copy of ctx carrying the loaders of a request
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+// loadersFrom returns the loaders stored in ctx
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
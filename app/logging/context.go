@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"books-api/app/auth"
+	"books-api/app/tenancy"
+	"context"
+	"log/slog"
+)
+
+// requestIDKey is the context key under which the request ID is stored
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// contextHandler adds what is known about the request to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds the request ID, principal and tenant of ctx to the record
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		if id, ok := RequestIDFromContext(ctx); ok {
+			record.AddAttrs(slog.String("request_id", id))
+		}
+		if principal, ok := auth.PrincipalFromContext(ctx); ok {
+			record.AddAttrs(slog.String("principal", principal.Subject), slog.String("auth_method", principal.Method))
+		}
+		if tenant, ok := tenancy.FromContext(ctx); ok {
+			record.AddAttrs(slog.String("tenant", tenant.Slug))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Config selects the format and verbosity of the application logger
+type Config struct {
+	// Format is "json" or "text"
+	Format string
+	// Level is the minimum level logged; Switches.Level lets it change at
+	// runtime
+	Level slog.Leveler
+}
+
+// LoadConfig builds a config from environment style lookups: LOG_FORMAT is
+// json (default) or text, LOG_LEVEL is debug, info (default), warn or error
+func LoadConfig(lookup func(string) (string, bool)) (Config, error) {
+	config := Config{Format: "json", Level: slog.LevelInfo}
+
+	if value, ok := lookup("LOG_FORMAT"); ok && value != "" {
+		config.Format = strings.ToLower(value)
+		if config.Format != "json" && config.Format != "text" {
+			return Config{}, fmt.Errorf("LOG_FORMAT: must be json or text")
+		}
+	}
+	if value, ok := lookup("LOG_LEVEL"); ok && value != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return Config{}, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+		config.Level = level
+	}
+	return config, nil
+}
+
+// New creates a logger writing to w. Records logged with a context carry
+// the request ID, principal and tenant found in it, and secrets are
+// redacted from every record.
+func New(w io.Writer, config Config) *slog.Logger {
+	options := &slog.HandlerOptions{
+		Level:       config.Level,
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	if config.Format == "text" {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+	return slog.New(&contextHandler{handler})
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces sensitive values in log records
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"authorization": true,
+	"api_key":       true,
+	"x-api-key":     true,
+	"key_hash":      true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
+
+// secretPatterns match credentials that end up inside messages or values
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// Plaintext API keys
+	{regexp.MustCompile(`bk_[A-Za-z0-9_-]{8,}`), "bk_" + Redacted},
+	// Bearer credentials
+	{regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`), "Bearer " + Redacted},
+	// JWTs outside an Authorization header
+	{regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), Redacted},
+}
+
+// redact hides the values of sensitive attributes and scrubs credentials
+// from strings, including the message
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, Redacted)
+	}
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, RedactString(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			return slog.String(attr.Key, RedactString(err.Error()))
+		}
+	}
+	return attr
+}
+
+// RedactString scrubs API keys, bearer credentials and JWTs from s
+func RedactString(s string) string {
+	for _, secret := range secretPatterns {
+		s = secret.pattern.ReplaceAllString(s, secret.replacement)
+	}
+	return s
+}
+
+// RedactURL reduces a URL to its scheme and host. Paths and queries of
+// callback URLs often carry tokens, so they are replaced as a whole.
+func RedactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return Redacted
+	}
+	redacted := parsed.Scheme + "://" + parsed.Host
+	if parsed.Path != "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+		redacted += "/" + Redacted
+	}
+	return redacted
+}
@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// DefaultSlowThreshold is the slow query threshold unless configured
+// otherwise
+const DefaultSlowThreshold = 200 * time.Millisecond
+
+// SQLConfig configures SQL logging
+type SQLConfig struct {
+	// Statements logs every statement at debug level
+	Statements bool
+	// SlowThreshold is the duration above which queries are logged at warn
+	// level; zero disables it
+	SlowThreshold time.Duration
+	// RedactParams leaves parameter values out of logged SQL, which then
+	// shows placeholders
+	RedactParams bool
+}
+
+// LoadSQLConfig builds a SQL logging config from environment style lookups:
+//
+//	SQL_LOG_STATEMENTS   true to log every statement at debug level
+//	SQL_SLOW_THRESHOLD   slow query threshold, 200ms unless set; 0 disables it
+//	SQL_REDACT_PARAMS    false to log parameter values
+func LoadSQLConfig(lookup func(string) (string, bool)) (SQLConfig, error) {
+	config := SQLConfig{SlowThreshold: DefaultSlowThreshold, RedactParams: true}
+
+	if value, ok := lookup("SQL_LOG_STATEMENTS"); ok {
+		config.Statements = value == "true"
+	}
+	if value, ok := lookup("SQL_SLOW_THRESHOLD"); ok && value != "" {
+		threshold, err := time.ParseDuration(value)
+		if err != nil || threshold < 0 {
+			return SQLConfig{}, fmt.Errorf("SQL_SLOW_THRESHOLD: invalid duration %s", value)
+		}
+		config.SlowThreshold = threshold
+	}
+	if value, ok := lookup("SQL_REDACT_PARAMS"); ok {
+		config.RedactParams = value != "false"
+	}
+	return config, nil
+}
+
+// SQLLogger is a GORM logger writing to slog. Failed queries are logged at
+// error level, queries over the slow threshold at warn level and, when
+// switched on, every statement at debug level, each with its duration, rows
+// affected, calling repository and the request of the context.
+type SQLLogger struct {
+	logger       *slog.Logger
+	switches     *Switches
+	redactParams bool
+	// level is set through LogMode, e.g. by db.Debug()
+	level gormlogger.LogLevel
+}
+
+// NewSQLLogger creates a GORM logger. Statement logging and the slow
+// threshold are read from switches on every query, so they can change at
+// runtime.
+func NewSQLLogger(logger *slog.Logger, switches *Switches, redactParams bool) *SQLLogger {
+	return &SQLLogger{
+		logger:       logger,
+		switches:     switches,
+		redactParams: redactParams,
+		level:        gormlogger.Warn,
+	}
+}
+
+// LogMode returns a copy logging at level. gormlogger.Info logs every
+// statement of the session regardless of the switches.
+func (l *SQLLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+func (l *SQLLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *SQLLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *SQLLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs a finished statement
+func (l *SQLLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	threshold := l.switches.SlowThreshold()
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := threshold > 0 && elapsed > threshold
+	statements := l.level >= gormlogger.Info || l.switches.SQLStatements()
+
+	var level slog.Level
+	var msg string
+	switch {
+	case failed && l.level >= gormlogger.Error:
+		level, msg = slog.LevelError, "SQL query failed"
+	case slow && l.level >= gormlogger.Warn:
+		level, msg = slog.LevelWarn, "Slow SQL query"
+	case statements:
+		level, msg = slog.LevelDebug, "SQL query"
+	default:
+		return
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Duration("duration", elapsed),
+		slog.String("caller", utils.FileWithLineNum()),
+	}
+	if rows >= 0 {
+		attrs = append(attrs, slog.Int64("rows", rows))
+	}
+	if level == slog.LevelError {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if level == slog.LevelWarn {
+		attrs = append(attrs, slog.Duration("threshold", threshold))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter leaves parameter values out of logged SQL when configured;
+// GORM only uses it for logging
+func (l *SQLLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.redactParams {
+		return sql, nil
+	}
+	return sql, params
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Settings are the logging settings that can change at runtime
+type Settings struct {
+	// Level is the minimum level logged: debug, info, warn or error
+	Level string `json:"level" example:"info"`
+	// SQLStatements logs every SQL statement at debug level
+	SQLStatements bool `json:"sql_statements"`
+	// SlowQueryThreshold is the duration above which queries are logged
+	// at warn level; "0s" disables it
+	SlowQueryThreshold string `json:"slow_query_threshold" example:"200ms"`
+}
+
+// SettingsUpdate changes the settings that are set
+type SettingsUpdate struct {
+	Level              *string `json:"level,omitempty" example:"debug"`
+	SQLStatements      *bool   `json:"sql_statements,omitempty"`
+	SlowQueryThreshold *string `json:"slow_query_threshold,omitempty" example:"50ms"`
+}
+
+// Switches holds the runtime logging settings shared by the application
+// logger and the SQL logger
+type Switches struct {
+	level         slog.LevelVar
+	sqlStatements atomic.Bool
+	slowThreshold atomic.Int64
+}
+
+// NewSwitches creates switches with the configured settings
+func NewSwitches(config Config, sql SQLConfig) *Switches {
+	s := &Switches{}
+	if config.Level != nil {
+		s.level.Set(config.Level.Level())
+	}
+	s.sqlStatements.Store(sql.Statements)
+	s.slowThreshold.Store(int64(sql.SlowThreshold))
+	return s
+}
+
+// Level is the leveler to build the application logger with
+func (s *Switches) Level() slog.Leveler {
+	return &s.level
+}
+
+// SQLStatements reports whether every SQL statement is logged
+func (s *Switches) SQLStatements() bool {
+	return s.sqlStatements.Load()
+}
+
+// SlowThreshold returns the duration above which queries are slow; zero
+// when slow queries are not logged
+func (s *Switches) SlowThreshold() time.Duration {
+	return time.Duration(s.slowThreshold.Load())
+}
+
+// Settings returns the current settings
+func (s *Switches) Settings() Settings {
+	return Settings{
+		Level:              strings.ToLower(s.level.Level().String()),
+		SQLStatements:      s.SQLStatements(),
+		SlowQueryThreshold: s.SlowThreshold().String(),
+	}
+}
+
+// Apply changes the settings named in update. Nothing changes when any of
+// them is invalid.
+func (s *Switches) Apply(update SettingsUpdate) (Settings, error) {
+	var level slog.Level
+	if update.Level != nil {
+		if err := level.UnmarshalText([]byte(*update.Level)); err != nil {
+			return Settings{}, fmt.Errorf("invalid level: %s", *update.Level)
+		}
+	}
+	var threshold time.Duration
+	if update.SlowQueryThreshold != nil {
+		var err error
+		threshold, err = time.ParseDuration(*update.SlowQueryThreshold)
+		if err != nil || threshold < 0 {
+			return Settings{}, fmt.Errorf("invalid slow query threshold: %s", *update.SlowQueryThreshold)
+		}
+	}
+
+	if update.Level != nil {
+		s.level.Set(level)
+	}
+	if update.SQLStatements != nil {
+		s.sqlStatements.Store(*update.SQLStatements)
+	}
+	if update.SlowQueryThreshold != nil {
+		s.slowThreshold.Store(int64(threshold))
+	}
+	return s.Settings(), nil
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"books-api/app/auth"
+	"books-api/app/models"
+	"books-api/app/service"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader is the request header carrying the plaintext API key
+const APIKeyHeader = "X-API-Key"
+
+// authenticatedKey marks gin contexts whose credentials were already checked
+const authenticatedKey = "auth.authenticated"
+
+// Authenticator resolves the caller of a request from an API key, a bearer
+// token or a TLS client certificate and enforces scopes on routes
+type Authenticator struct {
+	keyService service.APIKeyService
+	// tokens verifies bearer tokens; nil when JWT authentication is disabled
+	tokens auth.TokenVerifier
+	// anonymousReads lets requests without credentials through books:read routes
+	anonymousReads bool
+	// clientCertScopes are granted to verified TLS client certificates;
+	// nil when certificates are not accepted as credentials
+	clientCertScopes []models.Scope
+}
+
+// NewAuthenticator creates authentication middleware. tokens may be nil to
+// accept API keys only. When anonymousReads is set, routes requiring only
+// books:read stay open to callers without credentials; supplied credentials
+// are still validated.
+func NewAuthenticator(keyService service.APIKeyService, tokens auth.TokenVerifier, anonymousReads bool) *Authenticator {
+	return &Authenticator{
+		keyService:     keyService,
+		tokens:         tokens,
+		anonymousReads: anonymousReads,
+	}
+}
+
+// Credentials are what a caller presents to authenticate, whatever the
+// transport
+type Credentials struct {
+	// APIKey is the plaintext API key
+	APIKey string
+	// Authorization is the Authorization header, e.g. "Bearer <token>"
+	Authorization string
+	// TLS is the state of the caller's connection; nil over plain text
+	TLS *tls.ConnectionState
+}
+
+// AcceptClientCertificates makes verified TLS client certificates
+// credentials of their own: requests without an API key or bearer token
+// authenticate as the certificate's subject with scopes. Certificates are
+// verified by the TLS layer against the configured client CAs.
+func (a *Authenticator) AcceptClientCertificates(scopes []models.Scope) {
+	a.clientCertScopes = scopes
+}
+
+// Authenticate returns a handler that resolves the principal of every
+// request ahead of the route handlers, so that later middleware can depend
+// on it. Unusable credentials get 401; requests without credentials pass and
+// are left to Require.
+func (a *Authenticator) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := a.principal(c); err != nil {
+			abortUnauthorized(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Require returns a handler that rejects requests whose principal lacks
+// scope. Missing or unusable credentials get 401, principals without the
+// scope get 403. The principal is stored in the request context so the
+// service layer can see who is calling.
+func (a *Authenticator) Require(scope models.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := a.principal(c)
+		if err != nil {
+			abortUnauthorized(c, err)
+			return
+		}
+
+		if principal == nil {
+			if a.AllowsAnonymous(scope) {
+				c.Next()
+				return
+			}
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		if !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing scope " + string(scope)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// AllowsAnonymous reports whether callers without credentials may use what
+// requires scope
+func (a *Authenticator) AllowsAnonymous(scope models.Scope) bool {
+	return scope == models.ScopeBooksRead && a.anonymousReads
+}
+
+// RequirePlatform returns a handler that rejects principals bound to a
+// tenant. It guards routes that act across tenants, such as tenant and key
+// administration, and must follow Require.
+func (a *Authenticator) RequirePlatform() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := PrincipalFromRequest(c); ok && principal.Tenant != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "tenant credentials cannot manage the platform"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// principal authenticates the request once and stores the principal in the
+// request context. It returns nil when no credentials were supplied.
+func (a *Authenticator) principal(c *gin.Context) (*auth.Principal, error) {
+	if c.GetBool(authenticatedKey) {
+		principal, _ := PrincipalFromRequest(c)
+		return principal, nil
+	}
+
+	principal, err := a.authenticate(c)
+	if err != nil {
+		return nil, err
+	}
+	c.Set(authenticatedKey, true)
+	if principal != nil {
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+	}
+	return principal, nil
+}
+
+// abortUnauthorized rejects a request whose credentials could not be used
+func abortUnauthorized(c *gin.Context, err error) {
+	if strings.HasPrefix(err.Error(), "invalid token") {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+// authenticate returns the principal of the request, or nil when no
+// credentials were supplied
+func (a *Authenticator) authenticate(c *gin.Context) (*auth.Principal, error) {
+	return a.Principal(c.Request.Context(), Credentials{
+		APIKey:        c.GetHeader(APIKeyHeader),
+		Authorization: c.GetHeader("Authorization"),
+		TLS:           c.Request.TLS,
+	})
+}
+
+// Principal returns the principal of credentials, or nil when none were
+// supplied. An API key takes precedence over a bearer token, which takes
+// precedence over a client certificate.
+func (a *Authenticator) Principal(ctx context.Context, credentials Credentials) (*auth.Principal, error) {
+	if rawKey := strings.TrimSpace(credentials.APIKey); rawKey != "" {
+		key, err := a.keyService.Authenticate(ctx, rawKey)
+		if err != nil {
+			return nil, err
+		}
+		return &auth.Principal{
+			Subject: fmt.Sprintf("api_key:%d", key.ID),
+			Method:  auth.MethodAPIKey,
+			Scopes:  key.Scopes,
+			Tenant:  key.Tenant,
+		}, nil
+	}
+
+	header := credentials.Authorization
+	if header == "" {
+		return a.clientCertificate(credentials.TLS), nil
+	}
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, fmt.Errorf("unsupported authorization scheme")
+	}
+	if a.tokens == nil {
+		return nil, fmt.Errorf("bearer tokens are not accepted")
+	}
+	return a.tokens.Verify(ctx, strings.TrimSpace(token))
+}
+
+// clientCertificate returns the principal of the connection's verified
+// client certificate, or nil when there is none or certificates are not
+// accepted
+func (a *Authenticator) clientCertificate(state *tls.ConnectionState) *auth.Principal {
+	if a.clientCertScopes == nil || state == nil || len(state.VerifiedChains) == 0 {
+		return nil
+	}
+	cert := state.VerifiedChains[0][0]
+	subject := cert.Subject.CommonName
+	if subject == "" {
+		subject = cert.SerialNumber.String()
+	}
+	return &auth.Principal{
+		Subject: subject,
+		Issuer:  cert.Issuer.CommonName,
+		Method:  auth.MethodClientCert,
+		Scopes:  a.clientCertScopes,
+	}
+}
+
+// PrincipalFromRequest returns the principal that authenticated the request
+func PrincipalFromRequest(c *gin.Context) (*auth.Principal, bool) {
+	return auth.PrincipalFromContext(c.Request.Context())
+}
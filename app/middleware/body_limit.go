@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxBodyBytes is the request body limit used unless configured
+// otherwise
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// MaxBodySize returns a handler that refuses request bodies larger than
+// limit bytes. Requests announcing a larger Content-Length get 413 at once;
+// for the others the body is cut off at the limit, and handlers reading
+// past it get an *http.MaxBytesError they answer with 413.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig describes which browser origins may call the API
+type CORSConfig struct {
+	// AllowedOrigins lists origins such as https://app.example.com; "*"
+	// allows any origin. Empty disables CORS.
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers a preflight may ask for
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers scripts may read
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and HTTP authentication
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig returns a config for the API's methods and headers that
+// allows no origin yet
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowedHeaders: []string{"Authorization", "Content-Type", APIKeyHeader, TenantHeader, RequestIDHeader, "Last-Event-ID"},
+		ExposedHeaders: []string{"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", RequestIDHeader},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// Validate rejects configs browsers would refuse or that would be unsafe
+func (c CORSConfig) Validate() error {
+	if c.AllowCredentials && c.allowsAnyOrigin() {
+		return fmt.Errorf("credentials cannot be allowed for any origin")
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin != "*" && strings.HasSuffix(origin, "/") {
+			return fmt.Errorf("origin %s must not end with a slash", origin)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("max age cannot be negative")
+	}
+	return nil
+}
+
+// AllowsOrigin reports whether browsers on origin may call the API
+func (c CORSConfig) AllowsOrigin(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c CORSConfig) allowsAnyOrigin() bool {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS returns a handler that answers preflight requests and marks
+// responses to allowed origins as readable. Disallowed preflights get 403;
+// other requests from disallowed origins are served without CORS headers,
+// so the browser hides the response. It must run before authentication,
+// as browsers send preflights without credentials.
+func CORS(config CORSConfig) gin.HandlerFunc {
+	origins := make(map[string]bool, len(config.AllowedOrigins))
+	for _, origin := range config.AllowedOrigins {
+		origins[strings.ToLower(origin)] = true
+	}
+	anyOrigin := config.allowsAnyOrigin()
+
+	methods := make(map[string]bool, len(config.AllowedMethods))
+	for _, method := range config.AllowedMethods {
+		methods[strings.ToUpper(method)] = true
+	}
+	headers := make(map[string]bool, len(config.AllowedHeaders))
+	for _, header := range config.AllowedHeaders {
+		headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	allowMethods := strings.Join(config.AllowedMethods, ", ")
+	allowHeaders := strings.Join(config.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		// Responses differ per origin, so caches must keep them apart
+		c.Writer.Header().Add("Vary", "Origin")
+
+		if !anyOrigin && !origins[strings.ToLower(origin)] {
+			if preflight {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "origin not allowed"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		if !methods[strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "method not allowed"})
+			return
+		}
+		for _, header := range strings.Split(c.GetHeader("Access-Control-Request-Headers"), ",") {
+			header = strings.TrimSpace(header)
+			if header != "" && !headers[http.CanonicalHeaderKey(header)] {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "header not allowed: " + header})
+				return
+			}
+		}
+
+		c.Header("Access-Control-Allow-Methods", allowMethods)
+		if allowHeaders != "" {
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+		}
+		if config.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"books-api/app/auth"
+	"books-api/app/ratelimit"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimiter throttles callers with token buckets kept in a store
+type RateLimiter struct {
+	store  ratelimit.Store
+	config ratelimit.Config
+	logger *slog.Logger
+}
+
+// NewRateLimiter creates rate limiting middleware. Store failures are logged
+// through logger.
+func NewRateLimiter(store ratelimit.Store, config ratelimit.Config, logger *slog.Logger) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Limit returns a handler that applies the limit configured for group. Each
+// caller has its own bucket per group: API keys and token principals are
+// limited by identity, anonymous callers by client IP. Responses carry
+// RateLimit-* headers; callers over the limit get 429 with Retry-After. It
+// must run after Authenticator.Authenticate.
+func (l *RateLimiter) Limit(group string) gin.HandlerFunc {
+	return l.limit(group, callerKey, true)
+}
+
+// LimitClients returns a handler that applies the limit configured for group
+// per client IP, whatever credentials the request carries. It runs before
+// Authenticator.Authenticate so that requests with invalid credentials, and
+// the key lookups they cause, are throttled as well. RateLimit-* headers are
+// only sent with 429 responses and otherwise left to the group limits.
+func (l *RateLimiter) LimitClients(group string) gin.HandlerFunc {
+	return l.limit(group, clientKey, false)
+}
+
+// limit applies the limit of group to the buckets named by key; advertise
+// sends RateLimit-* headers with allowed responses too
+func (l *RateLimiter) limit(group string, key func(c *gin.Context) string, advertise bool) gin.HandlerFunc {
+	limit := l.config.For(group)
+	if limit.IsUnlimited() {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	// The window is advertised in whole seconds, rounded up so that clients
+	// pacing themselves by it stay under the limit
+	policy := fmt.Sprintf("%d;w=%s", limit.Requests, ceilSeconds(limit.Period))
+	if limit.Burst > 0 {
+		policy += fmt.Sprintf(";burst=%d", limit.Burst)
+	}
+
+	return func(c *gin.Context) {
+		result, err := l.store.Take(c.Request.Context(), group+"|"+key(c), limit, time.Now())
+		if err != nil {
+			// An unavailable store must not take the API down with it
+			l.logger.ErrorContext(c.Request.Context(), "Rate limit store failed, allowing request", "group", group, "error", err)
+			c.Next()
+			return
+		}
+
+		if advertise || !result.Allowed {
+			c.Header("RateLimit-Policy", policy)
+			c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+			c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			c.Header("RateLimit-Reset", ceilSeconds(result.Reset))
+		}
+
+		if !result.Allowed {
+			c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Allow takes a request of a caller outside of gin, such as a gRPC call,
+// from its bucket in group. Callers are keyed like Limit keys them: by
+// principal, or by client address when principal is nil. It reports whether
+// the call may proceed and, if not, when it may be retried. An unavailable
+// store allows the call.
+func (l *RateLimiter) Allow(ctx context.Context, group string, principal *auth.Principal, client string) (bool, time.Duration) {
+	limit := l.config.For(group)
+	if limit.IsUnlimited() {
+		return true, 0
+	}
+
+	key := "ip:" + client
+	if principal != nil {
+		key = principalKey(principal)
+	}
+	result, err := l.store.Take(ctx, group+"|"+key, limit, time.Now())
+	if err != nil {
+		l.logger.ErrorContext(ctx, "Rate limit store failed, allowing request", "group", group, "error", err)
+		return true, 0
+	}
+	return result.Allowed, result.RetryAfter
+}
+
+// callerKey identifies the caller a bucket belongs to
+func callerKey(c *gin.Context) string {
+	if principal, ok := PrincipalFromRequest(c); ok {
+		return principalKey(principal)
+	}
+	return clientKey(c)
+}
+
+// principalKey identifies an authenticated caller
+func principalKey(principal *auth.Principal) string {
+	if principal.Method == auth.MethodAPIKey {
+		return principal.Subject
+	}
+	return principal.Method + ":" + principal.Issuer + "/" + principal.Subject
+}
+
+// clientKey identifies the client address a bucket belongs to
+func clientKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds renders a duration as whole seconds, rounded up
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"books-api/app/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader is the request and response header carrying the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID matches request IDs accepted from callers; anything else
+// could be used to forge log lines
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:=+/-]{1,128}$`)
+
+// RequestID returns a handler that gives every request an ID: the one sent
+// in X-Request-ID when it is well formed, otherwise a new random one. The
+// ID is echoed in the response and stored in the request context, where
+// the logger picks it up. It should run first.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// newRequestID returns a random 128 bit ID
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
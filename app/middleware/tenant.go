@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"books-api/app/auth"
+	"books-api/app/models"
+	"books-api/app/service"
+	"books-api/app/tenancy"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHeader is the request header naming the tenant by slug
+const TenantHeader = "X-Tenant"
+
+// TenantResolver selects the tenant a request acts for
+type TenantResolver struct {
+	tenantService service.TenantService
+	// baseDomain is the host under which subdomains name tenants; empty
+	// disables subdomain resolution
+	baseDomain string
+	// fallback is the slug used when the request names no tenant; empty
+	// makes a tenant mandatory
+	fallback string
+}
+
+// NewTenantResolver creates tenant resolution middleware. Requests name
+// their tenant with the X-Tenant header or a subdomain of baseDomain, and
+// otherwise get fallback.
+func NewTenantResolver(tenantService service.TenantService, baseDomain, fallback string) *TenantResolver {
+	return &TenantResolver{
+		tenantService: tenantService,
+		baseDomain:    strings.ToLower(strings.TrimPrefix(baseDomain, ".")),
+		fallback:      fallback,
+	}
+}
+
+// TenantError is a request for a tenant the caller cannot act for
+type TenantError struct {
+	// Status is the HTTP status answering the request
+	Status  int
+	Message string
+}
+
+func (e *TenantError) Error() string {
+	return e.Message
+}
+
+// Resolve returns a handler that stores the request's tenant in the request
+// context, where the repositories pick it up. It must run after
+// Authenticator.Authenticate.
+func (t *TenantResolver) Resolve() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := PrincipalFromRequest(c)
+		tenant, err := t.Tenant(c.Request.Context(), principal, t.Requested(c.GetHeader(TenantHeader), c.Request.Host))
+		if err != nil {
+			c.AbortWithStatusJSON(err.Status, gin.H{"error": err.Message})
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenancy.WithTenant(c.Request.Context(), tenant))
+		c.Next()
+	}
+}
+
+// Tenant returns the tenant a caller acts for: the one the request names
+// by slug, or the fallback. Principals bound to a tenant through their token
+// claim or API key always act for that tenant and are refused when the
+// request names another one. principal is nil for anonymous callers.
+func (t *TenantResolver) Tenant(ctx context.Context, principal *auth.Principal, requested string) (*models.Tenant, *TenantError) {
+	slug := requested
+	if principal != nil && principal.Tenant != "" {
+		if slug != "" && slug != principal.Tenant {
+			return nil, &TenantError{Status: http.StatusForbidden, Message: "credentials are not valid for tenant " + slug}
+		}
+		slug = principal.Tenant
+	}
+	if slug == "" {
+		slug = t.fallback
+	}
+	if slug == "" {
+		return nil, &TenantError{Status: http.StatusBadRequest, Message: "tenant is required"}
+	}
+
+	tenant, err := t.tenantService.GetTenantBySlug(ctx, slug)
+	if err != nil {
+		return nil, &TenantError{Status: http.StatusNotFound, Message: err.Error()}
+	}
+	return tenant, nil
+}
+
+// Requested returns the slug a request names with the tenant header or the
+// subdomain of host, if any
+func (t *TenantResolver) Requested(header, host string) string {
+	if slug := strings.TrimSpace(header); slug != "" {
+		return strings.ToLower(slug)
+	}
+	if t.baseDomain == "" {
+		return ""
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	sub, found := strings.CutSuffix(host, "."+t.baseDomain)
+	if !found || sub == "" || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
@@ -5,4 +5,9 @@ import "gorm.io/gorm"
 // MigrationManager defines the interface for database migrations
 type MigrationManager interface {
 	RunMigrations(db *gorm.DB) error
+	// Version is the schema version RunMigrations migrates to
+	Version() int
+	// AppliedVersion returns the schema version db was last migrated to, or
+	// 0 when it predates schema versions
+	AppliedVersion(db *gorm.DB) (int, error)
 }
@@ -1,34 +1,226 @@
 package migrations
 
 import (
+	"books-api/app/database"
 	"books-api/app/models"
-	"log"
+	"fmt"
+	"log/slog"
+	"time"
 	"gorm.io/gorm"
 )
 
+// schemaVersion identifies the schema RunMigrations creates. Bump it when a
+// model, index or seed changes, so restores can tell snapshots apart.
+const schemaVersion = 6
+
+// appliedSchema records the schema version of a database (table 'schema_version')
+type appliedSchema struct {
+	ID         uint `gorm:"primaryKey"`
+	Version    int  `gorm:"not null"`
+	MigratedAt time.Time
+}
+
+// TableName names the single row table of the schema version
+func (appliedSchema) TableName() string {
+	return "schema_version"
+}
+
+// partialIndexes are unique indexes limited to some rows, which GORM tags
+// cannot declare for every database
+var partialIndexes = []database.PartialIndex{
+	// A copy is on at most one active loan
+	{Name: "idx_loans_active_copy", Table: "loans", Column: "copy_id", Where: "returned_at IS NULL"},
+	// A copy is set aside for at most one hold
+	{Name: "idx_holds_ready_copy", Table: "holds", Column: "copy_id", Where: "status = 'ready'"},
+}
+
+// sharedUniqueIndexes made barcodes, member emails and card numbers, and
+// tag names unique across all tenants. They are unique per tenant now.
+var sharedUniqueIndexes = []struct {
+	model interface{}
+	name  string
+}{
+	{&models.Copy{}, "idx_copies_barcode"},
+	{&models.Member{}, "idx_members_email"},
+	{&models.Member{}, "idx_members_card_number"},
+	{&models.Tag{}, "idx_tags_name"},
+}
+
+// inheritedTenants are the tables whose rows belong to the tenant of the row
+// they refer to through column, in the order they are assigned
+var inheritedTenants = []struct {
+	table, column, parent string
+}{
+	{"copies", "book_id", "books"},
+	{"loans", "book_id", "books"},
+	{"holds", "book_id", "books"},
+	{"reviews", "book_id", "books"},
+	{"fines", "loan_id", "loans"},
+}
+
 // migrationManager implements the MigrationManager interface
-type migrationManager struct{}
+type migrationManager struct {
+	logger *slog.Logger
+}
 
-// NewMigrationManager creates a new instance of migration manager
-func NewMigrationManager() MigrationManager {
-	return &migrationManager{}
+// NewMigrationManager creates a new instance of migration manager. Progress
+// and failures are logged through logger.
+func NewMigrationManager(logger *slog.Logger) MigrationManager {
+	return &migrationManager{
+		logger: logger,
+	}
 }
 
 // RunMigrations executes all database migrations
 func (m *migrationManager) RunMigrations(db *gorm.DB) error {
-	log.Println("Starting database migrations...")
+	m.logger.Info("Starting database migrations")
+	
+	dialect, err := database.DialectOf(db)
+	if err != nil {
+		return err
+	}
 	
 	// Auto-migrate all models
-	err := db.AutoMigrate(
+	err = db.AutoMigrate(
 		&models.Book{},
+		&models.Tag{},
+		&models.Category{},
+		&models.Review{},
+		&models.Copy{},
+		&models.Loan{},
+		&models.Hold{},
+		&models.Member{},
+		&models.Fine{},
+		&models.APIKey{},
+		&models.Tenant{},
+		&models.OutboxEvent{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.ChangeCounter{},
+		&models.BookTombstone{},
+		&appliedSchema{},
 		// Add more models here as your application grows
 	)
 	
 	if err != nil {
-		log.Printf("Failed to run migrations: %v", err)
+		m.logger.Error("Failed to run migrations", "error", err)
+		return err
+	}
+	
+	for _, index := range partialIndexes {
+		if err := dialect.CreatePartialUniqueIndex(db, index); err != nil {
+			m.logger.Error("Failed to create index", "index", index.Name, "error", err)
+			return err
+		}
+	}
+	
+	for _, index := range sharedUniqueIndexes {
+		if !db.Migrator().HasIndex(index.model, index.name) {
+			continue
+		}
+		if err := db.Migrator().DropIndex(index.model, index.name); err != nil {
+			m.logger.Error("Failed to drop index", "index", index.name, "error", err)
+			return err
+		}
+	}
+	
+	// Circulation data and reviews written before they were scoped to
+	// tenants follow their book; members, tags and categories stay with the
+	// default tenant
+	if err := assignInheritedTenants(db); err != nil {
+		m.logger.Error("Failed to assign tenants", "error", err)
+		return err
+	}
+	
+	// Books created before tenants existed belong to the default tenant
+	if err := seedDefaultTenant(db, dialect); err != nil {
+		m.logger.Error("Failed to create default tenant", "error", err)
 		return err
 	}
 	
-	log.Println("Database migrations completed successfully")
+	// Incremental sync needs every book to have a change sequence number
+	if err := seedChangeCounters(db); err != nil {
+		m.logger.Error("Failed to seed change counters", "error", err)
+		return err
+	}
+	
+	applied := appliedSchema{ID: 1, Version: schemaVersion, MigratedAt: time.Now()}
+	if err := db.Save(&applied).Error; err != nil {
+		m.logger.Error("Failed to record schema version", "error", err)
+		return err
+	}
+	
+	m.logger.Info("Database migrations completed successfully")
 	return nil
 }
+
+// Version is the schema version RunMigrations migrates to
+func (m *migrationManager) Version() int {
+	return schemaVersion
+}
+
+// AppliedVersion returns the schema version recorded in db, or 0 when
+// there is none
+func (m *migrationManager) AppliedVersion(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&appliedSchema{}) {
+		return 0, nil
+	}
+	var applied appliedSchema
+	err := db.Limit(1).Find(&applied).Error
+	return applied.Version, err
+}
+
+// seedDefaultTenant creates the default tenant unless it already exists.
+// Its ID is explicit, so the sequence of new tenants is moved past it.
+func seedDefaultTenant(db *gorm.DB, dialect database.Dialect) error {
+	tenant := models.Tenant{ID: models.DefaultTenantID, Slug: models.DefaultTenantSlug, Name: "Default"}
+	if err := db.Where(models.Tenant{ID: models.DefaultTenantID}).FirstOrCreate(&tenant).Error; err != nil {
+		return err
+	}
+	return dialect.SyncSequence(db, "tenants")
+}
+
+// assignInheritedTenants moves the rows of inheritedTenants to the tenant of
+// the row they refer to
+func assignInheritedTenants(db *gorm.DB) error {
+	for _, inherited := range inheritedTenants {
+		parentTenant := fmt.Sprintf("(SELECT %[1]s.tenant_id FROM %[1]s WHERE %[1]s.id = %[2]s.%[3]s)",
+			inherited.parent, inherited.table, inherited.column)
+		err := db.Exec(fmt.Sprintf("UPDATE %s SET tenant_id = %s WHERE tenant_id <> %s",
+			inherited.table, parentTenant, parentTenant)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedChangeCounters creates the change counters unless they already exist.
+// Books written before the change sequence existed are numbered after the
+// counter, in ID order, and the counter is moved past them.
+func seedChangeCounters(db *gorm.DB) error {
+	for _, name := range []string{models.BookChangeCounter, models.TombstonePurgeCounter} {
+		counter := models.ChangeCounter{Name: name}
+		if err := db.Where(models.ChangeCounter{Name: name}).FirstOrCreate(&counter).Error; err != nil {
+			return err
+		}
+	}
+	
+	return db.Transaction(func(tx *gorm.DB) error {
+		var counter models.ChangeCounter
+		if err := tx.First(&counter, "name = ?", models.BookChangeCounter).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.Book{}).
+			Where("change_seq = 0").
+			UpdateColumn("change_seq", gorm.Expr("id + ?", counter.Value))
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		var last uint64
+		if err := tx.Model(&models.Book{}).Select("MAX(change_seq)").Scan(&last).Error; err != nil {
+			return err
+		}
+		return tx.Model(&counter).UpdateColumn("value", last).Error
+	})
+}
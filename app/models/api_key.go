@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Scope is a permission granted to an API key
+type Scope string
+
+const (
+	ScopeBooksRead  Scope = "books:read"
+	ScopeBooksWrite Scope = "books:write"
+	// ScopeWebhooks manages the webhooks of the key's tenant
+	ScopeWebhooks Scope = "webhooks:manage"
+	// ScopeAdmin grants every other scope as well as key management
+	ScopeAdmin Scope = "admin"
+)
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeBooksRead, ScopeBooksWrite, ScopeWebhooks, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// APIKey is a credential for calling the API (GORM table 'api_keys'). Only
+// the SHA-256 hash of the key is stored; the plaintext is shown once when the
+// key is issued. Prefix is kept so that keys can be recognised in listings.
+type APIKey struct {
+	ID      uint    `gorm:"primaryKey" json:"id"`
+	Name    string  `gorm:"not null" json:"name"`
+	Prefix  string  `gorm:"not null" json:"prefix"`
+	KeyHash string  `gorm:"not null;size:255;uniqueIndex" json:"-"`
+	Scopes  []Scope `gorm:"serializer:json;not null" json:"scopes"`
+	// Tenant is the slug of the only tenant the key may act for; empty for
+	// platform keys, which may select any tenant
+	Tenant     string     `gorm:"index" json:"tenant,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the key grants scope, directly or through admin
+func (key *APIKey) HasScope(scope Scope) bool {
+	for _, granted := range key.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired reports whether the key's expiry has passed at now
+func (key *APIKey) IsExpired(now time.Time) bool {
+	return key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)
+}
+
+// IssuedAPIKey is a freshly issued key together with its plaintext value
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
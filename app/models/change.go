@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+const (
+	// BookChangeCounter counts the changes of books; its value is the last
+	// change sequence number handed out
+	BookChangeCounter = "books"
+	// TombstonePurgeCounter holds the highest change sequence number of the
+	// purged tombstones. Sync tokens at or below it may have missed deletes.
+	TombstonePurgeCounter = "book_tombstones_purged"
+)
+
+// ChangeCounter is a named, monotonically increasing counter (GORM table
+// 'change_counters')
+type ChangeCounter struct {
+	Name  string `gorm:"primaryKey;size:64"`
+	Value uint64 `gorm:"not null;default:0"`
+}
+
+// BookTombstone records the deletion of a book for clients syncing
+// incrementally (GORM table 'book_tombstones'). Tombstones are kept for the
+// configured retention and purged after that.
+type BookTombstone struct {
+	ID        uint      `gorm:"primaryKey"`
+	BookID    uint      `gorm:"not null;index"`
+	ChangeSeq uint64    `gorm:"not null;uniqueIndex"`
+	TenantID  uint      `gorm:"not null;default:1;index"`
+	DeletedAt time.Time `gorm:"not null;index"`
+}
+
+// BookChangeType tells whether a change wrote or deleted a book
+type BookChangeType string
+
+const (
+	BookUpserted BookChangeType = "upsert"
+	BookRemoved  BookChangeType = "delete"
+)
+
+// BookChange is one entry of the incremental sync feed: the current state
+// of a book written since the client's token, or the ID of a deleted one
+type BookChange struct {
+	Seq       uint64         `json:"seq"`
+	Type      BookChangeType `json:"type" example:"upsert"`
+	BookID    uint           `json:"book_id"`
+	Book      *Book          `json:"book,omitempty"`
+	DeletedAt *time.Time     `json:"deleted_at,omitempty"`
+}
+
+// BookChangePage is a batch of changes in sequence order. NextToken resumes
+// after the last change; HasMore tells that more changes are waiting.
+type BookChangePage struct {
+	Changes   []BookChange `json:"changes"`
+	NextToken string       `json:"next_token"`
+	HasMore   bool         `json:"has_more"`
+}
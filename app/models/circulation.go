@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// CopyCondition describes the physical state of a copy
+type CopyCondition string
+
+const (
+	ConditionNew     CopyCondition = "new"
+	ConditionGood    CopyCondition = "good"
+	ConditionFair    CopyCondition = "fair"
+	ConditionPoor    CopyCondition = "poor"
+	ConditionDamaged CopyCondition = "damaged"
+)
+
+func (c CopyCondition) IsValid() bool {
+	switch c {
+	case ConditionNew, ConditionGood, ConditionFair, ConditionPoor, ConditionDamaged:
+		return true
+	}
+	return false
+}
+
+// Copy is a physical, barcoded item of a book held at a branch
+// (GORM table 'copies')
+type Copy struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	BookID    uint          `gorm:"not null;index" json:"book_id"`
+	Barcode   string        `gorm:"not null;size:255;uniqueIndex:idx_copies_tenant_barcode,priority:2" json:"barcode"`
+	Branch    string        `gorm:"not null;index" json:"branch"`
+	Condition CopyCondition `gorm:"not null;default:good" json:"condition"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+
+	// Tenant owning the copy; barcodes are unique within a tenant
+	TenantID uint `gorm:"not null;default:1;uniqueIndex:idx_copies_tenant_barcode,priority:1" json:"tenant_id"`
+}
+
+// Update applies the non-empty fields of update to the copy
+func (bookCopy *Copy) Update(update Copy) {
+	if update.Barcode != "" {
+		bookCopy.Barcode = update.Barcode
+	}
+	if update.Branch != "" {
+		bookCopy.Branch = update.Branch
+	}
+	if update.Condition != "" {
+		bookCopy.Condition = update.Condition
+	}
+}
+
+// Loan records a copy checked out to a member (GORM table 'loans').
+// A loan is active until ReturnedAt is set; the partial unique index
+// idx_loans_active_copy, created by the migrations, keeps a copy from being on
+// more than one active loan.
+type Loan struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	CopyID       uint       `gorm:"not null" json:"copy_id"`
+	BookID       uint       `gorm:"not null;index" json:"book_id"`
+	MemberID     uint       `gorm:"not null;index" json:"member_id"`
+	CheckedOutAt time.Time  `gorm:"not null" json:"checked_out_at"`
+	DueAt        time.Time  `gorm:"not null;index" json:"due_at"`
+	ReturnedAt   *time.Time `json:"returned_at,omitempty"`
+	Renewals     int        `gorm:"not null;default:0" json:"renewals"`
+	Overdue      bool       `gorm:"-" json:"overdue"`
+
+	// Tenant owning the loan, the one of its copy and member
+	TenantID uint `gorm:"not null;default:1;index" json:"tenant_id"`
+}
+
+// IsActive reports whether the copy has not been returned yet
+func (loan *Loan) IsActive() bool {
+	return loan.ReturnedAt == nil
+}
+
+// IsOverdue reports whether the loan was, or still is, past its due date
+func (loan *Loan) IsOverdue(now time.Time) bool {
+	if loan.ReturnedAt != nil {
+		return loan.ReturnedAt.After(loan.DueAt)
+	}
+	return now.After(loan.DueAt)
+}
+
+// BookAvailability summarizes the copies of a book
+type BookAvailability struct {
+	Total     int64 `json:"total"`
+	Available int64 `json:"available"`
+	OnLoan    int64 `json:"on_loan"`
+	// OnHold counts returned copies set aside for a member's pickup
+	OnHold int64 `json:"on_hold"`
+	// Waiting counts members queued for the next available copy
+	Waiting int64 `json:"waiting"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// HoldStatus is the state of a hold in a book's reservation queue
+type HoldStatus string
+
+const (
+	HoldWaiting   HoldStatus = "waiting"
+	HoldReady     HoldStatus = "ready"
+	HoldFulfilled HoldStatus = "fulfilled"
+	HoldExpired   HoldStatus = "expired"
+	HoldCancelled HoldStatus = "cancelled"
+)
+
+func (s HoldStatus) IsValid() bool {
+	switch s {
+	case HoldWaiting, HoldReady, HoldFulfilled, HoldExpired, HoldCancelled:
+		return true
+	}
+	return false
+}
+
+// IsActive reports whether the hold is still queued or awaiting pickup
+func (s HoldStatus) IsActive() bool {
+	return s == HoldWaiting || s == HoldReady
+}
+
+// Hold is a member's place in the FIFO reservation queue of a book
+// (GORM table 'holds'). When a copy comes back the oldest waiting hold is
+// promoted to ready and the copy is set aside until ExpiresAt. The partial
+// unique index idx_holds_ready_copy, created by the migrations, keeps a copy
+// from being set aside for two holds at once.
+type Hold struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	BookID    uint       `gorm:"not null;index:idx_holds_queue" json:"book_id"`
+	MemberID  uint       `gorm:"not null;index" json:"member_id"`
+	Status    HoldStatus `gorm:"not null;default:waiting;index:idx_holds_queue" json:"status"`
+	CopyID    *uint      `json:"copy_id,omitempty"`
+	ReadyAt   *time.Time `json:"ready_at,omitempty"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	CreatedAt time.Time  `gorm:"index:idx_holds_queue" json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Tenant owning the hold, the one of its book and member
+	TenantID uint `gorm:"not null;default:1;index" json:"tenant_id"`
+}
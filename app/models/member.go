@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// MemberStatus is the standing of a library member
+type MemberStatus string
+
+const (
+	MemberActive    MemberStatus = "active"
+	MemberSuspended MemberStatus = "suspended"
+)
+
+func (s MemberStatus) IsValid() bool {
+	switch s {
+	case MemberActive, MemberSuspended:
+		return true
+	}
+	return false
+}
+
+// Member is a library patron who can borrow copies and place holds
+// (GORM table 'members')
+type Member struct {
+	ID              uint         `gorm:"primaryKey" json:"id"`
+	Name            string       `gorm:"not null;index" json:"name"`
+	Email           string       `gorm:"not null;size:255;uniqueIndex:idx_members_tenant_email,priority:2" json:"email"`
+	CardNumber      string       `gorm:"not null;size:255;uniqueIndex:idx_members_tenant_card_number,priority:2" json:"card_number"`
+	Status          MemberStatus `gorm:"not null;default:active;index" json:"status"`
+	SuspendedReason string       `json:"suspended_reason,omitempty"`
+	// MaxLoans overrides the circulation policy limit when greater than zero
+	MaxLoans  int       `gorm:"not null;default:0" json:"max_loans"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Tenant the member belongs to; email and card number are unique
+	// within a tenant
+	TenantID uint `gorm:"not null;default:1;uniqueIndex:idx_members_tenant_email,priority:1;uniqueIndex:idx_members_tenant_card_number,priority:1" json:"tenant_id"`
+}
+
+// Update applies the non-empty contact and limit fields of update to the
+// member. Status changes go through suspension and reinstatement instead.
+func (member *Member) Update(update Member) {
+	if update.Name != "" {
+		member.Name = update.Name
+	}
+	if update.Email != "" {
+		member.Email = update.Email
+	}
+	if update.CardNumber != "" {
+		member.CardNumber = update.CardNumber
+	}
+	if update.MaxLoans != 0 {
+		member.MaxLoans = update.MaxLoans
+	}
+}
+
+// MemberFilter narrows down member searches
+type MemberFilter struct {
+	// Query matches name, email or card number, case-insensitively
+	Query  string
+	Status MemberStatus
+}
+
+// Fine is a charge for returning a loan after its due date
+// (GORM table 'fines'). Amounts are in cents.
+type Fine struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	MemberID    uint       `gorm:"not null;index" json:"member_id"`
+	LoanID      uint       `gorm:"not null;uniqueIndex" json:"loan_id"`
+	AmountCents int64      `gorm:"not null" json:"amount_cents"`
+	DaysOverdue int        `gorm:"not null" json:"days_overdue"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PaidAt      *time.Time `json:"paid_at,omitempty"`
+
+	// Tenant owning the fine, the one of its loan
+	TenantID uint `gorm:"not null;default:1;index" json:"tenant_id"`
+}
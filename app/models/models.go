@@ -51,6 +51,57 @@ type Book struct {
 	Title  string `json:"title"`
 	Pages  int    `json:"pages"`
 	Color  *Color `json:"color,omitempty"`
+
+	// Denormalized from approved reviews; maintained by the review repository
+	AverageRating float64 `gorm:"not null;default:0;index" json:"average_rating"`
+	RatingCount   int     `gorm:"not null;default:0" json:"rating_count"`
+
+	Tags       []Tag      `gorm:"many2many:book_tags;" json:"tags,omitempty"`
+	Categories []Category `gorm:"many2many:book_categories;" json:"categories,omitempty"`
+
+	// Tenant owning the book; assigned from the request, never from the client
+	TenantID uint `gorm:"not null;default:1;index" json:"tenant_id"`
+
+	// Subject of the principal that created the book; editors may only
+	// change books they created
+	CreatedBy string `gorm:"index" json:"created_by,omitempty"`
+
+	// ChangeSeq is the change sequence number of the last write, which
+	// incremental sync orders by; maintained by the repositories
+	ChangeSeq uint64 `gorm:"not null;default:0;index" json:"-"`
+
+	// Filled in when a single book is requested, not stored
+	Availability *BookAvailability `gorm:"-" json:"availability,omitempty"`
+}
+
+// BookFilter narrows down book listings
+type BookFilter struct {
+	// Tag matches books carrying the tag, compared after normalization
+	Tag string
+	// CategoryID matches books in the category or any of its descendants
+	CategoryID *uint
+	// Sort orders the result by a field in BookSortFields; a leading '-'
+	// sorts descending
+	Sort string
+	// Limit caps the number of books returned after skipping Offset; zero
+	// returns all of them
+	Limit  int
+	Offset int
+}
+
+// BookSortFields lists the fields books can be sorted by
+var BookSortFields = map[string]bool{
+	"id":     true,
+	"title":  true,
+	"rating": true,
+}
+
+// SortField splits Sort into the field name and direction
+func (f BookFilter) SortField() (field string, desc bool) {
+	if len(f.Sort) > 0 && f.Sort[0] == '-' {
+		return f.Sort[1:], true
+	}
+	return f.Sort, false
 }
 
 func (book *Book) Update(update Book) {
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// OutboxStatus is the delivery state of an outbox event
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxDelivered OutboxStatus = "delivered"
+	OutboxFailed    OutboxStatus = "failed"
+)
+
+// OutboxEvent is a domain event waiting to be delivered to the event sinks
+// (GORM table 'outbox_events'). It is written in the transaction of the
+// change it describes, so it exists exactly when the change was committed.
+type OutboxEvent struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	Type   string `gorm:"not null;size:64" json:"type"`
+	BookID uint   `gorm:"not null;index" json:"book_id"`
+	// Payload is the JSON encoded event body
+	Payload  string       `gorm:"not null;type:text" json:"payload"`
+	TenantID uint         `gorm:"not null;default:1;index" json:"tenant_id"`
+	Status   OutboxStatus `gorm:"not null;size:16;default:pending;index" json:"status"`
+	// Attempts counts the failed deliveries; the next one is not made
+	// before NextAttemptAt
+	Attempts      int       `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `gorm:"type:text" json:"last_error,omitempty"`
+	// DeliveredSinks names the sinks that took the event, which are
+	// skipped when a failed delivery to the others is retried
+	DeliveredSinks []string   `gorm:"serializer:json" json:"delivered_sinks,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// ReviewStatus is the moderation state of a review
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewApproved ReviewStatus = "approved"
+	ReviewRejected ReviewStatus = "rejected"
+)
+
+func (s ReviewStatus) IsValid() bool {
+	switch s {
+	case ReviewPending, ReviewApproved, ReviewRejected:
+		return true
+	}
+	return false
+}
+
+// Review is a reader's rating and opinion of a book (GORM table 'reviews').
+// A reviewer can review each book once; only approved reviews count towards
+// the book's rating.
+type Review struct {
+	ID        uint         `gorm:"primaryKey" json:"id"`
+	BookID    uint         `gorm:"not null;uniqueIndex:idx_reviews_book_reviewer" json:"book_id"`
+	Reviewer  string       `gorm:"not null;size:255;uniqueIndex:idx_reviews_book_reviewer" json:"reviewer"`
+	Rating    int          `gorm:"not null" json:"rating"`
+	Text      string       `json:"text"`
+	Status    ReviewStatus `gorm:"not null;default:pending;index" json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+
+	// Tenant owning the review, the one of its book
+	TenantID uint `gorm:"not null;default:1;index" json:"tenant_id"`
+}
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Update applies the editable, non-empty fields of update to the review
+func (review *Review) Update(update Review) {
+	if update.Rating != 0 {
+		review.Rating = update.Rating
+	}
+	if update.Text != "" {
+		review.Text = update.Text
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import "strings"
+
+// Tag is a free-form label attached to books (GORM table 'tags').
+// Names are stored normalized so "Sci Fi" and " sci  fi" are the same tag.
+type Tag struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"uniqueIndex:idx_tags_tenant_name,priority:2;size:255;not null" json:"name"`
+
+	// Tenant owning the tag; names are unique within a tenant
+	TenantID uint `gorm:"not null;default:1;uniqueIndex:idx_tags_tenant_name,priority:1" json:"tenant_id"`
+}
+
+// TagCount is a tag together with the number of books carrying it
+type TagCount struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	BookCount int64  `json:"book_count"`
+}
+
+// NormalizeTagName lowercases a tag name and collapses surrounding and
+// repeated inner whitespace
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Category is a node in the category tree, stored as an adjacency list
+// (GORM table 'categories'). Root categories have no parent.
+type Category struct {
+	ID       uint       `gorm:"primaryKey" json:"id"`
+	Name     string     `gorm:"not null" json:"name"`
+	ParentID *uint      `gorm:"index" json:"parent_id,omitempty"`
+	Children []Category `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+
+	// Tenant owning the category and its subcategories
+	TenantID uint `gorm:"not null;default:1;index" json:"tenant_id"`
+}
+
+// Update applies the non-empty fields of update to the category. A ParentID
+// of 0 moves the category to the root of the tree.
+func (category *Category) Update(update Category) {
+	if update.Name != "" {
+		category.Name = update.Name
+	}
+	if update.ParentID != nil {
+		if *update.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			parentID := *update.ParentID
+			category.ParentID = &parentID
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	// DefaultTenantID owns the data created before tenants existed
+	DefaultTenantID = 1
+	// DefaultTenantSlug is the slug of the default tenant
+	DefaultTenantSlug = "default"
+)
+
+// Tenant is an organization whose catalogue is isolated from every other
+// tenant's (GORM table 'tenants'). Slug is what requests use to select the
+// tenant: in a header, as subdomain or in a token claim.
+type Tenant struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Slug string `gorm:"not null;size:255;uniqueIndex" json:"slug"`
+	Name string `gorm:"not null" json:"name"`
+	// MaxBooks limits the size of the catalogue; zero means unlimited
+	MaxBooks  int       `gorm:"not null;default:0" json:"max_books"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Filled in when a single tenant is requested, not stored
+	Usage *TenantUsage `gorm:"-" json:"usage,omitempty"`
+}
+
+// TenantUsage is what a tenant currently consumes of its quotas
+type TenantUsage struct {
+	Books int64 `json:"books"`
+}
+
+// Update applies the non-empty fields of update to the tenant. The slug is
+// fixed once created because credentials and hostnames refer to it.
+func (tenant *Tenant) Update(update Tenant) {
+	if update.Name != "" {
+		tenant.Name = update.Name
+	}
+	if update.MaxBooks != 0 {
+		tenant.MaxBooks = update.MaxBooks
+	}
+}
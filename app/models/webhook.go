@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// Webhook is a partner endpoint that book events are posted to, signed
+// with its secret (GORM table 'webhooks'). It receives the events of its
+// tenant whose type is in Events, or all of them when Events is empty. A
+// webhook whose delivery keeps failing is disabled until it is re-enabled.
+type Webhook struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	URL         string   `gorm:"not null;size:2048" json:"url" example:"https://partner.example/hooks/books"`
+	Events      []string `gorm:"serializer:json;not null" json:"events" example:"book.created,book.updated"`
+	Description string   `json:"description,omitempty"`
+	// Secret signs the deliveries; it is only returned when the webhook is
+	// created or the secret is replaced
+	Secret         string     `gorm:"not null;size:255" json:"-"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	TenantID       uint       `gorm:"not null;default:1;index" json:"tenant_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether deliveries are made to the webhook
+func (w *Webhook) IsActive() bool {
+	return w.DisabledAt == nil
+}
+
+// Accepts reports whether the webhook receives events of eventType
+func (w *Webhook) Accepts(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, accepted := range w.Events {
+		if accepted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookWithSecret is a webhook together with its signing secret, which
+// is only set right after the secret was chosen
+type WebhookWithSecret struct {
+	Webhook
+	Secret string `json:"secret,omitempty" example:"whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"`
+}
+
+// WebhookUpdate changes the given fields of a webhook
+type WebhookUpdate struct {
+	URL         *string   `json:"url"`
+	Events      *[]string `json:"events"`
+	Description *string   `json:"description"`
+	// Active re-enables a disabled webhook or disables it
+	Active *bool `json:"active"`
+	// RotateSecret replaces the secret with a new one, which is returned
+	RotateSecret bool `json:"rotate_secret"`
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is the delivery of one event to one webhook (GORM table
+// 'webhook_deliveries'), with the outcome of its latest attempt. The
+// payload is fixed when the delivery is created, so redeliveries post the
+// same body.
+type WebhookDelivery struct {
+	ID        uint                  `gorm:"primaryKey" json:"id"`
+	WebhookID uint                  `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event" json:"webhook_id"`
+	EventID   uint                  `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event" json:"event_id"`
+	EventType string                `gorm:"not null;size:64" json:"event_type"`
+	Payload   string                `gorm:"not null;type:text" json:"payload"`
+	Status    WebhookDeliveryStatus `gorm:"not null;size:16;default:pending;index" json:"status"`
+	// Attempts counts the attempts so far; the next one is not made before
+	// NextAttemptAt
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	// ResponseBody is the start of the response to the latest attempt
+	ResponseBody string     `gorm:"type:text" json:"response_body,omitempty"`
+	Error        string     `gorm:"type:text" json:"error,omitempty"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+	TenantID     uint       `gorm:"not null;default:1;index" json:"tenant_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
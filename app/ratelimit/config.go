@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Config holds the limit of each route group
+type Config struct {
+	// Default applies to groups without an entry in Groups
+	Default Limit
+	Groups  map[string]Limit
+}
+
+// For returns the limit of a route group
+func (c Config) For(group string) Limit {
+	if limit, ok := c.Groups[group]; ok {
+		return limit
+	}
+	return c.Default
+}
+
+// LoadConfig builds a config from environment style lookups: RATE_LIMIT sets
+// the default and RATE_LIMIT_<GROUP> overrides it for one group, e.g.
+// RATE_LIMIT_BOOKS=600/1m. Unset variables keep fallback.
+func LoadConfig(lookup func(string) (string, bool), fallback Limit, groups ...string) (Config, error) {
+	config := Config{Default: fallback, Groups: make(map[string]Limit)}
+
+	if value, ok := lookup("RATE_LIMIT"); ok {
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("RATE_LIMIT: %w", err)
+		}
+		config.Default = limit
+	}
+	for _, group := range groups {
+		name := "RATE_LIMIT_" + strings.ToUpper(group)
+		if value, ok := lookup(name); ok {
+			limit, err := ParseLimit(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %w", name, err)
+			}
+			config.Groups[group] = limit
+		}
+	}
+	return config, nil
+}
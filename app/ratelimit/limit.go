@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket: Burst requests may be made at once and the bucket
+// refills at Requests per Period
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Unlimited disables rate limiting where it is used
+var Unlimited = Limit{}
+
+// IsUnlimited reports whether the limit lets every request through
+func (l Limit) IsUnlimited() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+// Capacity is the size of the bucket, Burst or else Requests
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate is the refill rate in tokens per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// String formats the limit the way ParseLimit reads it
+func (l Limit) String() string {
+	if l.IsUnlimited() {
+		return "off"
+	}
+	s := fmt.Sprintf("%d/%s", l.Requests, l.Period)
+	if l.Burst > 0 {
+		s += fmt.Sprintf(",burst=%d", l.Burst)
+	}
+	return s
+}
+
+// ParseLimit reads a limit such as "300/1m" or "10/1s,burst=50". "off"
+// returns Unlimited.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "off" {
+		return Unlimited, nil
+	}
+
+	spec, options, _ := strings.Cut(value, ",")
+	requests, period, found := strings.Cut(spec, "/")
+	if !found {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected requests/period", value)
+	}
+
+	var limit Limit
+	var err error
+	if limit.Requests, err = strconv.Atoi(strings.TrimSpace(requests)); err != nil || limit.Requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive number", value)
+	}
+	if limit.Period, err = time.ParseDuration(strings.TrimSpace(period)); err != nil || limit.Period <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", value)
+	}
+
+	if options != "" {
+		name, burst, _ := strings.Cut(strings.TrimSpace(options), "=")
+		if name != "burst" {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: unknown option %s", value, name)
+		}
+		if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: burst must be a positive number", value)
+		}
+	}
+	return limit, nil
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Limit is the capacity of the bucket
+	Limit int
+	// Remaining is the number of requests that may still be made right away
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed; zero when
+	// this one was
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. MemoryStore serves a single instance; a store
+// backed by a shared database lets several instances enforce one limit.
+type Store interface {
+	// Take removes a token from the bucket under key as of now, creating a
+	// full bucket on first use
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
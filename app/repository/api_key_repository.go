@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyRepository implements the APIKeyRepository interface
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new instance of API key repository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+// Create adds a new API key to the database
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetByID retrieves an API key by its ID
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).First(&key, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash retrieves an API key by the hash of its plaintext value
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetAll retrieves all API keys, including revoked ones
+func (r *apiKeyRepository) GetAll(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.WithContext(ctx).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// Update modifies an existing API key in the database
+func (r *apiKeyRepository) Update(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Save(key).Error
+}
+
+// RevokeByTenant revokes the active keys bound to the tenant with slug
+func (r *apiKeyRepository) RevokeByTenant(ctx context.Context, slug string, revokedAt time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.APIKey{}).Where("tenant = ? AND revoked_at IS NULL", slug).UpdateColumn("revoked_at", revokedAt)
+	return result.RowsAffected, result.Error
+}
+
+// TouchLastUsed records when a key was last used without rewriting the row
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
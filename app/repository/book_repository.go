@@ -2,10 +2,16 @@ package repository
 
 import (
 	"books-api/app/models"
+	"context"
+	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
-// bookRepository implements the BookRepository interface
+// bookRepository implements the BookRepository interface. Every query runs
+// with the caller's context, so the tenancy callbacks confine it to the
+// tenant of the request.
 type bookRepository struct {
 	db *gorm.DB
 }
@@ -18,33 +24,173 @@ func NewBookRepository(db *gorm.DB) BookRepository {
 }
 
 // Create adds a new book to the database
-func (r *bookRepository) Create(book *models.Book) error {
-	return r.db.Create(book).Error
+func (r *bookRepository) Create(ctx context.Context, book *models.Book) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(book).Error; err != nil {
+			return err
+		}
+		return touchBook(tx, book)
+	})
 }
 
 // GetByID retrieves a book by its ID
-func (r *bookRepository) GetByID(id uint) (*models.Book, error) {
+func (r *bookRepository) GetByID(ctx context.Context, id uint) (*models.Book, error) {
 	var book models.Book
-	err := r.db.First(&book, id).Error
+	err := r.db.WithContext(ctx).Preload("Tags").Preload("Categories").First(&book, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &book, nil
 }
 
-// GetAll retrieves all books from the database
-func (r *bookRepository) GetAll() ([]models.Book, error) {
+// GetAll retrieves all books matching the filter from the database
+func (r *bookRepository) GetAll(ctx context.Context, filter models.BookFilter) ([]models.Book, error) {
 	var books []models.Book
-	err := r.db.Find(&books).Error
+	query := r.db.WithContext(ctx).Preload("Tags").Preload("Categories")
+
+	if filter.Tag != "" {
+		query = query.Where("books.id IN (?)",
+			r.db.Table("book_tags").
+				Select("book_tags.book_id").
+				Joins("JOIN tags ON tags.id = book_tags.tag_id").
+				Where("tags.name = ?", models.NormalizeTagName(filter.Tag)))
+	}
+
+	if filter.CategoryID != nil {
+		query = query.Where("books.id IN (?)",
+			r.db.Table("book_categories").
+				Select("book_categories.book_id").
+				Where("book_categories.category_id IN (?)", descendantCategoryIDs(r.db, *filter.CategoryID)))
+	}
+
+	if field, desc := filter.SortField(); field != "" {
+		direction := "ASC"
+		if desc {
+			direction = "DESC"
+		}
+		switch field {
+		case "id":
+			query = query.Order("books.id " + direction)
+		case "title":
+			query = query.Order("books.title " + direction)
+		case "rating":
+			query = query.Order("books.average_rating " + direction).Order("books.rating_count " + direction)
+		}
+	}
+
+	if filter.Limit > 0 {
+		// Pages need a stable order
+		query = query.Order("books.id").Limit(filter.Limit).Offset(filter.Offset)
+	}
+
+	err := query.Find(&books).Error
 	return books, err
 }
 
-// Update modifies an existing book in the database
-func (r *bookRepository) Update(book *models.Book) error {
-	return r.db.Save(book).Error
+// Update modifies an existing book in the database. Rating aggregates are
+// left alone, they are owned by the review repository. Selecting the columns
+// explicitly keeps Save from inserting the row when it is outside the
+// caller's tenant.
+func (r *bookRepository) Update(ctx context.Context, book *models.Book) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Select("*").Omit(clause.Associations, "AverageRating", "RatingCount").Save(book).Error
+		if err != nil {
+			return err
+		}
+		return touchBook(tx, book)
+	})
 }
 
-// Delete removes a book from the database by ID
-func (r *bookRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Book{}, id).Error
+// Delete removes a book and its reviews from the database by ID and leaves
+// a tombstone for incremental sync
+func (r *bookRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var book models.Book
+		if err := tx.Select("id", "tenant_id").Take(&book, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Where("book_id = ?", id).Delete(&models.Review{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Select(clause.Associations).Delete(&book).Error; err != nil {
+			return err
+		}
+
+		seq, err := nextChangeSeqs(tx, 1)
+		if err != nil {
+			return err
+		}
+		tombstone := models.BookTombstone{BookID: id, ChangeSeq: seq, TenantID: book.TenantID, DeletedAt: time.Now()}
+		return tx.Create(&tombstone).Error
+	})
+}
+
+// Count returns the number of books visible to the caller
+func (r *bookRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Book{}).Count(&count).Error
+	return count, err
+}
+
+// ReplaceTags sets the book's tags to exactly the given list
+func (r *bookRepository) ReplaceTags(ctx context.Context, book *models.Book, tags []models.Tag) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(book).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		return touchBook(tx, book)
+	})
+}
+
+// ReplaceCategories sets the book's categories to exactly the given list
+func (r *bookRepository) ReplaceCategories(ctx context.Context, book *models.Book, categories []models.Category) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(book).Association("Categories").Replace(categories); err != nil {
+			return err
+		}
+		return touchBook(tx, book)
+	})
+}
+
+// touchBook gives a written book its new change sequence number
+func touchBook(tx *gorm.DB, book *models.Book) error {
+	seq, err := touchBooks(tx, []uint{book.ID})
+	if err != nil {
+		return err
+	}
+	book.ChangeSeq = seq
+	return nil
+}
+
+// GetAvailability counts the copies of a book and how many are on loan or
+// set aside for holds
+func (r *bookRepository) GetAvailability(ctx context.Context, bookID uint) (*models.BookAvailability, error) {
+	db := r.db.WithContext(ctx)
+	var availability models.BookAvailability
+	if err := db.Model(&models.Copy{}).Where("book_id = ?", bookID).Count(&availability.Total).Error; err != nil {
+		return nil, err
+	}
+	err := db.Model(&models.Loan{}).
+		Where("book_id = ? AND returned_at IS NULL", bookID).
+		Count(&availability.OnLoan).Error
+	if err != nil {
+		return nil, err
+	}
+	err = db.Model(&models.Hold{}).
+		Where("book_id = ? AND status = ?", bookID, models.HoldReady).
+		Count(&availability.OnHold).Error
+	if err != nil {
+		return nil, err
+	}
+	err = db.Model(&models.Hold{}).
+		Where("book_id = ? AND status = ?", bookID, models.HoldWaiting).
+		Count(&availability.Waiting).Error
+	if err != nil {
+		return nil, err
+	}
+	availability.Available = availability.Total - availability.OnLoan - availability.OnHold
+	return &availability, nil
 }
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"books-api/app/cache"
+	"books-api/app/models"
+	"books-api/app/tenancy"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+// generationShards is how many locks guard the generations of the cached
+// books, so that invalidations of unrelated books do not wait on each other
+const generationShards = 64
+
+// CachedBookRepository decorates a BookRepository, serving GetByID from a
+// cache. Update, Delete and the association replacements drop the book
+// from the cache once they succeed; a unit of work drops the books it wrote
+// once it commits.
+type CachedBookRepository struct {
+	books BookRepository
+	store cache.Store
+	ttl   time.Duration
+	// loads collapses concurrent misses of the same book and tenant into
+	// one query
+	loads singleflight.Group
+	// generations counts the invalidations of the books being loaded. A
+	// load caches the book only if it was not invalidated since the load
+	// began, so a load racing a write cannot cache the book as it was
+	// before.
+	generations [generationShards]generationShard
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	loaded atomic.Uint64
+}
+
+// NewCachedBookRepository creates a repository caching the books of books
+// in store for ttl
+func NewCachedBookRepository(books BookRepository, store cache.Store, ttl time.Duration) *CachedBookRepository {
+	return &CachedBookRepository{
+		books: books,
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+// Stats returns the hits, misses and loads of GetByID so far
+func (r *CachedBookRepository) Stats() cache.Stats {
+	return cache.Stats{
+		Hits:   r.hits.Load(),
+		Misses: r.misses.Load(),
+		Loads:  r.loaded.Load(),
+	}
+}
+
+// Create adds a new book; it is cached once it is first read
+func (r *CachedBookRepository) Create(ctx context.Context, book *models.Book) error {
+	return r.books.Create(ctx, book)
+}
+
+// GetByID returns the cached book or loads and caches it. Entries are
+// shared by all tenants, so a book of another tenant than the one in ctx
+// is not found, as it would not be in the database.
+func (r *CachedBookRepository) GetByID(ctx context.Context, id uint) (*models.Book, error) {
+	// A failing cache is skipped rather than failing the read
+	if data, ok, err := r.store.Get(ctx, bookCacheKey(id)); err == nil && ok {
+		r.hits.Add(1)
+		return decodeCachedBook(ctx, data)
+	}
+	r.misses.Add(1)
+
+	// The shared load ignores the cancellation of the caller that started
+	// it, so the other callers still get the book
+	loadCtx := context.WithoutCancel(ctx)
+	results := r.loads.DoChan(bookLoadKey(ctx, id), func() (interface{}, error) {
+		r.loaded.Add(1)
+		generation := r.startLoad(id)
+		data, err := r.load(loadCtx, id)
+		r.finishLoad(loadCtx, id, generation, data)
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
+	})
+
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return decodeCachedBook(ctx, result.Val.([]byte))
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// GetAll retrieves all books matching the filter, bypassing the cache
+func (r *CachedBookRepository) GetAll(ctx context.Context, filter models.BookFilter) ([]models.Book, error) {
+	return r.books.GetAll(ctx, filter)
+}
+
+// Count returns the number of books visible to the caller
+func (r *CachedBookRepository) Count(ctx context.Context) (int64, error) {
+	return r.books.Count(ctx)
+}
+
+// Update modifies a book and drops it from the cache
+func (r *CachedBookRepository) Update(ctx context.Context, book *models.Book) error {
+	if err := r.books.Update(ctx, book); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, book.ID)
+}
+
+// Delete removes a book and drops it from the cache
+func (r *CachedBookRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.books.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, id)
+}
+
+// ReplaceTags sets the book's tags and drops it from the cache
+func (r *CachedBookRepository) ReplaceTags(ctx context.Context, book *models.Book, tags []models.Tag) error {
+	if err := r.books.ReplaceTags(ctx, book, tags); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, book.ID)
+}
+
+// ReplaceCategories sets the book's categories and drops it from the cache
+func (r *CachedBookRepository) ReplaceCategories(ctx context.Context, book *models.Book, categories []models.Category) error {
+	if err := r.books.ReplaceCategories(ctx, book, categories); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, book.ID)
+}
+
+// GetAvailability counts the copies of a book; it changes with every loan,
+// so it is not cached
+func (r *CachedBookRepository) GetAvailability(ctx context.Context, bookID uint) (*models.BookAvailability, error) {
+	return r.books.GetAvailability(ctx, bookID)
+}
+
+// generationShard guards the generations of a share of the books. A book
+// has an entry only while loads of it are in flight.
+type generationShard struct {
+	mu          sync.Mutex
+	generations map[uint]*generation
+}
+
+// generation counts the loads of a book in flight and the invalidations
+// since the first of them began
+type generation struct {
+	loads         int
+	invalidations uint64
+}
+
+// load reads a book and encodes it for the cache
+func (r *CachedBookRepository) load(ctx context.Context, id uint) ([]byte, error) {
+	book, err := r.books.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(book)
+}
+
+// startLoad records a load of a book in flight and returns how often the
+// book was invalidated so far
+func (r *CachedBookRepository) startLoad(id uint) uint64 {
+	shard := &r.generations[id%generationShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if shard.generations == nil {
+		shard.generations = make(map[uint]*generation)
+	}
+	current, ok := shard.generations[id]
+	if !ok {
+		current = &generation{}
+		shard.generations[id] = current
+	}
+	current.loads++
+	return current.invalidations
+}
+
+// finishLoad stores a book loaded at invalidations, unless data is nil or
+// the book was invalidated since, and forgets the book once no other load
+// of it is in flight. The shard stays locked while storing, so an
+// invalidation either comes before and the book is not stored, or after and
+// drops it again.
+func (r *CachedBookRepository) finishLoad(ctx context.Context, id uint, invalidations uint64, data []byte) {
+	shard := &r.generations[id%generationShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	current := shard.generations[id]
+	if data != nil && current.invalidations == invalidations {
+		r.store.Set(ctx, bookCacheKey(id), data, r.ttl)
+	}
+	if current.loads--; current.loads == 0 {
+		delete(shard.generations, id)
+	}
+}
+
+// invalidate drops a book from the cache. It runs even when the caller has
+// given up, since the write it follows has happened. Only the loads in
+// flight need to know; later loads read the book as written.
+func (r *CachedBookRepository) invalidate(ctx context.Context, id uint) error {
+	shard := &r.generations[id%generationShards]
+	shard.mu.Lock()
+	if current, ok := shard.generations[id]; ok {
+		current.invalidations++
+	}
+	shard.mu.Unlock()
+
+	if err := r.store.Delete(context.WithoutCancel(ctx), bookCacheKey(id)); err != nil {
+		return fmt.Errorf("failed to invalidate cached book %d: %w", id, err)
+	}
+	return nil
+}
+
+// bookCacheKey is the cache key of a book
+func bookCacheKey(id uint) string {
+	return fmt.Sprintf("book:%d", id)
+}
+
+// bookLoadKey identifies a load of a book on behalf of the tenant in ctx,
+// whose scope decides whether the book is found
+func bookLoadKey(ctx context.Context, id uint) string {
+	var tenantID uint
+	if tenant, ok := tenancy.FromContext(ctx); ok {
+		tenantID = tenant.ID
+	}
+	return fmt.Sprintf("%d:%d", tenantID, id)
+}
+
+// decodeCachedBook decodes a cached book into a copy of the caller's own,
+// applying the tenant scope of ctx
+func decodeCachedBook(ctx context.Context, data []byte) (*models.Book, error) {
+	var book models.Book
+	if err := json.Unmarshal(data, &book); err != nil {
+		return nil, err
+	}
+	if tenant, ok := tenancy.FromContext(ctx); ok && tenant.ID != book.TenantID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &book, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"gorm.io/gorm"
+)
+
+// categoryRepository implements the CategoryRepository interface
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+// NewCategoryRepository creates a new instance of category repository
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepository{
+		db: db,
+	}
+}
+
+// descendantCategoryIDs builds a subquery selecting the category and all of
+// its descendants using a recursive CTE over the parent_id adjacency list
+func descendantCategoryIDs(db *gorm.DB, id uint) *gorm.DB {
+	return db.Raw(`WITH RECURSIVE category_tree(id) AS (
+		SELECT id FROM categories WHERE id = ?
+		UNION ALL
+		SELECT categories.id FROM categories JOIN category_tree ON categories.parent_id = category_tree.id
+	) SELECT id FROM category_tree`, id)
+}
+
+// Create adds a new category to the database
+func (r *categoryRepository) Create(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Omit("Children").Create(category).Error
+}
+
+// GetByID retrieves a category by its ID together with its direct children
+func (r *categoryRepository) GetByID(ctx context.Context, id uint) (*models.Category, error) {
+	var category models.Category
+	err := r.db.WithContext(ctx).Preload("Children").First(&category, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// GetByIDs retrieves all categories with the given IDs
+func (r *categoryRepository) GetByIDs(ctx context.Context, ids []uint) ([]models.Category, error) {
+	var categories []models.Category
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&categories).Error
+	return categories, err
+}
+
+// GetAll retrieves all categories as a flat list
+func (r *categoryRepository) GetAll(ctx context.Context) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.WithContext(ctx).Order("id").Find(&categories).Error
+	return categories, err
+}
+
+// GetDescendantIDs returns the IDs of the category and all its descendants
+func (r *categoryRepository) GetDescendantIDs(ctx context.Context, id uint) ([]uint, error) {
+	var ids []uint
+	err := descendantCategoryIDs(r.db.WithContext(ctx), id).Scan(&ids).Error
+	return ids, err
+}
+
+// Update modifies an existing category in the database. The books in it
+// count as changed for incremental sync.
+func (r *categoryRepository) Update(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("*").Omit("Children").Save(category).Error; err != nil {
+			return err
+		}
+		return touchLinkedBooks(tx, "book_categories", "category_id", category.ID)
+	})
+}
+
+// Delete removes a category and its book links from the database by ID
+func (r *categoryRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := touchLinkedBooks(tx, "book_categories", "category_id", id); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM book_categories WHERE category_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Category{}, id).Error
+	})
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// nextChangeSeqs takes n numbers from the book change counter and returns
+// the first. The counter row stays locked until tx ends, so changes commit
+// in the order of their numbers and a client that synced up to a number
+// never misses a change committed later with a lower one.
+func nextChangeSeqs(tx *gorm.DB, n int) (uint64, error) {
+	db := tx.Session(&gorm.Session{NewDB: true})
+	result := db.Model(&models.ChangeCounter{}).
+		Where("name = ?", models.BookChangeCounter).
+		UpdateColumn("value", gorm.Expr("value + ?", n))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf("change counter %s is missing", models.BookChangeCounter)
+	}
+	var last uint64
+	err := db.Model(&models.ChangeCounter{}).
+		Where("name = ?", models.BookChangeCounter).
+		Select("value").
+		Scan(&last).Error
+	if err != nil {
+		return 0, err
+	}
+	return last - uint64(n) + 1, nil
+}
+
+// touchBooks gives the books new change sequence numbers, so incremental
+// sync returns them again. Call it after the write that changed them. It
+// returns the number given to the first book.
+func touchBooks(tx *gorm.DB, ids []uint) (uint64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	ids = append([]uint(nil), ids...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	first, err := nextChangeSeqs(tx, len(ids))
+	if err != nil {
+		return 0, err
+	}
+	books := tx.Session(&gorm.Session{NewDB: true})
+	for i, id := range ids {
+		err := books.Model(&models.Book{}).Where("id = ?", id).UpdateColumn("change_seq", first+uint64(i)).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+	return first, nil
+}
+
+// touchLinkedBooks touches the books linked to a tag or category through
+// a join table
+func touchLinkedBooks(tx *gorm.DB, joinTable, column string, id uint) error {
+	ids, err := linkedBookIDs(tx, joinTable, column, id)
+	if err != nil {
+		return err
+	}
+	_, err = touchBooks(tx, ids)
+	return err
+}
+
+// linkedBookIDs returns the IDs of the books linked to a tag or category
+// through a join table
+func linkedBookIDs(tx *gorm.DB, joinTable, column string, id uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Session(&gorm.Session{NewDB: true}).Table(joinTable).Where(column+" = ?", id).Pluck("book_id", &ids).Error
+	return ids, err
+}
+
+// bookChangeRepository implements the BookChangeRepository interface.
+// Books and tombstones are confined to the tenant in the context by the
+// tenancy callbacks.
+type bookChangeRepository struct {
+	db *gorm.DB
+}
+
+// NewBookChangeRepository creates a new instance of book change repository
+func NewBookChangeRepository(db *gorm.DB) BookChangeRepository {
+	return &bookChangeRepository{
+		db: db,
+	}
+}
+
+// GetSince returns up to limit changes after the given sequence number in
+// sequence order, merging the written books with the tombstones
+func (r *bookChangeRepository) GetSince(ctx context.Context, since uint64, limit int) ([]models.BookChange, error) {
+	db := r.db.WithContext(ctx)
+
+	var books []models.Book
+	err := db.Preload("Tags").Preload("Categories").
+		Where("change_seq > ?", since).
+		Order("change_seq").
+		Limit(limit).
+		Find(&books).Error
+	if err != nil {
+		return nil, err
+	}
+	var tombstones []models.BookTombstone
+	err = db.Where("change_seq > ?", since).
+		Order("change_seq").
+		Limit(limit).
+		Find(&tombstones).Error
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.BookChange, 0, min(limit, len(books)+len(tombstones)))
+	for len(changes) < limit && (len(books) > 0 || len(tombstones) > 0) {
+		if len(tombstones) == 0 || (len(books) > 0 && books[0].ChangeSeq < tombstones[0].ChangeSeq) {
+			book := books[0]
+			changes = append(changes, models.BookChange{Seq: book.ChangeSeq, Type: models.BookUpserted, BookID: book.ID, Book: &book})
+			books = books[1:]
+			continue
+		}
+		tombstone := tombstones[0]
+		changes = append(changes, models.BookChange{Seq: tombstone.ChangeSeq, Type: models.BookRemoved, BookID: tombstone.BookID, DeletedAt: &tombstone.DeletedAt})
+		tombstones = tombstones[1:]
+	}
+	return changes, nil
+}
+
+// GetPurgedSeq returns the highest sequence number of the purged tombstones
+func (r *bookChangeRepository) GetPurgedSeq(ctx context.Context) (uint64, error) {
+	var purged []uint64
+	err := r.db.WithContext(ctx).Model(&models.ChangeCounter{}).
+		Where("name = ?", models.TombstonePurgeCounter).
+		Pluck("value", &purged).Error
+	if err != nil || len(purged) == 0 {
+		return 0, err
+	}
+	return purged[0], nil
+}
+
+// PurgeTombstones removes the tombstones of books deleted before the given
+// time, of all tenants, and moves the purge counter up to them. Later
+// tombstones have higher numbers, so the counter only grows.
+func (r *bookChangeRepository) PurgeTombstones(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var last uint64
+		err := tx.Model(&models.BookTombstone{}).
+			Where("deleted_at < ?", before).
+			Select("COALESCE(MAX(change_seq), 0)").
+			Scan(&last).Error
+		if err != nil || last == 0 {
+			return err
+		}
+
+		result := tx.Where("change_seq <= ?", last).Delete(&models.BookTombstone{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+
+		return tx.Model(&models.ChangeCounter{}).
+			Where("name = ?", models.TombstonePurgeCounter).
+			UpdateColumn("value", last).Error
+	})
+	return purged, err
+}
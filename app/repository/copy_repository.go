@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"gorm.io/gorm"
+)
+
+// copyRepository implements the CopyRepository interface
+type copyRepository struct {
+	db *gorm.DB
+}
+
+// NewCopyRepository creates a new instance of copy repository
+func NewCopyRepository(db *gorm.DB) CopyRepository {
+	return &copyRepository{
+		db: db,
+	}
+}
+
+// Create adds a new copy to the database
+func (r *copyRepository) Create(ctx context.Context, bookCopy *models.Copy) error {
+	return r.db.WithContext(ctx).Create(bookCopy).Error
+}
+
+// GetByID retrieves a copy by its ID
+func (r *copyRepository) GetByID(ctx context.Context, id uint) (*models.Copy, error) {
+	var bookCopy models.Copy
+	err := r.db.WithContext(ctx).First(&bookCopy, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+// GetByBarcode retrieves a copy by its barcode
+func (r *copyRepository) GetByBarcode(ctx context.Context, barcode string) (*models.Copy, error) {
+	var bookCopy models.Copy
+	err := r.db.WithContext(ctx).Where("barcode = ?", barcode).First(&bookCopy).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bookCopy, nil
+}
+
+// GetByBook retrieves all copies of a book
+func (r *copyRepository) GetByBook(ctx context.Context, bookID uint) ([]models.Copy, error) {
+	var copies []models.Copy
+	err := r.db.WithContext(ctx).Where("book_id = ?", bookID).Order("id").Find(&copies).Error
+	return copies, err
+}
+
+// Update modifies an existing copy in the database. Selecting the columns
+// keeps Save from inserting the copy when it belongs to another tenant.
+func (r *copyRepository) Update(ctx context.Context, bookCopy *models.Copy) error {
+	return r.db.WithContext(ctx).Select("*").Save(bookCopy).Error
+}
+
+// Delete removes a copy from the database by ID
+func (r *copyRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Copy{}, id).Error
+}
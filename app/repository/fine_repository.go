@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"gorm.io/gorm"
+)
+
+// fineRepository implements the FineRepository interface
+type fineRepository struct {
+	db *gorm.DB
+}
+
+// NewFineRepository creates a new instance of fine repository
+func NewFineRepository(db *gorm.DB) FineRepository {
+	return &fineRepository{
+		db: db,
+	}
+}
+
+// Create adds a new fine to the database
+func (r *fineRepository) Create(ctx context.Context, fine *models.Fine) error {
+	return r.db.WithContext(ctx).Create(fine).Error
+}
+
+// GetByID retrieves a fine by its ID
+func (r *fineRepository) GetByID(ctx context.Context, id uint) (*models.Fine, error) {
+	var fine models.Fine
+	err := r.db.WithContext(ctx).First(&fine, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &fine, nil
+}
+
+// GetByMember retrieves all fines of a member, newest first
+func (r *fineRepository) GetByMember(ctx context.Context, memberID uint) ([]models.Fine, error) {
+	var fines []models.Fine
+	err := r.db.WithContext(ctx).Where("member_id = ?", memberID).Order("created_at DESC").Order("id DESC").Find(&fines).Error
+	return fines, err
+}
+
+// SumUnpaidByMember totals the outstanding fines of a member in cents
+func (r *fineRepository) SumUnpaidByMember(ctx context.Context, memberID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&models.Fine{}).
+		Where("member_id = ? AND paid_at IS NULL", memberID).
+		Select("COALESCE(SUM(amount_cents), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// Update modifies an existing fine of the caller's tenant in the database
+func (r *fineRepository) Update(ctx context.Context, fine *models.Fine) error {
+	return r.db.WithContext(ctx).Select("*").Save(fine).Error
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// holdRepository implements the HoldRepository interface
+type holdRepository struct {
+	db *gorm.DB
+}
+
+// NewHoldRepository creates a new instance of hold repository
+func NewHoldRepository(db *gorm.DB) HoldRepository {
+	return &holdRepository{
+		db: db,
+	}
+}
+
+// Create adds a new hold to the database
+func (r *holdRepository) Create(ctx context.Context, hold *models.Hold) error {
+	return r.db.WithContext(ctx).Create(hold).Error
+}
+
+// GetByID retrieves a hold by its ID
+func (r *holdRepository) GetByID(ctx context.Context, id uint) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.db.WithContext(ctx).First(&hold, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// GetActiveByMemberAndBook retrieves a member's waiting or ready hold on a book
+func (r *holdRepository) GetActiveByMemberAndBook(ctx context.Context, memberID, bookID uint) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.db.WithContext(ctx).Where("member_id = ? AND book_id = ? AND status IN ?", memberID, bookID,
+		[]models.HoldStatus{models.HoldWaiting, models.HoldReady}).First(&hold).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// GetByMember retrieves all holds of a member, newest first
+func (r *holdRepository) GetByMember(ctx context.Context, memberID uint) ([]models.Hold, error) {
+	var holds []models.Hold
+	err := r.db.WithContext(ctx).Where("member_id = ?", memberID).Order("created_at DESC").Order("id DESC").Find(&holds).Error
+	return holds, err
+}
+
+// GetReadyByCopy retrieves the ready hold a copy is set aside for
+func (r *holdRepository) GetReadyByCopy(ctx context.Context, copyID uint) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.db.WithContext(ctx).Where("copy_id = ? AND status = ?", copyID, models.HoldReady).First(&hold).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// GetExpiredReady retrieves ready holds whose pickup window closed before now
+func (r *holdRepository) GetExpiredReady(ctx context.Context, now time.Time) ([]models.Hold, error) {
+	var holds []models.Hold
+	err := r.db.WithContext(ctx).Where("status = ? AND expires_at < ?", models.HoldReady, now).Order("expires_at").Find(&holds).Error
+	return holds, err
+}
+
+// PromoteNext sets the copy aside for the oldest waiting hold on the book.
+// Each promotion is a conditional update, so concurrent returns never promote
+// the same hold twice, and the partial unique index on ready holds rejects a
+// copy being set aside twice. It returns nil when nobody is waiting.
+func (r *holdRepository) PromoteNext(ctx context.Context, bookID, copyID uint, readyAt, expiresAt time.Time) (*models.Hold, error) {
+	for {
+		var next models.Hold
+		err := r.db.WithContext(ctx).Where("book_id = ? AND status = ?", bookID, models.HoldWaiting).
+			Order("created_at").Order("id").
+			First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result := r.db.WithContext(ctx).Model(&models.Hold{}).
+			Where("id = ? AND status = ?", next.ID, models.HoldWaiting).
+			Updates(map[string]interface{}{
+				"status":     models.HoldReady,
+				"copy_id":    copyID,
+				"ready_at":   readyAt,
+				"expires_at": expiresAt,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return r.GetByID(ctx, next.ID)
+		}
+		// Another return promoted this hold first; try the next one in line
+	}
+}
+
+// UpdateIfStatus saves the hold only if its stored status still equals
+// expected, reporting whether the hold was saved
+func (r *holdRepository) UpdateIfStatus(ctx context.Context, hold *models.Hold, expected models.HoldStatus) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Hold{}).
+		Where("id = ? AND status = ?", hold.ID, expected).
+		Select("Status", "CopyID", "ReadyAt", "ExpiresAt", "UpdatedAt").
+		Updates(hold)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
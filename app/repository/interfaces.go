@@ -1,12 +1,226 @@
 package repository
 
-import "books-api/app/models"
+import (
+	"books-api/app/models"
+	"context"
+	"time"
+)
 
-// BookRepository defines the interface for book data operations
+// BookRepository defines the interface for book data operations. Results
+// are limited to the tenant carried by ctx, if any.
 type BookRepository interface {
-	Create(book *models.Book) error
-	GetByID(id uint) (*models.Book, error)
-	GetAll() ([]models.Book, error)
-	Update(book *models.Book) error
-	Delete(id uint) error
+	Create(ctx context.Context, book *models.Book) error
+	GetByID(ctx context.Context, id uint) (*models.Book, error)
+	GetAll(ctx context.Context, filter models.BookFilter) ([]models.Book, error)
+	Count(ctx context.Context) (int64, error)
+	Update(ctx context.Context, book *models.Book) error
+	Delete(ctx context.Context, id uint) error
+	ReplaceTags(ctx context.Context, book *models.Book, tags []models.Tag) error
+	ReplaceCategories(ctx context.Context, book *models.Book, categories []models.Category) error
+	GetAvailability(ctx context.Context, bookID uint) (*models.BookAvailability, error)
+}
+
+// TagRepository defines the interface for tag data operations. Results are
+// limited to the tenant carried by ctx, if any.
+type TagRepository interface {
+	Create(ctx context.Context, tag *models.Tag) error
+	GetByID(ctx context.Context, id uint) (*models.Tag, error)
+	GetByName(ctx context.Context, name string) (*models.Tag, error)
+	GetAllWithCounts(ctx context.Context) ([]models.TagCount, error)
+	Update(ctx context.Context, tag *models.Tag) error
+	Delete(ctx context.Context, id uint) error
+	Merge(ctx context.Context, sourceID, targetID uint) error
+}
+
+// CategoryRepository defines the interface for category data operations.
+// Results are limited to the tenant carried by ctx, if any.
+type CategoryRepository interface {
+	Create(ctx context.Context, category *models.Category) error
+	GetByID(ctx context.Context, id uint) (*models.Category, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]models.Category, error)
+	GetAll(ctx context.Context) ([]models.Category, error)
+	GetDescendantIDs(ctx context.Context, id uint) ([]uint, error)
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// ReviewRepository defines the interface for review data operations. Every
+// write also refreshes the rating aggregates of the reviewed book. Results
+// are limited to the tenant carried by ctx, if any.
+type ReviewRepository interface {
+	Create(ctx context.Context, review *models.Review) error
+	GetByID(ctx context.Context, id uint) (*models.Review, error)
+	GetByBookAndReviewer(ctx context.Context, bookID uint, reviewer string) (*models.Review, error)
+	GetByBook(ctx context.Context, bookID uint, status *models.ReviewStatus) ([]models.Review, error)
+	// GetApprovedByBooks returns the approved reviews of several books,
+	// newest first
+	GetApprovedByBooks(ctx context.Context, bookIDs []uint) ([]models.Review, error)
+	Update(ctx context.Context, review *models.Review) error
+	Delete(ctx context.Context, review *models.Review) error
+}
+
+// CopyRepository defines the interface for physical copy data operations.
+// Results are limited to the tenant carried by ctx, if any.
+type CopyRepository interface {
+	Create(ctx context.Context, bookCopy *models.Copy) error
+	GetByID(ctx context.Context, id uint) (*models.Copy, error)
+	GetByBarcode(ctx context.Context, barcode string) (*models.Copy, error)
+	GetByBook(ctx context.Context, bookID uint) ([]models.Copy, error)
+	Update(ctx context.Context, bookCopy *models.Copy) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// LoanRepository defines the interface for loan data operations. Results
+// are limited to the tenant carried by ctx, if any.
+type LoanRepository interface {
+	Create(ctx context.Context, loan *models.Loan) error
+	GetByID(ctx context.Context, id uint) (*models.Loan, error)
+	GetActiveByCopy(ctx context.Context, copyID uint) (*models.Loan, error)
+	CountActiveByMember(ctx context.Context, memberID uint) (int64, error)
+	GetByMember(ctx context.Context, memberID uint) ([]models.Loan, error)
+	GetOverdue(ctx context.Context, now time.Time) ([]models.Loan, error)
+	Update(ctx context.Context, loan *models.Loan) error
+	MarkReturned(ctx context.Context, loan *models.Loan, returnedAt time.Time) (bool, error)
+}
+
+// HoldRepository defines the interface for hold queue data operations.
+// Results are limited to the tenant carried by ctx, if any.
+type HoldRepository interface {
+	Create(ctx context.Context, hold *models.Hold) error
+	GetByID(ctx context.Context, id uint) (*models.Hold, error)
+	GetActiveByMemberAndBook(ctx context.Context, memberID, bookID uint) (*models.Hold, error)
+	GetByMember(ctx context.Context, memberID uint) ([]models.Hold, error)
+	GetReadyByCopy(ctx context.Context, copyID uint) (*models.Hold, error)
+	GetExpiredReady(ctx context.Context, now time.Time) ([]models.Hold, error)
+	PromoteNext(ctx context.Context, bookID, copyID uint, readyAt, expiresAt time.Time) (*models.Hold, error)
+	UpdateIfStatus(ctx context.Context, hold *models.Hold, expected models.HoldStatus) (bool, error)
+}
+
+// MemberRepository defines the interface for member data operations.
+// Results are limited to the tenant carried by ctx, if any.
+type MemberRepository interface {
+	Create(ctx context.Context, member *models.Member) error
+	GetByID(ctx context.Context, id uint) (*models.Member, error)
+	GetByEmail(ctx context.Context, email string) (*models.Member, error)
+	GetByCardNumber(ctx context.Context, cardNumber string) (*models.Member, error)
+	Search(ctx context.Context, filter models.MemberFilter) ([]models.Member, error)
+	Update(ctx context.Context, member *models.Member) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// FineRepository defines the interface for overdue fine data operations.
+// Results are limited to the tenant carried by ctx, if any.
+type FineRepository interface {
+	Create(ctx context.Context, fine *models.Fine) error
+	GetByID(ctx context.Context, id uint) (*models.Fine, error)
+	GetByMember(ctx context.Context, memberID uint) ([]models.Fine, error)
+	SumUnpaidByMember(ctx context.Context, memberID uint) (int64, error)
+	Update(ctx context.Context, fine *models.Fine) error
+}
+
+// APIKeyRepository defines the interface for API key data operations
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByID(ctx context.Context, id uint) (*models.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	GetAll(ctx context.Context) ([]models.APIKey, error)
+	Update(ctx context.Context, key *models.APIKey) error
+	TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error
+	// RevokeByTenant revokes the keys bound to the tenant with slug that
+	// are not revoked yet and returns how many it revoked
+	RevokeByTenant(ctx context.Context, slug string, revokedAt time.Time) (int64, error)
+}
+
+// TenantRepository defines the interface for tenant data operations
+type TenantRepository interface {
+	Create(ctx context.Context, tenant *models.Tenant) error
+	GetByID(ctx context.Context, id uint) (*models.Tenant, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Tenant, error)
+	GetAll(ctx context.Context) ([]models.Tenant, error)
+	Update(ctx context.Context, tenant *models.Tenant) error
+	Delete(ctx context.Context, id uint) error
+	// Owned returns the tables that still hold rows of the tenant with id
+	Owned(ctx context.Context, id uint) ([]string, error)
+}
+
+// OutboxRepository defines the interface for outbox event data operations
+type OutboxRepository interface {
+	Create(ctx context.Context, event *models.OutboxEvent) error
+	// GetPending returns up to limit undelivered events that are due at
+	// now, oldest first. Events of a book with an earlier undelivered event
+	// that is not due are left out, so the events of a book stay in order.
+	GetPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error)
+	Update(ctx context.Context, event *models.OutboxEvent) error
+	// DeleteDeliveredBefore removes events delivered before the given time
+	DeleteDeliveredBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// BookChangeRepository defines the interface for reading the book changes
+// of incremental sync. Every book write takes the next number of a global
+// change sequence; deletes leave a tombstone with theirs. Results are
+// limited to the tenant carried by ctx, if any.
+type BookChangeRepository interface {
+	// GetSince returns up to limit changes numbered above since, in order
+	GetSince(ctx context.Context, since uint64, limit int) ([]models.BookChange, error)
+	// GetPurgedSeq returns the highest number of the purged tombstones
+	GetPurgedSeq(ctx context.Context) (uint64, error)
+	// PurgeTombstones removes the tombstones of books deleted before the
+	// given time
+	PurgeTombstones(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WebhookRepository defines the interface for webhook data operations.
+// Results are limited to the tenant carried by ctx, if any.
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	GetByID(ctx context.Context, id uint) (*models.Webhook, error)
+	GetAll(ctx context.Context) ([]models.Webhook, error)
+	// GetActiveByTenant returns the enabled webhooks of a tenant
+	GetActiveByTenant(ctx context.Context, tenantID uint) ([]models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) error
+	// Disable marks an enabled webhook disabled without touching its other
+	// columns
+	Disable(ctx context.Context, id uint, disabledAt time.Time, reason string) error
+	// Delete removes a webhook together with its deliveries
+	Delete(ctx context.Context, id uint) error
+}
+
+// WebhookDeliveryRepository defines the interface for webhook delivery data
+// operations. Results are limited to the tenant carried by ctx, if any.
+type WebhookDeliveryRepository interface {
+	// CreateMissing adds the deliveries that do not exist yet for their
+	// webhook and event, so an event delivered twice is queued once
+	CreateMissing(ctx context.Context, deliveries []models.WebhookDelivery) error
+	GetByID(ctx context.Context, id uint) (*models.WebhookDelivery, error)
+	// GetByWebhook returns the latest deliveries of a webhook, newest first
+	GetByWebhook(ctx context.Context, webhookID uint, limit int) ([]models.WebhookDelivery, error)
+	// GetDue returns up to limit pending deliveries of enabled webhooks
+	// whose next attempt is due, oldest first
+	GetDue(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+	Update(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+// UnitOfWork runs repository calls in one database transaction, so that a
+// read and the write depending on it cannot interleave with another change
+type UnitOfWork interface {
+	// Do calls fn with repositories bound to a new transaction. It commits
+	// when fn returns nil and rolls back when fn returns an error or panics.
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+// Repositories gives access to the repositories of one unit of work. Books
+// are locked by GetByID until the unit of work ends.
+type Repositories interface {
+	Books() BookRepository
+	Tags() TagRepository
+	Categories() CategoryRepository
+	Reviews() ReviewRepository
+	Copies() CopyRepository
+	Loans() LoanRepository
+	Holds() HoldRepository
+	Members() MemberRepository
+	Fines() FineRepository
+	APIKeys() APIKeyRepository
+	Tenants() TenantRepository
+	Outbox() OutboxRepository
 }
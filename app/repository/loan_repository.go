@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// loanRepository implements the LoanRepository interface
+type loanRepository struct {
+	db *gorm.DB
+}
+
+// NewLoanRepository creates a new instance of loan repository
+func NewLoanRepository(db *gorm.DB) LoanRepository {
+	return &loanRepository{
+		db: db,
+	}
+}
+
+// Create adds a new loan to the database
+func (r *loanRepository) Create(ctx context.Context, loan *models.Loan) error {
+	return r.db.WithContext(ctx).Create(loan).Error
+}
+
+// GetByID retrieves a loan by its ID
+func (r *loanRepository) GetByID(ctx context.Context, id uint) (*models.Loan, error) {
+	var loan models.Loan
+	err := r.db.WithContext(ctx).First(&loan, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+// GetActiveByCopy retrieves the unreturned loan of a copy
+func (r *loanRepository) GetActiveByCopy(ctx context.Context, copyID uint) (*models.Loan, error) {
+	var loan models.Loan
+	err := r.db.WithContext(ctx).Where("copy_id = ? AND returned_at IS NULL", copyID).First(&loan).Error
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+// CountActiveByMember counts the unreturned loans of a member
+func (r *loanRepository) CountActiveByMember(ctx context.Context, memberID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Loan{}).
+		Where("member_id = ? AND returned_at IS NULL", memberID).
+		Count(&count).Error
+	return count, err
+}
+
+// GetByMember retrieves the loan history of a member, newest first
+func (r *loanRepository) GetByMember(ctx context.Context, memberID uint) ([]models.Loan, error) {
+	var loans []models.Loan
+	err := r.db.WithContext(ctx).Where("member_id = ?", memberID).Order("checked_out_at DESC").Order("id DESC").Find(&loans).Error
+	return loans, err
+}
+
+// GetOverdue retrieves unreturned loans that were due before now
+func (r *loanRepository) GetOverdue(ctx context.Context, now time.Time) ([]models.Loan, error) {
+	var loans []models.Loan
+	err := r.db.WithContext(ctx).Where("returned_at IS NULL AND due_at < ?", now).Order("due_at").Find(&loans).Error
+	return loans, err
+}
+
+// Update modifies an existing loan of the caller's tenant in the database
+func (r *loanRepository) Update(ctx context.Context, loan *models.Loan) error {
+	return r.db.WithContext(ctx).Select("*").Save(loan).Error
+}
+
+// MarkReturned closes the loan only if it is still unreturned, reporting
+// whether it was closed. Of two concurrent returns, only one closes the loan.
+func (r *loanRepository) MarkReturned(ctx context.Context, loan *models.Loan, returnedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Loan{}).
+		Where("id = ? AND returned_at IS NULL", loan.ID).
+		Update("returned_at", returnedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return false, nil
+	}
+	loan.ReturnedAt = &returnedAt
+	return true, nil
+}
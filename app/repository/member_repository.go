@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// memberRepository implements the MemberRepository interface
+type memberRepository struct {
+	db *gorm.DB
+}
+
+// NewMemberRepository creates a new instance of member repository
+func NewMemberRepository(db *gorm.DB) MemberRepository {
+	return &memberRepository{
+		db: db,
+	}
+}
+
+// Create adds a new member to the database
+func (r *memberRepository) Create(ctx context.Context, member *models.Member) error {
+	return r.db.WithContext(ctx).Create(member).Error
+}
+
+// GetByID retrieves a member by its ID
+func (r *memberRepository) GetByID(ctx context.Context, id uint) (*models.Member, error) {
+	var member models.Member
+	err := r.db.WithContext(ctx).First(&member, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetByEmail retrieves a member by email address
+func (r *memberRepository) GetByEmail(ctx context.Context, email string) (*models.Member, error) {
+	var member models.Member
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetByCardNumber retrieves a member by library card number
+func (r *memberRepository) GetByCardNumber(ctx context.Context, cardNumber string) (*models.Member, error) {
+	var member models.Member
+	err := r.db.WithContext(ctx).Where("card_number = ?", cardNumber).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// Search retrieves members matching the filter, ordered by name
+func (r *memberRepository) Search(ctx context.Context, filter models.MemberFilter) ([]models.Member, error) {
+	var members []models.Member
+	query := r.db.WithContext(ctx).Model(&models.Member{})
+
+	if filter.Query != "" {
+		pattern := "%" + strings.ToLower(filter.Query) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ? OR LOWER(card_number) LIKE ?", pattern, pattern, pattern)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	err := query.Order("name").Order("id").Find(&members).Error
+	return members, err
+}
+
+// Update modifies an existing member of the caller's tenant in the database
+func (r *memberRepository) Update(ctx context.Context, member *models.Member) error {
+	return r.db.WithContext(ctx).Select("*").Save(member).Error
+}
+
+// Delete removes a member from the database by ID
+func (r *memberRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Member{}, id).Error
+}
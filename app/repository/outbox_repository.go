@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// outboxRepository implements the OutboxRepository interface. Events carry
+// a tenant, so the dispatcher reads them without one in its context to see
+// the events of all tenants.
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates a new instance of outbox repository
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+// Create adds an event to the outbox
+func (r *outboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// GetPending returns the oldest undelivered events that are due and not
+// held back by an earlier event of their book waiting for a retry
+func (r *outboxRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events AS earlier
+			WHERE earlier.book_id = outbox_events.book_id AND earlier.id < outbox_events.id
+			AND earlier.status = ? AND earlier.next_attempt_at > ?)`, models.OutboxPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// Update records the outcome of a delivery attempt
+func (r *outboxRepository) Update(ctx context.Context, event *models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Save(event).Error
+}
+
+// DeleteDeliveredBefore removes delivered events older than before
+func (r *outboxRepository) DeleteDeliveredBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND delivered_at < ?", models.OutboxDelivered, before).
+		Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
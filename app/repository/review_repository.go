@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"gorm.io/gorm"
+)
+
+// reviewRepository implements the ReviewRepository interface
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+// NewReviewRepository creates a new instance of review repository
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{
+		db: db,
+	}
+}
+
+// refreshBookRating recomputes the denormalized rating columns of a book
+// from its approved reviews. The book counts as changed for incremental
+// sync.
+func refreshBookRating(tx *gorm.DB, bookID uint) error {
+	approved := tx.Model(&models.Review{}).Where("book_id = ? AND status = ?", bookID, models.ReviewApproved)
+	err := tx.Model(&models.Book{}).Where("id = ?", bookID).Updates(map[string]interface{}{
+		"average_rating": gorm.Expr("(?)", approved.Session(&gorm.Session{}).Select("COALESCE(AVG(rating), 0)")),
+		"rating_count":   gorm.Expr("(?)", approved.Session(&gorm.Session{}).Select("COUNT(*)")),
+	}).Error
+	if err != nil {
+		return err
+	}
+	_, err = touchBooks(tx, []uint{bookID})
+	return err
+}
+
+// Create adds a new review and refreshes the book rating in one transaction
+func (r *reviewRepository) Create(ctx context.Context, review *models.Review) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		return refreshBookRating(tx, review.BookID)
+	})
+}
+
+// GetByID retrieves a review by its ID
+func (r *reviewRepository) GetByID(ctx context.Context, id uint) (*models.Review, error) {
+	var review models.Review
+	err := r.db.WithContext(ctx).First(&review, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetByBookAndReviewer retrieves the review a reviewer wrote for a book
+func (r *reviewRepository) GetByBookAndReviewer(ctx context.Context, bookID uint, reviewer string) (*models.Review, error) {
+	var review models.Review
+	err := r.db.WithContext(ctx).Where("book_id = ? AND reviewer = ?", bookID, reviewer).First(&review).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetByBook retrieves the reviews of a book, optionally only those with the
+// given status, newest first
+func (r *reviewRepository) GetByBook(ctx context.Context, bookID uint, status *models.ReviewStatus) ([]models.Review, error) {
+	var reviews []models.Review
+	query := r.db.WithContext(ctx).Where("book_id = ?", bookID)
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+	err := query.Order("created_at DESC").Order("id DESC").Find(&reviews).Error
+	return reviews, err
+}
+
+// GetApprovedByBooks retrieves the approved reviews of several books in one
+// query, newest first
+func (r *reviewRepository) GetApprovedByBooks(ctx context.Context, bookIDs []uint) ([]models.Review, error) {
+	var reviews []models.Review
+	err := r.db.WithContext(ctx).Where("book_id IN ? AND status = ?", bookIDs, models.ReviewApproved).
+		Order("created_at DESC").Order("id DESC").
+		Find(&reviews).Error
+	return reviews, err
+}
+
+// Update modifies a review and refreshes the book rating in one transaction
+func (r *reviewRepository) Update(ctx context.Context, review *models.Review) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("*").Save(review).Error; err != nil {
+			return err
+		}
+		return refreshBookRating(tx, review.BookID)
+	})
+}
+
+// Delete removes a review and refreshes the book rating in one transaction
+func (r *reviewRepository) Delete(ctx context.Context, review *models.Review) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Review{}, review.ID).Error; err != nil {
+			return err
+		}
+		return refreshBookRating(tx, review.BookID)
+	})
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"gorm.io/gorm"
+)
+
+// tagRepository implements the TagRepository interface
+type tagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository creates a new instance of tag repository
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{
+		db: db,
+	}
+}
+
+// Create adds a new tag to the database
+func (r *tagRepository) Create(ctx context.Context, tag *models.Tag) error {
+	return r.db.WithContext(ctx).Create(tag).Error
+}
+
+// GetByID retrieves a tag by its ID
+func (r *tagRepository) GetByID(ctx context.Context, id uint) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.WithContext(ctx).First(&tag, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetByName retrieves a tag by its normalized name
+func (r *tagRepository) GetByName(ctx context.Context, name string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetAllWithCounts retrieves all tags with the number of books per tag
+func (r *tagRepository) GetAllWithCounts(ctx context.Context) ([]models.TagCount, error) {
+	var counts []models.TagCount
+	err := r.db.WithContext(ctx).Model(&models.Tag{}).
+		Select("tags.id, tags.name, COUNT(book_tags.book_id) AS book_count").
+		Joins("LEFT JOIN book_tags ON book_tags.tag_id = tags.id").
+		Group("tags.id, tags.name").
+		Order("tags.name").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// Update modifies an existing tag in the database. The books carrying it
+// count as changed for incremental sync.
+func (r *tagRepository) Update(ctx context.Context, tag *models.Tag) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("*").Save(tag).Error; err != nil {
+			return err
+		}
+		return touchLinkedBooks(tx, "book_tags", "tag_id", tag.ID)
+	})
+}
+
+// Delete removes a tag and its book links from the database by ID
+func (r *tagRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := touchLinkedBooks(tx, "book_tags", "tag_id", id); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM book_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, id).Error
+	})
+}
+
+// Merge moves all book links from the source tag to the target tag and
+// removes the source tag, in a single transaction
+func (r *tagRepository) Merge(ctx context.Context, sourceID, targetID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := touchLinkedBooks(tx, "book_tags", "tag_id", sourceID); err != nil {
+			return err
+		}
+		err := tx.Exec(`INSERT INTO book_tags (book_id, tag_id)
+			SELECT book_id, ? FROM book_tags
+			WHERE tag_id = ? AND book_id NOT IN (SELECT book_id FROM book_tags WHERE tag_id = ?)`,
+			targetID, sourceID, targetID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM book_tags WHERE tag_id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, sourceID).Error
+	})
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// tenantOwnedTables hold the rows that keep a tenant from being deleted
+var tenantOwnedTables = []string{
+	"books", "copies", "members", "loans", "holds", "fines",
+	"reviews", "tags", "categories", "webhooks",
+}
+
+// tenantRepository implements the TenantRepository interface
+type tenantRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantRepository creates a new instance of tenant repository
+func NewTenantRepository(db *gorm.DB) TenantRepository {
+	return &tenantRepository{
+		db: db,
+	}
+}
+
+// Create adds a new tenant to the database
+func (r *tenantRepository) Create(ctx context.Context, tenant *models.Tenant) error {
+	return r.db.WithContext(ctx).Create(tenant).Error
+}
+
+// GetByID retrieves a tenant by its ID
+func (r *tenantRepository) GetByID(ctx context.Context, id uint) (*models.Tenant, error) {
+	var tenant models.Tenant
+	err := r.db.WithContext(ctx).First(&tenant, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// GetBySlug retrieves a tenant by its slug
+func (r *tenantRepository) GetBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
+	var tenant models.Tenant
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&tenant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// GetAll retrieves all tenants ordered by slug
+func (r *tenantRepository) GetAll(ctx context.Context) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := r.db.WithContext(ctx).Order("slug").Find(&tenants).Error
+	return tenants, err
+}
+
+// Update modifies an existing tenant in the database
+func (r *tenantRepository) Update(ctx context.Context, tenant *models.Tenant) error {
+	return r.db.WithContext(ctx).Save(tenant).Error
+}
+
+// Delete removes a tenant from the database by ID
+func (r *tenantRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Tenant{}, id).Error
+}
+
+// Owned returns the tables that still hold rows of the tenant with id. The
+// tables are queried by name, so the statements are not scoped to the tenant
+// of the context.
+func (r *tenantRepository) Owned(ctx context.Context, id uint) ([]string, error) {
+	var owned []string
+	for _, table := range tenantOwnedTables {
+		var count int64
+		if err := r.db.WithContext(ctx).Table(table).Where("tenant_id = ?", id).Limit(1).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			owned = append(owned, table)
+		}
+	}
+	return owned, nil
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// unitOfWork implements the UnitOfWork interface with GORM transactions
+type unitOfWork struct {
+	db *gorm.DB
+	// bookCache drops the books a unit of work wrote once it commits; nil
+	// when books are not cached
+	bookCache *CachedBookRepository
+	logger    *slog.Logger
+}
+
+// NewUnitOfWork creates a unit of work running transactions on db. Books
+// written in a transaction, including those whose tags, categories or
+// reviews changed, are dropped from bookCache, if not nil, after it
+// commits, so that no reader caches them again in between. The transaction
+// stands when a book cannot be dropped; logger records the failure and the
+// book shows once its entry expires.
+func NewUnitOfWork(db *gorm.DB, bookCache *CachedBookRepository, logger *slog.Logger) UnitOfWork {
+	return &unitOfWork{
+		db:        db,
+		bookCache: bookCache,
+		logger:    logger,
+	}
+}
+
+// Do runs fn in a transaction
+func (u *unitOfWork) Do(ctx context.Context, fn func(repos Repositories) error) error {
+	repos := &transactionRepositories{}
+	err := u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repos.tx = tx
+		return fn(repos)
+	})
+	if err != nil {
+		return err
+	}
+
+	if u.bookCache != nil {
+		for _, id := range repos.writtenBooks {
+			if err := u.bookCache.invalidate(ctx, id); err != nil {
+				u.logger.ErrorContext(ctx, "Failed to invalidate cached book after commit", "book_id", id, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// transactionRepositories implements Repositories for one transaction
+type transactionRepositories struct {
+	tx *gorm.DB
+	// writtenBooks are the IDs of the books changed in the transaction,
+	// directly or through their tags, categories and reviews
+	writtenBooks []uint
+}
+
+func (r *transactionRepositories) Books() BookRepository {
+	return &transactionBookRepository{bookRepository: bookRepository{db: r.tx}, written: &r.writtenBooks}
+}
+
+func (r *transactionRepositories) Tags() TagRepository {
+	return &transactionTagRepository{tagRepository: tagRepository{db: r.tx}, written: &r.writtenBooks}
+}
+
+func (r *transactionRepositories) Categories() CategoryRepository {
+	return &transactionCategoryRepository{categoryRepository: categoryRepository{db: r.tx}, written: &r.writtenBooks}
+}
+
+func (r *transactionRepositories) Reviews() ReviewRepository {
+	return &transactionReviewRepository{reviewRepository: reviewRepository{db: r.tx}, written: &r.writtenBooks}
+}
+
+func (r *transactionRepositories) Copies() CopyRepository {
+	return NewCopyRepository(r.tx)
+}
+
+func (r *transactionRepositories) Loans() LoanRepository {
+	return NewLoanRepository(r.tx)
+}
+
+func (r *transactionRepositories) Holds() HoldRepository {
+	return NewHoldRepository(r.tx)
+}
+
+func (r *transactionRepositories) Members() MemberRepository {
+	return NewMemberRepository(r.tx)
+}
+
+func (r *transactionRepositories) Fines() FineRepository {
+	return NewFineRepository(r.tx)
+}
+
+func (r *transactionRepositories) APIKeys() APIKeyRepository {
+	return NewAPIKeyRepository(r.tx)
+}
+
+func (r *transactionRepositories) Tenants() TenantRepository {
+	return NewTenantRepository(r.tx)
+}
+
+func (r *transactionRepositories) Outbox() OutboxRepository {
+	return NewOutboxRepository(r.tx)
+}
+
+// transactionBookRepository is the book repository of a unit of work. It
+// locks the books it reads and records the books it writes.
+type transactionBookRepository struct {
+	bookRepository
+	written *[]uint
+}
+
+// GetByID retrieves a book by its ID and locks it until the transaction
+// ends. SQLite has no row locks; its transactions write one at a time.
+func (r *transactionBookRepository) GetByID(ctx context.Context, id uint) (*models.Book, error) {
+	var book models.Book
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+		Preload("Tags").Preload("Categories").
+		First(&book, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// Update modifies an existing book in the transaction
+func (r *transactionBookRepository) Update(ctx context.Context, book *models.Book) error {
+	*r.written = append(*r.written, book.ID)
+	return r.bookRepository.Update(ctx, book)
+}
+
+// Delete removes a book and its reviews in the transaction
+func (r *transactionBookRepository) Delete(ctx context.Context, id uint) error {
+	*r.written = append(*r.written, id)
+	return r.bookRepository.Delete(ctx, id)
+}
+
+// ReplaceTags sets the book's tags in the transaction
+func (r *transactionBookRepository) ReplaceTags(ctx context.Context, book *models.Book, tags []models.Tag) error {
+	*r.written = append(*r.written, book.ID)
+	return r.bookRepository.ReplaceTags(ctx, book, tags)
+}
+
+// ReplaceCategories sets the book's categories in the transaction
+func (r *transactionBookRepository) ReplaceCategories(ctx context.Context, book *models.Book, categories []models.Category) error {
+	*r.written = append(*r.written, book.ID)
+	return r.bookRepository.ReplaceCategories(ctx, book, categories)
+}
+
+// transactionTagRepository is the tag repository of a unit of work. It
+// records the books carrying the tags it renames, deletes or merges away.
+type transactionTagRepository struct {
+	tagRepository
+	written *[]uint
+}
+
+// Update renames a tag in the transaction
+func (r *transactionTagRepository) Update(ctx context.Context, tag *models.Tag) error {
+	if err := r.recordLinkedBooks(ctx, tag.ID); err != nil {
+		return err
+	}
+	return r.tagRepository.Update(ctx, tag)
+}
+
+// Delete removes a tag and its book links in the transaction
+func (r *transactionTagRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.recordLinkedBooks(ctx, id); err != nil {
+		return err
+	}
+	return r.tagRepository.Delete(ctx, id)
+}
+
+// Merge moves the books of the source tag to the target tag in the
+// transaction
+func (r *transactionTagRepository) Merge(ctx context.Context, sourceID, targetID uint) error {
+	if err := r.recordLinkedBooks(ctx, sourceID); err != nil {
+		return err
+	}
+	return r.tagRepository.Merge(ctx, sourceID, targetID)
+}
+
+func (r *transactionTagRepository) recordLinkedBooks(ctx context.Context, id uint) error {
+	ids, err := linkedBookIDs(r.db.WithContext(ctx), "book_tags", "tag_id", id)
+	if err != nil {
+		return err
+	}
+	*r.written = append(*r.written, ids...)
+	return nil
+}
+
+// transactionCategoryRepository is the category repository of a unit of
+// work. It records the books in the categories it changes or deletes.
+type transactionCategoryRepository struct {
+	categoryRepository
+	written *[]uint
+}
+
+// Update modifies a category in the transaction
+func (r *transactionCategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	if err := r.recordLinkedBooks(ctx, category.ID); err != nil {
+		return err
+	}
+	return r.categoryRepository.Update(ctx, category)
+}
+
+// Delete removes a category and its book links in the transaction
+func (r *transactionCategoryRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.recordLinkedBooks(ctx, id); err != nil {
+		return err
+	}
+	return r.categoryRepository.Delete(ctx, id)
+}
+
+func (r *transactionCategoryRepository) recordLinkedBooks(ctx context.Context, id uint) error {
+	ids, err := linkedBookIDs(r.db.WithContext(ctx), "book_categories", "category_id", id)
+	if err != nil {
+		return err
+	}
+	*r.written = append(*r.written, ids...)
+	return nil
+}
+
+// transactionReviewRepository is the review repository of a unit of work.
+// It records the reviewed books, whose rating aggregates its writes
+// refresh.
+type transactionReviewRepository struct {
+	reviewRepository
+	written *[]uint
+}
+
+// Create adds a review in the transaction
+func (r *transactionReviewRepository) Create(ctx context.Context, review *models.Review) error {
+	*r.written = append(*r.written, review.BookID)
+	return r.reviewRepository.Create(ctx, review)
+}
+
+// Update modifies a review in the transaction
+func (r *transactionReviewRepository) Update(ctx context.Context, review *models.Review) error {
+	*r.written = append(*r.written, review.BookID)
+	return r.reviewRepository.Update(ctx, review)
+}
+
+// Delete removes a review in the transaction
+func (r *transactionReviewRepository) Delete(ctx context.Context, review *models.Review) error {
+	*r.written = append(*r.written, review.BookID)
+	return r.reviewRepository.Delete(ctx, review)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"books-api/app/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// webhookRepository implements the WebhookRepository interface
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new instance of webhook repository
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+// Create adds a new webhook to the database
+func (r *webhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+// GetByID retrieves a webhook by its ID
+func (r *webhookRepository) GetByID(ctx context.Context, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.db.WithContext(ctx).First(&webhook, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetAll retrieves all webhooks, including disabled ones
+func (r *webhookRepository) GetAll(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// GetActiveByTenant retrieves the enabled webhooks of a tenant
+func (r *webhookRepository) GetActiveByTenant(ctx context.Context, tenantID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND disabled_at IS NULL", tenantID).
+		Order("id").
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Update modifies an existing webhook in the database
+func (r *webhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Select("*").Save(webhook).Error
+}
+
+// Disable records why a webhook was disabled. Only these columns are
+// written, so a concurrent update is kept and a deleted webhook stays
+// deleted; a webhook disabled already keeps its first reason.
+func (r *webhookRepository) Disable(ctx context.Context, id uint, disabledAt time.Time, reason string) error {
+	return r.db.WithContext(ctx).Model(&models.Webhook{}).
+		Where("id = ? AND disabled_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"disabled_at":     disabledAt,
+			"disabled_reason": reason,
+		}).Error
+}
+
+// Delete removes a webhook and its deliveries in one transaction
+func (r *webhookRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Webhook{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// webhookDeliveryRepository implements the WebhookDeliveryRepository
+// interface
+type webhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookDeliveryRepository creates a new instance of webhook delivery
+// repository
+func NewWebhookDeliveryRepository(db *gorm.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		db: db,
+	}
+}
+
+// CreateMissing adds deliveries, skipping those whose webhook already has
+// one for the event
+func (r *webhookDeliveryRepository) CreateMissing(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "webhook_id"}, {Name: "event_id"}},
+			DoNothing: true,
+		}).
+		Create(&deliveries).Error
+}
+
+// GetByID retrieves a delivery by its ID
+func (r *webhookDeliveryRepository) GetByID(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetByWebhook retrieves the latest deliveries of a webhook
+func (r *webhookDeliveryRepository) GetByWebhook(ctx context.Context, webhookID uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetDue retrieves the pending deliveries to attempt now
+func (r *webhookDeliveryRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Where("webhook_id IN (?)", r.db.Model(&models.Webhook{}).Select("id").Where("disabled_at IS NULL")).
+		Order("id").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// Update records the outcome of a delivery attempt
+func (r *webhookDeliveryRepository) Update(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Select("*").Save(delivery).Error
+}
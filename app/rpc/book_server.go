@@ -0,0 +1,255 @@
+package rpc
+
+import (
+	"books-api/app/auth"
+	"books-api/app/events"
+	"books-api/app/models"
+	"books-api/app/rpc/booksv1"
+	"books-api/app/service"
+	"books-api/app/stream"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultPageSize is the number of books per page unless requested
+	// otherwise
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page of books
+	MaxPageSize = 100
+	// pageTokenPrefix versions the page token format
+	pageTokenPrefix = "offset:"
+)
+
+// BookServer implements books.v1.BookService on top of the book services.
+// The caller's principal and tenant are expected in the context, put there
+// by the interceptors or, for the gateway, by the HTTP middleware.
+type BookServer struct {
+	booksv1.UnimplementedBookServiceServer
+
+	bookService service.BookService
+	feedService service.BookFeedService
+	// allowsAnonymous tells whether callers without credentials may use
+	// what requires a scope
+	allowsAnonymous func(scope models.Scope) bool
+}
+
+// NewBookServer creates the books.v1 service. allowsAnonymous decides
+// whether callers without credentials may use what requires a scope, as
+// Authenticator.AllowsAnonymous does for HTTP.
+func NewBookServer(bookService service.BookService, feedService service.BookFeedService, allowsAnonymous func(scope models.Scope) bool) *BookServer {
+	return &BookServer{
+		bookService:     bookService,
+		feedService:     feedService,
+		allowsAnonymous: allowsAnonymous,
+	}
+}
+
+// require checks that the caller holds scope, like Authenticator.Require
+func (s *BookServer) require(ctx context.Context, scope models.Scope) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		if s.allowsAnonymous(scope) {
+			return nil
+		}
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if !principal.HasScope(scope) {
+		return status.Error(codes.PermissionDenied, "missing scope "+string(scope))
+	}
+	return nil
+}
+
+// GetBook returns one book
+func (s *BookServer) GetBook(ctx context.Context, req *booksv1.GetBookRequest) (*booksv1.Book, error) {
+	if err := s.require(ctx, models.ScopeBooksRead); err != nil {
+		return nil, err
+	}
+	book, err := s.bookService.GetBookByID(ctx, uint(req.GetId()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return fromBook(book), nil
+}
+
+// ListBooks returns a page of books. One book more than the page is read
+// to tell whether there is a next page.
+func (s *BookServer) ListBooks(ctx context.Context, req *booksv1.ListBooksRequest) (*booksv1.ListBooksResponse, error) {
+	if err := s.require(ctx, models.ScopeBooksRead); err != nil {
+		return nil, err
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d", MaxPageSize)
+	}
+	filter := models.BookFilter{Tag: req.GetTag(), Sort: req.GetOrderBy(), Limit: pageSize + 1}
+	if token := req.GetPageToken(); token != "" {
+		offset, err := decodePageToken(token)
+		if err != nil {
+			return nil, err
+		}
+		filter.Offset = offset
+	}
+	if categoryID := uint(req.GetCategoryId()); categoryID != 0 {
+		filter.CategoryID = &categoryID
+	}
+
+	books, err := s.bookService.GetAllBooks(ctx, filter)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &booksv1.ListBooksResponse{}
+	if len(books) > pageSize {
+		books = books[:pageSize]
+		resp.NextPageToken = encodePageToken(filter.Offset + pageSize)
+	}
+	for i := range books {
+		resp.Books = append(resp.Books, fromBook(&books[i]))
+	}
+	return resp, nil
+}
+
+// CreateBook adds a book
+func (s *BookServer) CreateBook(ctx context.Context, req *booksv1.CreateBookRequest) (*booksv1.Book, error) {
+	if err := s.require(ctx, models.ScopeBooksWrite); err != nil {
+		return nil, err
+	}
+	if req.GetBook() == nil {
+		return nil, status.Error(codes.InvalidArgument, "book is required")
+	}
+	book, ok := toBook(req.GetBook())
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid color")
+	}
+	if err := s.bookService.CreateBook(ctx, &book); err != nil {
+		return nil, statusError(err)
+	}
+	return fromBook(&book), nil
+}
+
+// UpdateBook changes the fields of a book set in the request
+func (s *BookServer) UpdateBook(ctx context.Context, req *booksv1.UpdateBookRequest) (*booksv1.Book, error) {
+	if err := s.require(ctx, models.ScopeBooksWrite); err != nil {
+		return nil, err
+	}
+	if req.GetBook() == nil {
+		return nil, status.Error(codes.InvalidArgument, "book is required")
+	}
+	update, ok := toBook(req.GetBook())
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid color")
+	}
+	book, err := s.bookService.UpdateBook(ctx, uint(req.GetId()), update)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return fromBook(book), nil
+}
+
+// DeleteBook removes a book
+func (s *BookServer) DeleteBook(ctx context.Context, req *booksv1.DeleteBookRequest) (*booksv1.DeleteBookResponse, error) {
+	if err := s.require(ctx, models.ScopeBooksWrite); err != nil {
+		return nil, err
+	}
+	if err := s.bookService.DeleteBook(ctx, uint(req.GetId())); err != nil {
+		return nil, statusError(err)
+	}
+	return &booksv1.DeleteBookResponse{}, nil
+}
+
+// WatchBooks streams the book events matching the request until the client
+// goes away, falls behind or the server shuts down
+func (s *BookServer) WatchBooks(req *booksv1.WatchBooksRequest, srv grpc.ServerStreamingServer[booksv1.WatchBooksResponse]) error {
+	ctx := srv.Context()
+	if err := s.require(ctx, models.ScopeBooksRead); err != nil {
+		return err
+	}
+
+	filter := stream.Filter{Author: strings.TrimSpace(req.GetAuthor()), BookID: uint(req.GetBookId())}
+	for _, name := range req.GetTypes() {
+		eventType := events.Type(strings.TrimSpace(name))
+		if !eventType.IsValid() {
+			return status.Error(codes.InvalidArgument, "invalid event type: "+string(eventType))
+		}
+		filter.Types = append(filter.Types, eventType)
+	}
+	color, ok := toColor(req.GetColor())
+	if !ok {
+		return status.Error(codes.InvalidArgument, "invalid color")
+	}
+	if color != nil {
+		filter.Color = *color
+	}
+
+	subscription, err := s.feedService.Subscribe(ctx, filter, uint(req.GetLastEventId()))
+	if err != nil {
+		return statusError(err)
+	}
+	defer subscription.Close()
+
+	// Headers tell the client it is subscribed, like the opening comment of
+	// the SSE stream
+	if err := srv.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	if subscription.Reset {
+		if err := srv.Send(&booksv1.WatchBooksResponse{Kind: &booksv1.WatchBooksResponse_Reset_{Reset_: true}}); err != nil {
+			return err
+		}
+	}
+	send := func(event events.Event) error {
+		return srv.Send(&booksv1.WatchBooksResponse{Kind: &booksv1.WatchBooksResponse_Event{Event: fromEvent(event)}})
+	}
+	for _, event := range subscription.Replay {
+		if err := send(event); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case event, open := <-subscription.Events():
+			if !open {
+				if errors.Is(subscription.Err(), stream.ErrLagged) {
+					return status.Error(codes.ResourceExhausted, "client too slow; resume after the last event")
+				}
+				return statusError(subscription.Err())
+			}
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// encodePageToken turns a list offset into an opaque page token
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(pageTokenPrefix + strconv.Itoa(offset)))
+}
+
+// decodePageToken returns the list offset of a page token
+func decodePageToken(token string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil && strings.HasPrefix(string(decoded), pageTokenPrefix) {
+		offset, err := strconv.Atoi(strings.TrimPrefix(string(decoded), pageTokenPrefix))
+		if err == nil && offset >= 0 {
+			return offset, nil
+		}
+	}
+	return 0, status.Error(codes.InvalidArgument, "invalid page_token")
+}
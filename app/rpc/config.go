@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Config controls how the books.v1 gRPC service is served
+type Config struct {
+	// Addr is a TCP address of its own for gRPC, e.g. ":9090"; empty
+	// disables the separate listener
+	Addr string
+	// Multiplex also serves gRPC on the HTTP port, telling the calls apart
+	// by their content type
+	Multiplex bool
+	// Reflection lets tools such as grpcurl discover the services
+	Reflection bool
+	// Gateway serves the service as JSON over HTTP under /v1
+	Gateway bool
+}
+
+// Enabled reports whether gRPC is served at all
+func (c Config) Enabled() bool {
+	return c.Addr != "" || c.Multiplex
+}
+
+// LoadConfig builds a config from environment style lookups:
+//
+//	GRPC_PORT         port of a separate gRPC listener; unset disables it
+//	GRPC_MULTIPLEX    true to serve gRPC on the HTTP port as well
+//	GRPC_REFLECTION   false disables server reflection
+//	GRPC_GATEWAY      true to serve the JSON gateway under /v1
+func LoadConfig(lookup func(string) (string, bool)) (Config, error) {
+	config := Config{Reflection: true}
+
+	if port, ok := lookup("GRPC_PORT"); ok && port != "" {
+		if value, err := strconv.Atoi(port); err != nil || value < 1 || value > 65535 {
+			return Config{}, fmt.Errorf("GRPC_PORT: must be a port number")
+		}
+		config.Addr = ":" + port
+	}
+
+	flags := []struct {
+		name  string
+		value *bool
+	}{
+		{"GRPC_MULTIPLEX", &config.Multiplex},
+		{"GRPC_REFLECTION", &config.Reflection},
+		{"GRPC_GATEWAY", &config.Gateway},
+	}
+	for _, flag := range flags {
+		if value, ok := lookup(flag.name); ok && value != "" {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: must be true or false", flag.name)
+			}
+			*flag.value = enabled
+		}
+	}
+	return config, nil
+}
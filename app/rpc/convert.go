@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"books-api/app/events"
+	"books-api/app/models"
+	"books-api/app/rpc/booksv1"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// colors maps the model colors to their protobuf values
+var colors = map[models.Color]booksv1.Color{
+	models.Red:   booksv1.Color_COLOR_RED,
+	models.Green: booksv1.Color_COLOR_GREEN,
+	models.Blue:  booksv1.Color_COLOR_BLUE,
+}
+
+// toColor returns the model color of a protobuf color; ok is false for
+// unknown values. COLOR_UNSPECIFIED is no color.
+func toColor(color booksv1.Color) (*models.Color, bool) {
+	if color == booksv1.Color_COLOR_UNSPECIFIED {
+		return nil, true
+	}
+	for model, value := range colors {
+		if value == color {
+			return &model, true
+		}
+	}
+	return nil, false
+}
+
+// fromBook converts a book to protobuf
+func fromBook(book *models.Book) *booksv1.Book {
+	if book == nil {
+		return nil
+	}
+	message := &booksv1.Book{
+		Id:            uint32(book.ID),
+		Title:         book.Title,
+		Author:        book.Author,
+		Pages:         int32(book.Pages),
+		AverageRating: book.AverageRating,
+		RatingCount:   int32(book.RatingCount),
+	}
+	if book.Color != nil {
+		message.Color = colors[*book.Color]
+	}
+	for _, tag := range book.Tags {
+		message.Tags = append(message.Tags, &booksv1.Tag{Id: uint32(tag.ID), Name: tag.Name})
+	}
+	for _, category := range book.Categories {
+		converted := &booksv1.Category{Id: uint32(category.ID), Name: category.Name}
+		if category.ParentID != nil {
+			converted.ParentId = uint32(*category.ParentID)
+		}
+		message.Categories = append(message.Categories, converted)
+	}
+	return message
+}
+
+// toBook converts the writable fields of a protobuf book
+func toBook(message *booksv1.Book) (models.Book, bool) {
+	color, ok := toColor(message.GetColor())
+	if !ok {
+		return models.Book{}, false
+	}
+	return models.Book{
+		Title:  message.GetTitle(),
+		Author: message.GetAuthor(),
+		Pages:  int(message.GetPages()),
+		Color:  color,
+	}, true
+}
+
+// fromEvent converts a book event to protobuf
+func fromEvent(event events.Event) *booksv1.BookEvent {
+	return &booksv1.BookEvent{
+		Id:         uint64(event.ID),
+		Type:       string(event.Type),
+		BookId:     uint32(event.BookID),
+		Book:       fromBook(event.Book),
+		Changed:    event.Changed,
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+}
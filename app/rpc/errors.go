@@ -0,0 +1,38 @@
+package rpc
+
+import (
+	"books-api/app/authz"
+	"books-api/app/database"
+	"books-api/app/stream"
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError converts a service error to the gRPC status matching the
+// HTTP status the REST controllers answer it with
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	message := err.Error()
+	switch {
+	case database.IsBusy(err), errors.Is(err, stream.ErrClosed):
+		return status.Error(codes.Unavailable, message)
+	case authz.IsForbidden(err), message == "book quota exceeded":
+		return status.Error(codes.PermissionDenied, message)
+	case message == "book not found":
+		return status.Error(codes.NotFound, message)
+	case strings.HasPrefix(message, "failed to"):
+		return status.Error(codes.Internal, message)
+	default:
+		return status.Error(codes.InvalidArgument, message)
+	}
+}
@@ -0,0 +1,294 @@
+package rpc
+
+import (
+	"books-api/app/auth"
+	"books-api/app/middleware"
+	"books-api/app/rpc/booksv1"
+	"books-api/app/tenancy"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Metadata keys carrying the credentials and tenant of a call, named
+	// after the HTTP headers
+	apiKeyMetadata        = "x-api-key"
+	authorizationMetadata = "authorization"
+	tenantMetadata        = "x-tenant"
+	retryAfterMetadata    = "retry-after"
+	// shutdownTimeout bounds how long in-flight calls may finish
+	shutdownTimeout = 10 * time.Second
+)
+
+// Server serves the books.v1 service with gRPC health checking and, when
+// configured, reflection
+type Server struct {
+	grpc   *grpc.Server
+	health *health.Server
+	logger *slog.Logger
+}
+
+// NewServer creates a gRPC server for books. Calls are authenticated,
+// rate limited in the books group and act for the tenant resolved from their
+// metadata like HTTP requests. tlsConfig is nil to serve plain text.
+func NewServer(books *BookServer, authenticator *middleware.Authenticator, tenants *middleware.TenantResolver, limiter *middleware.RateLimiter, config Config, tlsConfig *tls.Config, logger *slog.Logger) *Server {
+	calls := &callContext{authenticator: authenticator, tenants: tenants, limiter: limiter, logger: logger}
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(calls.unary),
+		grpc.ChainStreamInterceptor(calls.stream),
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s := &Server{grpc: grpc.NewServer(options...), health: health.NewServer(), logger: logger}
+	booksv1.RegisterBookServiceServer(s.grpc, books)
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	s.health.SetServingStatus(booksv1.BookService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	if config.Reflection {
+		reflection.Register(s.grpc)
+	}
+	return s
+}
+
+// Serve serves gRPC on listener until Stop is called
+func (s *Server) Serve(listener net.Listener) error {
+	s.logger.Info("Serving gRPC", "addr", listener.Addr().String())
+	return s.grpc.Serve(listener)
+}
+
+// Stop reports the services as not serving and ends the calls in flight,
+// giving them until shutdownTimeout to finish. It also ends calls served
+// through Multiplex.
+func (s *Server) Stop() {
+	s.health.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		s.grpc.Stop()
+	}
+}
+
+// Multiplex returns a handler that passes gRPC calls to the server and
+// everything else to next. gRPC needs HTTP/2, which the HTTP server offers
+// over TLS and, when enabled, over plain text.
+func (s *Server) Multiplex(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			s.grpc.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewGateway returns a handler serving books as JSON over HTTP, translated
+// to the books.v1 calls of books. Requests must already carry their
+// principal and tenant, as the HTTP middleware provides. WatchBooks is not
+// available through the gateway.
+func NewGateway(ctx context.Context, books *BookServer) (http.Handler, error) {
+	mux := runtime.NewServeMux()
+	if err := booksv1.RegisterBookServiceHandlerServer(ctx, mux, books); err != nil {
+		return nil, fmt.Errorf("failed to register gRPC gateway: %w", err)
+	}
+	return mux, nil
+}
+
+// callContext resolves the principal and tenant of the books.v1 calls and
+// rate limits them
+type callContext struct {
+	authenticator *middleware.Authenticator
+	tenants       *middleware.TenantResolver
+	limiter       *middleware.RateLimiter
+	logger        *slog.Logger
+}
+
+// unary is the interceptor of unary calls
+func (c *callContext) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	start := time.Now()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = c.recovered(ctx, recovered)
+		}
+		c.log(ctx, info.FullMethod, start, err)
+	}()
+
+	resolved, err := c.resolve(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(resolved, req)
+}
+
+// stream is the interceptor of streaming calls
+func (c *callContext) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	start := time.Now()
+	ctx := ss.Context()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = c.recovered(ctx, recovered)
+		}
+		c.log(ctx, info.FullMethod, start, err)
+	}()
+
+	resolved, err := c.resolve(ctx, info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: resolved})
+}
+
+// resolve stores the principal and tenant of a books.v1 call in its
+// context, failing the call like the HTTP middleware fails a request: the
+// client address is limited before the credentials are checked and the
+// principal after. Health checks and reflection need neither.
+func (c *callContext) resolve(ctx context.Context, method string) (context.Context, error) {
+	if !strings.HasPrefix(method, "/"+booksv1.BookService_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+	if err := c.limit(ctx, "clients", nil); err != nil {
+		return nil, err
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	credentials := middleware.Credentials{
+		APIKey:        firstValue(md, apiKeyMetadata),
+		Authorization: firstValue(md, authorizationMetadata),
+		TLS:           tlsState(ctx),
+	}
+	principal, err := c.authenticator.Principal(ctx, credentials)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if principal != nil {
+		ctx = auth.WithPrincipal(ctx, principal)
+	}
+
+	tenant, tenantErr := c.tenants.Tenant(ctx, principal, c.tenants.Requested(firstValue(md, tenantMetadata), firstValue(md, ":authority")))
+	if tenantErr != nil {
+		code := codes.InvalidArgument
+		switch tenantErr.Status {
+		case http.StatusForbidden:
+			code = codes.PermissionDenied
+		case http.StatusNotFound:
+			code = codes.NotFound
+		}
+		return nil, status.Error(code, tenantErr.Message)
+	}
+	if err := c.limit(ctx, "books", principal); err != nil {
+		return nil, err
+	}
+	return tenancy.WithTenant(ctx, tenant), nil
+}
+
+// limit fails a call over the limit of group with ResourceExhausted and
+// tells the client when to retry in retry-after metadata, like the HTTP
+// Retry-After header
+func (c *callContext) limit(ctx context.Context, group string, principal *auth.Principal) error {
+	allowed, retryAfter := c.limiter.Allow(ctx, group, principal, clientAddr(ctx))
+	if allowed {
+		return nil
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(retryAfterMetadata, strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))))
+	return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+}
+
+// recovered logs a panic of a call and returns the error ending it
+func (c *callContext) recovered(ctx context.Context, recovered any) error {
+	c.logger.ErrorContext(ctx, "panic recovered",
+		slog.String("panic", fmt.Sprint(recovered)),
+		slog.String("stack", string(debug.Stack())),
+	)
+	return status.Error(codes.Internal, "internal server error")
+}
+
+// log records a finished call like RequestLogger records requests
+func (c *callContext) log(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		level = slog.LevelError
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		attrs = append(attrs, slog.String("client_ip", p.Addr.String()))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("errors", status.Convert(err).Message()))
+	}
+	c.logger.LogAttrs(ctx, level, "gRPC call completed", attrs...)
+}
+
+// contextStream is a server stream with the context of the resolved call
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// firstValue returns the first value of a metadata key
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// clientAddr returns the host of the call's peer
+func clientAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// tlsState returns the TLS state of the call's connection; nil over plain
+// text
+func tlsState(ctx context.Context) *tls.ConnectionState {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		return &info.State
+	}
+	return nil
+}
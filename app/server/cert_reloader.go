@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileState is what a certificate file is compared by to notice changes
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// CertReloader serves a certificate loaded from files and reloads it when
+// the files change, so renewed certificates are picked up without a
+// restart
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	loaded [2]fileState
+}
+
+// NewCertReloader loads the PEM certificate chain and private key from
+// certFile and keyFile. Reloads while watching are logged through logger.
+func NewCertReloader(certFile, keyFile string, logger *slog.Logger) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, logger: logger}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate; it fits
+// tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload loads the certificate again if either file changed since the last
+// successful load, and reports whether it did. On failure the previous
+// certificate stays in use, so a half written renewal is retried later.
+func (r *CertReloader) Reload() (bool, error) {
+	current, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && current == r.loaded
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.loaded = current
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Watch checks the files for changes every interval until ctx is done
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				r.logger.ErrorContext(ctx, "Certificate reload failed, keeping the current one", "error", err)
+			} else if reloaded {
+				r.logger.InfoContext(ctx, "Reloaded certificate", "cert_file", r.certFile)
+			}
+		}
+	}
+}
+
+// stat returns the state of the certificate and key files
+func (r *CertReloader) stat() ([2]fileState, error) {
+	var states [2]fileState
+	for i, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return states, fmt.Errorf("failed to load certificate: %w", err)
+		}
+		states[i] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return states, nil
+}
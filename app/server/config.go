@@ -0,0 +1,113 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+)
+
+// DefaultReloadInterval is how often certificate files are checked for
+// changes unless configured otherwise
+const DefaultReloadInterval = time.Minute
+
+// Config describes how the API is served
+type Config struct {
+	// Addr is the TCP address served, and with HTTP3 also the UDP one
+	Addr string
+	// CertFile and KeyFile hold the PEM certificate chain and key; empty
+	// serves plain HTTP
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the PEM certificates client certificates must
+	// chain to; empty disables mTLS
+	ClientCAFile string
+	// ClientAuth is tls.VerifyClientCertIfGiven or
+	// tls.RequireAndVerifyClientCert when ClientCAFile is set
+	ClientAuth tls.ClientAuthType
+	// HTTP3 also serves HTTP/3 over QUIC and advertises it with Alt-Svc
+	HTTP3 bool
+	// RedirectAddr is a plain HTTP address redirecting to HTTPS; empty
+	// disables the redirect
+	RedirectAddr string
+	// ReloadInterval is how often the certificate files are checked
+	ReloadInterval time.Duration
+	// UnencryptedHTTP2 also accepts HTTP/2 without TLS (h2c), which gRPC
+	// clients use to reach a multiplexed plain text port
+	UnencryptedHTTP2 bool
+}
+
+// TLSEnabled reports whether the API is served over TLS
+func (c Config) TLSEnabled() bool {
+	return c.CertFile != ""
+}
+
+// Validate rejects incomplete or contradictory configs
+func (c Config) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("certificate and key file must be set together")
+	}
+	if !c.TLSEnabled() {
+		switch {
+		case c.ClientCAFile != "":
+			return fmt.Errorf("client certificates require TLS")
+		case c.HTTP3:
+			return fmt.Errorf("HTTP/3 requires TLS")
+		case c.RedirectAddr != "":
+			return fmt.Errorf("redirecting to HTTPS requires TLS")
+		}
+	}
+	if c.ReloadInterval <= 0 {
+		return fmt.Errorf("reload interval must be positive")
+	}
+	return nil
+}
+
+// LoadConfig builds a config from environment style lookups:
+//
+//	PORT                 port served, 8080 unless set
+//	TLS_CERT_FILE        PEM certificate chain; enables TLS
+//	TLS_KEY_FILE         PEM private key
+//	TLS_CLIENT_CA_FILE   PEM CA certificates for client certificates
+//	TLS_CLIENT_AUTH      "request" (default) or "require" a client certificate
+//	TLS_RELOAD_INTERVAL  certificate change check interval, e.g. 30s
+//	HTTP3                true to also serve HTTP/3
+//	HTTP_REDIRECT_ADDR   plain HTTP address redirecting to HTTPS, e.g. :80
+func LoadConfig(lookup func(string) (string, bool)) (Config, error) {
+	get := func(name string) string {
+		value, _ := lookup(name)
+		return value
+	}
+
+	config := Config{
+		Addr:           ":8080",
+		CertFile:       get("TLS_CERT_FILE"),
+		KeyFile:        get("TLS_KEY_FILE"),
+		ClientCAFile:   get("TLS_CLIENT_CA_FILE"),
+		HTTP3:          get("HTTP3") == "true",
+		RedirectAddr:   get("HTTP_REDIRECT_ADDR"),
+		ReloadInterval: DefaultReloadInterval,
+	}
+	if port := get("PORT"); port != "" {
+		config.Addr = ":" + port
+	}
+
+	if config.ClientCAFile != "" {
+		switch get("TLS_CLIENT_AUTH") {
+		case "", "request":
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+		case "require":
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		default:
+			return Config{}, fmt.Errorf("TLS_CLIENT_AUTH: must be request or require")
+		}
+	}
+	if value := get("TLS_RELOAD_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("TLS_RELOAD_INTERVAL: %w", err)
+		}
+		config.ReloadInterval = interval
+	}
+
+	return config, config.Validate()
+}
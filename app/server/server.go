@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+const (
+	// readHeaderTimeout bounds how long clients may take to send headers
+	readHeaderTimeout = 10 * time.Second
+	// shutdownTimeout bounds how long in-flight requests may finish
+	shutdownTimeout = 10 * time.Second
+)
+
+// Listeners are the sockets a server serves on
+type Listeners struct {
+	// Main serves the API, over TLS when enabled
+	Main net.Listener
+	// QUIC serves HTTP/3; nil unless enabled
+	QUIC net.PacketConn
+	// Redirect serves redirects to HTTPS; nil unless enabled
+	Redirect net.Listener
+}
+
+// Server serves the API over HTTP, HTTPS and HTTP/3 as configured
+type Server struct {
+	config  Config
+	handler http.Handler
+	// reloader and tlsConfig are nil when TLS is disabled
+	reloader  *CertReloader
+	tlsConfig *tls.Config
+	logger    *slog.Logger
+}
+
+// New creates a server for handler, loading the certificates the config
+// names. What it serves and reloads is logged through logger.
+func New(config Config, handler http.Handler, logger *slog.Logger) (*Server, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	s := &Server{config: config, handler: handler, logger: logger}
+	if !config.TLSEnabled() {
+		return s, nil
+	}
+
+	reloader, err := NewCertReloader(config.CertFile, config.KeyFile, logger)
+	if err != nil {
+		return nil, err
+	}
+	s.reloader = reloader
+	s.tlsConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if config.ClientCAFile != "" {
+		pemData, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("failed to load client CA: no certificates in %s", config.ClientCAFile)
+		}
+		s.tlsConfig.ClientCAs = pool
+		s.tlsConfig.ClientAuth = config.ClientAuth
+	}
+	return s, nil
+}
+
+// Wrap puts middleware around the handler, e.g. one that needs TLSConfig.
+// It must be called before serving.
+func (s *Server) Wrap(middleware func(http.Handler) http.Handler) {
+	s.handler = middleware(s.handler)
+}
+
+// TLSConfig returns the TLS configuration of the server; nil when TLS is
+// disabled
+func (s *Server) TLSConfig() *tls.Config {
+	return s.tlsConfig
+}
+
+// Listen opens the sockets of the configured addresses
+func (s *Server) Listen() (Listeners, error) {
+	var listeners Listeners
+	var err error
+
+	if listeners.Main, err = net.Listen("tcp", s.config.Addr); err != nil {
+		return Listeners{}, err
+	}
+	if s.config.HTTP3 {
+		if listeners.QUIC, err = net.ListenPacket("udp", s.config.Addr); err != nil {
+			listeners.Main.Close()
+			return Listeners{}, err
+		}
+	}
+	if s.config.RedirectAddr != "" {
+		if listeners.Redirect, err = net.Listen("tcp", s.config.RedirectAddr); err != nil {
+			listeners.Main.Close()
+			if listeners.QUIC != nil {
+				listeners.QUIC.Close()
+			}
+			return Listeners{}, err
+		}
+	}
+	return listeners, nil
+}
+
+// ListenAndServe opens the configured sockets and serves until ctx is done
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listeners, err := s.Listen()
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listeners)
+}
+
+// Serve serves on listeners until ctx is done or one of them fails, then
+// shuts down gracefully. Certificates are reloaded while serving.
+func (s *Server) Serve(ctx context.Context, listeners Listeners) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if s.reloader != nil {
+		go s.reloader.Watch(ctx, s.config.ReloadInterval)
+	}
+
+	errs := make(chan error, 3)
+	var shutdowns []func(context.Context) error
+
+	main := &http.Server{
+		Handler:           s.handler,
+		TLSConfig:         s.tlsConfig,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	if s.config.UnencryptedHTTP2 && s.tlsConfig == nil {
+		main.Protocols = new(http.Protocols)
+		main.Protocols.SetHTTP1(true)
+		main.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	if listeners.QUIC != nil && s.tlsConfig != nil {
+		quicServer := &http3.Server{
+			Handler:   s.handler,
+			TLSConfig: s.tlsConfig,
+			// 0-RTT data can be replayed, so it is not accepted
+			QUICConfig: &quic.Config{Allow0RTT: false},
+		}
+		// Responses over TCP advertise the HTTP/3 endpoint
+		main.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			quicServer.SetQUICHeaders(w.Header())
+			s.handler.ServeHTTP(w, r)
+		})
+		shutdowns = append(shutdowns, quicServer.Shutdown)
+		s.logger.InfoContext(ctx, "Serving HTTP/3", "addr", listeners.QUIC.LocalAddr().String())
+		go func() { errs <- quicServer.Serve(listeners.QUIC) }()
+	}
+
+	if listeners.Redirect != nil {
+		_, port, _ := net.SplitHostPort(listeners.Main.Addr().String())
+		redirect := &http.Server{
+			Handler:           RedirectHandler(port),
+			ReadHeaderTimeout: readHeaderTimeout,
+		}
+		shutdowns = append(shutdowns, redirect.Shutdown)
+		s.logger.InfoContext(ctx, "Redirecting HTTP to HTTPS", "addr", listeners.Redirect.Addr().String())
+		go func() { errs <- redirect.Serve(listeners.Redirect) }()
+	}
+
+	shutdowns = append(shutdowns, main.Shutdown)
+	if s.tlsConfig != nil {
+		s.logger.InfoContext(ctx, "Serving HTTPS", "addr", listeners.Main.Addr().String())
+		go func() { errs <- main.ServeTLS(listeners.Main, "", "") }()
+	} else {
+		s.logger.InfoContext(ctx, "Serving HTTP", "addr", listeners.Main.Addr().String())
+		go func() { errs <- main.Serve(listeners.Main) }()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	for _, shutdown := range shutdowns {
+		if shutdownErr := shutdown(shutdownCtx); shutdownErr != nil {
+			s.logger.ErrorContext(shutdownCtx, "Server shutdown failed", "error", shutdownErr)
+		}
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// RedirectHandler redirects every request to the same URL over HTTPS on
+// httpsPort. 308 keeps the method and body of the request.
+func RedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		}
+		if host == "" {
+			http.Error(w, "host is required", http.StatusBadRequest)
+			return
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		} else if strings.Contains(host, ":") {
+			// IPv6 literal
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	// apiKeyPrefix marks plaintext keys so they are easy to spot in configs
+	apiKeyPrefix = "bk_"
+	// apiKeyBytes is the amount of randomness in a key
+	apiKeyBytes = 32
+	// apiKeyDisplayLength is how much of a key is kept for listings
+	apiKeyDisplayLength = 10
+	// lastUsedResolution limits how often last-used timestamps are written
+	lastUsedResolution = time.Minute
+)
+
+// apiKeyService implements the APIKeyService interface
+type apiKeyService struct {
+	keyRepo    repository.APIKeyRepository
+	tenantRepo repository.TenantRepository
+	logger     *slog.Logger
+}
+
+// NewAPIKeyService creates a new instance of API key service. Issued,
+// revoked and rejected keys are logged through logger with the call's
+// context; the secrets never are.
+func NewAPIKeyService(keyRepo repository.APIKeyRepository, tenantRepo repository.TenantRepository, logger *slog.Logger) APIKeyService {
+	return &apiKeyService{
+		keyRepo:    keyRepo,
+		tenantRepo: tenantRepo,
+		logger:     logger,
+	}
+}
+
+// HashAPIKey returns the hex encoded SHA-256 digest stored for a key. Keys
+// carry 256 bits of randomness, so a fast unsalted hash is sufficient.
+func HashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAPIKey creates a new random plaintext key
+func generateAPIKey() (string, error) {
+	buf := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// IssueKey creates a key with the given scopes. A non-empty tenant binds the
+// key to that tenant's slug. The plaintext key is only available in the
+// returned value.
+func (s *apiKeyService) IssueKey(ctx context.Context, name, tenant string, scopes []models.Scope, expiresAt *time.Time) (*models.IssuedAPIKey, error) {
+	name = strings.TrimSpace(name)
+	s.logger.InfoContext(ctx, "Issuing API key", "name", name)
+
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	seen := make(map[models.Scope]bool)
+	unique := make([]models.Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			s.logger.WarnContext(ctx, "Invalid scope requested for API key", "scope", scope)
+			return nil, fmt.Errorf("invalid scope: %s", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expiry must be in the future")
+	}
+	tenant = strings.ToLower(strings.TrimSpace(tenant))
+	if tenant != "" {
+		if _, err := s.tenantRepo.GetBySlug(ctx, tenant); err != nil {
+			s.logger.WarnContext(ctx, "Tenant not found for API key", "tenant", tenant, "error", err)
+			return nil, fmt.Errorf("tenant not found")
+		}
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to generate API key", "error", err)
+		return nil, fmt.Errorf("failed to issue API key: %w", err)
+	}
+
+	key := models.APIKey{
+		Name:      name,
+		Prefix:    rawKey[:apiKeyDisplayLength],
+		KeyHash:   HashAPIKey(rawKey),
+		Scopes:    unique,
+		Tenant:    tenant,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.keyRepo.Create(ctx, &key); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to store API key", "error", err)
+		return nil, fmt.Errorf("failed to issue API key: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Issued API key", "api_key_id", key.ID, "prefix", key.Prefix, "scopes", key.Scopes)
+	return &models.IssuedAPIKey{APIKey: key, Key: rawKey}, nil
+}
+
+// ListKeys retrieves all API keys without their secrets
+func (s *apiKeyService) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	s.logger.InfoContext(ctx, "Retrieving all API keys")
+
+	keys, err := s.keyRepo.GetAll(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve API keys", "error", err)
+		return nil, fmt.Errorf("failed to retrieve API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeKey permanently disables an API key
+func (s *apiKeyService) RevokeKey(ctx context.Context, id uint) (*models.APIKey, error) {
+	s.logger.InfoContext(ctx, "Revoking API key", "api_key_id", id)
+
+	key, err := s.keyRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "API key not found", "api_key_id", id, "error", err)
+		return nil, fmt.Errorf("API key not found")
+	}
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("API key already revoked")
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := s.keyRepo.Update(ctx, key); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to revoke API key", "api_key_id", id, "error", err)
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Revoked API key", "api_key_id", key.ID, "prefix", key.Prefix)
+	return key, nil
+}
+
+// Authenticate resolves a plaintext key to a usable API key and records its
+// use
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	key, err := s.keyRepo.GetByHash(ctx, HashAPIKey(rawKey))
+	if err != nil {
+		s.logger.WarnContext(ctx, "Rejected unknown API key")
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil {
+		s.logger.WarnContext(ctx, "Rejected revoked API key", "api_key_id", key.ID, "prefix", key.Prefix)
+		return nil, fmt.Errorf("API key revoked")
+	}
+	if key.IsExpired(now) {
+		s.logger.WarnContext(ctx, "Rejected expired API key", "api_key_id", key.ID, "prefix", key.Prefix)
+		return nil, fmt.Errorf("API key expired")
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.keyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to record use of API key", "api_key_id", key.ID, "error", err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return key, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"books-api/app/authz"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultChangeLimit is the number of changes returned unless the client
+	// asks for another
+	DefaultChangeLimit = 100
+	// MaxChangeLimit is the largest number of changes returned at once
+	MaxChangeLimit = 1000
+	// syncTokenPrefix versions the sync token format
+	syncTokenPrefix = "v1:"
+)
+
+// bookChangeService implements the BookChangeService interface
+type bookChangeService struct {
+	changeRepo repository.BookChangeRepository
+	authorizer authz.Authorizer
+	logger     *slog.Logger
+}
+
+// NewBookChangeService creates a new instance of book change service.
+// Callers need the book read permission of authorizer.
+func NewBookChangeService(changeRepo repository.BookChangeRepository, authorizer authz.Authorizer, logger *slog.Logger) BookChangeService {
+	return &bookChangeService{
+		changeRepo: changeRepo,
+		authorizer: authorizer,
+		logger:     logger,
+	}
+}
+
+// encodeSyncToken turns a change sequence number into an opaque token
+func encodeSyncToken(seq uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatUint(seq, 10)))
+}
+
+// decodeSyncToken returns the change sequence number of a token
+func decodeSyncToken(token string) (uint64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(decoded), syncTokenPrefix) {
+		return 0, errors.New("invalid sync token")
+	}
+	seq, err := strconv.ParseUint(strings.TrimPrefix(string(decoded), syncTokenPrefix), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid sync token")
+	}
+	return seq, nil
+}
+
+// GetChanges returns the book changes after the token in sequence order.
+// A book written several times is only returned with its latest state.
+// Without a token every book is returned; deletes older than the purged
+// tombstones would be missing, so such tokens are refused.
+func (s *bookChangeService) GetChanges(ctx context.Context, token string, limit int) (*models.BookChangePage, error) {
+	if err := s.authorizer.Authorize(ctx, authz.ActionBookRead, ""); err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > MaxChangeLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxChangeLimit)
+	}
+
+	var since uint64
+	if token != "" {
+		var err error
+		if since, err = decodeSyncToken(token); err != nil {
+			return nil, err
+		}
+		purged, err := s.changeRepo.GetPurgedSeq(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve book changes: %w", err)
+		}
+		// The client may have missed deletes; it has to start over
+		if since < purged {
+			return nil, errors.New("sync token expired")
+		}
+	}
+
+	// One change more than asked for tells whether there are more
+	changes, err := s.changeRepo.GetSince(ctx, since, limit+1)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve book changes", "error", err)
+		return nil, fmt.Errorf("failed to retrieve book changes: %w", err)
+	}
+
+	page := &models.BookChangePage{Changes: changes}
+	if len(changes) > limit {
+		page.Changes = changes[:limit]
+		page.HasMore = true
+	}
+	if len(page.Changes) > 0 {
+		since = page.Changes[len(page.Changes)-1].Seq
+	}
+	page.NextToken = encodeSyncToken(since)
+	return page, nil
+}
+
+// PurgeTombstones removes the tombstones of books deleted longer than
+// retention ago
+func (s *bookChangeService) PurgeTombstones(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.changeRepo.PurgeTombstones(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge tombstones: %w", err)
+	}
+	if purged > 0 {
+		s.logger.InfoContext(ctx, "Purged book tombstones", "count", purged)
+	}
+	return purged, nil
+}
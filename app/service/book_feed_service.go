@@ -0,0 +1,48 @@
+package service
+
+import (
+	"books-api/app/authz"
+	"books-api/app/stream"
+	"books-api/app/tenancy"
+	"context"
+	"log/slog"
+)
+
+// bookFeedService implements the BookFeedService interface on top of a
+// stream broker
+type bookFeedService struct {
+	broker     *stream.Broker
+	authorizer authz.Authorizer
+	logger     *slog.Logger
+}
+
+// NewBookFeedService creates a new instance of book feed service. Callers
+// need the book read permission of authorizer.
+func NewBookFeedService(broker *stream.Broker, authorizer authz.Authorizer, logger *slog.Logger) BookFeedService {
+	return &bookFeedService{
+		broker:     broker,
+		authorizer: authorizer,
+		logger:     logger,
+	}
+}
+
+// Subscribe starts following the book events that match filter, resuming
+// after lastEventID if it is not zero. Any tenant in filter is replaced by
+// the tenant in ctx.
+func (s *bookFeedService) Subscribe(ctx context.Context, filter stream.Filter, lastEventID uint) (*stream.Subscription, error) {
+	if err := s.authorizer.Authorize(ctx, authz.ActionBookRead, ""); err != nil {
+		return nil, err
+	}
+
+	filter.TenantID = 0
+	if tenant, ok := tenancy.FromContext(ctx); ok {
+		filter.TenantID = tenant.ID
+	}
+
+	subscription, err := s.broker.Subscribe(filter, lastEventID)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "Client subscribed to book changes", "last_event_id", lastEventID, "replayed", len(subscription.Replay), "reset", subscription.Reset, "clients", s.broker.Clients())
+	return subscription, nil
+}
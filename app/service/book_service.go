@@ -1,119 +1,255 @@
 package service
 
 import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/events"
 	"books-api/app/repository"
 	"books-api/app/models"
+	"books-api/app/tenancy"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 )
 
 // bookService implements the BookService interface
 type bookService struct {
-	bookRepo repository.BookRepository
+	bookRepo   repository.BookRepository
+	unitOfWork repository.UnitOfWork
+	authorizer authz.Authorizer
+	logger     *slog.Logger
 }
 
-// NewBookService creates a new instance of book service
-func NewBookService(bookRepo repository.BookRepository) BookService {
+// NewBookService creates a new instance of book service. Every operation is
+// checked by authorizer against the principal in the call's context and
+// confined to the tenant in the context. It logs through logger with the
+// call's context, so records carry the request ID and principal. Writes run
+// in a transaction of unitOfWork, which also adds their domain event to the
+// outbox.
+func NewBookService(bookRepo repository.BookRepository, unitOfWork repository.UnitOfWork, authorizer authz.Authorizer, logger *slog.Logger) BookService {
 	return &bookService{
-		bookRepo: bookRepo,
+		bookRepo:   bookRepo,
+		unitOfWork: unitOfWork,
+		authorizer: authorizer,
+		logger:     logger,
 	}
 }
 
 // CreateBook creates a new book with validation and logging
-func (s *bookService) CreateBook(book *models.Book) error {
-	log.Printf("Creating new book: %s by %s", book.Title, book.Author)
+func (s *bookService) CreateBook(ctx context.Context, book *models.Book) error {
+	s.logger.InfoContext(ctx, "Creating new book", "title", book.Title, "author", book.Author)
+	
+	if err := s.authorizer.Authorize(ctx, authz.ActionBookCreate, ""); err != nil {
+		return err
+	}
 	
 	// Validate color if provided
 	if book.Color != nil && !book.Color.IsValid() {
-		log.Printf("Invalid color provided for book: %s", *book.Color)
+		s.logger.WarnContext(ctx, "Invalid color provided for book", "color", *book.Color)
 		return fmt.Errorf("invalid color: %s", *book.Color)
 	}
 	
-	err := s.bookRepo.Create(book)
+	// Ratings are derived from reviews, never taken from the client
+	book.AverageRating = 0
+	book.RatingCount = 0
+	
+	// Ownership is recorded from the caller, never taken from the client
+	book.CreatedBy = ""
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		book.CreatedBy = principal.Subject
+	}
+	
+	// The tenant comes from the request as well; without one the book goes
+	// to the default tenant
+	book.TenantID = 0
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if tenant, ok := tenancy.FromContext(ctx); ok {
+			if err := s.checkBookQuota(ctx, repos.Books(), tenant); err != nil {
+				return err
+			}
+			book.TenantID = tenant.ID
+		}
+		
+		if err := repos.Books().Create(ctx, book); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create book", "error", err)
+			return fmt.Errorf("failed to create book: %w", err)
+		}
+		return s.recordEvent(ctx, repos, events.NewBookCreated(book))
+	})
 	if err != nil {
-		log.Printf("Failed to create book: %v", err)
-		return fmt.Errorf("failed to create book: %w", err)
+		return err
 	}
 	
-	log.Printf("Successfully created book with ID: %d", book.ID)
+	s.logger.InfoContext(ctx, "Successfully created book", "book_id", book.ID)
 	return nil
 }
 
-// GetBookByID retrieves a book by ID with logging
-func (s *bookService) GetBookByID(id uint) (*models.Book, error) {
-	log.Printf("Retrieving book with ID: %d", id)
-	
-	book, err := s.bookRepo.GetByID(id)
+// checkBookQuota fails when the tenant's catalogue is already full
+func (s *bookService) checkBookQuota(ctx context.Context, books repository.BookRepository, tenant *models.Tenant) error {
+	if tenant.MaxBooks <= 0 {
+		return nil
+	}
+	count, err := books.Count(ctx)
 	if err != nil {
-		log.Printf("Failed to retrieve book with ID %d: %v", id, err)
-		return nil, fmt.Errorf("book not found")
+		s.logger.ErrorContext(ctx, "Failed to count books of tenant", "error", err)
+		return fmt.Errorf("failed to create book: %w", err)
 	}
-	
-	log.Printf("Successfully retrieved book: %s", book.Title)
-	return book, nil
+	if count >= int64(tenant.MaxBooks) {
+		s.logger.WarnContext(ctx, "Tenant reached its book quota", "max_books", tenant.MaxBooks)
+		return fmt.Errorf("book quota exceeded")
+	}
+	return nil
 }
 
-// GetAllBooks retrieves all books with logging
-func (s *bookService) GetAllBooks() ([]models.Book, error) {
-	log.Printf("Retrieving all books")
-	
-	books, err := s.bookRepo.GetAll()
+// recordEvent adds event to the outbox in the transaction of repos
+func (s *bookService) recordEvent(ctx context.Context, repos repository.Repositories, event events.Event) error {
+	record, err := event.Record()
+	if err == nil {
+		err = repos.Outbox().Create(ctx, record)
+	}
 	if err != nil {
-		log.Printf("Failed to retrieve books: %v", err)
-		return nil, fmt.Errorf("failed to retrieve books: %w", err)
+		s.logger.ErrorContext(ctx, "Failed to record book event", "type", event.Type, "book_id", event.BookID, "error", err)
+		return fmt.Errorf("failed to record %s event: %w", event.Type, err)
 	}
-	
-	log.Printf("Successfully retrieved %d books", len(books))
-	return books, nil
+	return nil
 }
 
-// UpdateBook updates an existing book with validation and logging
-func (s *bookService) UpdateBook(id uint, updateData models.Book) (*models.Book, error) {
-	log.Printf("Updating book with ID: %d", id)
+// GetBookByID retrieves a book by ID with logging
+func (s *bookService) GetBookByID(ctx context.Context, id uint) (*models.Book, error) {
+	s.logger.InfoContext(ctx, "Retrieving book", "book_id", id)
 	
-	// First, get the existing book
-	existingBook, err := s.bookRepo.GetByID(id)
+	if err := s.authorizer.Authorize(ctx, authz.ActionBookRead, ""); err != nil {
+		return nil, err
+	}
+	
+	book, err := s.bookRepo.GetByID(ctx, id)
 	if err != nil {
-		log.Printf("Book with ID %d not found for update: %v", id, err)
+		s.logger.WarnContext(ctx, "Failed to retrieve book", "book_id", id, "error", err)
 		return nil, fmt.Errorf("book not found")
 	}
 	
-	// Validate color if provided in update
-	if updateData.Color != nil && !updateData.Color.IsValid() {
-		log.Printf("Invalid color provided for book update: %s", *updateData.Color)
-		return nil, fmt.Errorf("invalid color: %s", *updateData.Color)
+	availability, err := s.bookRepo.GetAvailability(ctx, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to count copies of book", "book_id", id, "error", err)
+		return nil, fmt.Errorf("failed to retrieve book: %w", err)
 	}
+	book.Availability = availability
 	
-	// Update the existing book with new data
-	existingBook.Update(updateData)
+	s.logger.InfoContext(ctx, "Successfully retrieved book", "book_id", book.ID, "title", book.Title)
+	return book, nil
+}
+
+// GetAllBooks retrieves all books matching the filter with logging
+func (s *bookService) GetAllBooks(ctx context.Context, filter models.BookFilter) ([]models.Book, error) {
+	s.logger.InfoContext(ctx, "Retrieving all books")
+	
+	if err := s.authorizer.Authorize(ctx, authz.ActionBookRead, ""); err != nil {
+		return nil, err
+	}
 	
-	err = s.bookRepo.Update(existingBook)
+	if field, _ := filter.SortField(); field != "" && !models.BookSortFields[field] {
+		return nil, fmt.Errorf("invalid sort field: %s", field)
+	}
+	
+	books, err := s.bookRepo.GetAll(ctx, filter)
 	if err != nil {
-		log.Printf("Failed to update book with ID %d: %v", id, err)
-		return nil, fmt.Errorf("failed to update book: %w", err)
+		s.logger.ErrorContext(ctx, "Failed to retrieve books", "error", err)
+		return nil, fmt.Errorf("failed to retrieve books: %w", err)
 	}
 	
-	log.Printf("Successfully updated book: %s", existingBook.Title)
-	return existingBook, nil
+	s.logger.InfoContext(ctx, "Successfully retrieved books", "count", len(books))
+	return books, nil
 }
 
-// DeleteBook deletes a book by ID with logging
-func (s *bookService) DeleteBook(id uint) error {
-	log.Printf("Deleting book with ID: %d", id)
+// UpdateBook updates an existing book with validation and logging. The book
+// stays locked from the read until the update commits, so a concurrent
+// change is not overwritten.
+func (s *bookService) UpdateBook(ctx context.Context, id uint, updateData models.Book) (*models.Book, error) {
+	s.logger.InfoContext(ctx, "Updating book", "book_id", id)
 	
-	// Check if book exists first
-	_, err := s.bookRepo.GetByID(id)
+	var existingBook *models.Book
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		books := repos.Books()
+		
+		// First, get the existing book
+		var err error
+		existingBook, err = books.GetByID(ctx, id)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Book not found for update", "book_id", id, "error", err)
+			return fmt.Errorf("book not found")
+		}
+		
+		if err := s.authorizer.Authorize(ctx, authz.ActionBookUpdate, existingBook.CreatedBy); err != nil {
+			return err
+		}
+		
+		// Validate color if provided in update
+		if updateData.Color != nil && !updateData.Color.IsValid() {
+			s.logger.WarnContext(ctx, "Invalid color provided for book update", "book_id", id, "color", *updateData.Color)
+			return fmt.Errorf("invalid color: %s", *updateData.Color)
+		}
+		
+		// Update the existing book with new data
+		before := *existingBook
+		existingBook.Update(updateData)
+		
+		if err := books.Update(ctx, existingBook); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to update book", "book_id", id, "error", err)
+			return fmt.Errorf("failed to update book: %w", err)
+		}
+		if changed := events.ChangedFields(before, *existingBook); len(changed) > 0 {
+			return s.recordEvent(ctx, repos, events.NewBookUpdated(existingBook, changed))
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("Book with ID %d not found for deletion: %v", id, err)
-		return fmt.Errorf("book not found")
+		return nil, err
 	}
 	
-	err = s.bookRepo.Delete(id)
+	s.logger.InfoContext(ctx, "Successfully updated book", "book_id", id, "title", existingBook.Title)
+	return existingBook, nil
+}
+
+// DeleteBook deletes a book by ID with logging. The check for copies and
+// the delete run in one transaction, so no copy is added in between.
+func (s *bookService) DeleteBook(ctx context.Context, id uint) error {
+	s.logger.InfoContext(ctx, "Deleting book", "book_id", id)
+	
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		books := repos.Books()
+		
+		// Check if book exists first
+		book, err := books.GetByID(ctx, id)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Book not found for deletion", "book_id", id, "error", err)
+			return fmt.Errorf("book not found")
+		}
+		
+		if err := s.authorizer.Authorize(ctx, authz.ActionBookDelete, book.CreatedBy); err != nil {
+			return err
+		}
+		
+		// Copies must be withdrawn before the title record goes away
+		availability, err := books.GetAvailability(ctx, id)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to count copies of book", "book_id", id, "error", err)
+			return fmt.Errorf("failed to delete book: %w", err)
+		}
+		if availability.Total > 0 {
+			s.logger.WarnContext(ctx, "Book still has copies", "book_id", id, "copies", availability.Total)
+			return fmt.Errorf("book has copies")
+		}
+		
+		if err := books.Delete(ctx, id); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete book", "book_id", id, "error", err)
+			return fmt.Errorf("failed to delete book: %w", err)
+		}
+		return s.recordEvent(ctx, repos, events.NewBookDeleted(book))
+	})
 	if err != nil {
-		log.Printf("Failed to delete book with ID %d: %v", id, err)
-		return fmt.Errorf("failed to delete book: %w", err)
+		return err
 	}
 	
-	log.Printf("Successfully deleted book with ID: %d", id)
+	s.logger.InfoContext(ctx, "Successfully deleted book", "book_id", id)
 	return nil
 }
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"books-api/app/authz"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// categoryService implements the CategoryService interface
+type categoryService struct {
+	categoryRepo repository.CategoryRepository
+	bookRepo     repository.BookRepository
+	unitOfWork   repository.UnitOfWork
+	authorizer   authz.Authorizer
+	logger       *slog.Logger
+}
+
+// NewCategoryService creates a new instance of category service.
+// Categorizing a book counts as updating it and is authorized as such.
+// Writes that change how books are categorized run in a transaction of
+// unitOfWork, which drops those books from the cache once it commits. It
+// logs through logger with the call's context.
+func NewCategoryService(categoryRepo repository.CategoryRepository, bookRepo repository.BookRepository, unitOfWork repository.UnitOfWork, authorizer authz.Authorizer, logger *slog.Logger) CategoryService {
+	return &categoryService{
+		categoryRepo: categoryRepo,
+		bookRepo:     bookRepo,
+		unitOfWork:   unitOfWork,
+		authorizer:   authorizer,
+		logger:       logger,
+	}
+}
+
+// CreateCategory creates a new category, optionally below an existing parent
+func (s *categoryService) CreateCategory(ctx context.Context, category *models.Category) error {
+	s.logger.InfoContext(ctx, "Creating new category", "name", category.Name)
+
+	if category.Name == "" {
+		return fmt.Errorf("category name is required")
+	}
+	category.TenantID = 0
+
+	if category.ParentID != nil && *category.ParentID == 0 {
+		category.ParentID = nil
+	}
+	if category.ParentID != nil {
+		if _, err := s.categoryRepo.GetByID(ctx, *category.ParentID); err != nil {
+			s.logger.WarnContext(ctx, "Parent category not found", "parent_id", *category.ParentID, "error", err)
+			return fmt.Errorf("parent category not found")
+		}
+	}
+
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create category", "error", err)
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully created category", "category_id", category.ID)
+	return nil
+}
+
+// GetCategoryByID retrieves a category with its direct children
+func (s *categoryService) GetCategoryByID(ctx context.Context, id uint) (*models.Category, error) {
+	s.logger.InfoContext(ctx, "Retrieving category", "category_id", id)
+
+	category, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to retrieve category", "category_id", id, "error", err)
+		return nil, fmt.Errorf("category not found")
+	}
+
+	return category, nil
+}
+
+// GetCategoryTree retrieves all categories nested under their parents
+func (s *categoryService) GetCategoryTree(ctx context.Context) ([]models.Category, error) {
+	s.logger.InfoContext(ctx, "Retrieving category tree")
+
+	categories, err := s.categoryRepo.GetAll(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve categories", "error", err)
+		return nil, fmt.Errorf("failed to retrieve categories: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully retrieved categories", "count", len(categories))
+	return buildCategoryTree(categories), nil
+}
+
+// buildCategoryTree nests a flat category list by parent ID
+func buildCategoryTree(categories []models.Category) []models.Category {
+	byParent := make(map[uint][]models.Category)
+	known := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		known[category.ID] = true
+	}
+	for _, category := range categories {
+		parent := uint(0)
+		if category.ParentID != nil && known[*category.ParentID] {
+			parent = *category.ParentID
+		}
+		byParent[parent] = append(byParent[parent], category)
+	}
+
+	var attach func(parent uint) []models.Category
+	attach = func(parent uint) []models.Category {
+		nodes := byParent[parent]
+		for i := range nodes {
+			nodes[i].Children = attach(nodes[i].ID)
+		}
+		return nodes
+	}
+	return attach(0)
+}
+
+// UpdateCategory renames or moves a category, refusing moves that would
+// create a cycle
+func (s *categoryService) UpdateCategory(ctx context.Context, id uint, updateData models.Category) (*models.Category, error) {
+	s.logger.InfoContext(ctx, "Updating category", "category_id", id)
+
+	existingCategory, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Category not found for update", "category_id", id, "error", err)
+		return nil, fmt.Errorf("category not found")
+	}
+
+	if updateData.ParentID != nil && *updateData.ParentID != 0 {
+		parentID := *updateData.ParentID
+		if _, err := s.categoryRepo.GetByID(ctx, parentID); err != nil {
+			s.logger.WarnContext(ctx, "Parent category not found", "parent_id", parentID, "error", err)
+			return nil, fmt.Errorf("parent category not found")
+		}
+
+		descendants, err := s.categoryRepo.GetDescendantIDs(ctx, id)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to load descendants of category", "category_id", id, "error", err)
+			return nil, fmt.Errorf("failed to update category: %w", err)
+		}
+		for _, descendant := range descendants {
+			if descendant == parentID {
+				s.logger.WarnContext(ctx, "Refusing to move category below its descendant", "category_id", id, "parent_id", parentID)
+				return nil, fmt.Errorf("category cannot be moved below itself")
+			}
+		}
+	}
+
+	existingCategory.Update(updateData)
+	existingCategory.Children = nil
+
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		return repos.Categories().Update(ctx, existingCategory)
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to update category", "category_id", id, "error", err)
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully updated category", "name", existingCategory.Name)
+	return existingCategory, nil
+}
+
+// DeleteCategory deletes a leaf category and detaches it from all books
+func (s *categoryService) DeleteCategory(ctx context.Context, id uint) error {
+	s.logger.InfoContext(ctx, "Deleting category", "category_id", id)
+
+	category, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Category not found for deletion", "category_id", id, "error", err)
+		return fmt.Errorf("category not found")
+	}
+
+	if len(category.Children) > 0 {
+		s.logger.WarnContext(ctx, "Category still has subcategories", "category_id", id, "subcategories", len(category.Children))
+		return fmt.Errorf("category has subcategories")
+	}
+
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		return repos.Categories().Delete(ctx, id)
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to delete category", "category_id", id, "error", err)
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully deleted category", "category_id", id)
+	return nil
+}
+
+// SetBookCategories replaces the categories of a book
+func (s *categoryService) SetBookCategories(ctx context.Context, bookID uint, categoryIDs []uint) (*models.Book, error) {
+	s.logger.InfoContext(ctx, "Setting categories on book", "count", len(categoryIDs), "book_id", bookID)
+
+	book, err := s.bookRepo.GetByID(ctx, bookID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Book not found for categorization", "book_id", bookID, "error", err)
+		return nil, fmt.Errorf("book not found")
+	}
+	if err := s.authorizer.Authorize(ctx, authz.ActionBookUpdate, book.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	unique := make([]uint, 0, len(categoryIDs))
+	seen := make(map[uint]bool)
+	for _, id := range categoryIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	categories, err := s.categoryRepo.GetByIDs(ctx, unique)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load categories", "error", err)
+		return nil, fmt.Errorf("failed to set categories: %w", err)
+	}
+	if len(categories) != len(unique) {
+		s.logger.WarnContext(ctx, "Some categories do not exist", "category_ids", unique)
+		return nil, fmt.Errorf("category not found")
+	}
+
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		return repos.Books().ReplaceCategories(ctx, book, categories)
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to set categories on book", "book_id", bookID, "error", err)
+		return nil, fmt.Errorf("failed to set categories: %w", err)
+	}
+
+	book.Categories = categories
+	s.logger.InfoContext(ctx, "Successfully set categories on book", "title", book.Title)
+	return book, nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// copyService implements the CopyService interface
+type copyService struct {
+	copyRepo   repository.CopyRepository
+	loanRepo   repository.LoanRepository
+	bookRepo   repository.BookRepository
+	unitOfWork repository.UnitOfWork
+	policy     CirculationPolicy
+	logger     *slog.Logger
+}
+
+// NewCopyService creates a new instance of copy service. New copies are
+// created in a transaction of unitOfWork that locks their book and sets
+// them aside for the book's hold queue. It logs through logger with the
+// call's context.
+func NewCopyService(copyRepo repository.CopyRepository, loanRepo repository.LoanRepository, bookRepo repository.BookRepository, unitOfWork repository.UnitOfWork, policy CirculationPolicy, logger *slog.Logger) CopyService {
+	return &copyService{
+		copyRepo:   copyRepo,
+		loanRepo:   loanRepo,
+		bookRepo:   bookRepo,
+		unitOfWork: unitOfWork,
+		policy:     policy,
+		logger:     logger,
+	}
+}
+
+// CreateCopy registers a new physical copy of a book. The copy goes to the
+// oldest waiting hold on the book, if any.
+func (s *copyService) CreateCopy(ctx context.Context, bookID uint, bookCopy *models.Copy) error {
+	bookCopy.Barcode = strings.TrimSpace(bookCopy.Barcode)
+	s.logger.InfoContext(ctx, "Creating copy of book", "barcode", bookCopy.Barcode, "book_id", bookID)
+
+	if bookCopy.Condition == "" {
+		bookCopy.Condition = models.ConditionGood
+	}
+
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		book, err := repos.Books().GetByID(ctx, bookID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Book not found for new copy", "book_id", bookID, "error", err)
+			return fmt.Errorf("book not found")
+		}
+
+		if bookCopy.Barcode == "" {
+			return fmt.Errorf("barcode is required")
+		}
+		if bookCopy.Branch == "" {
+			return fmt.Errorf("branch is required")
+		}
+		if !bookCopy.Condition.IsValid() {
+			s.logger.WarnContext(ctx, "Invalid condition provided for copy", "condition", bookCopy.Condition)
+			return fmt.Errorf("invalid condition: %s", bookCopy.Condition)
+		}
+
+		copies := repos.Copies()
+		if _, err := copies.GetByBarcode(ctx, bookCopy.Barcode); err == nil {
+			s.logger.WarnContext(ctx, "Barcode is already in use", "barcode", bookCopy.Barcode)
+			return fmt.Errorf("barcode already exists")
+		}
+
+		bookCopy.ID = 0
+		bookCopy.BookID = bookID
+		bookCopy.TenantID = book.TenantID
+		if err := copies.Create(ctx, bookCopy); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create copy", "error", err)
+			return fmt.Errorf("failed to create copy: %w", err)
+		}
+
+		now := time.Now()
+		hold, err := repos.Holds().PromoteNext(ctx, bookID, bookCopy.ID, now, now.Add(s.policy.HoldPickupWindow))
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to promote next hold for copy", "copy_id", bookCopy.ID, "error", err)
+			return fmt.Errorf("failed to create copy: %w", err)
+		}
+		if hold != nil {
+			s.logger.InfoContext(ctx, "Copy set aside for hold", "copy_id", bookCopy.ID, "hold_id", hold.ID, "member_id", hold.MemberID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully created copy", "copy_id", bookCopy.ID)
+	return nil
+}
+
+// GetCopyByID retrieves a copy by ID
+func (s *copyService) GetCopyByID(ctx context.Context, id uint) (*models.Copy, error) {
+	s.logger.InfoContext(ctx, "Retrieving copy", "copy_id", id)
+
+	bookCopy, err := s.copyRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to retrieve copy", "copy_id", id, "error", err)
+		return nil, fmt.Errorf("copy not found")
+	}
+
+	return bookCopy, nil
+}
+
+// GetBookCopies retrieves all copies of a book
+func (s *copyService) GetBookCopies(ctx context.Context, bookID uint) ([]models.Copy, error) {
+	s.logger.InfoContext(ctx, "Retrieving copies of book", "book_id", bookID)
+
+	if _, err := s.bookRepo.GetByID(ctx, bookID); err != nil {
+		s.logger.WarnContext(ctx, "Book not found for copy listing", "book_id", bookID, "error", err)
+		return nil, fmt.Errorf("book not found")
+	}
+
+	copies, err := s.copyRepo.GetByBook(ctx, bookID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve copies of book", "book_id", bookID, "error", err)
+		return nil, fmt.Errorf("failed to retrieve copies: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully retrieved copies", "count", len(copies))
+	return copies, nil
+}
+
+// UpdateCopy updates the barcode, branch or condition of a copy
+func (s *copyService) UpdateCopy(ctx context.Context, id uint, updateData models.Copy) (*models.Copy, error) {
+	s.logger.InfoContext(ctx, "Updating copy", "copy_id", id)
+
+	bookCopy, err := s.copyRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Copy not found for update", "copy_id", id, "error", err)
+		return nil, fmt.Errorf("copy not found")
+	}
+
+	if updateData.Condition != "" && !updateData.Condition.IsValid() {
+		s.logger.WarnContext(ctx, "Invalid condition provided for copy update", "condition", updateData.Condition)
+		return nil, fmt.Errorf("invalid condition: %s", updateData.Condition)
+	}
+
+	updateData.Barcode = strings.TrimSpace(updateData.Barcode)
+	if updateData.Barcode != "" && updateData.Barcode != bookCopy.Barcode {
+		if _, err := s.copyRepo.GetByBarcode(ctx, updateData.Barcode); err == nil {
+			s.logger.WarnContext(ctx, "Barcode is already in use", "barcode", updateData.Barcode)
+			return nil, fmt.Errorf("barcode already exists")
+		}
+	}
+
+	bookCopy.Update(updateData)
+	if err := s.copyRepo.Update(ctx, bookCopy); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to update copy", "copy_id", id, "error", err)
+		return nil, fmt.Errorf("failed to update copy: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully updated copy", "barcode", bookCopy.Barcode)
+	return bookCopy, nil
+}
+
+// DeleteCopy withdraws a copy that is not currently checked out
+func (s *copyService) DeleteCopy(ctx context.Context, id uint) error {
+	s.logger.InfoContext(ctx, "Deleting copy", "copy_id", id)
+
+	// The book stays locked until the delete commits, so the copy cannot be
+	// checked out or set aside for a hold in the meantime
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if _, err := lockCopy(ctx, repos, id); err != nil {
+			s.logger.WarnContext(ctx, "Copy not found for deletion", "copy_id", id, "error", err)
+			return fmt.Errorf("copy not found")
+		}
+
+		if _, err := repos.Loans().GetActiveByCopy(ctx, id); err == nil {
+			s.logger.WarnContext(ctx, "Copy is checked out and cannot be deleted", "copy_id", id)
+			return fmt.Errorf("copy is checked out")
+		}
+		if hold, err := repos.Holds().GetReadyByCopy(ctx, id); err == nil {
+			s.logger.WarnContext(ctx, "Copy is set aside for a hold and cannot be deleted", "copy_id", id, "hold_id", hold.ID)
+			return fmt.Errorf("copy is reserved for a hold")
+		}
+
+		if err := repos.Copies().Delete(ctx, id); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete copy", "copy_id", id, "error", err)
+			return fmt.Errorf("failed to delete copy: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully deleted copy", "copy_id", id)
+	return nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// holdService implements the HoldService interface
+type holdService struct {
+	holdRepo   repository.HoldRepository
+	unitOfWork repository.UnitOfWork
+	policy     CirculationPolicy
+	logger     *slog.Logger
+}
+
+// NewHoldService creates a new instance of hold service. Placing, cancelling
+// and expiring holds run in a transaction of unitOfWork that locks the held
+// book, like checkouts and returns of its copies. It logs through logger
+// with the call's context.
+func NewHoldService(holdRepo repository.HoldRepository, unitOfWork repository.UnitOfWork, policy CirculationPolicy, logger *slog.Logger) HoldService {
+	return &holdService{
+		holdRepo:   holdRepo,
+		unitOfWork: unitOfWork,
+		policy:     policy,
+		logger:     logger,
+	}
+}
+
+// PlaceHold queues a member for the next copy of a book that has no copy
+// available right now
+func (s *holdService) PlaceHold(ctx context.Context, bookID, memberID uint) (*models.Hold, error) {
+	s.logger.InfoContext(ctx, "Placing hold", "book_id", bookID, "member_id", memberID)
+
+	var hold *models.Hold
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		member, err := repos.Members().GetByID(ctx, memberID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Member not found for hold", "member_id", memberID, "error", err)
+			return fmt.Errorf("member not found")
+		}
+		if member.Status != models.MemberActive {
+			s.logger.WarnContext(ctx, "Member cannot place holds", "member_id", memberID, "status", member.Status)
+			return fmt.Errorf("member is suspended")
+		}
+
+		books := repos.Books()
+		book, err := books.GetByID(ctx, bookID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Book not found for hold", "book_id", bookID, "error", err)
+			return fmt.Errorf("book not found")
+		}
+
+		availability, err := books.GetAvailability(ctx, bookID)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to count copies of book", "book_id", bookID, "error", err)
+			return fmt.Errorf("failed to place hold: %w", err)
+		}
+		if availability.Available > 0 {
+			s.logger.WarnContext(ctx, "Book has copies available, no hold needed", "book_id", bookID, "available", availability.Available)
+			return fmt.Errorf("copies are available for checkout")
+		}
+
+		holds := repos.Holds()
+		if _, err := holds.GetActiveByMemberAndBook(ctx, memberID, bookID); err == nil {
+			s.logger.WarnContext(ctx, "Member already holds book", "member_id", memberID, "book_id", bookID)
+			return fmt.Errorf("hold already exists")
+		}
+
+		hold = &models.Hold{
+			BookID:   bookID,
+			MemberID: memberID,
+			Status:   models.HoldWaiting,
+			TenantID: book.TenantID,
+		}
+		if err := holds.Create(ctx, hold); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to place hold", "error", err)
+			return fmt.Errorf("failed to place hold: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully placed hold", "hold_id", hold.ID)
+	return hold, nil
+}
+
+// GetMemberHolds retrieves all holds of a member
+func (s *holdService) GetMemberHolds(ctx context.Context, memberID uint) ([]models.Hold, error) {
+	s.logger.InfoContext(ctx, "Retrieving holds of member", "member_id", memberID)
+
+	holds, err := s.holdRepo.GetByMember(ctx, memberID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve holds of member", "member_id", memberID, "error", err)
+		return nil, fmt.Errorf("failed to retrieve holds: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully retrieved holds", "count", len(holds))
+	return holds, nil
+}
+
+// CancelHold cancels a waiting or ready hold. A copy set aside for the hold
+// passes on to the next member in the queue.
+func (s *holdService) CancelHold(ctx context.Context, id uint) (*models.Hold, error) {
+	s.logger.InfoContext(ctx, "Cancelling hold", "hold_id", id)
+
+	var hold *models.Hold
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		var err error
+		hold, err = repos.Holds().GetByID(ctx, id)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Hold not found for cancellation", "hold_id", id, "error", err)
+			return fmt.Errorf("hold not found")
+		}
+
+		if !hold.Status.IsActive() {
+			s.logger.WarnContext(ctx, "Hold is already closed", "hold_id", id, "status", hold.Status)
+			return fmt.Errorf("hold is no longer active")
+		}
+
+		return closeHold(ctx, repos, hold, models.HoldCancelled, s.policy, s.logger)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully cancelled hold", "hold_id", id)
+	return hold, nil
+}
+
+// ExpireHolds expires ready holds whose pickup window has passed and hands
+// their copies to the next members in line. Each hold expires in its own
+// transaction. It returns how many holds expired.
+func (s *holdService) ExpireHolds(ctx context.Context) (int, error) {
+	holds, err := s.holdRepo.GetExpiredReady(ctx, time.Now())
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve expired holds", "error", err)
+		return 0, fmt.Errorf("failed to expire holds: %w", err)
+	}
+
+	expired := 0
+	for i := range holds {
+		err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+			return closeHold(ctx, repos, &holds[i], models.HoldExpired, s.policy, s.logger)
+		})
+		if err != nil {
+			continue
+		}
+		expired++
+	}
+
+	if expired > 0 {
+		s.logger.InfoContext(ctx, "Expired holds past their pickup window", "expired", expired)
+	}
+	return expired, nil
+}
+
+// closeHold moves an active hold to a final status and, if it held a copy,
+// promotes the next waiting hold for that copy under policy. The book of the
+// hold stays locked until the unit of work of repos ends.
+func closeHold(ctx context.Context, repos repository.Repositories, hold *models.Hold, status models.HoldStatus, policy CirculationPolicy, logger *slog.Logger) error {
+	if _, err := repos.Books().GetByID(ctx, hold.BookID); err != nil {
+		logger.WarnContext(ctx, "Book not found for hold", "book_id", hold.BookID, "hold_id", hold.ID, "error", err)
+		return fmt.Errorf("book not found")
+	}
+
+	previous := hold.Status
+	copyID := hold.CopyID
+
+	hold.Status = status
+	holds := repos.Holds()
+	saved, err := holds.UpdateIfStatus(ctx, hold, previous)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to update hold", "hold_id", hold.ID, "error", err)
+		return fmt.Errorf("failed to update hold: %w", err)
+	}
+	if !saved {
+		logger.WarnContext(ctx, "Hold changed concurrently", "hold_id", hold.ID)
+		return fmt.Errorf("hold is no longer active")
+	}
+
+	if previous == models.HoldReady && copyID != nil {
+		now := time.Now()
+		next, err := holds.PromoteNext(ctx, hold.BookID, *copyID, now, now.Add(policy.HoldPickupWindow))
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to promote next hold for copy", "copy_id", *copyID, "error", err)
+			return fmt.Errorf("failed to update hold: %w", err)
+		}
+		if next != nil {
+			logger.InfoContext(ctx, "Copy set aside for hold", "copy_id", *copyID, "hold_id", next.ID, "member_id", next.MemberID)
+		}
+	}
+	return nil
+}
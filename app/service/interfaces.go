@@ -1,12 +1,153 @@
 package service
 
-import "books-api/app/models"
+import (
+	"books-api/app/models"
+	"books-api/app/stream"
+	"context"
+	"time"
+)
 
-// BookService defines the interface for book business logic
+// BookService defines the interface for book business logic. The context
+// carries the calling principal, which every method authorizes.
 type BookService interface {
-	CreateBook(book *models.Book) error
-	GetBookByID(id uint) (*models.Book, error)
-	GetAllBooks() ([]models.Book, error)
-	UpdateBook(id uint, updateData models.Book) (*models.Book, error)
-	DeleteBook(id uint) error
+	CreateBook(ctx context.Context, book *models.Book) error
+	GetBookByID(ctx context.Context, id uint) (*models.Book, error)
+	GetAllBooks(ctx context.Context, filter models.BookFilter) ([]models.Book, error)
+	UpdateBook(ctx context.Context, id uint, updateData models.Book) (*models.Book, error)
+	DeleteBook(ctx context.Context, id uint) error
+}
+
+// BookFeedService defines the interface for following book changes live.
+// Subscriptions are authorized like reads and confined to the tenant in the
+// context.
+type BookFeedService interface {
+	Subscribe(ctx context.Context, filter stream.Filter, lastEventID uint) (*stream.Subscription, error)
+}
+
+// BookChangeService defines the interface for incremental sync of books.
+// Reads are authorized like book reads and confined to the tenant in the
+// context.
+type BookChangeService interface {
+	// GetChanges returns up to limit changes since the sync token; an empty
+	// token starts a full sync
+	GetChanges(ctx context.Context, token string, limit int) (*models.BookChangePage, error)
+	// PurgeTombstones removes the tombstones older than retention. Tokens
+	// from before then can no longer be used.
+	PurgeTombstones(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// TagService defines the interface for tag business logic. Tags are
+// confined to the tenant in the context.
+type TagService interface {
+	CreateTag(ctx context.Context, tag *models.Tag) error
+	GetTagByID(ctx context.Context, id uint) (*models.Tag, error)
+	GetAllTags(ctx context.Context) ([]models.TagCount, error)
+	UpdateTag(ctx context.Context, id uint, updateData models.Tag) (*models.Tag, error)
+	DeleteTag(ctx context.Context, id uint) error
+	MergeTags(ctx context.Context, sourceID, targetID uint) (*models.Tag, error)
+	SetBookTags(ctx context.Context, bookID uint, names []string) (*models.Book, error)
+}
+
+// CategoryService defines the interface for category business logic.
+// Categories are confined to the tenant in the context.
+type CategoryService interface {
+	CreateCategory(ctx context.Context, category *models.Category) error
+	GetCategoryByID(ctx context.Context, id uint) (*models.Category, error)
+	GetCategoryTree(ctx context.Context) ([]models.Category, error)
+	UpdateCategory(ctx context.Context, id uint, updateData models.Category) (*models.Category, error)
+	DeleteCategory(ctx context.Context, id uint) error
+	SetBookCategories(ctx context.Context, bookID uint, categoryIDs []uint) (*models.Book, error)
+}
+
+// ReviewService defines the interface for review business logic. Reviews are
+// reached through their book, which must be visible to the tenant in ctx.
+// The principal in ctx writes new reviews, may edit and delete its own, and
+// needs the moderator permission to see reviews that are not approved.
+type ReviewService interface {
+	CreateReview(ctx context.Context, bookID uint, review *models.Review) error
+	GetReview(ctx context.Context, bookID, reviewID uint) (*models.Review, error)
+	GetBookReviews(ctx context.Context, bookID uint, status *models.ReviewStatus) ([]models.Review, error)
+	// GetApprovedReviews returns the approved reviews of books the caller
+	// has already loaded, by book ID
+	GetApprovedReviews(ctx context.Context, bookIDs []uint) (map[uint][]models.Review, error)
+	UpdateReview(ctx context.Context, bookID, reviewID uint, updateData models.Review) (*models.Review, error)
+	ModerateReview(ctx context.Context, bookID, reviewID uint, status models.ReviewStatus) (*models.Review, error)
+	DeleteReview(ctx context.Context, bookID, reviewID uint) error
+}
+
+// CopyService defines the interface for physical copy business logic. Copies
+// are confined to the tenant in the context.
+type CopyService interface {
+	CreateCopy(ctx context.Context, bookID uint, bookCopy *models.Copy) error
+	GetCopyByID(ctx context.Context, id uint) (*models.Copy, error)
+	GetBookCopies(ctx context.Context, bookID uint) ([]models.Copy, error)
+	UpdateCopy(ctx context.Context, id uint, updateData models.Copy) (*models.Copy, error)
+	DeleteCopy(ctx context.Context, id uint) error
+}
+
+// LoanService defines the interface for circulation business logic. Copies,
+// members, loans and fines are confined to the tenant in the context.
+type LoanService interface {
+	Checkout(ctx context.Context, copyID, memberID uint) (*models.Loan, error)
+	Return(ctx context.Context, copyID uint) (*models.Loan, error)
+	Renew(ctx context.Context, copyID uint) (*models.Loan, error)
+	GetOverdueLoans(ctx context.Context) ([]models.Loan, error)
+}
+
+// HoldService defines the interface for hold queue business logic. Holds
+// are confined to the tenant in the context; without one, ExpireHolds
+// expires the holds of every tenant.
+type HoldService interface {
+	PlaceHold(ctx context.Context, bookID, memberID uint) (*models.Hold, error)
+	GetMemberHolds(ctx context.Context, memberID uint) ([]models.Hold, error)
+	CancelHold(ctx context.Context, id uint) (*models.Hold, error)
+	ExpireHolds(ctx context.Context) (int, error)
+}
+
+// MemberService defines the interface for member account business logic.
+// Members and their loans and fines are confined to the tenant in the
+// context.
+type MemberService interface {
+	CreateMember(ctx context.Context, member *models.Member) error
+	GetMemberByID(ctx context.Context, id uint) (*models.Member, error)
+	SearchMembers(ctx context.Context, filter models.MemberFilter) ([]models.Member, error)
+	UpdateMember(ctx context.Context, id uint, updateData models.Member) (*models.Member, error)
+	DeleteMember(ctx context.Context, id uint) error
+	SuspendMember(ctx context.Context, id uint, reason string) (*models.Member, error)
+	ReinstateMember(ctx context.Context, id uint) (*models.Member, error)
+	GetMemberLoans(ctx context.Context, id uint) ([]models.Loan, error)
+	GetMemberFines(ctx context.Context, id uint) ([]models.Fine, error)
+	PayFine(ctx context.Context, fineID uint) (*models.Fine, error)
+}
+
+// APIKeyService defines the interface for API key management and
+// authentication
+type APIKeyService interface {
+	IssueKey(ctx context.Context, name, tenant string, scopes []models.Scope, expiresAt *time.Time) (*models.IssuedAPIKey, error)
+	ListKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeKey(ctx context.Context, id uint) (*models.APIKey, error)
+	Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error)
+}
+
+// WebhookService defines the interface for webhook management. Webhooks and
+// their deliveries are confined to the tenant in the context.
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.WebhookWithSecret, error)
+	GetWebhook(ctx context.Context, id uint) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	UpdateWebhook(ctx context.Context, id uint, update models.WebhookUpdate) (*models.WebhookWithSecret, error)
+	DeleteWebhook(ctx context.Context, id uint) error
+	ListDeliveries(ctx context.Context, webhookID uint) ([]models.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+}
+
+// TenantService defines the interface for tenant administration and lookup
+type TenantService interface {
+	CreateTenant(ctx context.Context, tenant *models.Tenant) error
+	GetTenantByID(ctx context.Context, id uint) (*models.Tenant, error)
+	GetTenantBySlug(ctx context.Context, slug string) (*models.Tenant, error)
+	GetAllTenants(ctx context.Context) ([]models.Tenant, error)
+	UpdateTenant(ctx context.Context, id uint, updateData models.Tenant) (*models.Tenant, error)
+	DeleteTenant(ctx context.Context, id uint) error
 }
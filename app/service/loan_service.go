@@ -0,0 +1,339 @@
+package service
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// CirculationPolicy holds the lending rules enforced by the loan service
+type CirculationPolicy struct {
+	// MaxLoansPerMember is the number of copies a member may hold at once
+	MaxLoansPerMember int
+	// LoanPeriod is how long a copy may be kept per checkout or renewal
+	LoanPeriod time.Duration
+	// MaxRenewals is how often a single loan may be renewed
+	MaxRenewals int
+	// HoldPickupWindow is how long a returned copy stays set aside for a hold
+	HoldPickupWindow time.Duration
+	// FinePerDayCents is charged for every started day a copy is returned late
+	FinePerDayCents int64
+	// MaxUnpaidFinesCents blocks checkouts for members owing at least this
+	// much; zero disables the check
+	MaxUnpaidFinesCents int64
+}
+
+// DefaultCirculationPolicy returns the lending rules used when none are configured
+func DefaultCirculationPolicy() CirculationPolicy {
+	return CirculationPolicy{
+		MaxLoansPerMember:   5,
+		LoanPeriod:          14 * 24 * time.Hour,
+		MaxRenewals:         2,
+		HoldPickupWindow:    3 * 24 * time.Hour,
+		FinePerDayCents:     25,
+		MaxUnpaidFinesCents: 1000,
+	}
+}
+
+// loanService implements the LoanService interface
+type loanService struct {
+	loanRepo   repository.LoanRepository
+	unitOfWork repository.UnitOfWork
+	policy     CirculationPolicy
+	logger     *slog.Logger
+}
+
+// NewLoanService creates a new instance of loan service. Checkouts, returns
+// and renewals run in a transaction of unitOfWork that locks the book of the
+// copy, so the copies of a book circulate one change at a time. Records
+// are logged through logger with the call's context.
+func NewLoanService(loanRepo repository.LoanRepository, unitOfWork repository.UnitOfWork, policy CirculationPolicy, logger *slog.Logger) LoanService {
+	return &loanService{
+		loanRepo:   loanRepo,
+		unitOfWork: unitOfWork,
+		policy:     policy,
+		logger:     logger,
+	}
+}
+
+// lockCopy retrieves a copy and locks its book until the unit of work ends
+func lockCopy(ctx context.Context, repos repository.Repositories, copyID uint) (*models.Copy, error) {
+	bookCopy, err := repos.Copies().GetByID(ctx, copyID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repos.Books().GetByID(ctx, bookCopy.BookID); err != nil {
+		return nil, err
+	}
+	return bookCopy, nil
+}
+
+// Checkout lends a copy to a member. An available copy first goes to the
+// next hold in the book's queue, so walk-in borrowers never jump the queue.
+func (s *loanService) Checkout(ctx context.Context, copyID, memberID uint) (*models.Loan, error) {
+	s.logger.InfoContext(ctx, "Checking out copy to member", "copy_id", copyID, "member_id", memberID)
+
+	var loan *models.Loan
+	var createErr error
+	reserved := false
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		bookCopy, err := lockCopy(ctx, repos, copyID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Copy not found for checkout", "copy_id", copyID, "error", err)
+			return fmt.Errorf("copy not found")
+		}
+
+		loans := repos.Loans()
+		if _, err := loans.GetActiveByCopy(ctx, copyID); err == nil {
+			s.logger.WarnContext(ctx, "Copy is already checked out", "copy_id", copyID)
+			return fmt.Errorf("copy is already checked out")
+		}
+
+		now := time.Now()
+
+		// A copy set aside for a hold can only go to the member who placed it
+		hold, err := repos.Holds().GetReadyByCopy(ctx, copyID)
+		if err != nil {
+			hold, err = repos.Holds().PromoteNext(ctx, bookCopy.BookID, copyID, now, now.Add(s.policy.HoldPickupWindow))
+			if err != nil {
+				s.logger.ErrorContext(ctx, "Failed to promote next hold for copy", "copy_id", copyID, "error", err)
+				return fmt.Errorf("failed to check out copy: %w", err)
+			}
+			if hold != nil {
+				s.logger.InfoContext(ctx, "Copy set aside for hold", "copy_id", copyID, "hold_id", hold.ID, "member_id", hold.MemberID)
+			}
+		}
+		if hold != nil && hold.MemberID != memberID {
+			// Commit the promotion, but not the checkout
+			s.logger.WarnContext(ctx, "Copy is reserved for another member", "copy_id", copyID, "member_id", hold.MemberID)
+			reserved = true
+			return nil
+		}
+
+		member, err := repos.Members().GetByID(ctx, memberID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Member not found for checkout", "member_id", memberID, "error", err)
+			return fmt.Errorf("member not found")
+		}
+		if member.Status != models.MemberActive {
+			s.logger.WarnContext(ctx, "Member cannot borrow", "member_id", memberID, "status", member.Status)
+			return fmt.Errorf("member is suspended")
+		}
+
+		if s.policy.MaxUnpaidFinesCents > 0 {
+			owed, err := repos.Fines().SumUnpaidByMember(ctx, memberID)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "Failed to sum fines of member", "member_id", memberID, "error", err)
+				return fmt.Errorf("failed to check out copy: %w", err)
+			}
+			if owed >= s.policy.MaxUnpaidFinesCents {
+				s.logger.WarnContext(ctx, "Member has unpaid fines", "member_id", memberID, "owed_cents", owed)
+				return fmt.Errorf("member has unpaid fines")
+			}
+		}
+
+		limit := s.policy.MaxLoansPerMember
+		if member.MaxLoans > 0 {
+			limit = member.MaxLoans
+		}
+
+		active, err := loans.CountActiveByMember(ctx, memberID)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to count loans of member", "member_id", memberID, "error", err)
+			return fmt.Errorf("failed to check out copy: %w", err)
+		}
+		if active >= int64(limit) {
+			s.logger.WarnContext(ctx, "Member has reached the loan limit", "member_id", memberID, "active_loans", active)
+			return fmt.Errorf("member has reached the loan limit")
+		}
+
+		loan = &models.Loan{
+			CopyID:       copyID,
+			BookID:       bookCopy.BookID,
+			MemberID:     memberID,
+			TenantID:     bookCopy.TenantID,
+			CheckedOutAt: now,
+			DueAt:        now.Add(s.policy.LoanPeriod),
+		}
+		if err := loans.Create(ctx, loan); err != nil {
+			createErr = err
+			return fmt.Errorf("failed to check out copy: %w", err)
+		}
+
+		if hold != nil {
+			hold.Status = models.HoldFulfilled
+			fulfilled, err := repos.Holds().UpdateIfStatus(ctx, hold, models.HoldReady)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "Failed to mark hold as fulfilled", "hold_id", hold.ID, "error", err)
+				return fmt.Errorf("failed to check out copy: %w", err)
+			}
+			if !fulfilled {
+				s.logger.WarnContext(ctx, "Hold is no longer ready for pickup", "hold_id", hold.ID)
+				return fmt.Errorf("failed to check out copy: hold %d is no longer ready", hold.ID)
+			}
+		}
+		return nil
+	})
+	if createErr != nil {
+		// A concurrent checkout of the same copy loses on the unique index
+		if _, activeErr := s.loanRepo.GetActiveByCopy(ctx, copyID); activeErr == nil {
+			s.logger.WarnContext(ctx, "Copy was checked out concurrently", "copy_id", copyID)
+			return nil, fmt.Errorf("copy is already checked out")
+		}
+		s.logger.ErrorContext(ctx, "Failed to check out copy", "copy_id", copyID, "error", createErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, fmt.Errorf("copy is reserved for another member")
+	}
+
+	s.logger.InfoContext(ctx, "Successfully created loan", "loan_id", loan.ID)
+	return loan, nil
+}
+
+// Return closes the active loan of a copy. Charging a fine for a late
+// return and setting the copy aside for the next hold happen in the same
+// transaction.
+func (s *loanService) Return(ctx context.Context, copyID uint) (*models.Loan, error) {
+	s.logger.InfoContext(ctx, "Returning copy", "copy_id", copyID)
+
+	var loan *models.Loan
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if _, err := lockCopy(ctx, repos, copyID); err != nil {
+			s.logger.WarnContext(ctx, "Copy not found for return", "copy_id", copyID, "error", err)
+			return fmt.Errorf("copy not found")
+		}
+
+		var err error
+		loan, err = repos.Loans().GetActiveByCopy(ctx, copyID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Copy is not checked out", "copy_id", copyID, "error", err)
+			return fmt.Errorf("copy is not checked out")
+		}
+
+		now := time.Now()
+		returned, err := repos.Loans().MarkReturned(ctx, loan, now)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to return copy", "copy_id", copyID, "error", err)
+			return fmt.Errorf("failed to return copy: %w", err)
+		}
+		if !returned {
+			s.logger.WarnContext(ctx, "Loan was returned concurrently", "loan_id", loan.ID, "copy_id", copyID)
+			return fmt.Errorf("copy is not checked out")
+		}
+
+		loan.Overdue = loan.IsOverdue(now)
+		if loan.Overdue {
+			s.logger.InfoContext(ctx, "Copy was returned after its due date", "copy_id", copyID, "due_at", loan.DueAt)
+			if err := s.chargeOverdueFine(ctx, repos.Fines(), loan, now); err != nil {
+				return err
+			}
+		}
+
+		// The copy goes to the next member in the hold queue, if any
+		hold, err := repos.Holds().PromoteNext(ctx, loan.BookID, copyID, now, now.Add(s.policy.HoldPickupWindow))
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to promote next hold for copy", "copy_id", copyID, "error", err)
+			return fmt.Errorf("failed to return copy: %w", err)
+		}
+		if hold != nil {
+			s.logger.InfoContext(ctx, "Copy set aside for hold", "copy_id", copyID, "hold_id", hold.ID, "member_id", hold.MemberID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully returned loan", "loan_id", loan.ID)
+	return loan, nil
+}
+
+// chargeOverdueFine records a fine for every started day the loan was late
+func (s *loanService) chargeOverdueFine(ctx context.Context, fines repository.FineRepository, loan *models.Loan, returnedAt time.Time) error {
+	if s.policy.FinePerDayCents <= 0 {
+		return nil
+	}
+
+	days := int((returnedAt.Sub(loan.DueAt) + 24*time.Hour - 1) / (24 * time.Hour))
+	fine := &models.Fine{
+		MemberID:    loan.MemberID,
+		LoanID:      loan.ID,
+		AmountCents: int64(days) * s.policy.FinePerDayCents,
+		DaysOverdue: days,
+		TenantID:    loan.TenantID,
+	}
+	if err := fines.Create(ctx, fine); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to charge fine for loan", "loan_id", loan.ID, "error", err)
+		return fmt.Errorf("failed to return copy: %w", err)
+	}
+	s.logger.InfoContext(ctx, "Charged overdue fine", "member_id", loan.MemberID, "amount_cents", fine.AmountCents, "days_overdue", days)
+	return nil
+}
+
+// Renew extends the due date of a copy's active loan by one loan period
+func (s *loanService) Renew(ctx context.Context, copyID uint) (*models.Loan, error) {
+	s.logger.InfoContext(ctx, "Renewing copy", "copy_id", copyID)
+
+	var loan *models.Loan
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if _, err := lockCopy(ctx, repos, copyID); err != nil {
+			s.logger.WarnContext(ctx, "Copy not found for renewal", "copy_id", copyID, "error", err)
+			return fmt.Errorf("copy not found")
+		}
+
+		var err error
+		loan, err = repos.Loans().GetActiveByCopy(ctx, copyID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Copy is not checked out", "copy_id", copyID, "error", err)
+			return fmt.Errorf("copy is not checked out")
+		}
+
+		now := time.Now()
+		if loan.IsOverdue(now) {
+			s.logger.WarnContext(ctx, "Loan is overdue and cannot be renewed", "loan_id", loan.ID)
+			return fmt.Errorf("overdue loans cannot be renewed")
+		}
+		if loan.Renewals >= s.policy.MaxRenewals {
+			s.logger.WarnContext(ctx, "Loan has reached the renewal limit", "loan_id", loan.ID, "renewals", loan.Renewals)
+			return fmt.Errorf("loan has reached the renewal limit")
+		}
+
+		loan.Renewals++
+		loan.DueAt = loan.DueAt.Add(s.policy.LoanPeriod)
+		if err := repos.Loans().Update(ctx, loan); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to renew loan", "loan_id", loan.ID, "error", err)
+			return fmt.Errorf("failed to renew loan: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully renewed loan", "loan_id", loan.ID, "due_at", loan.DueAt)
+	return loan, nil
+}
+
+// GetOverdueLoans retrieves all unreturned loans past their due date
+func (s *loanService) GetOverdueLoans(ctx context.Context) ([]models.Loan, error) {
+	s.logger.InfoContext(ctx, "Retrieving overdue loans")
+
+	loans, err := s.loanRepo.GetOverdue(ctx, time.Now())
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve overdue loans", "error", err)
+		return nil, fmt.Errorf("failed to retrieve overdue loans: %w", err)
+	}
+
+	for i := range loans {
+		loans[i].Overdue = true
+	}
+
+	s.logger.InfoContext(ctx, "Successfully retrieved overdue loans", "count", len(loans))
+	return loans, nil
+}
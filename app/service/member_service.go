@@ -0,0 +1,337 @@
+package service
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// memberService implements the MemberService interface
+type memberService struct {
+	memberRepo repository.MemberRepository
+	loanRepo   repository.LoanRepository
+	fineRepo   repository.FineRepository
+	unitOfWork repository.UnitOfWork
+	policy     CirculationPolicy
+	logger     *slog.Logger
+}
+
+// NewMemberService creates a new instance of member service that logs
+// through logger with the call's context. Members are deleted in a unit of
+// work that cancels their holds, passing set aside copies on under policy.
+func NewMemberService(memberRepo repository.MemberRepository, loanRepo repository.LoanRepository, fineRepo repository.FineRepository, unitOfWork repository.UnitOfWork, policy CirculationPolicy, logger *slog.Logger) MemberService {
+	return &memberService{
+		memberRepo: memberRepo,
+		loanRepo:   loanRepo,
+		fineRepo:   fineRepo,
+		unitOfWork: unitOfWork,
+		policy:     policy,
+		logger:     logger,
+	}
+}
+
+// validateMember normalizes and checks the contact fields of a member
+func (s *memberService) validateMember(member *models.Member) error {
+	member.Name = strings.TrimSpace(member.Name)
+	member.Email = strings.ToLower(strings.TrimSpace(member.Email))
+	member.CardNumber = strings.TrimSpace(member.CardNumber)
+
+	if member.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if member.Email == "" {
+		return fmt.Errorf("email is required")
+	}
+	if _, err := mail.ParseAddress(member.Email); err != nil {
+		return fmt.Errorf("invalid email: %s", member.Email)
+	}
+	if member.CardNumber == "" {
+		return fmt.Errorf("card number is required")
+	}
+	if member.MaxLoans < 0 {
+		return fmt.Errorf("max loans cannot be negative")
+	}
+	return nil
+}
+
+// checkUnique makes sure no other member uses the email or card number
+func (s *memberService) checkUnique(ctx context.Context, member *models.Member) error {
+	if existing, err := s.memberRepo.GetByEmail(ctx, member.Email); err == nil && existing.ID != member.ID {
+		s.logger.WarnContext(ctx, "Email is already in use", "email", member.Email, "member_id", existing.ID)
+		return fmt.Errorf("email already exists")
+	}
+	if existing, err := s.memberRepo.GetByCardNumber(ctx, member.CardNumber); err == nil && existing.ID != member.ID {
+		s.logger.WarnContext(ctx, "Card number is already in use", "card_number", member.CardNumber, "member_id", existing.ID)
+		return fmt.Errorf("card number already exists")
+	}
+	return nil
+}
+
+// CreateMember registers a new library member
+func (s *memberService) CreateMember(ctx context.Context, member *models.Member) error {
+	s.logger.InfoContext(ctx, "Creating member", "name", member.Name)
+
+	if err := s.validateMember(member); err != nil {
+		s.logger.WarnContext(ctx, "Invalid member", "error", err)
+		return err
+	}
+
+	member.ID = 0
+	member.TenantID = 0
+	member.Status = models.MemberActive
+	member.SuspendedReason = ""
+	if err := s.checkUnique(ctx, member); err != nil {
+		return err
+	}
+
+	if err := s.memberRepo.Create(ctx, member); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create member", "error", err)
+		return fmt.Errorf("failed to create member: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully created member", "member_id", member.ID)
+	return nil
+}
+
+// GetMemberByID retrieves a member by ID
+func (s *memberService) GetMemberByID(ctx context.Context, id uint) (*models.Member, error) {
+	s.logger.InfoContext(ctx, "Retrieving member", "member_id", id)
+
+	member, err := s.memberRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to retrieve member", "member_id", id, "error", err)
+		return nil, fmt.Errorf("member not found")
+	}
+
+	return member, nil
+}
+
+// SearchMembers retrieves members matching the filter
+func (s *memberService) SearchMembers(ctx context.Context, filter models.MemberFilter) ([]models.Member, error) {
+	s.logger.InfoContext(ctx, "Searching members", "query", filter.Query, "status", filter.Status)
+
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("invalid status: %s", filter.Status)
+	}
+
+	members, err := s.memberRepo.Search(ctx, filter)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to search members", "error", err)
+		return nil, fmt.Errorf("failed to search members: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Found members", "count", len(members))
+	return members, nil
+}
+
+// UpdateMember updates the contact details and loan limit of a member
+func (s *memberService) UpdateMember(ctx context.Context, id uint, updateData models.Member) (*models.Member, error) {
+	s.logger.InfoContext(ctx, "Updating member", "member_id", id)
+
+	member, err := s.memberRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Member not found for update", "member_id", id, "error", err)
+		return nil, fmt.Errorf("member not found")
+	}
+
+	member.Update(updateData)
+	if err := s.validateMember(member); err != nil {
+		s.logger.WarnContext(ctx, "Invalid member update", "error", err)
+		return nil, err
+	}
+	if err := s.checkUnique(ctx, member); err != nil {
+		return nil, err
+	}
+
+	if err := s.memberRepo.Update(ctx, member); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to update member", "member_id", id, "error", err)
+		return nil, fmt.Errorf("failed to update member: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully updated member", "member_id", id)
+	return member, nil
+}
+
+// DeleteMember removes a member who has nothing checked out and no unpaid fines
+func (s *memberService) DeleteMember(ctx context.Context, id uint) error {
+	s.logger.InfoContext(ctx, "Deleting member", "member_id", id)
+
+	// Holds of the member are cancelled with the delete, so copies set aside
+	// for them go to the next member in line
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if _, err := repos.Members().GetByID(ctx, id); err != nil {
+			s.logger.WarnContext(ctx, "Member not found for deletion", "member_id", id, "error", err)
+			return fmt.Errorf("member not found")
+		}
+
+		active, err := repos.Loans().CountActiveByMember(ctx, id)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to count loans of member", "member_id", id, "error", err)
+			return fmt.Errorf("failed to delete member: %w", err)
+		}
+		if active > 0 {
+			return fmt.Errorf("member has active loans")
+		}
+
+		owed, err := repos.Fines().SumUnpaidByMember(ctx, id)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to sum fines of member", "member_id", id, "error", err)
+			return fmt.Errorf("failed to delete member: %w", err)
+		}
+		if owed > 0 {
+			return fmt.Errorf("member has unpaid fines")
+		}
+
+		holds, err := repos.Holds().GetByMember(ctx, id)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to retrieve holds of member", "member_id", id, "error", err)
+			return fmt.Errorf("failed to delete member: %w", err)
+		}
+		for i := range holds {
+			if !holds[i].Status.IsActive() {
+				continue
+			}
+			if err := closeHold(ctx, repos, &holds[i], models.HoldCancelled, s.policy, s.logger); err != nil {
+				return err
+			}
+			s.logger.InfoContext(ctx, "Cancelled hold of deleted member", "hold_id", holds[i].ID, "member_id", id)
+		}
+
+		if err := repos.Members().Delete(ctx, id); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete member", "member_id", id, "error", err)
+			return fmt.Errorf("failed to delete member: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully deleted member", "member_id", id)
+	return nil
+}
+
+// SuspendMember stops a member from borrowing and placing holds
+func (s *memberService) SuspendMember(ctx context.Context, id uint, reason string) (*models.Member, error) {
+	s.logger.InfoContext(ctx, "Suspending member", "member_id", id)
+
+	member, err := s.memberRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Member not found for suspension", "member_id", id, "error", err)
+		return nil, fmt.Errorf("member not found")
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+	if member.Status == models.MemberSuspended {
+		return nil, fmt.Errorf("member is already suspended")
+	}
+
+	member.Status = models.MemberSuspended
+	member.SuspendedReason = reason
+	if err := s.memberRepo.Update(ctx, member); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to suspend member", "member_id", id, "error", err)
+		return nil, fmt.Errorf("failed to suspend member: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Suspended member", "member_id", id, "reason", reason)
+	return member, nil
+}
+
+// ReinstateMember lifts the suspension of a member
+func (s *memberService) ReinstateMember(ctx context.Context, id uint) (*models.Member, error) {
+	s.logger.InfoContext(ctx, "Reinstating member", "member_id", id)
+
+	member, err := s.memberRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Member not found for reinstatement", "member_id", id, "error", err)
+		return nil, fmt.Errorf("member not found")
+	}
+	if member.Status != models.MemberSuspended {
+		return nil, fmt.Errorf("member is not suspended")
+	}
+
+	member.Status = models.MemberActive
+	member.SuspendedReason = ""
+	if err := s.memberRepo.Update(ctx, member); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to reinstate member", "member_id", id, "error", err)
+		return nil, fmt.Errorf("failed to reinstate member: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Reinstated member", "member_id", id)
+	return member, nil
+}
+
+// GetMemberLoans retrieves the loan history of a member, flagging overdue loans
+func (s *memberService) GetMemberLoans(ctx context.Context, id uint) ([]models.Loan, error) {
+	s.logger.InfoContext(ctx, "Retrieving loans of member", "member_id", id)
+
+	if _, err := s.memberRepo.GetByID(ctx, id); err != nil {
+		s.logger.WarnContext(ctx, "Member not found for loans", "member_id", id, "error", err)
+		return nil, fmt.Errorf("member not found")
+	}
+
+	loans, err := s.loanRepo.GetByMember(ctx, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve loans of member", "member_id", id, "error", err)
+		return nil, fmt.Errorf("failed to retrieve loans: %w", err)
+	}
+
+	now := time.Now()
+	for i := range loans {
+		loans[i].Overdue = loans[i].IsOverdue(now)
+	}
+
+	s.logger.InfoContext(ctx, "Found loans of member", "count", len(loans), "member_id", id)
+	return loans, nil
+}
+
+// GetMemberFines retrieves all fines charged to a member
+func (s *memberService) GetMemberFines(ctx context.Context, id uint) ([]models.Fine, error) {
+	s.logger.InfoContext(ctx, "Retrieving fines of member", "member_id", id)
+
+	if _, err := s.memberRepo.GetByID(ctx, id); err != nil {
+		s.logger.WarnContext(ctx, "Member not found for fines", "member_id", id, "error", err)
+		return nil, fmt.Errorf("member not found")
+	}
+
+	fines, err := s.fineRepo.GetByMember(ctx, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve fines of member", "member_id", id, "error", err)
+		return nil, fmt.Errorf("failed to retrieve fines: %w", err)
+	}
+
+	return fines, nil
+}
+
+// PayFine marks a fine as settled
+func (s *memberService) PayFine(ctx context.Context, fineID uint) (*models.Fine, error) {
+	s.logger.InfoContext(ctx, "Paying fine", "fine_id", fineID)
+
+	fine, err := s.fineRepo.GetByID(ctx, fineID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Fine not found", "fine_id", fineID, "error", err)
+		return nil, fmt.Errorf("fine not found")
+	}
+	if fine.PaidAt != nil {
+		return nil, fmt.Errorf("fine already paid")
+	}
+
+	now := time.Now()
+	fine.PaidAt = &now
+	if err := s.fineRepo.Update(ctx, fine); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to pay fine", "fine_id", fineID, "error", err)
+		return nil, fmt.Errorf("failed to pay fine: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully paid fine", "fine_id", fineID, "member_id", fine.MemberID)
+	return fine, nil
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// reviewService implements the ReviewService interface
+type reviewService struct {
+	reviewRepo repository.ReviewRepository
+	bookRepo   repository.BookRepository
+	unitOfWork repository.UnitOfWork
+	authorizer authz.Authorizer
+	logger     *slog.Logger
+}
+
+// NewReviewService creates a new instance of review service. Writes run in
+// a transaction of unitOfWork that locks the reviewed book, so its rating
+// aggregates follow its reviews one change at a time. Reviews are owned by
+// the subject that wrote them. It logs through logger with the call's
+// context.
+func NewReviewService(reviewRepo repository.ReviewRepository, bookRepo repository.BookRepository, unitOfWork repository.UnitOfWork, authorizer authz.Authorizer, logger *slog.Logger) ReviewService {
+	return &reviewService{
+		reviewRepo: reviewRepo,
+		bookRepo:   bookRepo,
+		unitOfWork: unitOfWork,
+		authorizer: authorizer,
+		logger:     logger,
+	}
+}
+
+// canModerate reports whether the caller may see reviews that are not
+// approved
+func (s *reviewService) canModerate(ctx context.Context) bool {
+	return s.authorizer.Authorize(ctx, authz.ActionReviewModerate, "") == nil
+}
+
+// validateRating checks that a rating is within the allowed range
+func validateRating(rating int) error {
+	if rating < models.MinRating || rating > models.MaxRating {
+		return fmt.Errorf("rating must be between %d and %d", models.MinRating, models.MaxRating)
+	}
+	return nil
+}
+
+// getBookReview loads a review and checks that it belongs to the book, and
+// that the book is visible to the caller's tenant
+func (s *reviewService) getBookReview(ctx context.Context, books repository.BookRepository, reviews repository.ReviewRepository, bookID, reviewID uint) (*models.Review, error) {
+	if _, err := books.GetByID(ctx, bookID); err != nil {
+		s.logger.WarnContext(ctx, "Book not found for review", "book_id", bookID, "review_id", reviewID, "error", err)
+		return nil, fmt.Errorf("book not found")
+	}
+
+	review, err := reviews.GetByID(ctx, reviewID)
+	if err != nil || review.BookID != bookID {
+		s.logger.WarnContext(ctx, "Review not found for book", "review_id", reviewID, "book_id", bookID, "error", err)
+		return nil, fmt.Errorf("review not found")
+	}
+	return review, nil
+}
+
+// CreateReview adds a pending review by the caller, allowing one review per
+// reviewer and book
+func (s *reviewService) CreateReview(ctx context.Context, bookID uint, review *models.Review) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		s.logger.WarnContext(ctx, "Denied anonymous action", "action", authz.ActionReviewCreate)
+		return &authz.ForbiddenError{Action: authz.ActionReviewCreate}
+	}
+	if err := s.authorizer.Authorize(ctx, authz.ActionReviewCreate, ""); err != nil {
+		return err
+	}
+
+	review.Reviewer = principal.Subject
+	s.logger.InfoContext(ctx, "Creating review", "reviewer", review.Reviewer, "book_id", bookID)
+
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		book, err := repos.Books().GetByID(ctx, bookID)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Book not found for review", "book_id", bookID, "error", err)
+			return fmt.Errorf("book not found")
+		}
+
+		if review.Reviewer == "" {
+			return fmt.Errorf("reviewer is required")
+		}
+		if err := validateRating(review.Rating); err != nil {
+			s.logger.WarnContext(ctx, "Invalid rating provided for review", "rating", review.Rating)
+			return err
+		}
+
+		reviews := repos.Reviews()
+		if _, err := reviews.GetByBookAndReviewer(ctx, bookID, review.Reviewer); err == nil {
+			s.logger.WarnContext(ctx, "Reviewer already reviewed book", "reviewer", review.Reviewer, "book_id", bookID)
+			return fmt.Errorf("review already exists")
+		}
+
+		review.ID = 0
+		review.BookID = bookID
+		review.TenantID = book.TenantID
+		review.Status = models.ReviewPending
+
+		if err := reviews.Create(ctx, review); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create review", "error", err)
+			return fmt.Errorf("failed to create review: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully created review", "review_id", review.ID)
+	return nil
+}
+
+// GetReview retrieves a single review of a book. Reviews that are not
+// approved are only visible to moderators.
+func (s *reviewService) GetReview(ctx context.Context, bookID, reviewID uint) (*models.Review, error) {
+	s.logger.InfoContext(ctx, "Retrieving review", "review_id", reviewID, "book_id", bookID)
+
+	review, err := s.getBookReview(ctx, s.bookRepo, s.reviewRepo, bookID, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != models.ReviewApproved && !s.canModerate(ctx) {
+		s.logger.WarnContext(ctx, "Review is hidden from the caller", "review_id", reviewID, "status", review.Status)
+		return nil, fmt.Errorf("review not found")
+	}
+	return review, nil
+}
+
+// GetBookReviews retrieves the reviews of a book, optionally filtered by
+// status. Only moderators may list reviews other than approved ones.
+func (s *reviewService) GetBookReviews(ctx context.Context, bookID uint, status *models.ReviewStatus) ([]models.Review, error) {
+	s.logger.InfoContext(ctx, "Retrieving reviews of book", "book_id", bookID)
+
+	if status != nil && !status.IsValid() {
+		return nil, fmt.Errorf("invalid review status: %s", *status)
+	}
+	if status == nil || *status != models.ReviewApproved {
+		if err := s.authorizer.Authorize(ctx, authz.ActionReviewModerate, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.bookRepo.GetByID(ctx, bookID); err != nil {
+		s.logger.WarnContext(ctx, "Book not found for review listing", "book_id", bookID, "error", err)
+		return nil, fmt.Errorf("book not found")
+	}
+
+	reviews, err := s.reviewRepo.GetByBook(ctx, bookID, status)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve reviews of book", "book_id", bookID, "error", err)
+		return nil, fmt.Errorf("failed to retrieve reviews: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully retrieved reviews", "count", len(reviews))
+	return reviews, nil
+}
+
+// GetApprovedReviews retrieves the approved reviews of several books at
+// once. The books are not looked up again, so callers pass only books
+// visible to them.
+func (s *reviewService) GetApprovedReviews(ctx context.Context, bookIDs []uint) (map[uint][]models.Review, error) {
+	reviewsByBook := make(map[uint][]models.Review, len(bookIDs))
+	if len(bookIDs) == 0 {
+		return reviewsByBook, nil
+	}
+
+	reviews, err := s.reviewRepo.GetApprovedByBooks(ctx, bookIDs)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve reviews of books", "count", len(bookIDs), "error", err)
+		return nil, fmt.Errorf("failed to retrieve reviews: %w", err)
+	}
+	for _, review := range reviews {
+		reviewsByBook[review.BookID] = append(reviewsByBook[review.BookID], review)
+	}
+	return reviewsByBook, nil
+}
+
+// UpdateReview edits the rating or text of a review. Edited reviews go back
+// to moderation.
+func (s *reviewService) UpdateReview(ctx context.Context, bookID, reviewID uint, updateData models.Review) (*models.Review, error) {
+	s.logger.InfoContext(ctx, "Updating review", "review_id", reviewID, "book_id", bookID)
+
+	if updateData.Rating != 0 {
+		if err := validateRating(updateData.Rating); err != nil {
+			s.logger.WarnContext(ctx, "Invalid rating provided for review update", "rating", updateData.Rating)
+			return nil, err
+		}
+	}
+
+	var review *models.Review
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		var err error
+		review, err = s.getBookReview(ctx, repos.Books(), repos.Reviews(), bookID, reviewID)
+		if err != nil {
+			return err
+		}
+		if err := s.authorizer.Authorize(ctx, authz.ActionReviewUpdate, review.Reviewer); err != nil {
+			return err
+		}
+
+		review.Update(updateData)
+		review.Status = models.ReviewPending
+
+		if err := repos.Reviews().Update(ctx, review); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to update review", "review_id", reviewID, "error", err)
+			return fmt.Errorf("failed to update review: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully updated review", "review_id", review.ID)
+	return review, nil
+}
+
+// ModerateReview changes the moderation status of a review
+func (s *reviewService) ModerateReview(ctx context.Context, bookID, reviewID uint, status models.ReviewStatus) (*models.Review, error) {
+	s.logger.InfoContext(ctx, "Moderating review", "review_id", reviewID, "book_id", bookID, "status", status)
+
+	if err := s.authorizer.Authorize(ctx, authz.ActionReviewModerate, ""); err != nil {
+		return nil, err
+	}
+	if !status.IsValid() {
+		return nil, fmt.Errorf("invalid review status: %s", status)
+	}
+
+	var review *models.Review
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		var err error
+		review, err = s.getBookReview(ctx, repos.Books(), repos.Reviews(), bookID, reviewID)
+		if err != nil {
+			return err
+		}
+
+		review.Status = status
+		if err := repos.Reviews().Update(ctx, review); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to moderate review", "review_id", reviewID, "error", err)
+			return fmt.Errorf("failed to moderate review: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully moderated review", "review_id", review.ID)
+	return review, nil
+}
+
+// DeleteReview deletes a review of a book
+func (s *reviewService) DeleteReview(ctx context.Context, bookID, reviewID uint) error {
+	s.logger.InfoContext(ctx, "Deleting review", "review_id", reviewID, "book_id", bookID)
+
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		review, err := s.getBookReview(ctx, repos.Books(), repos.Reviews(), bookID, reviewID)
+		if err != nil {
+			return err
+		}
+		if err := s.authorizer.Authorize(ctx, authz.ActionReviewDelete, review.Reviewer); err != nil {
+			return err
+		}
+
+		if err := repos.Reviews().Delete(ctx, review); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete review", "review_id", reviewID, "error", err)
+			return fmt.Errorf("failed to delete review: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully deleted review", "review_id", reviewID)
+	return nil
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"books-api/app/authz"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// tagService implements the TagService interface
+type tagService struct {
+	tagRepo    repository.TagRepository
+	bookRepo   repository.BookRepository
+	unitOfWork repository.UnitOfWork
+	authorizer authz.Authorizer
+	logger     *slog.Logger
+}
+
+// NewTagService creates a new instance of tag service. Tagging a book counts
+// as updating it and is authorized as such. Writes that change how books
+// are tagged run in a transaction of unitOfWork, which drops those books
+// from the cache once it commits. Records are logged through logger with
+// the call's context.
+func NewTagService(tagRepo repository.TagRepository, bookRepo repository.BookRepository, unitOfWork repository.UnitOfWork, authorizer authz.Authorizer, logger *slog.Logger) TagService {
+	return &tagService{
+		tagRepo:    tagRepo,
+		bookRepo:   bookRepo,
+		unitOfWork: unitOfWork,
+		authorizer: authorizer,
+		logger:     logger,
+	}
+}
+
+// CreateTag creates a new tag with a normalized, unique name
+func (s *tagService) CreateTag(ctx context.Context, tag *models.Tag) error {
+	tag.Name = models.NormalizeTagName(tag.Name)
+	s.logger.InfoContext(ctx, "Creating new tag", "name", tag.Name)
+
+	if tag.Name == "" {
+		return fmt.Errorf("tag name is required")
+	}
+	tag.TenantID = 0
+
+	if _, err := s.tagRepo.GetByName(ctx, tag.Name); err == nil {
+		s.logger.WarnContext(ctx, "Tag already exists", "name", tag.Name)
+		return fmt.Errorf("tag already exists")
+	}
+
+	if err := s.tagRepo.Create(ctx, tag); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create tag", "error", err)
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully created tag", "tag_id", tag.ID)
+	return nil
+}
+
+// GetTagByID retrieves a tag by ID
+func (s *tagService) GetTagByID(ctx context.Context, id uint) (*models.Tag, error) {
+	s.logger.InfoContext(ctx, "Retrieving tag", "tag_id", id)
+
+	tag, err := s.tagRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to retrieve tag", "tag_id", id, "error", err)
+		return nil, fmt.Errorf("tag not found")
+	}
+
+	return tag, nil
+}
+
+// GetAllTags retrieves all tags with their book counts
+func (s *tagService) GetAllTags(ctx context.Context) ([]models.TagCount, error) {
+	s.logger.InfoContext(ctx, "Retrieving all tags")
+
+	tags, err := s.tagRepo.GetAllWithCounts(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve tags", "error", err)
+		return nil, fmt.Errorf("failed to retrieve tags: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully retrieved tags", "count", len(tags))
+	return tags, nil
+}
+
+// UpdateTag renames a tag. Renaming onto an existing tag is rejected; use
+// MergeTags to combine duplicates instead.
+func (s *tagService) UpdateTag(ctx context.Context, id uint, updateData models.Tag) (*models.Tag, error) {
+	s.logger.InfoContext(ctx, "Updating tag", "tag_id", id)
+
+	existingTag, err := s.tagRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Tag not found for update", "tag_id", id, "error", err)
+		return nil, fmt.Errorf("tag not found")
+	}
+
+	name := models.NormalizeTagName(updateData.Name)
+	if name == "" {
+		return nil, fmt.Errorf("tag name is required")
+	}
+
+	if other, err := s.tagRepo.GetByName(ctx, name); err == nil && other.ID != id {
+		s.logger.WarnContext(ctx, "Tag name already in use", "name", name, "tag_id", other.ID)
+		return nil, fmt.Errorf("tag already exists")
+	}
+
+	existingTag.Name = name
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		return repos.Tags().Update(ctx, existingTag)
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to update tag", "tag_id", id, "error", err)
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully updated tag", "name", existingTag.Name)
+	return existingTag, nil
+}
+
+// DeleteTag deletes a tag and detaches it from all books
+func (s *tagService) DeleteTag(ctx context.Context, id uint) error {
+	s.logger.InfoContext(ctx, "Deleting tag", "tag_id", id)
+
+	if _, err := s.tagRepo.GetByID(ctx, id); err != nil {
+		s.logger.WarnContext(ctx, "Tag not found for deletion", "tag_id", id, "error", err)
+		return fmt.Errorf("tag not found")
+	}
+
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		return repos.Tags().Delete(ctx, id)
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to delete tag", "tag_id", id, "error", err)
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully deleted tag", "tag_id", id)
+	return nil
+}
+
+// MergeTags moves every book from the source tag onto the target tag and
+// deletes the source tag
+func (s *tagService) MergeTags(ctx context.Context, sourceID, targetID uint) (*models.Tag, error) {
+	s.logger.InfoContext(ctx, "Merging tags", "source_id", sourceID, "target_id", targetID)
+
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge a tag into itself")
+	}
+
+	if _, err := s.tagRepo.GetByID(ctx, sourceID); err != nil {
+		s.logger.WarnContext(ctx, "Source tag not found for merge", "source_id", sourceID, "error", err)
+		return nil, fmt.Errorf("tag not found")
+	}
+
+	target, err := s.tagRepo.GetByID(ctx, targetID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Target tag not found for merge", "target_id", targetID, "error", err)
+		return nil, fmt.Errorf("tag not found")
+	}
+
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		return repos.Tags().Merge(ctx, sourceID, targetID)
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to merge tags", "source_id", sourceID, "target_id", targetID, "error", err)
+		return nil, fmt.Errorf("failed to merge tags: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully merged tags", "source_id", sourceID, "target", target.Name)
+	return target, nil
+}
+
+// SetBookTags replaces the tags of a book, creating tags that do not exist yet
+func (s *tagService) SetBookTags(ctx context.Context, bookID uint, names []string) (*models.Book, error) {
+	s.logger.InfoContext(ctx, "Setting tags on book", "count", len(names), "book_id", bookID)
+
+	book, err := s.bookRepo.GetByID(ctx, bookID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Book not found for tagging", "book_id", bookID, "error", err)
+		return nil, fmt.Errorf("book not found")
+	}
+	if err := s.authorizer.Authorize(ctx, authz.ActionBookUpdate, book.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	tags := make([]models.Tag, 0, len(names))
+	seen := make(map[string]bool)
+	for _, raw := range names {
+		name := models.NormalizeTagName(raw)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		tag, err := s.tagRepo.GetByName(ctx, name)
+		if err != nil {
+			tag = &models.Tag{Name: name, TenantID: book.TenantID}
+			if err := s.tagRepo.Create(ctx, tag); err != nil {
+				s.logger.ErrorContext(ctx, "Failed to create tag", "name", name, "error", err)
+				return nil, fmt.Errorf("failed to create tag: %w", err)
+			}
+		}
+		tags = append(tags, *tag)
+	}
+
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		return repos.Books().ReplaceTags(ctx, book, tags)
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to set tags on book", "book_id", bookID, "error", err)
+		return nil, fmt.Errorf("failed to set tags: %w", err)
+	}
+
+	book.Tags = tags
+	s.logger.InfoContext(ctx, "Successfully set tags on book", "title", book.Title)
+	return book, nil
+}
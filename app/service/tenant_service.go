@@ -0,0 +1,212 @@
+package service
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/tenancy"
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// tenantSlugPattern accepts slugs that are also valid DNS labels, so every
+// tenant can be addressed by subdomain
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// tenantService implements the TenantService interface
+type tenantService struct {
+	tenantRepo repository.TenantRepository
+	bookRepo   repository.BookRepository
+	unitOfWork repository.UnitOfWork
+	logger     *slog.Logger
+}
+
+// NewTenantService creates a new instance of tenant service that logs
+// through logger with the call's context. Tenants are deleted in a unit of
+// work together with the revocation of their keys.
+func NewTenantService(tenantRepo repository.TenantRepository, bookRepo repository.BookRepository, unitOfWork repository.UnitOfWork, logger *slog.Logger) TenantService {
+	return &tenantService{
+		tenantRepo: tenantRepo,
+		bookRepo:   bookRepo,
+		unitOfWork: unitOfWork,
+		logger:     logger,
+	}
+}
+
+// validateTenant normalizes and checks the fields of a tenant
+func (s *tenantService) validateTenant(tenant *models.Tenant) error {
+	tenant.Slug = strings.ToLower(strings.TrimSpace(tenant.Slug))
+	tenant.Name = strings.TrimSpace(tenant.Name)
+
+	if !tenantSlugPattern.MatchString(tenant.Slug) {
+		return fmt.Errorf("invalid slug: %s", tenant.Slug)
+	}
+	if tenant.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if tenant.MaxBooks < 0 {
+		return fmt.Errorf("max books cannot be negative")
+	}
+	return nil
+}
+
+// usage counts what a tenant consumes of its quotas
+func (s *tenantService) usage(ctx context.Context, tenant *models.Tenant) (*models.TenantUsage, error) {
+	books, err := s.bookRepo.Count(tenancy.WithTenant(ctx, tenant))
+	if err != nil {
+		return nil, err
+	}
+	return &models.TenantUsage{Books: books}, nil
+}
+
+// CreateTenant registers a new tenant
+func (s *tenantService) CreateTenant(ctx context.Context, tenant *models.Tenant) error {
+	s.logger.InfoContext(ctx, "Creating tenant", "slug", tenant.Slug)
+
+	if err := s.validateTenant(tenant); err != nil {
+		s.logger.WarnContext(ctx, "Invalid tenant", "error", err)
+		return err
+	}
+	if _, err := s.tenantRepo.GetBySlug(ctx, tenant.Slug); err == nil {
+		s.logger.WarnContext(ctx, "Tenant slug is already in use", "slug", tenant.Slug)
+		return fmt.Errorf("slug already exists")
+	}
+
+	tenant.ID = 0
+	if err := s.tenantRepo.Create(ctx, tenant); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create tenant", "error", err)
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully created tenant", "tenant_id", tenant.ID)
+	return nil
+}
+
+// GetTenantByID retrieves a tenant together with its quota usage
+func (s *tenantService) GetTenantByID(ctx context.Context, id uint) (*models.Tenant, error) {
+	s.logger.InfoContext(ctx, "Retrieving tenant", "tenant_id", id)
+
+	tenant, err := s.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to retrieve tenant", "tenant_id", id, "error", err)
+		return nil, fmt.Errorf("tenant not found")
+	}
+
+	usage, err := s.usage(ctx, tenant)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to count usage of tenant", "tenant_id", id, "error", err)
+		return nil, fmt.Errorf("failed to retrieve tenant: %w", err)
+	}
+	tenant.Usage = usage
+
+	return tenant, nil
+}
+
+// GetTenantBySlug retrieves the tenant a request selected
+func (s *tenantService) GetTenantBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
+	tenant, err := s.tenantRepo.GetBySlug(ctx, strings.ToLower(slug))
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to retrieve tenant", "slug", slug, "error", err)
+		return nil, fmt.Errorf("tenant not found")
+	}
+	return tenant, nil
+}
+
+// GetAllTenants retrieves all tenants
+func (s *tenantService) GetAllTenants(ctx context.Context) ([]models.Tenant, error) {
+	s.logger.InfoContext(ctx, "Retrieving all tenants")
+
+	tenants, err := s.tenantRepo.GetAll(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve tenants", "error", err)
+		return nil, fmt.Errorf("failed to retrieve tenants: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully retrieved tenants", "count", len(tenants))
+	return tenants, nil
+}
+
+// UpdateTenant renames a tenant or changes its quotas
+func (s *tenantService) UpdateTenant(ctx context.Context, id uint, updateData models.Tenant) (*models.Tenant, error) {
+	s.logger.InfoContext(ctx, "Updating tenant", "tenant_id", id)
+
+	tenant, err := s.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Tenant not found for update", "tenant_id", id, "error", err)
+		return nil, fmt.Errorf("tenant not found")
+	}
+
+	tenant.Update(updateData)
+	if err := s.validateTenant(tenant); err != nil {
+		s.logger.WarnContext(ctx, "Invalid tenant update", "error", err)
+		return nil, err
+	}
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to update tenant", "tenant_id", id, "error", err)
+		return nil, fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Successfully updated tenant", "tenant_id", id)
+	return tenant, nil
+}
+
+// DeleteTenant removes a tenant that owns no books, members, circulation,
+// taxonomy or webhooks. Its API keys are revoked in the same transaction:
+// they name the tenant by slug and would otherwise act for a tenant later
+// created with it. The default tenant is kept because requests without a
+// tenant fall back to it.
+func (s *tenantService) DeleteTenant(ctx context.Context, id uint) error {
+	s.logger.InfoContext(ctx, "Deleting tenant", "tenant_id", id)
+
+	tenant, err := s.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Tenant not found for deletion", "tenant_id", id, "error", err)
+		return fmt.Errorf("tenant not found")
+	}
+	if tenant.ID == models.DefaultTenantID {
+		return fmt.Errorf("default tenant cannot be deleted")
+	}
+
+	usage, err := s.usage(ctx, tenant)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to count usage of tenant", "tenant_id", id, "error", err)
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+	if usage.Books > 0 {
+		return fmt.Errorf("tenant has books")
+	}
+
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		owned, err := repos.Tenants().Owned(ctx, id)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to check data of tenant", "tenant_id", id, "error", err)
+			return fmt.Errorf("failed to delete tenant: %w", err)
+		}
+		if len(owned) > 0 {
+			s.logger.WarnContext(ctx, "Tenant still owns data", "tenant_id", id, "owned", owned)
+			return fmt.Errorf("tenant has %s", owned[0])
+		}
+
+		revoked, err := repos.APIKeys().RevokeByTenant(ctx, tenant.Slug, time.Now())
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to revoke API keys of tenant", "tenant_id", id, "error", err)
+			return fmt.Errorf("failed to delete tenant: %w", err)
+		}
+		if err := repos.Tenants().Delete(ctx, id); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to delete tenant", "tenant_id", id, "error", err)
+			return fmt.Errorf("failed to delete tenant: %w", err)
+		}
+		s.logger.InfoContext(ctx, "Revoked API keys of tenant", "tenant_id", id, "count", revoked)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.InfoContext(ctx, "Successfully deleted tenant", "tenant_id", id)
+	return nil
+}
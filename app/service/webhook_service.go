@@ -0,0 +1,276 @@
+package service
+
+import (
+	"books-api/app/events"
+	"books-api/app/logging"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// webhookSecretPrefix marks generated signing secrets
+	webhookSecretPrefix = "whsec_"
+	// webhookSecretBytes is the amount of randomness in a generated secret
+	webhookSecretBytes = 24
+	// minWebhookSecretLength is the shortest secret a client may choose
+	minWebhookSecretLength = 16
+	// deliveryLogSize is how many of the latest deliveries are listed
+	deliveryLogSize = 100
+)
+
+// webhookService implements the WebhookService interface
+type webhookService struct {
+	webhookRepo  repository.WebhookRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	logger       *slog.Logger
+}
+
+// NewWebhookService creates a new instance of webhook service. It logs
+// through logger with the call's context; target URLs are logged without
+// their path and query, which may carry tokens of the receiver.
+func NewWebhookService(webhookRepo repository.WebhookRepository, deliveryRepo repository.WebhookDeliveryRepository, logger *slog.Logger) WebhookService {
+	return &webhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		logger:       logger,
+	}
+}
+
+// generateWebhookSecret creates a new random signing secret
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// validateWebhookURL checks that a webhook target is an absolute http(s) URL
+func validateWebhookURL(target string) error {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url must be an http or https URL")
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("url must not contain credentials")
+	}
+	return nil
+}
+
+// normalizeWebhookEvents checks the event filter and removes duplicates
+func normalizeWebhookEvents(eventTypes []string) ([]string, error) {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if !events.Type(eventType).IsValid() {
+			return nil, fmt.Errorf("invalid event type: %s", eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+	return unique, nil
+}
+
+// CreateWebhook registers a webhook. Without a secret of its own the
+// webhook gets a generated one; either way the secret is only available in
+// the returned value.
+func (s *webhookService) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.WebhookWithSecret, error) {
+	webhook.URL = strings.TrimSpace(webhook.URL)
+	s.logger.InfoContext(ctx, "Creating webhook", "url", logging.RedactURL(webhook.URL))
+
+	if err := validateWebhookURL(webhook.URL); err != nil {
+		s.logger.WarnContext(ctx, "Invalid webhook URL provided", "url", logging.RedactURL(webhook.URL))
+		return nil, err
+	}
+	eventTypes, err := normalizeWebhookEvents(webhook.Events)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Secret == "" {
+		if webhook.Secret, err = generateWebhookSecret(); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to generate webhook secret", "error", err)
+			return nil, fmt.Errorf("failed to create webhook: %w", err)
+		}
+	} else if len(webhook.Secret) < minWebhookSecretLength {
+		return nil, fmt.Errorf("secret must be at least %d characters", minWebhookSecretLength)
+	}
+
+	webhook.ID = 0
+	webhook.Events = eventTypes
+	webhook.DisabledAt = nil
+	webhook.DisabledReason = ""
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create webhook", "error", err)
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Created webhook", "webhook_id", webhook.ID, "url", logging.RedactURL(webhook.URL))
+	return &models.WebhookWithSecret{Webhook: *webhook, Secret: webhook.Secret}, nil
+}
+
+// GetWebhook retrieves a webhook by its ID
+func (s *webhookService) GetWebhook(ctx context.Context, id uint) (*models.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Webhook not found", "webhook_id", id, "error", err)
+		return nil, fmt.Errorf("webhook not found")
+	}
+	return webhook, nil
+}
+
+// ListWebhooks retrieves all webhooks, including disabled ones
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	s.logger.InfoContext(ctx, "Retrieving all webhooks")
+
+	webhooks, err := s.webhookRepo.GetAll(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve webhooks", "error", err)
+		return nil, fmt.Errorf("failed to retrieve webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// UpdateWebhook changes the given fields of a webhook. Enabling a disabled
+// webhook resumes its pending deliveries; rotating the secret returns the
+// new one.
+func (s *webhookService) UpdateWebhook(ctx context.Context, id uint, update models.WebhookUpdate) (*models.WebhookWithSecret, error) {
+	s.logger.InfoContext(ctx, "Updating webhook", "webhook_id", id)
+
+	webhook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.URL != nil {
+		target := strings.TrimSpace(*update.URL)
+		if err := validateWebhookURL(target); err != nil {
+			s.logger.WarnContext(ctx, "Invalid webhook URL provided", "url", logging.RedactURL(target))
+			return nil, err
+		}
+		webhook.URL = target
+	}
+	if update.Events != nil {
+		eventTypes, err := normalizeWebhookEvents(*update.Events)
+		if err != nil {
+			return nil, err
+		}
+		webhook.Events = eventTypes
+	}
+	if update.Description != nil {
+		webhook.Description = *update.Description
+	}
+	if update.Active != nil {
+		switch {
+		case *update.Active && !webhook.IsActive():
+			webhook.DisabledAt = nil
+			webhook.DisabledReason = ""
+		case !*update.Active && webhook.IsActive():
+			now := time.Now()
+			webhook.DisabledAt = &now
+			webhook.DisabledReason = "disabled by client"
+		}
+	}
+	var secret string
+	if update.RotateSecret {
+		if secret, err = generateWebhookSecret(); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to generate webhook secret", "error", err)
+			return nil, fmt.Errorf("failed to update webhook: %w", err)
+		}
+		webhook.Secret = secret
+	}
+
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to update webhook", "webhook_id", id, "error", err)
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Updated webhook", "webhook_id", id)
+	return &models.WebhookWithSecret{Webhook: *webhook, Secret: secret}, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+func (s *webhookService) DeleteWebhook(ctx context.Context, id uint) error {
+	s.logger.InfoContext(ctx, "Deleting webhook", "webhook_id", id)
+
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.Delete(ctx, id); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to delete webhook", "webhook_id", id, "error", err)
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "Deleted webhook", "webhook_id", id)
+	return nil
+}
+
+// ListDeliveries retrieves the latest deliveries of a webhook, newest first
+func (s *webhookService) ListDeliveries(ctx context.Context, webhookID uint) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.deliveryRepo.GetByWebhook(ctx, webhookID, deliveryLogSize)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to retrieve deliveries of webhook", "webhook_id", webhookID, "error", err)
+		return nil, fmt.Errorf("failed to retrieve deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// GetDelivery retrieves a delivery and checks that it belongs to the webhook
+func (s *webhookService) GetDelivery(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil || delivery.WebhookID != webhookID {
+		s.logger.WarnContext(ctx, "Delivery not found for webhook", "delivery_id", deliveryID, "webhook_id", webhookID, "error", err)
+		return nil, fmt.Errorf("delivery not found")
+	}
+	return delivery, nil
+}
+
+// Redeliver queues a delivery again with its original payload and a fresh
+// attempt budget
+func (s *webhookService) Redeliver(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	s.logger.InfoContext(ctx, "Redelivering delivery", "delivery_id", deliveryID, "webhook_id", webhookID)
+
+	webhook, err := s.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.IsActive() {
+		return nil, fmt.Errorf("webhook is disabled")
+	}
+	delivery, err := s.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.Error = ""
+	delivery.DeliveredAt = nil
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to queue delivery again", "delivery_id", deliveryID, "error", err)
+		return nil, fmt.Errorf("failed to redeliver: %w", err)
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,201 @@
+package stream
+
+import (
+	"books-api/app/events"
+	"books-api/app/models"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrLagged ends a subscription whose client fell ClientBuffer events
+	// behind; the client can resume from its last event
+	ErrLagged = errors.New("client too slow")
+	// ErrClosed ends the subscriptions of a closed broker
+	ErrClosed = errors.New("stream closed")
+)
+
+// Filter selects the events a client receives; zero fields match anything
+type Filter struct {
+	// TenantID limits the events to one tenant
+	TenantID uint
+	Types    []events.Type
+	BookID   uint
+	// Author matches the book's author, ignoring case
+	Author string
+	Color  models.Color
+}
+
+// Matches reports whether event passes the filter
+func (f Filter) Matches(event events.Event) bool {
+	if f.TenantID != 0 && event.TenantID != f.TenantID {
+		return false
+	}
+	if len(f.Types) > 0 {
+		found := false
+		for _, eventType := range f.Types {
+			found = found || eventType == event.Type
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.BookID != 0 && event.BookID != f.BookID {
+		return false
+	}
+	if f.Author != "" && (event.Book == nil || !strings.EqualFold(event.Book.Author, f.Author)) {
+		return false
+	}
+	if f.Color != "" && (event.Book == nil || event.Book.Color == nil || *event.Book.Color != f.Color) {
+		return false
+	}
+	return true
+}
+
+// Subscription is one client's view of the feed
+type Subscription struct {
+	// Replay holds the buffered events after the client's last event
+	Replay []events.Event
+	// Reset is set when the client's last event is no longer buffered, so
+	// it may have missed events and has to reload instead
+	Reset bool
+
+	broker *Broker
+	filter Filter
+	events chan events.Event
+	err    error
+}
+
+// Events delivers live events until the subscription ends. The channel is
+// then closed and Err tells why.
+func (s *Subscription) Events() <-chan events.Event {
+	return s.events
+}
+
+// Err returns why the subscription ended, once Events is closed
+func (s *Subscription) Err() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.end(s, nil)
+}
+
+// Broker fans the book events out to connected clients. It keeps the last
+// BufferSize events so reconnecting clients can catch up, and never blocks
+// on a client: one whose queue is full is disconnected with ErrLagged.
+type Broker struct {
+	config Config
+
+	mu            sync.Mutex
+	buffer        []events.Event
+	buffered      map[uint]bool
+	subscriptions map[*Subscription]bool
+	closed        bool
+}
+
+// NewBroker creates a broker without events or clients
+func NewBroker(config Config) *Broker {
+	return &Broker{
+		config:        config,
+		buffered:      make(map[uint]bool),
+		subscriptions: make(map[*Subscription]bool),
+	}
+}
+
+// Publish buffers an event and queues it for the matching clients. Events
+// delivered again by the dispatcher are ignored. Its signature makes it an
+// events.Handler.
+func (b *Broker) Publish(ctx context.Context, event events.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed || b.buffered[event.ID] {
+		return nil
+	}
+
+	b.buffer = append(b.buffer, event)
+	b.buffered[event.ID] = true
+	if len(b.buffer) > b.config.BufferSize {
+		delete(b.buffered, b.buffer[0].ID)
+		b.buffer = b.buffer[1:]
+	}
+
+	for subscription := range b.subscriptions {
+		if !subscription.filter.Matches(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			b.end(subscription, ErrLagged)
+		}
+	}
+	return nil
+}
+
+// Subscribe starts a subscription for the events matching filter. A
+// non-zero lastEventID resumes after that event: the buffered events since
+// then are in Replay, or Reset is set if it is no longer buffered.
+func (b *Broker) Subscribe(filter Filter, lastEventID uint) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	subscription := &Subscription{
+		broker: b,
+		filter: filter,
+		events: make(chan events.Event, b.config.ClientBuffer),
+	}
+	if lastEventID != 0 {
+		if b.buffered[lastEventID] {
+			after := false
+			for _, event := range b.buffer {
+				if after && filter.Matches(event) {
+					subscription.Replay = append(subscription.Replay, event)
+				}
+				after = after || event.ID == lastEventID
+			}
+		} else {
+			subscription.Reset = true
+		}
+	}
+	b.subscriptions[subscription] = true
+	return subscription, nil
+}
+
+// Clients returns the number of connected clients
+func (b *Broker) Clients() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscriptions)
+}
+
+// Close ends every subscription with ErrClosed and refuses new ones, so
+// streaming handlers return when the server shuts down
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for subscription := range b.subscriptions {
+		b.end(subscription, ErrClosed)
+	}
+}
+
+// end removes a subscription; b.mu must be held
+func (b *Broker) end(subscription *Subscription, err error) {
+	if !b.subscriptions[subscription] {
+		return
+	}
+	delete(b.subscriptions, subscription)
+	subscription.err = err
+	close(subscription.events)
+}
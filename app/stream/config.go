@@ -0,0 +1,62 @@
+package stream
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Config controls the live change feed
+type Config struct {
+	// BufferSize is the number of recent events kept for clients resuming
+	// with Last-Event-ID
+	BufferSize int
+	// ClientBuffer is the number of events queued for a client before it
+	// counts as too slow and is disconnected
+	ClientBuffer int
+	// Heartbeat is how often idle connections are pinged
+	Heartbeat time.Duration
+}
+
+// DefaultConfig returns the config used unless configured otherwise
+func DefaultConfig() Config {
+	return Config{
+		BufferSize:   1000,
+		ClientBuffer: 64,
+		Heartbeat:    15 * time.Second,
+	}
+}
+
+// LoadConfig builds a config from environment style lookups:
+//
+//	STREAM_BUFFER_SIZE     events kept for resuming clients, 1000 unless set
+//	STREAM_CLIENT_BUFFER   events queued per client, 64 unless set
+//	STREAM_HEARTBEAT       ping interval of idle connections, 15s unless set
+func LoadConfig(lookup func(string) (string, bool)) (Config, error) {
+	config := DefaultConfig()
+
+	sizes := []struct {
+		name  string
+		value *int
+	}{
+		{"STREAM_BUFFER_SIZE", &config.BufferSize},
+		{"STREAM_CLIENT_BUFFER", &config.ClientBuffer},
+	}
+	for _, size := range sizes {
+		if value, ok := lookup(size.name); ok && value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				return Config{}, fmt.Errorf("%s: must be a positive number", size.name)
+			}
+			*size.value = parsed
+		}
+	}
+	if value, ok := lookup("STREAM_HEARTBEAT"); ok && value != "" {
+		heartbeat, err := time.ParseDuration(value)
+		if err != nil || heartbeat <= 0 {
+			return Config{}, fmt.Errorf("STREAM_HEARTBEAT: invalid duration %s", value)
+		}
+		config.Heartbeat = heartbeat
+	}
+	return config, nil
+}
@@ -0,0 +1,21 @@
+package tenancy
+
+import (
+	"books-api/app/models"
+	"context"
+)
+
+// tenantKey is the context key under which the tenant is stored
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx acting for tenant. Queries run with the
+// returned context only see rows of that tenant.
+func WithTenant(ctx context.Context, tenant *models.Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// FromContext returns the tenant stored in ctx, if any
+func FromContext(ctx context.Context) (*models.Tenant, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(*models.Tenant)
+	return tenant, ok && tenant != nil
+}
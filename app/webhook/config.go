@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Config controls how deliveries are made to webhooks
+type Config struct {
+	// PollInterval is how often due deliveries are looked for
+	PollInterval time.Duration
+	// BatchSize is the number of deliveries attempted per poll
+	BatchSize int
+	// MaxAttempts is the number of failed attempts after which a delivery
+	// fails and its webhook is disabled
+	MaxAttempts int
+	// RetryBase is the delay after the first failed attempt; it doubles
+	// with every further failure up to RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Timeout limits a single attempt
+	Timeout time.Duration
+	// AllowPrivateTargets permits webhooks on loopback, private and link
+	// local addresses, which are refused so that webhooks cannot reach
+	// internal services
+	AllowPrivateTargets bool
+}
+
+// DefaultConfig returns the config used unless configured otherwise
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Second,
+		BatchSize:    100,
+		MaxAttempts:  8,
+		RetryBase:    30 * time.Second,
+		RetryMax:     time.Hour,
+		Timeout:      10 * time.Second,
+	}
+}
+
+// LoadConfig builds a config from environment style lookups:
+//
+//	WEBHOOK_MAX_ATTEMPTS            attempts before a webhook is disabled, 8 unless set
+//	WEBHOOK_TIMEOUT                 limit of one attempt, 10s unless set
+//	WEBHOOK_ALLOW_PRIVATE_TARGETS   true allows webhooks on internal addresses
+func LoadConfig(lookup func(string) (string, bool)) (Config, error) {
+	config := DefaultConfig()
+
+	if value, ok := lookup("WEBHOOK_MAX_ATTEMPTS"); ok && value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts < 1 {
+			return Config{}, fmt.Errorf("WEBHOOK_MAX_ATTEMPTS: must be a positive number")
+		}
+		config.MaxAttempts = attempts
+	}
+	if value, ok := lookup("WEBHOOK_TIMEOUT"); ok && value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return Config{}, fmt.Errorf("WEBHOOK_TIMEOUT: invalid duration %s", value)
+		}
+		config.Timeout = timeout
+	}
+	if value, ok := lookup("WEBHOOK_ALLOW_PRIVATE_TARGETS"); ok && value != "" {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("WEBHOOK_ALLOW_PRIVATE_TARGETS: must be true or false")
+		}
+		config.AllowPrivateTargets = allow
+	}
+	return config, nil
+}
+
+// backoff is the delay before the next attempt after the given number of
+// failed ones
+func (c Config) backoff(attempts int) time.Duration {
+	delay := c.RetryBase
+	for i := 1; i < attempts && delay < c.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, c.RetryMax)
+}
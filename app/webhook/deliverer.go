@@ -0,0 +1,234 @@
+package webhook
+
+import (
+	"books-api/app/logging"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// responseBodyLimit is how much of a response is kept in the delivery log
+const responseBodyLimit = 1024
+
+// Deliverer posts queued deliveries to their webhooks. Failed attempts are
+// retried with exponential backoff; after MaxAttempts the delivery fails
+// and its webhook is disabled, holding back its other deliveries until the
+// webhook is enabled again.
+type Deliverer struct {
+	webhooks   repository.WebhookRepository
+	deliveries repository.WebhookDeliveryRepository
+	client     *http.Client
+	config     Config
+	logger     *slog.Logger
+}
+
+// NewDeliverer creates a deliverer for the deliveries queued in deliveries.
+// Failures are logged through logger.
+func NewDeliverer(webhooks repository.WebhookRepository, deliveries repository.WebhookDeliveryRepository, config Config, logger *slog.Logger) *Deliverer {
+	return &Deliverer{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		client:     newClient(config),
+		config:     config,
+		logger:     logger,
+	}
+}
+
+// newClient creates the HTTP client for deliveries. It does not follow
+// redirects, which count as failures, and unless private targets are
+// allowed it refuses to connect to internal addresses. The check runs on
+// the resolved address, so a public name pointing inside is refused too.
+func newClient(config Config) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateTargets {
+		dialer.Control = refuseInternal
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refuseInternal fails dials to loopback, private, link local and other
+// non public addresses
+func refuseInternal(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("refusing to connect to internal address %s", host)
+	}
+	return nil
+}
+
+// Run delivers every PollInterval until ctx is done
+func (d *Deliverer) Run(ctx context.Context) {
+	poll := time.NewTicker(d.config.PollInterval)
+	defer poll.Stop()
+
+	for {
+		if _, err := d.Deliver(ctx); err != nil && ctx.Err() == nil {
+			d.logger.ErrorContext(ctx, "Webhook delivery failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// Deliver attempts one batch of due deliveries and returns how many
+// succeeded. The deliveries of different webhooks are made concurrently,
+// those of one webhook in order; after a failed attempt the webhook's
+// remaining deliveries wait for the next poll.
+func (d *Deliverer) Deliver(ctx context.Context) (int, error) {
+	due, err := d.deliveries.GetDue(ctx, time.Now(), d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var order []uint
+	groups := make(map[uint][]models.WebhookDelivery)
+	for _, delivery := range due {
+		if _, ok := groups[delivery.WebhookID]; !ok {
+			order = append(order, delivery.WebhookID)
+		}
+		groups[delivery.WebhookID] = append(groups[delivery.WebhookID], delivery)
+	}
+
+	var succeeded atomic.Int64
+	var wg sync.WaitGroup
+	errs := make([]error, len(order))
+	for i, webhookID := range order {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count, err := d.deliverGroup(ctx, webhookID, groups[webhookID])
+			succeeded.Add(int64(count))
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+	return int(succeeded.Load()), errors.Join(errs...)
+}
+
+// deliverGroup makes the deliveries of one webhook in order until one
+// fails
+func (d *Deliverer) deliverGroup(ctx context.Context, webhookID uint, deliveries []models.WebhookDelivery) (int, error) {
+	webhook, err := d.webhooks.GetByID(ctx, webhookID)
+	if err != nil {
+		return 0, err
+	}
+
+	succeeded := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		ok := d.attempt(ctx, webhook, delivery)
+		if ctx.Err() != nil {
+			return succeeded, ctx.Err()
+		}
+		if err := d.deliveries.Update(ctx, delivery); err != nil {
+			return succeeded, err
+		}
+		if !ok {
+			if delivery.Status == models.WebhookDeliveryFailed {
+				return succeeded, d.disable(ctx, webhook, delivery)
+			}
+			return succeeded, nil
+		}
+		succeeded++
+	}
+	return succeeded, nil
+}
+
+// attempt posts a delivery once and records the outcome on it
+func (d *Deliverer) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) bool {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	status, body, err := d.post(ctx, webhook, delivery, now)
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	if err == nil {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.Error = ""
+		return true
+	}
+
+	delivery.Error = err.Error()
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		d.logger.ErrorContext(ctx, "Giving up on webhook delivery", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+		return false
+	}
+	delivery.NextAttemptAt = now.Add(d.config.backoff(delivery.Attempts))
+	d.logger.WarnContext(ctx, "Webhook delivery attempt failed", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "retry_at", delivery.NextAttemptAt, "error", err)
+	return false
+}
+
+// post sends the signed payload and returns the response status and the
+// start of its body. Any status other than 2xx is an error.
+func (d *Deliverer) post(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "books-api-webhooks")
+	req.Header.Set(HeaderID, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		// Transport errors quote the target, whose path may carry a token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = logging.RedactURL(urlErr.URL)
+		}
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, responseBodyLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(excerpt), fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return resp.StatusCode, string(excerpt), nil
+}
+
+// disable turns off a webhook whose delivery ran out of attempts
+func (d *Deliverer) disable(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	webhook.DisabledAt = &now
+	webhook.DisabledReason = fmt.Sprintf("delivery %d failed %d times: %s", delivery.ID, delivery.Attempts, delivery.Error)
+	d.logger.ErrorContext(ctx, "Disabled failing webhook", "webhook_id", webhook.ID, "reason", webhook.DisabledReason)
+	return d.webhooks.Disable(ctx, webhook.ID, now, webhook.DisabledReason)
+}
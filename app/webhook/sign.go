@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	// HeaderID carries the delivery ID, which stays the same across
+	// attempts and redeliveries
+	HeaderID = "X-Webhook-ID"
+	// HeaderEvent carries the event type
+	HeaderEvent = "X-Webhook-Event"
+	// HeaderTimestamp carries the Unix time the attempt was signed at
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature carries the signature of the timestamp and body
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// signaturePrefix names the algorithm in the signature header
+const signaturePrefix = "sha256="
+
+// Sign returns the signature header value for a body sent at timestamp:
+// "sha256=" followed by the hex encoded HMAC-SHA256, keyed with the
+// webhook secret, of the Unix timestamp, a dot and the body. Signing the
+// timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the timestamp and signature headers of a delivery as a
+// receiver would. Deliveries signed more than tolerance away from now are
+// rejected.
+func Verify(secret, timestamp, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid timestamp")
+	}
+	signedAt := time.Unix(seconds, 0)
+	if now.Sub(signedAt) > tolerance || signedAt.Sub(now) > tolerance {
+		return errors.New("timestamp outside tolerance")
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return errors.New("unsupported signature")
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, signedAt, body))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
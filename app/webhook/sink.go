@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"books-api/app/events"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Sink is the events sink that queues a delivery of every event for each
+// enabled webhook of the event's tenant that accepts its type. Deliveries
+// are keyed by webhook and event, so an event the dispatcher hands over
+// again is not queued twice.
+type Sink struct {
+	webhooks   repository.WebhookRepository
+	deliveries repository.WebhookDeliveryRepository
+}
+
+// NewSink creates a sink queueing deliveries in deliveries for the
+// webhooks in webhooks
+func NewSink(webhooks repository.WebhookRepository, deliveries repository.WebhookDeliveryRepository) *Sink {
+	return &Sink{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+	}
+}
+
+func (s *Sink) Name() string {
+	return "webhooks"
+}
+
+// Deliver queues the event for the matching webhooks. The payload is the
+// event as JSON, fixed now so every attempt posts the same body.
+func (s *Sink) Deliver(ctx context.Context, event events.Event) error {
+	webhooks, err := s.webhooks.GetActiveByTenant(ctx, event.TenantID)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []models.WebhookDelivery
+	var payload []byte
+	now := time.Now()
+	for _, webhook := range webhooks {
+		if !webhook.Accepts(string(event.Type)) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     string(event.Type),
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			TenantID:      webhook.TenantID,
+		})
+	}
+	return s.deliveries.CreateMissing(ctx, deliveries)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"books-api/app/backup"
+	"books-api/app/database"
+	"books-api/app/migrations"
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// backupUsage describes the backup and restore subcommands
+const backupUsage = `usage:
+  books-api backup [create]
+  books-api backup list
+  books-api backup verify FILE
+  books-api restore FILE`
+
+// runBackupCommand creates, lists and verifies snapshots from the command
+// line, e.g. from cron
+func runBackupCommand(args []string, backups *backup.Manager, migrationManager migrations.MigrationManager, out io.Writer) error {
+	command := "create"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "create":
+		snapshot, err := backups.Create(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Created backup %s (%d bytes, schema version %d)\n", snapshot.Name, snapshot.Size, snapshot.SchemaVersion)
+		fmt.Fprintf(out, "SHA-256: %s\n", snapshot.SHA256)
+		return nil
+
+	case "list":
+		snapshots, err := backups.List()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSIZE\tSCHEMA\tCREATED")
+		for _, snapshot := range snapshots {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", snapshot.Name, snapshot.Size, snapshot.SchemaVersion, snapshot.CreatedAt.Format(time.RFC3339))
+		}
+		return w.Flush()
+
+	case "verify":
+		if len(args) != 2 {
+			return fmt.Errorf("verify takes exactly one snapshot file\n%s", backupUsage)
+		}
+		snapshot, err := backup.Verify(args[1], migrationManager)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Backup %s is intact (schema version %d)\n", snapshot.Name, snapshot.SchemaVersion)
+		return nil
+	}
+
+	return fmt.Errorf("unknown backup command %q\n%s", command, backupUsage)
+}
+
+// runRestoreCommand replaces the SQLite database file with a snapshot. It
+// runs before the database is opened; the API must not be running.
+func runRestoreCommand(args []string, dbConfig database.Config, migrationManager migrations.MigrationManager, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("restore takes exactly one snapshot file\n%s", backupUsage)
+	}
+	target, ok := dbConfig.SQLiteFile()
+	if !ok {
+		return backup.ErrUnsupported
+	}
+
+	snapshot, err := backup.Restore(args[0], target, migrationManager)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Restored %s from %s (schema version %d)\n", target, snapshot.Name, snapshot.SchemaVersion)
+	if snapshot.SchemaVersion < migrationManager.Version() {
+		fmt.Fprintf(out, "It is migrated to schema version %d when the API starts\n", migrationManager.Version())
+	}
+	return nil
+}
@@ -7,90 +7,729 @@
 // @license.name MIT
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	_ "books-api/docs"
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/backup"
+	"books-api/app/cache"
 	"books-api/app/controller"
+	"books-api/app/database"
+	"books-api/app/events"
+	"books-api/app/graph"
+	"books-api/app/logging"
+	"books-api/app/middleware"
 	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/ratelimit"
 	"books-api/app/repository"
+	"books-api/app/rpc"
+	"books-api/app/server"
+	"books-api/app/stream"
 	"books-api/app/service"
+	"books-api/app/tenancy"
+	"books-api/app/webhook"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 func main() {
-	// Initialize database connection
-	db, err := initDB()
+	// Structured logging configured by LOG_FORMAT and LOG_LEVEL; the
+	// standard logger goes through it as well
+	logConfig, err := logging.LoadConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure logging:", err)
+	}
+	sqlConfig, err := logging.LoadSQLConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure SQL logging:", err)
+	}
+
+	// The level and SQL logging can be switched at runtime via /admin/logging
+	logSwitches := logging.NewSwitches(logConfig, sqlConfig)
+	logConfig.Level = logSwitches.Level()
+	logger := logging.New(os.Stdout, logConfig)
+	slog.SetDefault(logger)
+
+	// Initialize database connection to DATABASE_URL
+	dbConfig, err := database.LoadConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure database:", err)
+	}
+
+	// Restoring replaces the database file, so it happens before it is opened
+	migrationManager := migrations.NewMigrationManager(logger)
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if err := runRestoreCommand(os.Args[2:], dbConfig, migrationManager, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	db, err := initDB(dbConfig, logging.NewSQLLogger(logger, logSwitches, sqlConfig.RedactParams), logger)
 	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
 	// Run migrations
-	migrationManager := migrations.NewMigrationManager()
 	if err := migrationManager.RunMigrations(db); err != nil {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
 	// Initialize layers
 	bookRepo := repository.NewBookRepository(db)
-	bookService := service.NewBookService(bookRepo)
-	bookController := controller.NewBookController(bookService)
 
-	// Initialize Gin router
-	r := gin.Default()
+	// Books are read through a cache sized by BOOK_CACHE_SIZE and BOOK_CACHE_TTL
+	cacheConfig, err := cache.LoadConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure book cache:", err)
+	}
+	var cachedBookRepo *repository.CachedBookRepository
+	if cacheConfig.Enabled() {
+		cachedBookRepo = repository.NewCachedBookRepository(bookRepo, cache.NewMemoryStore(cacheConfig.Size), cacheConfig.TTL)
+		bookRepo = cachedBookRepo
+	}
 
-	// Setup routes
-	setupRoutes(r, bookController)
+	tagRepo := repository.NewTagRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+	copyRepo := repository.NewCopyRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+	holdRepo := repository.NewHoldRepository(db)
+	memberRepo := repository.NewMemberRepository(db)
+	fineRepo := repository.NewFineRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	tenantRepo := repository.NewTenantRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(db)
 
-	// Get port from environment variable or default to 8080
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	circulationPolicy := service.DefaultCirculationPolicy()
+
+	// Book operations are authorized against AUTHZ_POLICY_FILE or the default policy
+	policy := authz.DefaultPolicy()
+	if path := os.Getenv("AUTHZ_POLICY_FILE"); path != "" {
+		if policy, err = authz.LoadPolicy(path); err != nil {
+			log.Fatal("Failed to load authorization policy:", err)
+		}
 	}
 
-	// Start server
-	log.Printf("Starting server on :%s", port)
-	if err := r.Run(":" + port); err != nil {
+	authorizer := authz.NewAuthorizer(policy, logger)
+
+	// Writes spanning several repositories share one transaction
+	unitOfWork := repository.NewUnitOfWork(db, cachedBookRepo, logger)
+
+	bookService := service.NewBookService(bookRepo, unitOfWork, authorizer, logger)
+	tagService := service.NewTagService(tagRepo, bookRepo, unitOfWork, authorizer, logger)
+	categoryService := service.NewCategoryService(categoryRepo, bookRepo, unitOfWork, authorizer, logger)
+	reviewService := service.NewReviewService(reviewRepo, bookRepo, unitOfWork, authorizer, logger)
+	copyService := service.NewCopyService(copyRepo, loanRepo, bookRepo, unitOfWork, circulationPolicy, logger)
+	loanService := service.NewLoanService(loanRepo, unitOfWork, circulationPolicy, logger)
+	holdService := service.NewHoldService(holdRepo, unitOfWork, circulationPolicy, logger)
+	memberService := service.NewMemberService(memberRepo, loanRepo, fineRepo, unitOfWork, circulationPolicy, logger)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, tenantRepo, logger)
+	tenantService := service.NewTenantService(tenantRepo, bookRepo, unitOfWork, logger)
+	webhookService := service.NewWebhookService(webhookRepo, webhookDeliveryRepo, logger)
+	bookChangeService := service.NewBookChangeService(repository.NewBookChangeRepository(db), authorizer, logger)
+
+	// Command line key management, e.g. to issue the first admin key
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := runAPIKeyCommand(os.Args[2:], apiKeyService, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Snapshots go to BACKUP_DIR, keeping the newest BACKUP_KEEP
+	backupConfig, err := backup.LoadConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure backups:", err)
+	}
+	backups := backup.NewManager(db, backupConfig, migrationManager)
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		if err := runBackupCommand(os.Args[2:], backups, migrationManager, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// GraphQL queries are limited by GRAPHQL_MAX_DEPTH and GRAPHQL_MAX_COMPLEXITY
+	graphConfig, err := graph.LoadConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure GraphQL:", err)
+	}
+	graphServer, err := graph.NewServer(bookService, reviewService, graphConfig)
+	if err != nil {
+		log.Fatal("Failed to create GraphQL server:", err)
+	}
+
+	controllers := appControllers{
+		book:     controller.NewBookController(bookService),
+		changes:  controller.NewBookChangeController(bookChangeService),
+		tag:      controller.NewTagController(tagService),
+		category: controller.NewCategoryController(categoryService),
+		review:   controller.NewReviewController(reviewService),
+		copy:     controller.NewCopyController(copyService),
+		loan:     controller.NewLoanController(loanService),
+		hold:     controller.NewHoldController(holdService),
+		member:   controller.NewMemberController(memberService),
+		apiKey:   controller.NewAPIKeyController(apiKeyService),
+		tenant:   controller.NewTenantController(tenantService),
+		webhook:  controller.NewWebhookController(webhookService),
+		logging:  controller.NewLoggingController(logSwitches, logger),
+		backup:   controller.NewBackupController(backups),
+		cache:    controller.NewCacheController(cachedBookRepo),
+		graphql:  controller.NewGraphQLController(graphServer),
+	}
+
+	// Bearer tokens are accepted when a JWT key source is configured
+	tokenVerifier, err := newTokenVerifier(logger)
+	if err != nil {
+		log.Fatal("Failed to configure JWT authentication:", err)
+	}
+
+	// Reads stay public unless ALLOW_ANONYMOUS_READS=false
+	authenticator := middleware.NewAuthenticator(apiKeyService, tokenVerifier, os.Getenv("ALLOW_ANONYMOUS_READS") != "false")
+
+	// TLS_* and HTTP3 switch to native TLS; see server.LoadConfig
+	serverConfig, err := server.LoadConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure server:", err)
+	}
+
+	// Verified client certificates authenticate with TLS_CLIENT_SCOPES
+	if value := os.Getenv("TLS_CLIENT_SCOPES"); value != "" {
+		if serverConfig.ClientCAFile == "" {
+			log.Fatal("TLS_CLIENT_SCOPES requires TLS_CLIENT_CA_FILE")
+		}
+		var scopes []models.Scope
+		for _, name := range strings.Split(value, ",") {
+			scope := models.Scope(strings.TrimSpace(name))
+			if !scope.IsValid() {
+				log.Fatal("Invalid scope in TLS_CLIENT_SCOPES: ", scope)
+			}
+			scopes = append(scopes, scope)
+		}
+		authenticator.AcceptClientCertificates(scopes)
+	}
+
+	// Requests without a tenant use DEFAULT_TENANT; setting it empty makes
+	// the tenant mandatory
+	fallbackTenant, ok := os.LookupEnv("DEFAULT_TENANT")
+	if !ok {
+		fallbackTenant = models.DefaultTenantSlug
+	}
+	tenantResolver := middleware.NewTenantResolver(tenantService, os.Getenv("TENANT_BASE_DOMAIN"), fallbackTenant)
+
+	// Throttle callers per route group; RATE_LIMIT and RATE_LIMIT_<GROUP>
+	// override the default, e.g. RATE_LIMIT_BOOKS=600/1m or RATE_LIMIT=off
+	rateLimits, err := ratelimit.LoadConfig(os.LookupEnv, defaultRateLimit, rateLimitGroups...)
+	if err != nil {
+		log.Fatal("Failed to configure rate limits:", err)
+	}
+	// Many callers can share one address, so the per client limit is looser
+	if _, ok := os.LookupEnv("RATE_LIMIT_CLIENTS"); !ok && !rateLimits.Default.IsUnlimited() {
+		rateLimits.Groups["clients"] = defaultClientRateLimit
+	}
+	rateLimiter := middleware.NewRateLimiter(ratelimit.NewMemoryStore(), rateLimits, logger)
+
+	// Expire holds whose pickup window has passed
+	go runHoldExpiry(holdService, time.Minute, logger)
+
+	// Tombstones of deleted books are kept for BOOK_TOMBSTONE_RETENTION,
+	// 720h unless set; older sync tokens have to start over
+	tombstoneRetention := 30 * 24 * time.Hour
+	if value := os.Getenv("BOOK_TOMBSTONE_RETENTION"); value != "" {
+		if tombstoneRetention, err = time.ParseDuration(value); err != nil || tombstoneRetention <= 0 {
+			log.Fatal("Failed to parse BOOK_TOMBSTONE_RETENTION: ", value)
+		}
+	}
+	go runTombstonePurge(bookChangeService, tombstoneRetention, time.Hour, logger)
+
+	// Book events are delivered from the outbox to subscribers in this
+	// process and to OUTBOX_WEBHOOK_URL and OUTBOX_FILE if set
+	outboxConfig, err := events.LoadConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure outbox:", err)
+	}
+	subscribers := events.NewSubscribers()
+	sinks := []events.Sink{subscribers, webhook.NewSink(webhookRepo, webhookDeliveryRepo)}
+	dispatcher := events.NewDispatcher(repository.NewOutboxRepository(db), append(sinks, outboxConfig.Sinks()...), outboxConfig, logger)
+
+	// Events queued for registered webhooks are posted by the deliverer;
+	// see webhook.LoadConfig
+	webhookConfig, err := webhook.LoadConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure webhooks:", err)
+	}
+	deliverer := webhook.NewDeliverer(webhookRepo, webhookDeliveryRepo, webhookConfig, logger)
+
+	// Connected clients follow book changes live; see stream.LoadConfig
+	streamConfig, err := stream.LoadConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure change streams:", err)
+	}
+	broker := stream.NewBroker(streamConfig)
+	subscribers.Subscribe(broker.Publish)
+
+	// Initialize Gin router; every request gets an ID and an access log line
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.RequestLogger(logger), middleware.Recovery(logger))
+
+	// Client IPs, which anonymous callers are rate limited by, are only taken
+	// from forwarding headers set by TRUSTED_PROXIES
+	var trustedProxies []string
+	if value := os.Getenv("TRUSTED_PROXIES"); value != "" {
+		trustedProxies = strings.Split(value, ",")
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("Failed to configure trusted proxies:", err)
+	}
+
+	// Browser access and response hardening
+	corsConfig, err := newCORSConfig(logger)
+	if err != nil {
+		log.Fatal("Failed to configure CORS:", err)
+	}
+	securityHeaders := middleware.DefaultSecurityHeadersConfig()
+	if value := os.Getenv("HSTS_MAX_AGE"); value != "" {
+		if securityHeaders.HSTSMaxAge, err = time.ParseDuration(value); err != nil {
+			log.Fatal("Failed to parse HSTS_MAX_AGE:", err)
+		}
+	}
+	securityHeaders.HSTSIncludeSubdomains = os.Getenv("HSTS_INCLUDE_SUBDOMAINS") == "true"
+
+	// WebSocket connections from other origins follow the CORS origins
+	feedService := service.NewBookFeedService(broker, authorizer, logger)
+	controllers.stream = controller.NewStreamController(feedService, streamConfig.Heartbeat, corsConfig.AllowsOrigin)
+
+	// Request bodies are capped at MAX_BODY_BYTES
+	maxBodyBytes := middleware.DefaultMaxBodyBytes
+	if value := os.Getenv("MAX_BODY_BYTES"); value != "" {
+		if maxBodyBytes, err = strconv.ParseInt(value, 10, 64); err != nil || maxBodyBytes <= 0 {
+			log.Fatal("Failed to parse MAX_BODY_BYTES: ", value)
+		}
+	}
+
+	// books.v1 is served over gRPC and, when enabled, as JSON under /v1;
+	// see rpc.LoadConfig
+	rpcConfig, err := rpc.LoadConfig(os.LookupEnv)
+	if err != nil {
+		log.Fatal("Failed to configure gRPC:", err)
+	}
+	bookServer := rpc.NewBookServer(bookService, feedService, authenticator.AllowsAnonymous)
+	if rpcConfig.Gateway {
+		if controllers.gateway, err = rpc.NewGateway(context.Background(), bookServer); err != nil {
+			log.Fatal("Failed to configure gRPC gateway:", err)
+		}
+	}
+
+	// Setup routes
+	setupRoutes(r, controllers, appMiddleware{
+		securityHeaders: middleware.SecurityHeaders(securityHeaders),
+		cors:            middleware.CORS(corsConfig),
+		bodyLimit:       middleware.MaxBodySize(maxBodyBytes),
+		authenticator:   authenticator,
+		tenantResolver:  tenantResolver,
+		rateLimiter:     rateLimiter,
+	})
+	logger.Info("Routes configured successfully")
+
+	// Start server on PORT, 8080 unless set; it stops gracefully on
+	// SIGINT or SIGTERM. Multiplexed gRPC over plain text needs h2c.
+	serverConfig.UnencryptedHTTP2 = rpcConfig.Multiplex
+	apiServer, err := server.New(serverConfig, r, logger)
+	if err != nil {
+		log.Fatal("Failed to configure server:", err)
+	}
+	var rpcServer *rpc.Server
+	if rpcConfig.Enabled() {
+		rpcServer = rpc.NewServer(bookServer, authenticator, tenantResolver, rateLimiter, rpcConfig, apiServer.TLSConfig(), logger)
+		if rpcConfig.Multiplex {
+			apiServer.Wrap(rpcServer.Multiplex)
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go dispatcher.Run(ctx)
+	go deliverer.Run(ctx)
+	if rpcConfig.Addr != "" {
+		listener, err := net.Listen("tcp", rpcConfig.Addr)
+		if err != nil {
+			log.Fatal("Failed to start gRPC server:", err)
+		}
+		go func() {
+			if err := rpcServer.Serve(listener); err != nil {
+				log.Fatal("Failed to start gRPC server:", err)
+			}
+		}()
+	}
+	// Streaming requests end when shutting down instead of holding it up
+	go func() {
+		<-ctx.Done()
+		broker.Close()
+		if rpcServer != nil {
+			rpcServer.Stop()
+		}
+	}()
+	if err := apiServer.ListenAndServe(ctx); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
 
-// initDB initializes the database connection
-func initDB() (*gorm.DB, error) {
-	log.Println("Initializing database connection...")
+// initDB initializes the database connection. Queries are logged through
+// sqlLogger with the request they belong to.
+func initDB(config database.Config, sqlLogger gormlogger.Interface, logger *slog.Logger) (*gorm.DB, error) {
+	logger.Info("Initializing database connection", "driver", config.Driver)
 	
-	db, err := gorm.Open(sqlite.Open("books.db"), &gorm.Config{})
+	db, err := database.Open(config, &gorm.Config{Logger: sqlLogger})
 	if err != nil {
 		return nil, err
 	}
+	
+	// Confine queries to the tenant of the request
+	if err := tenancy.RegisterCallbacks(db); err != nil {
+		return nil, err
+	}
 
-	log.Println("Database connection established successfully")
+	logger.Info("Database connection established successfully")
 	return db, nil
 }
 
+// newTokenVerifier configures bearer token verification from the
+// environment. It returns nil when no key source is configured:
+//
+//	JWT_JWKS_URL         JWKS endpoint of the token issuer (RS256/ES256)
+//	JWT_PUBLIC_KEY_FILE  PEM public key or certificate (RS256/ES256)
+//	JWT_HMAC_SECRET      shared secret (HS256)
+//	JWT_ISSUER           required iss claim
+//	JWT_AUDIENCE         required aud claim
+//	JWT_ALGORITHMS       comma separated override of the accepted algorithms
+func newTokenVerifier(logger *slog.Logger) (auth.TokenVerifier, error) {
+	var keys auth.KeySource
+	var algorithms []string
+
+	switch {
+	case os.Getenv("JWT_JWKS_URL") != "":
+		keys = auth.NewJWKSKeySource(os.Getenv("JWT_JWKS_URL"), time.Hour, logger)
+		algorithms = []string{"RS256", "ES256"}
+	case os.Getenv("JWT_PUBLIC_KEY_FILE") != "":
+		pemData, err := os.ReadFile(os.Getenv("JWT_PUBLIC_KEY_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		source, err := auth.NewPublicKeySource(pemData)
+		if err != nil {
+			return nil, err
+		}
+		keys = source
+		algorithms = []string{"RS256", "ES256"}
+	case os.Getenv("JWT_HMAC_SECRET") != "":
+		keys = auth.NewHMACKeySource([]byte(os.Getenv("JWT_HMAC_SECRET")))
+		algorithms = []string{"HS256"}
+	default:
+		return nil, nil
+	}
+
+	if value := os.Getenv("JWT_ALGORITHMS"); value != "" {
+		algorithms = strings.Split(value, ",")
+	}
+	if os.Getenv("JWT_ISSUER") == "" || os.Getenv("JWT_AUDIENCE") == "" {
+		return nil, fmt.Errorf("JWT_ISSUER and JWT_AUDIENCE must be set")
+	}
+
+	logger.Info("Accepting bearer tokens", "algorithms", algorithms, "issuer", os.Getenv("JWT_ISSUER"))
+	return auth.NewJWTVerifier(auth.JWTConfig{
+		Issuer:     os.Getenv("JWT_ISSUER"),
+		Audience:   os.Getenv("JWT_AUDIENCE"),
+		Algorithms: algorithms,
+		Leeway:     30 * time.Second,
+	}, keys), nil
+}
+
+// newCORSConfig configures cross-origin access from the environment. CORS
+// stays disabled until origins are allowed:
+//
+//	CORS_ALLOWED_ORIGINS    comma separated origins, or * for any origin
+//	CORS_ALLOWED_METHODS    comma separated override of the allowed methods
+//	CORS_ALLOWED_HEADERS    comma separated override of the allowed headers
+//	CORS_EXPOSED_HEADERS    comma separated override of the readable headers
+//	CORS_ALLOW_CREDENTIALS  true to allow cookies and HTTP authentication
+//	CORS_MAX_AGE            preflight cache duration, e.g. 1h
+func newCORSConfig(logger *slog.Logger) (middleware.CORSConfig, error) {
+	config := middleware.DefaultCORSConfig()
+
+	lists := map[string]*[]string{
+		"CORS_ALLOWED_ORIGINS": &config.AllowedOrigins,
+		"CORS_ALLOWED_METHODS": &config.AllowedMethods,
+		"CORS_ALLOWED_HEADERS": &config.AllowedHeaders,
+		"CORS_EXPOSED_HEADERS": &config.ExposedHeaders,
+	}
+	for name, list := range lists {
+		if value, ok := os.LookupEnv(name); ok {
+			*list = nil
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*list = append(*list, item)
+				}
+			}
+		}
+	}
+	config.AllowCredentials = os.Getenv("CORS_ALLOW_CREDENTIALS") == "true"
+	if value := os.Getenv("CORS_MAX_AGE"); value != "" {
+		maxAge, err := time.ParseDuration(value)
+		if err != nil {
+			return middleware.CORSConfig{}, fmt.Errorf("CORS_MAX_AGE: %w", err)
+		}
+		config.MaxAge = maxAge
+	}
+
+	if len(config.AllowedOrigins) > 0 {
+		logger.Info("Allowing cross-origin requests", "origins", config.AllowedOrigins)
+	}
+	return config, config.Validate()
+}
+
+// runHoldExpiry periodically expires holds that were not picked up in time
+func runHoldExpiry(holdService service.HoldService, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		if _, err := holdService.ExpireHolds(ctx); err != nil {
+			logger.ErrorContext(ctx, "Hold expiry failed", "error", err)
+		}
+	}
+}
+
+// runTombstonePurge removes the tombstones past the retention every
+// interval
+func runTombstonePurge(changeService service.BookChangeService, retention, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		if _, err := changeService.PurgeTombstones(ctx, retention); err != nil {
+			logger.ErrorContext(ctx, "Tombstone purge failed", "error", err)
+		}
+	}
+}
+
+// appControllers groups the HTTP handlers wired into the router
+type appControllers struct {
+	book     *controller.BookController
+	changes  *controller.BookChangeController
+	tag      *controller.TagController
+	category *controller.CategoryController
+	review   *controller.ReviewController
+	copy     *controller.CopyController
+	loan     *controller.LoanController
+	hold     *controller.HoldController
+	member   *controller.MemberController
+	apiKey   *controller.APIKeyController
+	tenant   *controller.TenantController
+	webhook  *controller.WebhookController
+	stream   *controller.StreamController
+	graphql  *controller.GraphQLController
+	logging  *controller.LoggingController
+	backup   *controller.BackupController
+	cache    *controller.CacheController
+	// gateway serves books.v1 as JSON; nil unless enabled
+	gateway http.Handler
+}
+
+// defaultRateLimit applies to every route group unless configured otherwise
+var defaultRateLimit = ratelimit.Limit{Requests: 300, Period: time.Minute}
+
+// defaultClientRateLimit applies per client IP before callers are authenticated
+var defaultClientRateLimit = ratelimit.Limit{Requests: 1200, Period: time.Minute}
+
+// rateLimitGroups are the route groups with their own rate limit
+var rateLimitGroups = []string{"clients", "books", "copies", "loans", "holds", "members", "fines", "tags", "categories", "webhooks", "graphql", "admin"}
+
+// appMiddleware groups the shared middleware wired into the router
+type appMiddleware struct {
+	securityHeaders gin.HandlerFunc
+	cors            gin.HandlerFunc
+	bodyLimit       gin.HandlerFunc
+	authenticator   *middleware.Authenticator
+	tenantResolver  *middleware.TenantResolver
+	rateLimiter     *middleware.RateLimiter
+}
+
 // setupRoutes configures all the API routes
-func setupRoutes(r *gin.Engine, bookController *controller.BookController) {
+func setupRoutes(r *gin.Engine, controllers appControllers, mw appMiddleware) {
+	authenticator := mw.authenticator
+	limit := mw.rateLimiter.Limit
+
+	// Every response, including 404s and preflights, is hardened first;
+	// CORS answers preflights before authentication
+	r.Use(mw.securityHeaders, mw.cors, mw.bodyLimit)
+
 	// Swagger endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// API routes know their caller and tenant before any scope check; clients
+	// are throttled by address first so bad credentials cannot bypass limits
+	api := r.Group("", mw.rateLimiter.LimitClients("clients"), authenticator.Authenticate(), mw.tenantResolver.Resolve())
+
+	// Scope checks applied per route
+	read := authenticator.Require(models.ScopeBooksRead)
+	write := authenticator.Require(models.ScopeBooksWrite)
+	admin := authenticator.Require(models.ScopeAdmin)
+
 	// Book routes
-	bookRoutes := r.Group("/books")
+	bookRoutes := api.Group("/books", limit("books"))
+	{
+		bookRoutes.POST("", write, controllers.book.CreateBook)
+		bookRoutes.GET("", read, controllers.book.ListBooks)
+		bookRoutes.GET("/changes", read, controllers.changes.ListBookChanges)
+		bookRoutes.GET("/stream", read, controllers.stream.StreamBooks)
+		bookRoutes.GET("/stream/ws", read, controllers.stream.StreamBooksWebSocket)
+		bookRoutes.GET("/:id", read, controllers.book.GetBook)
+		bookRoutes.PUT("/:id", write, controllers.book.UpdateBook)
+		bookRoutes.DELETE("/:id", write, controllers.book.DeleteBook)
+		bookRoutes.PUT("/:id/tags", write, controllers.tag.SetBookTags)
+		bookRoutes.PUT("/:id/categories", write, controllers.category.SetBookCategories)
+		bookRoutes.POST("/:id/reviews", write, controllers.review.CreateReview)
+		bookRoutes.GET("/:id/reviews", read, controllers.review.ListReviews)
+		bookRoutes.GET("/:id/reviews/:reviewId", read, controllers.review.GetReview)
+		bookRoutes.PUT("/:id/reviews/:reviewId", write, controllers.review.UpdateReview)
+		bookRoutes.DELETE("/:id/reviews/:reviewId", write, controllers.review.DeleteReview)
+		bookRoutes.PUT("/:id/reviews/:reviewId/moderation", write, controllers.review.ModerateReview)
+		bookRoutes.POST("/:id/copies", write, controllers.copy.CreateCopy)
+		bookRoutes.GET("/:id/copies", read, controllers.copy.ListBookCopies)
+		bookRoutes.POST("/:id/holds", write, controllers.hold.PlaceHold)
+	}
+
+	// Copy and circulation routes
+	copyRoutes := api.Group("/copies", limit("copies"))
+	{
+		copyRoutes.GET("/:id", read, controllers.copy.GetCopy)
+		copyRoutes.PUT("/:id", write, controllers.copy.UpdateCopy)
+		copyRoutes.DELETE("/:id", write, controllers.copy.DeleteCopy)
+		copyRoutes.POST("/:id/checkout", write, controllers.loan.Checkout)
+		copyRoutes.POST("/:id/return", write, controllers.loan.Return)
+		copyRoutes.POST("/:id/renew", write, controllers.loan.Renew)
+	}
+
+	// Loan routes; overdue loans identify members, so they are staff only
+	loanRoutes := api.Group("/loans", limit("loans"))
+	{
+		loanRoutes.GET("/overdue", write, controllers.loan.ListOverdueLoans)
+	}
+
+	// Hold routes
+	api.DELETE("/holds/:id", limit("holds"), write, controllers.hold.CancelHold)
+
+	// Member routes; patron records are only visible to staff keys
+	memberRoutes := api.Group("/members", limit("members"))
+	{
+		memberRoutes.POST("", write, controllers.member.CreateMember)
+		memberRoutes.GET("", write, controllers.member.ListMembers)
+		memberRoutes.GET("/:id", write, controllers.member.GetMember)
+		memberRoutes.PUT("/:id", write, controllers.member.UpdateMember)
+		memberRoutes.DELETE("/:id", write, controllers.member.DeleteMember)
+		memberRoutes.POST("/:id/suspend", write, controllers.member.SuspendMember)
+		memberRoutes.POST("/:id/reinstate", write, controllers.member.ReinstateMember)
+		memberRoutes.GET("/:id/loans", write, controllers.member.ListMemberLoans)
+		memberRoutes.GET("/:id/fines", write, controllers.member.ListMemberFines)
+		memberRoutes.GET("/:id/holds", write, controllers.hold.ListMemberHolds)
+	}
+
+	// Fine routes
+	api.POST("/fines/:id/pay", limit("fines"), write, controllers.member.PayFine)
+
+	// Tag routes
+	tagRoutes := api.Group("/tags", limit("tags"))
+	{
+		tagRoutes.POST("", write, controllers.tag.CreateTag)
+		tagRoutes.GET("", read, controllers.tag.ListTags)
+		tagRoutes.GET("/:id", read, controllers.tag.GetTag)
+		tagRoutes.PUT("/:id", write, controllers.tag.UpdateTag)
+		tagRoutes.DELETE("/:id", write, controllers.tag.DeleteTag)
+		tagRoutes.POST("/:id/merge", write, controllers.tag.MergeTag)
+	}
+
+	// Category routes
+	categoryRoutes := api.Group("/categories", limit("categories"))
+	{
+		categoryRoutes.POST("", write, controllers.category.CreateCategory)
+		categoryRoutes.GET("", read, controllers.category.ListCategories)
+		categoryRoutes.GET("/:id", read, controllers.category.GetCategory)
+		categoryRoutes.PUT("/:id", write, controllers.category.UpdateCategory)
+		categoryRoutes.DELETE("/:id", write, controllers.category.DeleteCategory)
+	}
+
+	// GraphQL routes; mutations check the write scope themselves
+	graphqlRoutes := api.Group("/graphql", limit("graphql"))
 	{
-		bookRoutes.POST("", bookController.CreateBook)
-		bookRoutes.GET("", bookController.ListBooks)
-		bookRoutes.GET("/:id", bookController.GetBook)
-		bookRoutes.PUT("/:id", bookController.UpdateBook)
-		bookRoutes.DELETE("/:id", bookController.DeleteBook)
+		graphqlRoutes.POST("", read, controllers.graphql.Query)
+		graphqlRoutes.GET("", read, controllers.graphql.QueryByGet)
+		graphqlRoutes.GET("/playground", controllers.graphql.Playground)
 	}
 
-	log.Println("Routes configured successfully")
+	// books.v1 JSON gateway; its calls check their scopes themselves
+	if controllers.gateway != nil {
+		api.Any("/v1/*path", limit("books"), gin.WrapH(controllers.gateway))
+	}
+
+	// Webhook routes; webhooks belong to the caller's tenant
+	webhookRoutes := api.Group("/webhooks", limit("webhooks"), authenticator.Require(models.ScopeWebhooks))
+	{
+		webhookRoutes.POST("", controllers.webhook.CreateWebhook)
+		webhookRoutes.GET("", controllers.webhook.ListWebhooks)
+		webhookRoutes.GET("/:id", controllers.webhook.GetWebhook)
+		webhookRoutes.PUT("/:id", controllers.webhook.UpdateWebhook)
+		webhookRoutes.DELETE("/:id", controllers.webhook.DeleteWebhook)
+		webhookRoutes.GET("/:id/deliveries", controllers.webhook.ListDeliveries)
+		webhookRoutes.GET("/:id/deliveries/:deliveryId", controllers.webhook.GetDelivery)
+		webhookRoutes.POST("/:id/deliveries/:deliveryId/redeliver", controllers.webhook.Redeliver)
+	}
+
+	// Admin routes; they act across tenants, so tenant bound credentials
+	// are refused
+	adminRoutes := api.Group("/admin", limit("admin"), admin, authenticator.RequirePlatform())
+	{
+		adminRoutes.POST("/api-keys", controllers.apiKey.IssueAPIKey)
+		adminRoutes.GET("/api-keys", controllers.apiKey.ListAPIKeys)
+		adminRoutes.DELETE("/api-keys/:id", controllers.apiKey.RevokeAPIKey)
+		adminRoutes.POST("/tenants", controllers.tenant.CreateTenant)
+		adminRoutes.GET("/tenants", controllers.tenant.ListTenants)
+		adminRoutes.GET("/tenants/:id", controllers.tenant.GetTenant)
+		adminRoutes.PUT("/tenants/:id", controllers.tenant.UpdateTenant)
+		adminRoutes.DELETE("/tenants/:id", controllers.tenant.DeleteTenant)
+		adminRoutes.GET("/logging", controllers.logging.GetLoggingSettings)
+		adminRoutes.PUT("/logging", controllers.logging.UpdateLoggingSettings)
+		adminRoutes.POST("/backups", controllers.backup.CreateBackup)
+		adminRoutes.GET("/backups", controllers.backup.ListBackups)
+		adminRoutes.GET("/cache", controllers.cache.GetCacheStats)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,264 @@
+package auth_test
+
+import (
+	"books-api/app/auth"
+	"books-api/app/models"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://gateway.example.com"
+	testAudience = "books-api"
+)
+
+// jwksServer is a local stand-in for the gateway's JWKS endpoint
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	keys     []map[string]string
+	requests atomic.Int32
+	// gate, when set, holds every response until it is closed
+	gate chan struct{}
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if s.gate != nil {
+			<-s.gate
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// publish replaces the served key set
+func (s *jwksServer) publish(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func encodeInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": encodeInt(key.N), "e": encodeInt(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC", "kid": kid, "use": "sig", "crv": "P-256",
+		"x": encodeInt(key.X), "y": encodeInt(key.Y),
+	}
+}
+
+func claims(mutate func(jwt.MapClaims)) jwt.MapClaims {
+	now := time.Now()
+	c := jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user-42",
+		"iat":   now.Unix(),
+		"nbf":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"scope": "books:read books:write unknown:scope",
+		"roles": []string{"editor"},
+	}
+	if mutate != nil {
+		mutate(c)
+	}
+	return c
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, c jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func newVerifier(keys auth.KeySource, algorithms ...string) *auth.JWTVerifier {
+	return auth.NewJWTVerifier(auth.JWTConfig{Issuer: testIssuer, Audience: testAudience, Algorithms: algorithms}, keys)
+}
+
+func TestJWTVerifier_JWKS_RS256AndES256(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	server := newJWKSServer(t)
+	server.publish(rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+
+	verifier := newVerifier(auth.NewJWKSKeySource(server.URL, time.Hour, slog.Default()))
+
+	principal, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(nil)))
+	require.NoError(t, err)
+	assert.Equal(t, "user-42", principal.Subject)
+	assert.Equal(t, testIssuer, principal.Issuer)
+	assert.Equal(t, auth.MethodJWT, principal.Method)
+	assert.Equal(t, []models.Scope{models.ScopeBooksRead, models.ScopeBooksWrite}, principal.Scopes)
+	assert.Equal(t, []string{"editor"}, principal.Roles)
+
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodES256, "ec-1", ecKey, claims(nil)))
+	assert.NoError(t, err)
+
+	// The key set is cached between verifications
+	assert.Equal(t, int32(1), server.requests.Load())
+}
+
+func TestJWTVerifier_JWKS_KeyRotation(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newJWKSServer(t)
+	server.publish(rsaJWK("2024", oldKey))
+
+	verifier := newVerifier(auth.NewJWKSKeySource(server.URL, time.Hour, slog.Default()))
+	_, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "2024", oldKey, claims(nil)))
+	require.NoError(t, err)
+
+	// The issuer rotates. Unknown kids only refetch the set once the minimum
+	// refresh interval has passed, so a flood of forged kids cannot hammer
+	// the issuer.
+	server.publish(rsaJWK("2025", newKey))
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "2025", newKey, claims(nil)))
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), server.requests.Load())
+
+	// A source that has not fetched recently picks up the rotated key set
+	verifier = newVerifier(auth.NewJWKSKeySource(server.URL, time.Hour, slog.Default()))
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "2025", newKey, claims(nil)))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "2024", oldKey, claims(nil)))
+	assert.Error(t, err)
+}
+
+func TestJWTVerifier_JWKS_StaleSetIsRefreshed(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newJWKSServer(t)
+	server.publish(rsaJWK("2024", oldKey))
+
+	verifier := newVerifier(auth.NewJWKSKeySource(server.URL, 50*time.Millisecond, slog.Default()))
+	_, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "2024", oldKey, claims(nil)))
+	require.NoError(t, err)
+
+	server.publish(rsaJWK("2025", newKey))
+	time.Sleep(60 * time.Millisecond)
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "2025", newKey, claims(nil)))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), server.requests.Load())
+}
+
+func TestJWTVerifier_JWKS_ConcurrentRefreshesShareOneFetch(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newJWKSServer(t)
+	server.publish(rsaJWK("2024", key))
+	server.gate = make(chan struct{})
+
+	verifier := newVerifier(auth.NewJWKSKeySource(server.URL, time.Hour, slog.Default()))
+	token := sign(t, jwt.SigningMethodRS256, "2024", key, claims(nil))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := verifier.Verify(context.Background(), token)
+			errs <- err
+		}()
+	}
+	// Let the callers pile up behind the first fetch before it completes
+	require.Eventually(t, func() bool { return server.requests.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(server.gate)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), server.requests.Load())
+}
+
+func TestJWTVerifier_RejectsInvalidClaims(t *testing.T) {
+	secret := []byte("shared-secret-for-tests")
+	verifier := newVerifier(auth.NewHMACKeySource(secret), "HS256")
+
+	cases := map[string]func(jwt.MapClaims){
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "other-api" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"not yet valid":  func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"missing expiry": func(c jwt.MapClaims) { delete(c, "exp") },
+		"missing sub":    func(c jwt.MapClaims) { delete(c, "sub") },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, "", secret, claims(mutate)))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, "", []byte("wrong-secret"), claims(nil)))
+	assert.Error(t, err)
+
+	principal, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, "", secret, claims(nil)))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-42", principal.Subject)
+}
+
+func TestJWTVerifier_StaticPublicKey(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	source, err := auth.NewPublicKeySource(pemData)
+	require.NoError(t, err)
+	verifier := newVerifier(source)
+
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "", rsaKey, claims(nil)))
+	assert.NoError(t, err)
+
+	// An HS256 token keyed with the public key must not verify
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, "", pemData, claims(nil)))
+	assert.Error(t, err)
+}
+
+func TestJWTVerifier_AlgorithmAllowList(t *testing.T) {
+	secret := []byte("shared-secret-for-tests")
+	verifier := newVerifier(auth.NewHMACKeySource(secret), "RS256")
+
+	_, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, "", secret, claims(nil)))
+	assert.Error(t, err)
+}
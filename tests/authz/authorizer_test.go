@@ -0,0 +1,86 @@
+package authz_test
+
+import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func as(subject string, roles ...string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Method: auth.MethodJWT, Roles: roles})
+}
+
+func TestAuthorizer_Roles(t *testing.T) {
+	authorizer := authz.NewAuthorizer(authz.DefaultPolicy(), slog.Default())
+
+	assert.NoError(t, authorizer.Authorize(as("vera", "viewer"), authz.ActionBookRead, ""))
+	assert.Error(t, authorizer.Authorize(as("vera", "viewer"), authz.ActionBookCreate, ""))
+
+	assert.NoError(t, authorizer.Authorize(as("lee", "librarian"), authz.ActionBookDelete, "someone-else"))
+	assert.NoError(t, authorizer.Authorize(as("ada", "admin"), authz.ActionBookDelete, "someone-else"))
+
+	// Unknown roles fall back to the default role
+	assert.NoError(t, authorizer.Authorize(as("sam", "superuser"), authz.ActionBookRead, ""))
+	assert.Error(t, authorizer.Authorize(as("sam", "superuser"), authz.ActionBookCreate, ""))
+}
+
+func TestAuthorizer_EditorOwnership(t *testing.T) {
+	authorizer := authz.NewAuthorizer(authz.DefaultPolicy(), slog.Default())
+	editor := as("eve", "editor")
+
+	assert.NoError(t, authorizer.Authorize(editor, authz.ActionBookCreate, ""))
+	assert.NoError(t, authorizer.Authorize(editor, authz.ActionBookUpdate, "eve"))
+	assert.NoError(t, authorizer.Authorize(editor, authz.ActionBookDelete, "eve"))
+
+	err := authorizer.Authorize(editor, authz.ActionBookUpdate, "mallory")
+	var forbidden *authz.ForbiddenError
+	require.True(t, errors.As(err, &forbidden))
+	assert.Equal(t, "eve", forbidden.Subject)
+	assert.Equal(t, authz.ActionBookUpdate, forbidden.Action)
+
+	// Books without a recorded owner are not owned by anyone
+	assert.Error(t, authorizer.Authorize(editor, authz.ActionBookDelete, ""))
+}
+
+func TestAuthorizer_AnonymousAndAPIKeys(t *testing.T) {
+	authorizer := authz.NewAuthorizer(authz.DefaultPolicy(), slog.Default())
+
+	assert.NoError(t, authorizer.Authorize(context.Background(), authz.ActionBookRead, ""))
+	err := authorizer.Authorize(context.Background(), authz.ActionBookCreate, "")
+	assert.True(t, authz.IsForbidden(err))
+	assert.True(t, authz.IsForbidden(fmt.Errorf("wrapped: %w", err)))
+
+	writer := auth.WithPrincipal(context.Background(), &auth.Principal{
+		Subject: "api_key:1", Method: auth.MethodAPIKey, Scopes: []models.Scope{models.ScopeBooksWrite},
+	})
+	assert.NoError(t, authorizer.Authorize(writer, authz.ActionBookDelete, "someone-else"))
+
+	policy := authz.DefaultPolicy()
+	policy.AnonymousRole = ""
+	assert.Error(t, authz.NewAuthorizer(policy, slog.Default()).Authorize(context.Background(), authz.ActionBookRead, ""))
+}
+
+func TestLoadPolicy(t *testing.T) {
+	policy, err := authz.LoadPolicy(filepath.Join("..", "..", "config", "authz_policy.json"))
+	require.NoError(t, err)
+	assert.Equal(t, authz.DefaultPolicy(), policy)
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"default_role": "ghost", "roles": {"viewer": {"allow": ["book:read"]}}}`), 0o600))
+	_, err = authz.LoadPolicy(path)
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`not json`), 0o600))
+	_, err = authz.LoadPolicy(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,178 @@
+package backup_test
+
+import (
+	"books-api/app/backup"
+	"books-api/app/database"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMigrated(t *testing.T, path string) *gorm.DB {
+	db, err := database.Open(database.Config{Driver: "sqlite", DSN: path, SQLite: database.DefaultSQLiteConfig()}, &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, migrations.NewMigrationManager(slog.Default()).RunMigrations(db))
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+// olderBuild migrates to a schema older than any snapshot
+type olderBuild struct {
+	migrations.MigrationManager
+}
+
+func (olderBuild) Version() int { return 0 }
+
+func TestManager_CreateWritesVerifiableSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	db := openMigrated(t, filepath.Join(dir, "books.db"))
+	require.NoError(t, db.Create(&models.Tag{Name: "classics"}).Error)
+
+	migrationManager := migrations.NewMigrationManager(slog.Default())
+	manager := backup.NewManager(db, backup.Config{Dir: filepath.Join(dir, "backups")}, migrationManager)
+	snapshot, err := manager.Create(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, migrationManager.Version(), snapshot.SchemaVersion)
+	assert.Len(t, snapshot.SHA256, 64)
+
+	verified, err := backup.Verify(filepath.Join(dir, "backups", snapshot.Name), migrationManager)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.Name, verified.Name)
+	assert.Equal(t, migrationManager.Version(), verified.SchemaVersion)
+}
+
+func TestManager_CreateDoesNotWaitForWriters(t *testing.T) {
+	dir := t.TempDir()
+	db := openMigrated(t, filepath.Join(dir, "books.db"))
+	require.NoError(t, db.Create(&models.Tag{Name: "classics"}).Error)
+
+	// The only write connection is busy with a transaction
+	tx := db.Begin()
+	require.NoError(t, tx.Error)
+	defer tx.Rollback()
+	require.NoError(t, tx.Create(&models.Tag{Name: "uncommitted"}).Error)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 2*time.Second)
+	defer cancel()
+	manager := backup.NewManager(db, backup.Config{Dir: filepath.Join(dir, "backups")}, migrations.NewMigrationManager(slog.Default()))
+	snapshot, err := manager.Create(ctx)
+	require.NoError(t, err)
+
+	// The snapshot holds what was committed
+	restored := openMigrated(t, filepath.Join(dir, "backups", snapshot.Name))
+	var names []string
+	require.NoError(t, restored.Model(&models.Tag{}).Pluck("name", &names).Error)
+	assert.Equal(t, []string{"classics"}, names)
+}
+
+func TestManager_RotatesOldestSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	db := openMigrated(t, filepath.Join(dir, "books.db"))
+	manager := backup.NewManager(db, backup.Config{Dir: filepath.Join(dir, "backups"), Keep: 2}, migrations.NewMigrationManager(slog.Default()))
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		snapshot, err := manager.Create(t.Context())
+		require.NoError(t, err)
+		names = append(names, snapshot.Name)
+	}
+
+	snapshots, err := manager.List()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, names[2], snapshots[0].Name)
+	assert.Equal(t, names[1], snapshots[1].Name)
+	assert.NoFileExists(t, filepath.Join(dir, "backups", names[0]))
+}
+
+func TestManager_UnsupportedOnOtherDatabases(t *testing.T) {
+	db := openMigrated(t, ":memory:")
+	db.Dialector = &renamedDialector{Dialector: db.Dialector}
+	manager := backup.NewManager(db, backup.DefaultConfig(), migrations.NewMigrationManager(slog.Default()))
+
+	_, err := manager.Create(t.Context())
+	assert.ErrorIs(t, err, backup.ErrUnsupported)
+}
+
+type renamedDialector struct {
+	gorm.Dialector
+}
+
+func (renamedDialector) Name() string { return "postgres" }
+
+func TestVerify_DetectsDamage(t *testing.T) {
+	dir := t.TempDir()
+	db := openMigrated(t, filepath.Join(dir, "books.db"))
+	manager := backup.NewManager(db, backup.Config{Dir: dir}, migrations.NewMigrationManager(slog.Default()))
+	snapshot, err := manager.Create(t.Context())
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, snapshot.Name)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = file.Write([]byte("damage"))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	_, err = backup.Verify(path, migrations.NewMigrationManager(slog.Default()))
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestRestore_ReplacesDatabase(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "books.db")
+	db := openMigrated(t, target)
+	require.NoError(t, db.Create(&models.Tag{Name: "classics"}).Error)
+	manager := backup.NewManager(db, backup.Config{Dir: filepath.Join(dir, "backups")}, migrations.NewMigrationManager(slog.Default()))
+	snapshot, err := manager.Create(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, db.Where("name = ?", "classics").Delete(&models.Tag{}).Error)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	_, err = backup.Restore(filepath.Join(dir, "backups", snapshot.Name), target, migrations.NewMigrationManager(slog.Default()))
+	require.NoError(t, err)
+
+	restored := openMigrated(t, target)
+	var count int64
+	require.NoError(t, restored.Model(&models.Tag{}).Where("name = ?", "classics").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRestore_RefusesNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "books.db")
+	db := openMigrated(t, target)
+	manager := backup.NewManager(db, backup.Config{Dir: filepath.Join(dir, "backups")}, migrations.NewMigrationManager(slog.Default()))
+	snapshot, err := manager.Create(t.Context())
+	require.NoError(t, err)
+
+	_, err = backup.Restore(filepath.Join(dir, "backups", snapshot.Name), target, olderBuild{migrations.NewMigrationManager(slog.Default())})
+	assert.ErrorContains(t, err, "is newer than")
+}
+
+func TestLoadConfig(t *testing.T) {
+	config, err := backup.LoadConfig(func(name string) (string, bool) {
+		value, ok := map[string]string{"BACKUP_DIR": "/var/backups/books", "BACKUP_KEEP": "0"}[name]
+		return value, ok
+	})
+	require.NoError(t, err)
+	assert.Equal(t, backup.Config{Dir: "/var/backups/books", Keep: 0}, config)
+
+	_, err = backup.LoadConfig(func(name string) (string, bool) { return "-1", name == "BACKUP_KEEP" })
+	assert.EqualError(t, err, "BACKUP_KEEP: must be a number of snapshots")
+}
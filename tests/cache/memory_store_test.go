@@ -0,0 +1,77 @@
+package cache_test
+
+import (
+	"books-api/app/cache"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	store := cache.NewMemoryStore(2)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, store.Set(ctx, "b", []byte("2"), time.Minute))
+	_, ok, _ := store.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, store.Set(ctx, "c", []byte("3"), time.Minute))
+
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok, "b was used least recently")
+	value, ok, _ := store.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	_, ok, _ = store.Get(ctx, "c")
+	assert.True(t, ok)
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	store := cache.NewMemoryStore(10)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), 10*time.Millisecond))
+	_, ok, _ := store.Get(ctx, "a")
+	assert.True(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+	_, ok, _ = store.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestMemoryStore_Delete(t *testing.T) {
+	store := cache.NewMemoryStore(10)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, store.Delete(ctx, "a"))
+	require.NoError(t, store.Delete(ctx, "missing"))
+
+	_, ok, _ := store.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestLoadConfig(t *testing.T) {
+	lookup := func(values map[string]string) func(string) (string, bool) {
+		return func(name string) (string, bool) {
+			value, ok := values[name]
+			return value, ok
+		}
+	}
+
+	config, err := cache.LoadConfig(lookup(nil))
+	require.NoError(t, err)
+	assert.Equal(t, cache.DefaultConfig(), config)
+	assert.True(t, config.Enabled())
+
+	config, err = cache.LoadConfig(lookup(map[string]string{"BOOK_CACHE_SIZE": "0", "BOOK_CACHE_TTL": "1m"}))
+	require.NoError(t, err)
+	assert.Equal(t, cache.Config{Size: 0, TTL: time.Minute}, config)
+	assert.False(t, config.Enabled())
+
+	_, err = cache.LoadConfig(lookup(map[string]string{"BOOK_CACHE_TTL": "soon"}))
+	assert.EqualError(t, err, "BOOK_CACHE_TTL: invalid duration soon")
+}
@@ -2,17 +2,20 @@ package controllers_test
 
 import (
 	"books-api/app/controller"
+	"books-api/app/database"
 	"books-api/tests/services/mocks"
 	"books-api/app/models"
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func setupTestRouter() *gin.Engine {
@@ -35,7 +38,7 @@ func TestBookController_CreateBook_Success(t *testing.T) {
 		Color:  &color,
 	}
 
-	mockService.On("CreateBook", &book).Return(nil)
+	mockService.On("CreateBook", mock.Anything, &book).Return(nil)
 
 	body, _ := json.Marshal(book)
 	req, _ := http.NewRequest("POST", "/books", bytes.NewBuffer(body))
@@ -79,7 +82,7 @@ func TestBookController_CreateBook_ValidationError(t *testing.T) {
 		Color:  &invalidColor,
 	}
 
-	mockService.On("CreateBook", &book).Return(errors.New("invalid color: Purple"))
+	mockService.On("CreateBook", mock.Anything, &book).Return(errors.New("invalid color: Purple"))
 
 	body, _ := json.Marshal(book)
 	req, _ := http.NewRequest("POST", "/books", bytes.NewBuffer(body))
@@ -104,7 +107,7 @@ func TestBookController_ListBooks(t *testing.T) {
 		{ID: 2, Title: "Book 2", Author: "Author 2", Pages: 200},
 	}
 
-	mockService.On("GetAllBooks").Return(expectedBooks, nil)
+	mockService.On("GetAllBooks", mock.Anything, models.BookFilter{}).Return(expectedBooks, nil)
 
 	req, _ := http.NewRequest("GET", "/books", nil)
 	w := httptest.NewRecorder()
@@ -133,7 +136,7 @@ func TestBookController_GetBook_Success(t *testing.T) {
 		Pages:  100,
 	}
 
-	mockService.On("GetBookByID", uint(1)).Return(expectedBook, nil)
+	mockService.On("GetBookByID", mock.Anything, uint(1)).Return(expectedBook, nil)
 
 	req, _ := http.NewRequest("GET", "/books/1", nil)
 	w := httptest.NewRecorder()
@@ -155,7 +158,7 @@ func TestBookController_GetBook_NotFound(t *testing.T) {
 
 	router.GET("/books/:id", ctrl.GetBook)
 
-	mockService.On("GetBookByID", uint(999)).Return(nil, errors.New("book not found"))
+	mockService.On("GetBookByID", mock.Anything, uint(999)).Return(nil, errors.New("book not found"))
 
 	req, _ := http.NewRequest("GET", "/books/999", nil)
 	w := httptest.NewRecorder()
@@ -185,7 +188,7 @@ func TestBookController_UpdateBook_Success(t *testing.T) {
 		Pages:  150,
 	}
 
-	mockService.On("UpdateBook", uint(1), updateData).Return(updatedBook, nil)
+	mockService.On("UpdateBook", mock.Anything, uint(1), updateData).Return(updatedBook, nil)
 
 	body, _ := json.Marshal(updateData)
 	req, _ := http.NewRequest("PUT", "/books/1", bytes.NewBuffer(body))
@@ -205,7 +208,7 @@ func TestBookController_DeleteBook_Success(t *testing.T) {
 
 	router.DELETE("/books/:id", ctrl.DeleteBook)
 
-	mockService.On("DeleteBook", uint(1)).Return(nil)
+	mockService.On("DeleteBook", mock.Anything, uint(1)).Return(nil)
 
 	req, _ := http.NewRequest("DELETE", "/books/1", nil)
 	w := httptest.NewRecorder()
@@ -215,3 +218,74 @@ func TestBookController_DeleteBook_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	mockService.AssertExpectations(t)
 }
+
+func TestBookController_ListBooks_InvalidSort(t *testing.T) {
+	mockService := new(mocks.MockBookService)
+	ctrl := controller.NewBookController(mockService)
+	router := setupTestRouter()
+
+	router.GET("/books", ctrl.ListBooks)
+
+	mockService.On("GetAllBooks", mock.Anything, models.BookFilter{Sort: "pages"}).Return([]models.Book(nil), errors.New("invalid sort field: pages"))
+
+	req, _ := http.NewRequest("GET", "/books?sort=pages", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestBookController_CreateBook_UnknownField(t *testing.T) {
+	mockService := new(mocks.MockBookService)
+	ctrl := controller.NewBookController(mockService)
+	router := setupTestRouter()
+
+	router.POST("/books", ctrl.CreateBook)
+
+	req, _ := http.NewRequest("POST", "/books", bytes.NewBufferString(`{"title":"Dune","author":"Frank Herbert","pages":412,"colour":"red"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `unknown field \"colour\"`)
+	mockService.AssertNotCalled(t, "CreateBook", mock.Anything, mock.Anything)
+}
+
+func TestBookController_UpdateBook_TrailingData(t *testing.T) {
+	mockService := new(mocks.MockBookService)
+	ctrl := controller.NewBookController(mockService)
+	router := setupTestRouter()
+
+	router.PUT("/books/:id", ctrl.UpdateBook)
+
+	req, _ := http.NewRequest("PUT", "/books/1", bytes.NewBufferString(`{"title":"Dune"} {"title":"Emma"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "UpdateBook", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBookController_DeleteBook_DatabaseBusy(t *testing.T) {
+	mockService := new(mocks.MockBookService)
+	ctrl := controller.NewBookController(mockService)
+	router := setupTestRouter()
+
+	router.DELETE("/books/:id", ctrl.DeleteBook)
+
+	mockService.On("DeleteBook", mock.Anything, uint(1)).Return(fmt.Errorf("failed to delete book: %w", database.ErrBusy))
+
+	req, _ := http.NewRequest("DELETE", "/books/1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,148 @@
+package database_test
+
+import (
+	"books-api/app/database"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func env(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url    string
+		driver string
+		dsn    string
+	}{
+		{"books.db", "sqlite", "books.db"},
+		{"sqlite://books.db", "sqlite", "books.db"},
+		{"sqlite:///var/lib/books.db", "sqlite", "/var/lib/books.db"},
+		{"sqlite://:memory:", "sqlite", ":memory:"},
+		{"postgres://books:secret@db:5432/books?sslmode=disable", "postgres", "postgres://books:secret@db:5432/books?sslmode=disable"},
+		{"postgresql://db/books", "postgres", "postgresql://db/books"},
+		{"mysql://books:secret@db:3306/books", "mysql", "books:secret@tcp(db:3306)/books?parseTime=true"},
+		{"mysql://books:secret@db:3306/books?charset=utf8mb4", "mysql", "books:secret@tcp(db:3306)/books?parseTime=true&charset=utf8mb4"},
+	}
+	for _, tt := range tests {
+		driver, dsn, err := database.ParseURL(tt.url)
+		require.NoError(t, err, tt.url)
+		assert.Equal(t, tt.driver, driver, tt.url)
+		assert.Equal(t, tt.dsn, dsn, tt.url)
+	}
+
+	_, _, err := database.ParseURL("oracle://db/books")
+	assert.EqualError(t, err, "unsupported database driver: oracle")
+}
+
+func TestLoadConfig(t *testing.T) {
+	config, err := database.LoadConfig(env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, database.Config{Driver: "sqlite", DSN: "books.db", SQLite: database.DefaultSQLiteConfig()}, config)
+
+	config, err = database.LoadConfig(env(map[string]string{
+		"DATABASE_URL":          "postgres://db/books",
+		"DB_MAX_OPEN_CONNS":     "20",
+		"DB_MAX_IDLE_CONNS":     "5",
+		"DB_CONN_MAX_LIFETIME":  "30m",
+		"DB_CONN_MAX_IDLE_TIME": "5m",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, database.Config{
+		Driver:          "postgres",
+		DSN:             "postgres://db/books",
+		MaxOpenConns:    20,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+	}, config)
+
+	_, err = database.LoadConfig(env(map[string]string{"DB_MAX_OPEN_CONNS": "many"}))
+	assert.EqualError(t, err, "DB_MAX_OPEN_CONNS: must be a number")
+
+	_, err = database.LoadConfig(env(map[string]string{"DB_MAX_IDLE_CONNS": "-1"}))
+	assert.Error(t, err)
+}
+
+func TestOpen_AppliesPoolLimits(t *testing.T) {
+	db, err := database.Open(database.Config{Driver: "sqlite", DSN: ":memory:", MaxOpenConns: 1}, &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	assert.Equal(t, 1, sqlDB.Stats().MaxOpenConnections)
+
+	dialect, err := database.DialectOf(db)
+	require.NoError(t, err)
+	assert.NotNil(t, dialect)
+}
+
+func TestMigrations_PartialUniqueIndexes(t *testing.T) {
+	db, err := database.Open(database.Config{Driver: "sqlite", DSN: ":memory:", MaxOpenConns: 1}, &gorm.Config{})
+	require.NoError(t, err)
+
+	// Running migrations twice leaves the indexes in place
+	require.NoError(t, migrations.NewMigrationManager(slog.Default()).RunMigrations(db))
+	require.NoError(t, migrations.NewMigrationManager(slog.Default()).RunMigrations(db))
+	assert.True(t, db.Migrator().HasIndex(&models.Loan{}, "idx_loans_active_copy"))
+	assert.True(t, db.Migrator().HasIndex(&models.Hold{}, "idx_holds_ready_copy"))
+
+	// A copy may have many returned loans but one active loan
+	returned := time.Now()
+	require.NoError(t, db.Create(&models.Loan{CopyID: 1, BookID: 1, MemberID: 1, CheckedOutAt: returned, DueAt: returned, ReturnedAt: &returned}).Error)
+	require.NoError(t, db.Create(&models.Loan{CopyID: 1, BookID: 1, MemberID: 1, CheckedOutAt: returned, DueAt: returned, ReturnedAt: &returned}).Error)
+	require.NoError(t, db.Create(&models.Loan{CopyID: 1, BookID: 1, MemberID: 1, CheckedOutAt: returned, DueAt: returned}).Error)
+	assert.Error(t, db.Create(&models.Loan{CopyID: 1, BookID: 1, MemberID: 2, CheckedOutAt: returned, DueAt: returned}).Error)
+
+	// New tenants are numbered after the default tenant
+	tenant := models.Tenant{Slug: "branch", Name: "Branch"}
+	require.NoError(t, db.Create(&tenant).Error)
+	assert.Greater(t, tenant.ID, uint(models.DefaultTenantID))
+}
+
+func TestMigrations_ScopeExistingRowsToTenants(t *testing.T) {
+	db, err := database.Open(database.Config{Driver: "sqlite", DSN: ":memory:", MaxOpenConns: 1}, &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, migrations.NewMigrationManager(slog.Default()).RunMigrations(db))
+
+	// A database from before copies and loans were scoped: barcodes are
+	// unique across tenants and every row is in the default tenant
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX idx_copies_barcode ON copies (barcode)").Error)
+	tenant := models.Tenant{Slug: "branch", Name: "Branch"}
+	require.NoError(t, db.Create(&tenant).Error)
+	book := models.Book{Title: "Dune", TenantID: tenant.ID}
+	require.NoError(t, db.Create(&book).Error)
+	bookCopy := models.Copy{BookID: book.ID, Barcode: "B-1", Branch: "Main"}
+	require.NoError(t, db.Create(&bookCopy).Error)
+	now := time.Now()
+	loan := models.Loan{CopyID: bookCopy.ID, BookID: book.ID, MemberID: 1, CheckedOutAt: now, DueAt: now, ReturnedAt: &now}
+	require.NoError(t, db.Create(&loan).Error)
+	require.NoError(t, db.Create(&models.Fine{MemberID: 1, LoanID: loan.ID, AmountCents: 25, DaysOverdue: 1}).Error)
+
+	require.NoError(t, migrations.NewMigrationManager(slog.Default()).RunMigrations(db))
+	assert.False(t, db.Migrator().HasIndex(&models.Copy{}, "idx_copies_barcode"))
+
+	var fine models.Fine
+	require.NoError(t, db.First(&bookCopy, bookCopy.ID).Error)
+	require.NoError(t, db.First(&loan, loan.ID).Error)
+	require.NoError(t, db.First(&fine, "loan_id = ?", loan.ID).Error)
+	assert.Equal(t, tenant.ID, bookCopy.TenantID)
+	assert.Equal(t, tenant.ID, loan.TenantID)
+	assert.Equal(t, tenant.ID, fine.TenantID)
+
+	// The barcode is now only unique within a tenant
+	require.NoError(t, db.Create(&models.Copy{BookID: 99, Barcode: "B-1", Branch: "Main", TenantID: models.DefaultTenantID}).Error)
+	assert.Error(t, db.Create(&models.Copy{BookID: 99, Barcode: "B-1", Branch: "Main", TenantID: models.DefaultTenantID}).Error)
+}
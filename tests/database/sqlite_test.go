@@ -0,0 +1,127 @@
+package database_test
+
+import (
+	"books-api/app/database"
+	"books-api/app/models"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+func openSQLiteFile(t *testing.T, path string, pragmas database.SQLiteConfig) *gorm.DB {
+	db, err := database.Open(database.Config{Driver: "sqlite", DSN: path, SQLite: pragmas, MaxOpenConns: 4}, &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Tag{}))
+	return db
+}
+
+func TestSQLite_PragmasApplyToEveryConnection(t *testing.T) {
+	db := openSQLiteFile(t, filepath.Join(t.TempDir(), "books.db"), database.DefaultSQLiteConfig())
+
+	for _, pool := range []dbresolver.Operation{dbresolver.Write, dbresolver.Read} {
+		var journalMode string
+		var foreignKeys, busyTimeout, cacheSize int
+		conn := db.Clauses(pool)
+		require.NoError(t, conn.Raw("PRAGMA journal_mode").Scan(&journalMode).Error)
+		require.NoError(t, conn.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error)
+		require.NoError(t, conn.Raw("PRAGMA busy_timeout").Scan(&busyTimeout).Error)
+		require.NoError(t, conn.Raw("PRAGMA cache_size").Scan(&cacheSize).Error)
+
+		assert.Equal(t, "wal", journalMode, pool)
+		assert.Equal(t, 1, foreignKeys, pool)
+		assert.Equal(t, 5000, busyTimeout, pool)
+		assert.Equal(t, -20000, cacheSize, pool)
+	}
+}
+
+func TestSQLite_ReadPoolIsReadOnly(t *testing.T) {
+	db := openSQLiteFile(t, filepath.Join(t.TempDir(), "books.db"), database.DefaultSQLiteConfig())
+
+	require.NoError(t, db.Create(&models.Tag{Name: "classics"}).Error)
+	assert.ErrorContains(t, db.Clauses(dbresolver.Read).Exec("DELETE FROM tags").Error, "readonly")
+
+	var count int64
+	require.NoError(t, db.Model(&models.Tag{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestSQLite_ConcurrentWritesQueue(t *testing.T) {
+	db := openSQLiteFile(t, filepath.Join(t.TempDir(), "books.db"), database.DefaultSQLiteConfig())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- db.Transaction(func(tx *gorm.DB) error {
+				var count int64
+				if err := tx.Model(&models.Tag{}).Count(&count).Error; err != nil {
+					return err
+				}
+				return tx.Create(&models.Tag{Name: fmt.Sprintf("tag-%d-%d", i, count)}).Error
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	var count int64
+	require.NoError(t, db.Model(&models.Tag{}).Count(&count).Error)
+	assert.Equal(t, int64(50), count)
+}
+
+func TestSQLite_LockErrorsAreBusy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "books.db")
+	pragmas := database.DefaultSQLiteConfig()
+	pragmas.BusyTimeout = 20 * time.Millisecond
+	db := openSQLiteFile(t, path, pragmas)
+
+	// Another process holds the write lock
+	other, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer other.Close()
+	conn, err := other.Conn(t.Context())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(t.Context(), "BEGIN IMMEDIATE")
+	require.NoError(t, err)
+
+	err = db.Create(&models.Tag{Name: "classics"}).Error
+	assert.True(t, database.IsBusy(err), err)
+
+	_, err = conn.ExecContext(t.Context(), "ROLLBACK")
+	require.NoError(t, err)
+	assert.NoError(t, db.Create(&models.Tag{Name: "classics"}).Error)
+}
+
+func TestLoadConfig_SQLitePragmas(t *testing.T) {
+	config, err := database.LoadConfig(env(map[string]string{
+		"SQLITE_JOURNAL_MODE": "delete",
+		"SQLITE_SYNCHRONOUS":  "full",
+		"SQLITE_BUSY_TIMEOUT": "1s",
+		"SQLITE_FOREIGN_KEYS": "false",
+		"SQLITE_CACHE_SIZE":   "2000",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, database.SQLiteConfig{
+		JournalMode: "DELETE",
+		Synchronous: "FULL",
+		BusyTimeout: time.Second,
+		CacheSize:   2000,
+	}, config.SQLite)
+
+	_, err = database.LoadConfig(env(map[string]string{"SQLITE_JOURNAL_MODE": "fast"}))
+	assert.EqualError(t, err, "SQLITE_JOURNAL_MODE: unknown mode fast")
+}
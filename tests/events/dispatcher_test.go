@@ -0,0 +1,326 @@
+package events_test
+
+import (
+	"books-api/app/authz"
+	"books-api/app/events"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, migrations.NewMigrationManager(slog.Default()).RunMigrations(db))
+	return db
+}
+
+// recordingSink collects the events delivered to it and fails while failing
+// is set
+type recordingSink struct {
+	mu      sync.Mutex
+	events  []events.Event
+	failing bool
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Deliver(ctx context.Context, event events.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failing {
+		return errors.New("sink unavailable")
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func testConfig() events.Config {
+	config := events.DefaultConfig()
+	config.RetryBase = time.Millisecond
+	config.RetryMax = time.Millisecond
+	config.MaxAttempts = 3
+	return config
+}
+
+func TestBookService_RecordsEventsInOutbox(t *testing.T) {
+	db := setupTestDB(t)
+	bookService := service.NewBookService(repository.NewBookRepository(db), repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), slog.Default())
+	ctx := context.Background()
+
+	book := &models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412}
+	require.NoError(t, bookService.CreateBook(ctx, book))
+	_, err := bookService.UpdateBook(ctx, book.ID, models.Book{Title: "Dune Messiah", Author: "Frank Herbert", Pages: 256})
+	require.NoError(t, err)
+	// An update changing nothing has no event
+	_, err = bookService.UpdateBook(ctx, book.ID, models.Book{Pages: 256})
+	require.NoError(t, err)
+	require.NoError(t, bookService.DeleteBook(ctx, book.ID))
+
+	sink := &recordingSink{}
+	dispatcher := events.NewDispatcher(repository.NewOutboxRepository(db), []events.Sink{sink}, testConfig(), slog.Default())
+	delivered, err := dispatcher.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+
+	require.Len(t, sink.events, 3)
+	assert.Equal(t, events.BookCreated, sink.events[0].Type)
+	assert.Equal(t, "Dune", sink.events[0].Book.Title)
+	assert.Equal(t, uint(models.DefaultTenantID), sink.events[0].TenantID)
+	assert.Equal(t, events.BookUpdated, sink.events[1].Type)
+	assert.Equal(t, []string{"title", "pages"}, sink.events[1].Changed)
+	assert.Equal(t, "Dune Messiah", sink.events[1].Book.Title)
+	assert.Equal(t, events.BookDeleted, sink.events[2].Type)
+	for _, event := range sink.events {
+		assert.Equal(t, book.ID, event.BookID)
+	}
+	assert.Less(t, sink.events[0].ID, sink.events[1].ID)
+
+	// Delivered events are not delivered again
+	delivered, err = dispatcher.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+}
+
+func TestBookService_RolledBackWriteHasNoEvent(t *testing.T) {
+	db := setupTestDB(t)
+	bookService := service.NewBookService(repository.NewBookRepository(db), repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), slog.Default())
+	ctx := context.Background()
+	book := &models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412}
+	require.NoError(t, bookService.CreateBook(ctx, book))
+
+	invalid := models.Color("Yellow")
+	_, err := bookService.UpdateBook(ctx, book.ID, models.Book{Color: &invalid})
+	require.Error(t, err)
+
+	pending, err := repository.NewOutboxRepository(db).GetPending(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, string(events.BookCreated), pending[0].Type)
+}
+
+func recordEvent(t *testing.T, outbox repository.OutboxRepository, event events.Event) *models.OutboxEvent {
+	record, err := event.Record()
+	require.NoError(t, err)
+	require.NoError(t, outbox.Create(context.Background(), record))
+	return record
+}
+
+func TestDispatcher_RetriesWithBackoffInOrder(t *testing.T) {
+	db := setupTestDB(t)
+	outbox := repository.NewOutboxRepository(db)
+	ctx := context.Background()
+	dune := &models.Book{ID: 1, Title: "Dune", TenantID: 1}
+	emma := &models.Book{ID: 2, Title: "Emma", TenantID: 1}
+	recordEvent(t, outbox, events.NewBookCreated(dune))
+	recordEvent(t, outbox, events.NewBookUpdated(dune, []string{"title"}))
+
+	config := testConfig()
+	config.RetryBase = time.Hour
+	config.RetryMax = time.Hour
+	sink := &recordingSink{failing: true}
+	dispatcher := events.NewDispatcher(outbox, []events.Sink{sink}, config, slog.Default())
+
+	delivered, err := dispatcher.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+
+	var first models.OutboxEvent
+	require.NoError(t, db.First(&first).Error)
+	assert.Equal(t, 1, first.Attempts)
+	assert.Equal(t, "recording: sink unavailable", first.LastError)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), first.NextAttemptAt, time.Minute)
+
+	// The later event of the same book waits for the first; other books
+	// are not held back
+	sink.failing = false
+	recordEvent(t, outbox, events.NewBookCreated(emma))
+	delivered, err = dispatcher.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, uint(2), sink.events[0].BookID)
+
+	require.NoError(t, db.Model(&first).Update("next_attempt_at", time.Now()).Error)
+	delivered, err = dispatcher.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	require.Len(t, sink.events, 3)
+	assert.Equal(t, events.BookCreated, sink.events[1].Type)
+	assert.Equal(t, events.BookUpdated, sink.events[2].Type)
+}
+
+func TestDispatcher_RetriesOnlyFailedSinks(t *testing.T) {
+	db := setupTestDB(t)
+	outbox := repository.NewOutboxRepository(db)
+	ctx := context.Background()
+	record := recordEvent(t, outbox, events.NewBookCreated(&models.Book{ID: 1, Title: "Dune", TenantID: 1}))
+
+	subscribers := events.NewSubscribers()
+	var calls int
+	subscribers.Subscribe(func(ctx context.Context, event events.Event) error {
+		calls++
+		return nil
+	})
+	external := &recordingSink{failing: true}
+	dispatcher := events.NewDispatcher(outbox, []events.Sink{subscribers, external}, testConfig(), slog.Default())
+
+	delivered, err := dispatcher.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+
+	// The retry goes to the failed sink only
+	external.failing = false
+	time.Sleep(2 * time.Millisecond)
+	delivered, err = dispatcher.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, 1, calls)
+	require.Len(t, external.events, 1)
+
+	var stored models.OutboxEvent
+	require.NoError(t, db.First(&stored, record.ID).Error)
+	assert.Equal(t, []string{"subscribers", "recording"}, stored.DeliveredSinks)
+}
+
+func TestOutboxRepository_GetPendingSkipsHeldBooks(t *testing.T) {
+	db := setupTestDB(t)
+	outbox := repository.NewOutboxRepository(db)
+	ctx := context.Background()
+	dune := &models.Book{ID: 1, Title: "Dune", TenantID: 1}
+	emma := &models.Book{ID: 2, Title: "Emma", TenantID: 1}
+
+	waiting := recordEvent(t, outbox, events.NewBookCreated(dune))
+	waiting.NextAttemptAt = time.Now().Add(time.Hour)
+	require.NoError(t, outbox.Update(ctx, waiting))
+	recordEvent(t, outbox, events.NewBookUpdated(dune, []string{"title"}))
+	due := recordEvent(t, outbox, events.NewBookCreated(emma))
+	recordEvent(t, outbox, events.NewBookUpdated(emma, []string{"title"}))
+
+	pending, err := outbox.GetPending(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, due.ID, pending[0].ID)
+	assert.Equal(t, uint(2), pending[1].BookID)
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	db := setupTestDB(t)
+	outbox := repository.NewOutboxRepository(db)
+	record := recordEvent(t, outbox, events.NewBookCreated(&models.Book{ID: 1, TenantID: 1}))
+	sink := &recordingSink{failing: true}
+	dispatcher := events.NewDispatcher(outbox, []events.Sink{sink}, testConfig(), slog.Default())
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * time.Millisecond)
+		_, err := dispatcher.Dispatch(context.Background())
+		require.NoError(t, err)
+	}
+
+	var stored models.OutboxEvent
+	require.NoError(t, db.First(&stored, record.ID).Error)
+	assert.Equal(t, models.OutboxFailed, stored.Status)
+	assert.Equal(t, 3, stored.Attempts)
+}
+
+func TestFileSink_AppendsNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	sink := events.NewFileSink(path)
+	ctx := context.Background()
+
+	require.NoError(t, sink.Deliver(ctx, events.Event{ID: 1, Type: events.BookCreated, BookID: 7}))
+	require.NoError(t, sink.Deliver(ctx, events.Event{ID: 2, Type: events.BookDeleted, BookID: 7}))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var ids []uint
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event events.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		ids = append(ids, event.ID)
+	}
+	assert.Equal(t, []uint{1, 2}, ids)
+}
+
+func TestWebhookSink_PostsEvents(t *testing.T) {
+	var received events.Event
+	var headers http.Header
+	status := http.StatusNoContent
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+	sink := events.NewWebhookSink(receiver.URL, time.Second)
+
+	event := events.Event{ID: 42, Type: events.BookUpdated, BookID: 7, Changed: []string{"title"}}
+	require.NoError(t, sink.Deliver(context.Background(), event))
+	assert.Equal(t, event, received)
+	assert.Equal(t, "42", headers.Get("X-Event-ID"))
+	assert.Equal(t, "book.updated", headers.Get("X-Event-Type"))
+
+	status = http.StatusServiceUnavailable
+	assert.EqualError(t, sink.Deliver(context.Background(), event), "webhook answered 503 Service Unavailable")
+}
+
+func TestSubscribers_CallsEveryHandler(t *testing.T) {
+	subscribers := events.NewSubscribers()
+	var calls int
+	subscribers.Subscribe(func(ctx context.Context, event events.Event) error {
+		calls++
+		return errors.New("handler failed")
+	})
+	subscribers.Subscribe(func(ctx context.Context, event events.Event) error {
+		calls++
+		return nil
+	})
+
+	err := subscribers.Deliver(context.Background(), events.Event{ID: 1})
+	assert.EqualError(t, err, "handler failed")
+	assert.Equal(t, 2, calls)
+}
+
+func TestLoadConfig(t *testing.T) {
+	lookup := func(values map[string]string) func(string) (string, bool) {
+		return func(name string) (string, bool) {
+			value, ok := values[name]
+			return value, ok
+		}
+	}
+
+	config, err := events.LoadConfig(lookup(map[string]string{
+		"OUTBOX_POLL_INTERVAL": "5s",
+		"OUTBOX_MAX_ATTEMPTS":  "3",
+		"OUTBOX_WEBHOOK_URL":   "https://search.internal/events",
+		"OUTBOX_FILE":          "events.ndjson",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, config.PollInterval)
+	assert.Equal(t, 3, config.MaxAttempts)
+	assert.Len(t, config.Sinks(), 2)
+
+	_, err = events.LoadConfig(lookup(map[string]string{"OUTBOX_WEBHOOK_URL": "search.internal"}))
+	assert.EqualError(t, err, "OUTBOX_WEBHOOK_URL: must be an http or https URL")
+}
@@ -0,0 +1,90 @@
+package graph_test
+
+import (
+	"books-api/app/graph"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_BatchesQueuedKeys(t *testing.T) {
+	var batches [][]int
+	loader := graph.NewLoader(func(ctx context.Context, keys []int) (map[int]string, error) {
+		batches = append(batches, keys)
+		values := make(map[int]string)
+		for _, key := range keys {
+			if key != 3 {
+				values[key] = string(rune('a' + key))
+			}
+		}
+		return values, nil
+	})
+
+	ctx := context.Background()
+	one, two, again, three := loader.Load(ctx, 1), loader.Load(ctx, 2), loader.Load(ctx, 1), loader.Load(ctx, 3)
+
+	value, err := two()
+	require.NoError(t, err)
+	assert.Equal(t, "c", value)
+	value, _ = one()
+	assert.Equal(t, "b", value)
+	value, _ = again()
+	assert.Equal(t, "b", value)
+	value, err = three()
+	assert.NoError(t, err)
+	assert.Empty(t, value)
+	assert.Equal(t, [][]int{{1, 2, 3}}, batches)
+
+	// Loaded keys are not fetched again
+	value, _ = loader.Load(ctx, 2)()
+	assert.Equal(t, "c", value)
+	value, _ = loader.Load(ctx, 4)()
+	assert.Equal(t, "e", value)
+	assert.Equal(t, [][]int{{1, 2, 3}, {4}}, batches)
+}
+
+func TestLoader_ReportsFetchErrors(t *testing.T) {
+	loader := graph.NewLoader(func(ctx context.Context, keys []int) (map[int]string, error) {
+		return nil, errors.New("failed to retrieve")
+	})
+
+	first, second := loader.Load(context.Background(), 1), loader.Load(context.Background(), 2)
+	_, err := first()
+	assert.EqualError(t, err, "failed to retrieve")
+	_, err = second()
+	assert.EqualError(t, err, "failed to retrieve")
+}
+
+func TestLoadConfig(t *testing.T) {
+	config, err := graph.LoadConfig(lookup(nil))
+	require.NoError(t, err)
+	assert.Equal(t, graph.DefaultConfig(), config)
+
+	config, err = graph.LoadConfig(lookup(map[string]string{
+		"GRAPHQL_MAX_DEPTH":               "4",
+		"GRAPHQL_MAX_INTROSPECTION_DEPTH": "12",
+		"GRAPHQL_MAX_COMPLEXITY":          "250",
+		"GRAPHQL_PLAYGROUND":              "false",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, graph.Config{MaxDepth: 4, MaxIntrospectionDepth: 12, MaxComplexity: 250, Playground: false}, config)
+
+	for _, env := range []map[string]string{
+		{"GRAPHQL_MAX_DEPTH": "0"},
+		{"GRAPHQL_MAX_COMPLEXITY": "lots"},
+		{"GRAPHQL_PLAYGROUND": "maybe"},
+	} {
+		_, err := graph.LoadConfig(lookup(env))
+		assert.Error(t, err, env)
+	}
+}
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
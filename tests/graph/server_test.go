@@ -0,0 +1,264 @@
+package graph_test
+
+import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/graph"
+	"books-api/app/models"
+	"books-api/app/service"
+	repomocks "books-api/tests/repositories/mocks"
+	"books-api/tests/services/mocks"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// response is a GraphQL result decoded as a client sees it
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newServer(t *testing.T, config graph.Config) (*graph.Server, *mocks.MockBookService, *repomocks.MockReviewRepository) {
+	bookService := new(mocks.MockBookService)
+	reviewRepo := new(repomocks.MockReviewRepository)
+	server, err := graph.NewServer(bookService, service.NewReviewService(reviewRepo, new(repomocks.MockBookRepository), &repomocks.MockUnitOfWork{ReviewRepo: reviewRepo}, authz.AllowAll(), slog.Default()), config)
+	require.NoError(t, err)
+	return server, bookService, reviewRepo
+}
+
+func execute(t *testing.T, server *graph.Server, ctx context.Context, req graph.Request) response {
+	body, err := json.Marshal(server.Execute(ctx, req))
+	require.NoError(t, err)
+	var resp response
+	require.NoError(t, json.Unmarshal(body, &resp))
+	return resp
+}
+
+func writer() context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "ci", Scopes: []models.Scope{models.ScopeBooksWrite}})
+}
+
+func TestServer_BooksBatchesReviews(t *testing.T) {
+	server, bookService, reviewRepo := newServer(t, graph.DefaultConfig())
+
+	bookService.On("GetAllBooks", mock.Anything, models.BookFilter{Tag: "classic", Limit: 3}).
+		Return([]models.Book{{ID: 1, Title: "Dune"}, {ID: 2, Title: "Emma"}, {ID: 3, Title: "Ulysses"}}, nil)
+	reviewRepo.On("GetApprovedByBooks", []uint{1, 2}).Return([]models.Review{
+		{ID: 7, BookID: 2, Reviewer: "carol", Rating: 5},
+		{ID: 6, BookID: 1, Reviewer: "bob", Rating: 4},
+		{ID: 5, BookID: 1, Reviewer: "alice", Rating: 3},
+	}, nil).Once()
+
+	resp := execute(t, server, context.Background(), graph.Request{
+		Query: `{ books(first: 2, tag: "classic") { nodes { id title reviews(first: 1) { reviewer } } pageInfo { hasNextPage endCursor } } }`,
+	})
+	require.Empty(t, resp.Errors)
+
+	books := resp.Data["books"].(map[string]interface{})
+	nodes := books["nodes"].([]interface{})
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "Dune", nodes[0].(map[string]interface{})["title"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"reviewer": "bob"}}, nodes[0].(map[string]interface{})["reviews"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"reviewer": "carol"}}, nodes[1].(map[string]interface{})["reviews"])
+	pageInfo := books["pageInfo"].(map[string]interface{})
+	assert.Equal(t, true, pageInfo["hasNextPage"])
+	reviewRepo.AssertNumberOfCalls(t, "GetApprovedByBooks", 1)
+
+	// The cursor continues after the last book
+	bookService.On("GetAllBooks", mock.Anything, models.BookFilter{Limit: 3, Offset: 2}).
+		Return([]models.Book{{ID: 3, Title: "Ulysses"}}, nil)
+	resp = execute(t, server, context.Background(), graph.Request{
+		Query:     `query Next($after: String) { books(first: 2, after: $after) { nodes { id } pageInfo { hasNextPage } } }`,
+		Variables: map[string]interface{}{"after": pageInfo["endCursor"]},
+	})
+	require.Empty(t, resp.Errors)
+	books = resp.Data["books"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": "3"}}, books["nodes"])
+	assert.Equal(t, false, books["pageInfo"].(map[string]interface{})["hasNextPage"])
+}
+
+func TestServer_BookNotFoundIsNull(t *testing.T) {
+	server, bookService, _ := newServer(t, graph.DefaultConfig())
+	bookService.On("GetBookByID", mock.Anything, uint(9)).Return(nil, errors.New("book not found"))
+
+	resp := execute(t, server, context.Background(), graph.Request{Query: `{ book(id: "9") { title } }`})
+	assert.Empty(t, resp.Errors)
+	assert.Nil(t, resp.Data["book"])
+}
+
+func TestServer_InvalidArguments(t *testing.T) {
+	server, _, _ := newServer(t, graph.DefaultConfig())
+
+	for _, query := range []string{
+		`{ books(after: "bogus") { nodes { id } } }`,
+		`{ books(first: 0) { nodes { id } } }`,
+		`{ book(id: "x") { id } }`,
+	} {
+		resp := execute(t, server, context.Background(), graph.Request{Query: query})
+		require.Len(t, resp.Errors, 1, query)
+		assert.Equal(t, graph.CodeBadUserInput, resp.Errors[0].Extensions["code"], query)
+	}
+}
+
+func TestServer_RejectsDeepQueries(t *testing.T) {
+	config := graph.DefaultConfig()
+	config.MaxDepth = 3
+	server, bookService, _ := newServer(t, config)
+
+	resp := execute(t, server, context.Background(), graph.Request{
+		Query: `{ books { nodes { tags { name } } } }`,
+	})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, graph.CodeQueryTooDeep, resp.Errors[0].Extensions["code"])
+	assert.Nil(t, resp.Data)
+
+	// Fragments count where they are spread
+	resp = execute(t, server, context.Background(), graph.Request{
+		Query: `{ books { ...page } } fragment page on BookConnection { nodes { categories { id } } }`,
+	})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, graph.CodeQueryTooDeep, resp.Errors[0].Extensions["code"])
+	bookService.AssertNotCalled(t, "GetAllBooks", mock.Anything, mock.Anything)
+}
+
+func TestServer_RejectsComplexQueries(t *testing.T) {
+	config := graph.DefaultConfig()
+	config.MaxComplexity = 500
+	server, bookService, _ := newServer(t, config)
+
+	// books 1 + 100 * (nodes 1 + id 1 + reviews (1 + 10 * 2))
+	resp := execute(t, server, context.Background(), graph.Request{
+		Query:     `query Q($n: Int) { books(first: $n) { nodes { id reviews { reviewer rating } } } }`,
+		Variables: map[string]interface{}{"n": float64(100)},
+	})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, graph.CodeQueryTooComplex, resp.Errors[0].Extensions["code"])
+	assert.Contains(t, resp.Errors[0].Message, "2301")
+	bookService.AssertNotCalled(t, "GetAllBooks", mock.Anything, mock.Anything)
+}
+
+func TestServer_MeasuresChainedFragmentsOnce(t *testing.T) {
+	server, bookService, _ := newServer(t, graph.DefaultConfig())
+
+	// Every fragment spreads the next twice, doubling the fields selected
+	var query strings.Builder
+	query.WriteString("{ books { nodes { ...f0 } } }")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&query, " fragment f%d on Book { ...f%d ...f%d }", i, i+1, i+1)
+	}
+	query.WriteString(" fragment f40 on Book { id }")
+
+	start := time.Now()
+	resp := execute(t, server, context.Background(), graph.Request{Query: query.String()})
+	assert.Less(t, time.Since(start), time.Second)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, graph.CodeQueryTooComplex, resp.Errors[0].Extensions["code"])
+	bookService.AssertNotCalled(t, "GetAllBooks", mock.Anything, mock.Anything)
+}
+
+func TestServer_IntrospectionIsNotLimited(t *testing.T) {
+	config := graph.DefaultConfig()
+	config.MaxDepth = 1
+	config.MaxComplexity = 1
+	server, _, _ := newServer(t, config)
+
+	resp := execute(t, server, context.Background(), graph.Request{
+		Query: `{ __schema { types { name fields { name type { name ofType { name } } } } } }`,
+	})
+	assert.Empty(t, resp.Errors)
+	assert.NotNil(t, resp.Data["__schema"])
+}
+
+func TestServer_LimitsIntrospectionDepth(t *testing.T) {
+	config := graph.DefaultConfig()
+	config.MaxIntrospectionDepth = 5
+	server, _, _ := newServer(t, config)
+
+	resp := execute(t, server, context.Background(), graph.Request{
+		Query: `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`,
+	})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, graph.CodeQueryTooDeep, resp.Errors[0].Extensions["code"])
+	assert.Contains(t, resp.Errors[0].Message, "introspection depth 7")
+
+	// __typename counts where it is selected
+	config.MaxIntrospectionDepth = 3
+	server, bookService, _ := newServer(t, config)
+	resp = execute(t, server, context.Background(), graph.Request{
+		Query: `{ books { nodes { tags { __typename } } } }`,
+	})
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "introspection depth 4")
+	bookService.AssertNotCalled(t, "GetAllBooks", mock.Anything, mock.Anything)
+}
+
+func TestServer_ReportsParseAndValidationErrors(t *testing.T) {
+	server, _, _ := newServer(t, graph.DefaultConfig())
+
+	resp := execute(t, server, context.Background(), graph.Request{Query: `{ books {`})
+	require.NotEmpty(t, resp.Errors)
+	assert.Equal(t, graph.CodeParseFailed, resp.Errors[0].Extensions["code"])
+
+	resp = execute(t, server, context.Background(), graph.Request{Query: `{ books { isbn } }`})
+	require.NotEmpty(t, resp.Errors)
+	assert.Equal(t, graph.CodeValidationFailed, resp.Errors[0].Extensions["code"])
+}
+
+func TestServer_MutationsNeedWriteScope(t *testing.T) {
+	server, bookService, _ := newServer(t, graph.DefaultConfig())
+	mutation := graph.Request{Query: `mutation { createBook(input: {title: "Dune", author: "Frank Herbert", color: Blue}) { id title color } }`}
+
+	resp := execute(t, server, context.Background(), mutation)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, graph.CodeUnauthenticated, resp.Errors[0].Extensions["code"])
+
+	reader := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "ro", Scopes: []models.Scope{models.ScopeBooksRead}})
+	resp = execute(t, server, reader, mutation)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, graph.CodeForbidden, resp.Errors[0].Extensions["code"])
+	bookService.AssertNotCalled(t, "CreateBook", mock.Anything, mock.Anything)
+
+	blue := models.Blue
+	bookService.On("CreateBook", mock.Anything, &models.Book{Title: "Dune", Author: "Frank Herbert", Color: &blue}).
+		Run(func(args mock.Arguments) { args.Get(1).(*models.Book).ID = 4 }).
+		Return(nil)
+	resp = execute(t, server, writer(), mutation)
+	require.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]interface{}{"id": "4", "title": "Dune", "color": "Blue"}, resp.Data["createBook"])
+}
+
+func TestServer_MutationErrorsCarryCodes(t *testing.T) {
+	server, bookService, _ := newServer(t, graph.DefaultConfig())
+	bookService.On("UpdateBook", mock.Anything, uint(1), models.Book{Pages: 412}).Return(nil, errors.New("book not found"))
+	bookService.On("DeleteBook", mock.Anything, uint(2)).Return(errors.New("failed to delete book: disk I/O error"))
+
+	resp := execute(t, server, writer(), graph.Request{Query: `mutation { updateBook(id: "1", input: {pages: 412}) { id } }`})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, graph.CodeNotFound, resp.Errors[0].Extensions["code"])
+
+	resp = execute(t, server, writer(), graph.Request{Query: `mutation { deleteBook(id: "2") }`})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, graph.CodeInternal, resp.Errors[0].Extensions["code"])
+}
+
+func TestServer_ReadOnlyRejectsMutations(t *testing.T) {
+	server, bookService, _ := newServer(t, graph.DefaultConfig())
+
+	resp := execute(t, server, writer(), graph.Request{Query: `mutation { deleteBook(id: "1") }`, ReadOnly: true})
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "POST")
+	bookService.AssertNotCalled(t, "DeleteBook", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,322 @@
+package integration
+
+import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/middleware"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type AuthAPITestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	keyService service.APIKeyService
+	bookRepo   repository.BookRepository
+}
+
+func (suite *AuthAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+
+	suite.db = db
+	suite.bookRepo = repository.NewBookRepository(db)
+	suite.keyService = service.NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewTenantRepository(db), slog.Default())
+}
+
+func (suite *AuthAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// router mirrors the scope layout of setupRoutes for books and key admin
+func (suite *AuthAPITestSuite) router(anonymousReads bool) *gin.Engine {
+	return suite.routerWithTokens(anonymousReads, nil)
+}
+
+func (suite *AuthAPITestSuite) routerWithTokens(anonymousReads bool, tokens auth.TokenVerifier) *gin.Engine {
+	authenticator := middleware.NewAuthenticator(suite.keyService, tokens, anonymousReads)
+	bookController := controller.NewBookController(service.NewBookService(suite.bookRepo, repository.NewUnitOfWork(suite.db, nil, slog.Default()), authz.NewAuthorizer(authz.DefaultPolicy(), slog.Default()), slog.Default()))
+	keyController := controller.NewAPIKeyController(suite.keyService)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	read := authenticator.Require(models.ScopeBooksRead)
+	write := authenticator.Require(models.ScopeBooksWrite)
+	router.GET("/books", read, bookController.ListBooks)
+	router.POST("/books", write, bookController.CreateBook)
+	router.PUT("/books/:id", write, bookController.UpdateBook)
+	router.DELETE("/books/:id", write, bookController.DeleteBook)
+
+	admin := router.Group("/admin", authenticator.Require(models.ScopeAdmin))
+	admin.POST("/api-keys", keyController.IssueAPIKey)
+	admin.GET("/api-keys", keyController.ListAPIKeys)
+	admin.DELETE("/api-keys/:id", keyController.RevokeAPIKey)
+	return router
+}
+
+func (suite *AuthAPITestSuite) request(router *gin.Engine, method, path, key string, payload interface{}) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(middleware.APIKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *AuthAPITestSuite) issue(scopes ...models.Scope) *models.IssuedAPIKey {
+	issued, err := suite.keyService.IssueKey(context.Background(), "test", "", scopes, nil)
+	suite.Require().NoError(err)
+	return issued
+}
+
+func (suite *AuthAPITestSuite) TestWritesRequireKey() {
+	router := suite.router(true)
+	book := models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412}
+
+	w := suite.request(router, "GET", "/books", "", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request(router, "POST", "/books", "", book)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = suite.request(router, "POST", "/books", "bk_made-up", book)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	reader := suite.issue(models.ScopeBooksRead)
+	w = suite.request(router, "POST", "/books", reader.Key, book)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	writer := suite.issue(models.ScopeBooksWrite)
+	w = suite.request(router, "POST", "/books", writer.Key, book)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var count int64
+	suite.db.Model(&models.Book{}).Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+func (suite *AuthAPITestSuite) TestAnonymousReadsCanBeDisabled() {
+	router := suite.router(false)
+
+	w := suite.request(router, "GET", "/books", "", nil)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = suite.request(router, "GET", "/books", suite.issue(models.ScopeBooksRead).Key, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request(router, "GET", "/books", suite.issue(models.ScopeAdmin).Key, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *AuthAPITestSuite) TestAdminKeyLifecycle() {
+	router := suite.router(true)
+	adminKey := suite.issue(models.ScopeAdmin)
+
+	w := suite.request(router, "POST", "/admin/api-keys", suite.issue(models.ScopeBooksWrite).Key,
+		controller.IssueAPIKeyRequest{Name: "ci", Scopes: []models.Scope{models.ScopeBooksWrite}})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.request(router, "POST", "/admin/api-keys", adminKey.Key,
+		controller.IssueAPIKeyRequest{Name: "ci", Scopes: []models.Scope{models.ScopeBooksWrite}})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	var issued models.IssuedAPIKey
+	json.Unmarshal(w.Body.Bytes(), &issued)
+	assert.NotEmpty(suite.T(), issued.Key)
+
+	w = suite.request(router, "GET", "/admin/api-keys", adminKey.Key, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), issued.Key)
+	assert.NotContains(suite.T(), w.Body.String(), "key_hash")
+
+	w = suite.request(router, "DELETE", "/books/1", issued.Key, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.request(router, "DELETE", fmt.Sprintf("/admin/api-keys/%d", issued.ID), adminKey.Key, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request(router, "DELETE", "/books/1", issued.Key, nil)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	var stored models.APIKey
+	suite.db.First(&stored, adminKey.ID)
+	assert.NotNil(suite.T(), stored.LastUsedAt)
+}
+
+func (suite *AuthAPITestSuite) TestBearerTokenPrincipal() {
+	secret := []byte("gateway-secret")
+	verifier := auth.NewJWTVerifier(auth.JWTConfig{Issuer: "gateway", Audience: "books-api", Algorithms: []string{"HS256"}}, auth.NewHMACKeySource(secret))
+	authenticator := middleware.NewAuthenticator(suite.keyService, verifier, true)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/whoami", authenticator.Require(models.ScopeBooksWrite), func(c *gin.Context) {
+		principal, ok := auth.PrincipalFromContext(c.Request.Context())
+		suite.Require().True(ok)
+		c.JSON(http.StatusOK, principal)
+	})
+
+	token := func(scope string, expiresIn time.Duration) string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iss": "gateway", "aud": "books-api", "sub": "alice",
+			"exp": time.Now().Add(expiresIn).Unix(), "scope": scope,
+		}).SignedString(secret)
+		suite.Require().NoError(err)
+		return signed
+	}
+	call := func(authorization string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/whoami", nil)
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call("Bearer " + token("books:write", time.Hour))
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var principal auth.Principal
+	json.Unmarshal(w.Body.Bytes(), &principal)
+	assert.Equal(suite.T(), "alice", principal.Subject)
+	assert.Equal(suite.T(), auth.MethodJWT, principal.Method)
+
+	w = call("Bearer " + token("books:read", time.Hour))
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = call("Bearer " + token("books:write", -time.Hour))
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	assert.Contains(suite.T(), w.Header().Get("WWW-Authenticate"), "invalid_token")
+
+	w = call("Basic YWxpY2U6c2VjcmV0")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	// Without a verifier bearer tokens are refused outright
+	router = suite.router(true)
+	req, _ := http.NewRequest("POST", "/books", nil)
+	req.Header.Set("Authorization", "Bearer "+token("books:write", time.Hour))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *AuthAPITestSuite) TestClientCertificatePrincipal() {
+	authenticator := middleware.NewAuthenticator(suite.keyService, nil, false)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/whoami", authenticator.Require(models.ScopeBooksWrite), func(c *gin.Context) {
+		principal, ok := auth.PrincipalFromContext(c.Request.Context())
+		suite.Require().True(ok)
+		c.JSON(http.StatusOK, principal)
+	})
+
+	// The TLS layer has already verified the chain against the client CAs
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "inventory-sync"}, Issuer: pkix.Name{CommonName: "Library CA"}}
+	call := func(key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/whoami", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		if key != "" {
+			req.Header.Set(middleware.APIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Certificates are no credentials until scopes are granted to them
+	w := call("")
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	authenticator.AcceptClientCertificates([]models.Scope{models.ScopeBooksWrite})
+	w = call("")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var principal auth.Principal
+	json.Unmarshal(w.Body.Bytes(), &principal)
+	assert.Equal(suite.T(), "inventory-sync", principal.Subject)
+	assert.Equal(suite.T(), "Library CA", principal.Issuer)
+	assert.Equal(suite.T(), auth.MethodClientCert, principal.Method)
+
+	// Explicit credentials take precedence over the certificate
+	w = call(suite.issue(models.ScopeBooksRead).Key)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *AuthAPITestSuite) TestEditorsOnlyChangeTheirOwnBooks() {
+	secret := []byte("gateway-secret")
+	verifier := auth.NewJWTVerifier(auth.JWTConfig{Issuer: "gateway", Audience: "books-api", Algorithms: []string{"HS256"}}, auth.NewHMACKeySource(secret))
+	router := suite.routerWithTokens(true, verifier)
+
+	call := func(method, path, subject, role string, payload interface{}) *httptest.ResponseRecorder {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iss": "gateway", "aud": "books-api", "sub": subject, "exp": time.Now().Add(time.Hour).Unix(),
+			"scope": "books:read books:write", "roles": []string{role},
+		}).SignedString(secret)
+		suite.Require().NoError(err)
+
+		var body bytes.Buffer
+		if payload != nil {
+			json.NewEncoder(&body).Encode(payload)
+		}
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call("POST", "/books", "viewer-vic", "viewer", models.Book{Title: "Nope", Author: "Nobody"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = call("POST", "/books", "eve", "editor", models.Book{Title: "Dune", Author: "Frank Herbert"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	var book models.Book
+	json.Unmarshal(w.Body.Bytes(), &book)
+	assert.Equal(suite.T(), "eve", book.CreatedBy)
+	path := fmt.Sprintf("/books/%d", book.ID)
+
+	w = call("PUT", path, "mallory", "editor", models.Book{Title: "Dune Messiah"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = call("DELETE", path, "mallory", "editor", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = call("PUT", path, "eve", "editor", models.Book{Title: "Dune Messiah"})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = call("PUT", path, "lee", "librarian", models.Book{Pages: 256})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = call("DELETE", path, "eve", "editor", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func TestAuthAPITestSuite(t *testing.T) {
+	suite.Run(t, new(AuthAPITestSuite))
+}
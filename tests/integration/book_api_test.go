@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"books-api/app/authz"
 	"books-api/app/controller"
 	"books-api/app/migrations"
 	"books-api/app/repository"
@@ -8,6 +9,7 @@ import (
 	"books-api/app/models"
 	"bytes"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -15,7 +17,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -26,18 +27,17 @@ type BookAPITestSuite struct {
 }
 
 func (suite *BookAPITestSuite) SetupTest() {
-	// Create in-memory database
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	suite.NoError(err)
+	// Create an empty test database
+	db := openTestDB(suite.T())
 
 	// Run migrations
-	migrationManager := migrations.NewMigrationManager()
-	err = migrationManager.RunMigrations(db)
+	migrationManager := migrations.NewMigrationManager(slog.Default())
+	err := migrationManager.RunMigrations(db)
 	suite.NoError(err)
 
 	// Setup layers
 	bookRepo := repository.NewBookRepository(db)
-	bookService := service.NewBookService(bookRepo)
+	bookService := service.NewBookService(bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), slog.Default())
 	bookController := controller.NewBookController(bookService)
 
 	// Setup router
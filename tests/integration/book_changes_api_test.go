@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/middleware"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"books-api/app/tenancy"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type BookChangesAPITestSuite struct {
+	suite.Suite
+	db            *gorm.DB
+	router        *gin.Engine
+	changeService service.BookChangeService
+}
+
+func (suite *BookChangesAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+	suite.NoError(tenancy.RegisterCallbacks(db))
+
+	bookRepo := repository.NewBookRepository(db)
+	tenantService := service.NewTenantService(repository.NewTenantRepository(db), bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), slog.Default())
+	keyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewTenantRepository(db), slog.Default())
+	authenticator := middleware.NewAuthenticator(keyService, nil, true)
+	tenantResolver := middleware.NewTenantResolver(tenantService, "", models.DefaultTenantSlug)
+
+	bookController := controller.NewBookController(service.NewBookService(bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), slog.Default()))
+	suite.changeService = service.NewBookChangeService(repository.NewBookChangeRepository(db), authz.AllowAll(), slog.Default())
+	changeController := controller.NewBookChangeController(suite.changeService)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	// Mirrors setupRoutes
+	api := router.Group("", authenticator.Authenticate(), tenantResolver.Resolve())
+	api.POST("/books", bookController.CreateBook)
+	api.GET("/books/changes", authenticator.Require(models.ScopeBooksRead), changeController.ListBookChanges)
+	api.PUT("/books/:id", bookController.UpdateBook)
+	api.DELETE("/books/:id", bookController.DeleteBook)
+
+	suite.db = db
+	suite.router = router
+}
+
+func (suite *BookChangesAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func TestBookChangesAPITestSuite(t *testing.T) {
+	suite.Run(t, new(BookChangesAPITestSuite))
+}
+
+func (suite *BookChangesAPITestSuite) request(method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+	req, _ := http.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *BookChangesAPITestSuite) createBook(title, author string) models.Book {
+	w := suite.request("POST", "/books", models.Book{Title: title, Author: author})
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var book models.Book
+	json.Unmarshal(w.Body.Bytes(), &book)
+	return book
+}
+
+func (suite *BookChangesAPITestSuite) changes(query string) models.BookChangePage {
+	w := suite.request("GET", "/books/changes"+query, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var page models.BookChangePage
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &page))
+	return page
+}
+
+func (suite *BookChangesAPITestSuite) TestSyncsIncrementally() {
+	dune := suite.createBook("Dune", "Frank Herbert")
+	emma := suite.createBook("Emma", "Jane Austen")
+
+	// A full sync in pages
+	first := suite.changes("?limit=1")
+	suite.Require().Len(first.Changes, 1)
+	assert.True(suite.T(), first.HasMore)
+	assert.Equal(suite.T(), dune.ID, first.Changes[0].Book.ID)
+	second := suite.changes("?limit=1&since=" + first.NextToken)
+	suite.Require().Len(second.Changes, 1)
+	assert.Equal(suite.T(), emma.ID, second.Changes[0].Book.ID)
+	done := suite.changes("?since=" + second.NextToken)
+	assert.Empty(suite.T(), done.Changes)
+	assert.False(suite.T(), done.HasMore)
+	assert.Equal(suite.T(), second.NextToken, done.NextToken)
+
+	// Only what changed since then
+	suite.Equal(http.StatusOK, suite.request("PUT", fmt.Sprintf("/books/%d", dune.ID), models.Book{Pages: 412}).Code)
+	suite.Equal(http.StatusOK, suite.request("DELETE", fmt.Sprintf("/books/%d", emma.ID), nil).Code)
+	page := suite.changes("?since=" + done.NextToken)
+	suite.Require().Len(page.Changes, 2)
+	assert.Equal(suite.T(), models.BookUpserted, page.Changes[0].Type)
+	assert.Equal(suite.T(), 412, page.Changes[0].Book.Pages)
+	assert.Equal(suite.T(), models.BookRemoved, page.Changes[1].Type)
+	assert.Equal(suite.T(), emma.ID, page.Changes[1].BookID)
+	assert.NotNil(suite.T(), page.Changes[1].DeletedAt)
+	assert.False(suite.T(), page.HasMore)
+}
+
+func (suite *BookChangesAPITestSuite) TestRefusesExpiredAndInvalidTokens() {
+	book := suite.createBook("Dune", "Frank Herbert")
+	token := suite.changes("").NextToken
+	suite.Equal(http.StatusOK, suite.request("DELETE", fmt.Sprintf("/books/%d", book.ID), nil).Code)
+
+	// The tombstone is gone, so a client holding the token misses the delete
+	purged, err := suite.changeService.PurgeTombstones(context.Background(), -time.Hour)
+	suite.Require().NoError(err)
+	suite.Equal(int64(1), purged)
+	w := suite.request("GET", "/books/changes?since="+token, nil)
+	assert.Equal(suite.T(), http.StatusGone, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "sync token expired")
+
+	// Starting over works
+	assert.Empty(suite.T(), suite.changes("").Changes)
+
+	for _, query := range []string{"?since=garbage", "?limit=0", "?limit=1001", "?limit=ten"} {
+		w := suite.request("GET", "/books/changes"+query, nil)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -0,0 +1,182 @@
+package integration
+
+import (
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type CirculationAPITestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	router *gin.Engine
+	book   models.Book
+}
+
+func (suite *CirculationAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+
+	bookRepo := repository.NewBookRepository(db)
+	copyRepo := repository.NewCopyRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+
+	policy := service.DefaultCirculationPolicy()
+	policy.MaxLoansPerMember = 1
+
+	bookController := controller.NewBookController(service.NewBookService(bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), slog.Default()))
+	copyController := controller.NewCopyController(service.NewCopyService(copyRepo, loanRepo, bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), policy, slog.Default()))
+	loanController := controller.NewLoanController(service.NewLoanService(loanRepo, repository.NewUnitOfWork(db, nil, slog.Default()), policy, slog.Default()))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	router.GET("/books/:id", bookController.GetBook)
+	router.DELETE("/books/:id", bookController.DeleteBook)
+	router.POST("/books/:id/copies", copyController.CreateCopy)
+	router.DELETE("/copies/:id", copyController.DeleteCopy)
+	router.POST("/copies/:id/checkout", loanController.Checkout)
+	router.POST("/copies/:id/return", loanController.Return)
+	router.POST("/copies/:id/renew", loanController.Renew)
+	router.GET("/loans/overdue", loanController.ListOverdueLoans)
+
+	suite.book = models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412}
+	db.Create(&suite.book)
+	for i := 1; i <= 2; i++ {
+		db.Create(&models.Member{Name: fmt.Sprintf("Member %d", i), Email: fmt.Sprintf("member%d@example.com", i), CardNumber: fmt.Sprintf("C-%03d", i)})
+	}
+
+	suite.db = db
+	suite.router = router
+}
+
+func (suite *CirculationAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func (suite *CirculationAPITestSuite) request(method, path string, payload interface{}) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *CirculationAPITestSuite) createCopy(barcode string) models.Copy {
+	w := suite.request("POST", fmt.Sprintf("/books/%d/copies", suite.book.ID), models.Copy{Barcode: barcode, Branch: "Main"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var bookCopy models.Copy
+	json.Unmarshal(w.Body.Bytes(), &bookCopy)
+	return bookCopy
+}
+
+func (suite *CirculationAPITestSuite) availability() models.BookAvailability {
+	w := suite.request("GET", fmt.Sprintf("/books/%d", suite.book.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var book models.Book
+	json.Unmarshal(w.Body.Bytes(), &book)
+	suite.Require().NotNil(book.Availability)
+	return *book.Availability
+}
+
+func (suite *CirculationAPITestSuite) TestCheckoutReturnCycle() {
+	first := suite.createCopy("B-001")
+	suite.createCopy("B-002")
+	assert.Equal(suite.T(), models.BookAvailability{Total: 2, Available: 2}, suite.availability())
+
+	w := suite.request("POST", fmt.Sprintf("/copies/%d/checkout", first.ID), controller.CheckoutRequest{MemberID: 1})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Equal(suite.T(), models.BookAvailability{Total: 2, Available: 1, OnLoan: 1}, suite.availability())
+
+	// No double checkout
+	w = suite.request("POST", fmt.Sprintf("/copies/%d/checkout", first.ID), controller.CheckoutRequest{MemberID: 2})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	// Copies on loan cannot be withdrawn
+	w = suite.request("DELETE", fmt.Sprintf("/copies/%d", first.ID), nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = suite.request("POST", fmt.Sprintf("/copies/%d/renew", first.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request("POST", fmt.Sprintf("/copies/%d/return", first.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var loan models.Loan
+	json.Unmarshal(w.Body.Bytes(), &loan)
+	assert.NotNil(suite.T(), loan.ReturnedAt)
+	assert.False(suite.T(), loan.Overdue)
+	assert.Equal(suite.T(), models.BookAvailability{Total: 2, Available: 2}, suite.availability())
+
+	w = suite.request("POST", fmt.Sprintf("/copies/%d/return", first.ID), nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *CirculationAPITestSuite) TestMaxLoansPerMember() {
+	first := suite.createCopy("B-001")
+	second := suite.createCopy("B-002")
+
+	w := suite.request("POST", fmt.Sprintf("/copies/%d/checkout", first.ID), controller.CheckoutRequest{MemberID: 1})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	w = suite.request("POST", fmt.Sprintf("/copies/%d/checkout", second.ID), controller.CheckoutRequest{MemberID: 1})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *CirculationAPITestSuite) TestOverdueLoans() {
+	bookCopy := suite.createCopy("B-001")
+	suite.db.Create(&models.Loan{
+		CopyID:       bookCopy.ID,
+		BookID:       suite.book.ID,
+		MemberID:     1,
+		CheckedOutAt: time.Now().Add(-30 * 24 * time.Hour),
+		DueAt:        time.Now().Add(-16 * 24 * time.Hour),
+	})
+
+	w := suite.request("GET", "/loans/overdue", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var loans []models.Loan
+	json.Unmarshal(w.Body.Bytes(), &loans)
+	assert.Len(suite.T(), loans, 1)
+	assert.True(suite.T(), loans[0].Overdue)
+
+	w = suite.request("POST", fmt.Sprintf("/copies/%d/renew", bookCopy.ID), nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *CirculationAPITestSuite) TestDeleteBookWithCopies() {
+	suite.createCopy("B-001")
+
+	w := suite.request("DELETE", fmt.Sprintf("/books/%d", suite.book.ID), nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func TestCirculationAPITestSuite(t *testing.T) {
+	suite.Run(t, new(CirculationAPITestSuite))
+}
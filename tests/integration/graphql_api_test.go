@@ -0,0 +1,205 @@
+package integration
+
+import (
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/graph"
+	"books-api/app/middleware"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type GraphQLAPITestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	keyService service.APIKeyService
+	reviewRepo repository.ReviewRepository
+}
+
+func (suite *GraphQLAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+
+	suite.db = db
+	suite.keyService = service.NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewTenantRepository(db), slog.Default())
+	suite.reviewRepo = repository.NewReviewRepository(db)
+}
+
+func (suite *GraphQLAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func TestGraphQLAPITestSuite(t *testing.T) {
+	suite.Run(t, new(GraphQLAPITestSuite))
+}
+
+// router mirrors setupRoutes for the GraphQL endpoint
+func (suite *GraphQLAPITestSuite) router(config graph.Config) *gin.Engine {
+	bookRepo := repository.NewBookRepository(suite.db)
+	bookService := service.NewBookService(bookRepo, repository.NewUnitOfWork(suite.db, nil, slog.Default()), authz.AllowAll(), slog.Default())
+	server, err := graph.NewServer(bookService, service.NewReviewService(suite.reviewRepo, bookRepo, repository.NewUnitOfWork(suite.db, nil, slog.Default()), authz.AllowAll(), slog.Default()), config)
+	suite.Require().NoError(err)
+	graphqlController := controller.NewGraphQLController(server)
+	authenticator := middleware.NewAuthenticator(suite.keyService, nil, true)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.SecurityHeaders(middleware.DefaultSecurityHeadersConfig()))
+
+	read := authenticator.Require(models.ScopeBooksRead)
+	api := router.Group("", authenticator.Authenticate())
+	api.POST("/graphql", read, graphqlController.Query)
+	api.GET("/graphql", read, graphqlController.QueryByGet)
+	api.GET("/graphql/playground", graphqlController.Playground)
+	return router
+}
+
+func (suite *GraphQLAPITestSuite) post(router *gin.Engine, key, query string, variables map[string]interface{}) map[string]interface{} {
+	var body bytes.Buffer
+	json.NewEncoder(&body).Encode(map[string]interface{}{"query": query, "variables": variables})
+	req, _ := http.NewRequest("POST", "/graphql", &body)
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(middleware.APIKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var result map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &result))
+	return result
+}
+
+func (suite *GraphQLAPITestSuite) get(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// errorCode returns the code of the first error of a result
+func errorCode(result map[string]interface{}) interface{} {
+	errs, _ := result["errors"].([]interface{})
+	if len(errs) == 0 {
+		return nil
+	}
+	extensions, _ := errs[0].(map[string]interface{})["extensions"].(map[string]interface{})
+	return extensions["code"]
+}
+
+func (suite *GraphQLAPITestSuite) TestBooksRoundTrip() {
+	router := suite.router(graph.DefaultConfig())
+	issued, err := suite.keyService.IssueKey(context.Background(), "ci", "", []models.Scope{models.ScopeBooksRead, models.ScopeBooksWrite}, nil)
+	suite.Require().NoError(err)
+
+	// Anonymous callers may read but not write
+	create := `mutation Create($input: BookInput!) { createBook(input: $input) { id title } }`
+	result := suite.post(router, "", create, map[string]interface{}{"input": map[string]interface{}{"title": "Dune", "author": "Frank Herbert"}})
+	assert.Equal(suite.T(), graph.CodeUnauthenticated, errorCode(result))
+
+	var ids []string
+	for _, title := range []string{"Dune", "Emma", "Ulysses"} {
+		result = suite.post(router, issued.Key, create, map[string]interface{}{"input": map[string]interface{}{"title": title, "author": "Someone", "pages": 100}})
+		suite.Require().Nil(result["errors"])
+		ids = append(ids, result["data"].(map[string]interface{})["createBook"].(map[string]interface{})["id"].(string))
+	}
+	for i, reviewer := range []string{"alice", "bob"} {
+		suite.Require().NoError(suite.reviewRepo.Create(context.Background(), &models.Review{BookID: uint(i + 1), Reviewer: reviewer, Rating: 4, Status: models.ReviewApproved}))
+	}
+	suite.Require().NoError(suite.reviewRepo.Create(context.Background(), &models.Review{BookID: 1, Reviewer: "carol", Rating: 1, Status: models.ReviewPending}))
+
+	// Pages of books with their approved reviews
+	result = suite.post(router, "", `{ books(first: 2) { nodes { title averageRating reviews { reviewer } } pageInfo { hasNextPage endCursor } } }`, nil)
+	suite.Require().Nil(result["errors"])
+	books := result["data"].(map[string]interface{})["books"].(map[string]interface{})
+	assert.Equal(suite.T(), []interface{}{
+		map[string]interface{}{"title": "Dune", "averageRating": float64(4), "reviews": []interface{}{map[string]interface{}{"reviewer": "alice"}}},
+		map[string]interface{}{"title": "Emma", "averageRating": float64(4), "reviews": []interface{}{map[string]interface{}{"reviewer": "bob"}}},
+	}, books["nodes"])
+	pageInfo := books["pageInfo"].(map[string]interface{})
+	assert.Equal(suite.T(), true, pageInfo["hasNextPage"])
+
+	next := url.Values{
+		"query":     {`query Next($after: String) { books(first: 2, after: $after) { nodes { title } pageInfo { hasNextPage } } }`},
+		"variables": {`{"after": "` + pageInfo["endCursor"].(string) + `"}`},
+	}
+	w := suite.get(router, "/graphql?"+next.Encode())
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.JSONEq(suite.T(), `{"data": {"books": {"nodes": [{"title": "Ulysses"}], "pageInfo": {"hasNextPage": false}}}}`, w.Body.String())
+
+	// Updates keep omitted fields, deletes remove the book
+	result = suite.post(router, issued.Key, `mutation { updateBook(id: "`+ids[0]+`", input: {pages: 412}) { title pages } }`, nil)
+	suite.Require().Nil(result["errors"])
+	assert.Equal(suite.T(), map[string]interface{}{"title": "Dune", "pages": float64(412)}, result["data"].(map[string]interface{})["updateBook"])
+
+	result = suite.post(router, issued.Key, `mutation { deleteBook(id: "`+ids[2]+`") }`, nil)
+	suite.Require().Nil(result["errors"])
+	result = suite.post(router, "", `{ book(id: "`+ids[2]+`") { title } }`, nil)
+	assert.Nil(suite.T(), result["errors"])
+	assert.Equal(suite.T(), map[string]interface{}{"book": nil}, result["data"])
+
+	result = suite.post(router, issued.Key, `mutation { deleteBook(id: "`+ids[2]+`") }`, nil)
+	assert.Equal(suite.T(), graph.CodeNotFound, errorCode(result))
+}
+
+func (suite *GraphQLAPITestSuite) TestGetIsReadOnly() {
+	router := suite.router(graph.DefaultConfig())
+
+	w := suite.get(router, "/graphql?"+url.Values{"query": {`mutation { deleteBook(id: "1") }`}}.Encode())
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "mutations must be sent with POST")
+
+	w = suite.get(router, "/graphql")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.get(router, "/graphql?"+url.Values{"query": {`{ books { nodes { id } } }`}, "variables": {"[1]"}}.Encode())
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *GraphQLAPITestSuite) TestLimits() {
+	config := graph.DefaultConfig()
+	config.MaxDepth = 2
+	router := suite.router(config)
+
+	result := suite.post(router, "", `{ books { nodes { tags { name } } } }`, nil)
+	assert.Equal(suite.T(), graph.CodeQueryTooDeep, errorCode(result))
+	assert.Nil(suite.T(), result["data"])
+
+	router = suite.router(graph.DefaultConfig())
+	result = suite.post(router, "", `{ books(first: 100) { nodes { id reviews(first: 100) { reviewer } } } }`, nil)
+	assert.Equal(suite.T(), graph.CodeQueryTooComplex, errorCode(result))
+}
+
+func (suite *GraphQLAPITestSuite) TestPlayground() {
+	w := suite.get(suite.router(graph.DefaultConfig()), "/graphql/playground")
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(suite.T(), w.Body.String(), "<script>")
+	// The page's own policy replaces the API-wide one
+	assert.Equal(suite.T(), graph.PlaygroundCSP, w.Header().Get("Content-Security-Policy"))
+	assert.Contains(suite.T(), graph.PlaygroundCSP, "script-src 'sha256-")
+
+	config := graph.DefaultConfig()
+	config.Playground = false
+	w = suite.get(suite.router(config), "/graphql/playground")
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,359 @@
+package integration
+
+import (
+	"books-api/app/authz"
+	"books-api/app/events"
+	"books-api/app/middleware"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/ratelimit"
+	"books-api/app/repository"
+	"books-api/app/rpc"
+	"books-api/app/rpc/booksv1"
+	"books-api/app/service"
+	"books-api/app/stream"
+	"books-api/app/tenancy"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/gorm"
+)
+
+type GRPCAPITestSuite struct {
+	suite.Suite
+	db             *gorm.DB
+	keyService     service.APIKeyService
+	authenticator  *middleware.Authenticator
+	tenantResolver *middleware.TenantResolver
+	bookServer     *rpc.BookServer
+	broker         *stream.Broker
+	dispatcher     *events.Dispatcher
+	server         *rpc.Server
+	router         *gin.Engine
+	conn           *grpc.ClientConn
+	client         booksv1.BookServiceClient
+}
+
+func (suite *GRPCAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+	suite.NoError(tenancy.RegisterCallbacks(db))
+
+	bookRepo := repository.NewBookRepository(db)
+	tenantService := service.NewTenantService(repository.NewTenantRepository(db), bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), slog.Default())
+	suite.keyService = service.NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewTenantRepository(db), slog.Default())
+	authenticator := middleware.NewAuthenticator(suite.keyService, nil, true)
+	tenantResolver := middleware.NewTenantResolver(tenantService, "", models.DefaultTenantSlug)
+
+	suite.broker = stream.NewBroker(stream.DefaultConfig())
+	subscribers := events.NewSubscribers()
+	subscribers.Subscribe(suite.broker.Publish)
+	suite.dispatcher = events.NewDispatcher(repository.NewOutboxRepository(db), []events.Sink{subscribers}, events.DefaultConfig(), slog.Default())
+
+	bookService := service.NewBookService(bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), slog.Default())
+	feedService := service.NewBookFeedService(suite.broker, authz.AllowAll(), slog.Default())
+	bookServer := rpc.NewBookServer(bookService, feedService, authenticator.AllowsAnonymous)
+	suite.authenticator = authenticator
+	suite.tenantResolver = tenantResolver
+	suite.bookServer = bookServer
+	suite.server, suite.conn = suite.serve(ratelimit.Config{Default: ratelimit.Unlimited})
+	suite.client = booksv1.NewBookServiceClient(suite.conn)
+
+	// Mirrors setupRoutes for the gateway
+	gateway, err := rpc.NewGateway(context.Background(), bookServer)
+	suite.Require().NoError(err)
+	gin.SetMode(gin.TestMode)
+	suite.router = gin.New()
+	api := suite.router.Group("", authenticator.Authenticate(), tenantResolver.Resolve())
+	api.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	api.Any("/v1/*path", gin.WrapH(gateway))
+
+	suite.db = db
+}
+
+func (suite *GRPCAPITestSuite) TearDownTest() {
+	suite.conn.Close()
+	suite.broker.Close()
+	suite.server.Stop()
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// serve starts a server for the suite's books with limits and connects to it
+func (suite *GRPCAPITestSuite) serve(limits ratelimit.Config) (*rpc.Server, *grpc.ClientConn) {
+	limiter := middleware.NewRateLimiter(ratelimit.NewMemoryStore(), limits, slog.Default())
+	server := rpc.NewServer(suite.bookServer, suite.authenticator, suite.tenantResolver, limiter, rpc.Config{Reflection: true}, nil, slog.Default())
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	suite.Require().NoError(err)
+	return server, conn
+}
+
+func TestGRPCAPITestSuite(t *testing.T) {
+	suite.Run(t, new(GRPCAPITestSuite))
+}
+
+// withKey returns a call context carrying a new API key with scopes
+func (suite *GRPCAPITestSuite) withKey(tenant string, scopes ...models.Scope) context.Context {
+	issued, err := suite.keyService.IssueKey(context.Background(), "ci", tenant, scopes, nil)
+	suite.Require().NoError(err)
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", issued.Key)
+}
+
+func (suite *GRPCAPITestSuite) TestBookLifecycle() {
+	ctx := suite.withKey("", models.ScopeBooksRead, models.ScopeBooksWrite)
+
+	created, err := suite.client.CreateBook(ctx, &booksv1.CreateBookRequest{Book: &booksv1.Book{
+		Title: "Dune", Author: "Frank Herbert", Pages: 412, Color: booksv1.Color_COLOR_GREEN,
+	}})
+	suite.Require().NoError(err)
+	assert.NotZero(suite.T(), created.GetId())
+	assert.Equal(suite.T(), booksv1.Color_COLOR_GREEN, created.GetColor())
+
+	// Reads are anonymous by default
+	fetched, err := suite.client.GetBook(context.Background(), &booksv1.GetBookRequest{Id: created.GetId()})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Dune", fetched.GetTitle())
+
+	updated, err := suite.client.UpdateBook(ctx, &booksv1.UpdateBookRequest{Id: created.GetId(), Book: &booksv1.Book{Pages: 500}})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int32(500), updated.GetPages())
+	assert.Equal(suite.T(), "Dune", updated.GetTitle())
+
+	_, err = suite.client.DeleteBook(ctx, &booksv1.DeleteBookRequest{Id: created.GetId()})
+	suite.Require().NoError(err)
+	_, err = suite.client.GetBook(ctx, &booksv1.GetBookRequest{Id: created.GetId()})
+	assert.Equal(suite.T(), codes.NotFound, status.Code(err))
+
+	_, err = suite.client.CreateBook(ctx, &booksv1.CreateBookRequest{Book: &booksv1.Book{Title: "Emma", Color: booksv1.Color(42)}})
+	assert.Equal(suite.T(), codes.InvalidArgument, status.Code(err))
+}
+
+func (suite *GRPCAPITestSuite) TestListBooksPages() {
+	ctx := suite.withKey("", models.ScopeBooksWrite, models.ScopeBooksRead)
+	for i := 1; i <= 5; i++ {
+		_, err := suite.client.CreateBook(ctx, &booksv1.CreateBookRequest{Book: &booksv1.Book{Title: fmt.Sprintf("Book %d", i), Author: "Author"}})
+		suite.Require().NoError(err)
+	}
+
+	var titles []string
+	req := &booksv1.ListBooksRequest{PageSize: 2, OrderBy: "title"}
+	for pages := 0; ; pages++ {
+		suite.Require().Less(pages, 3)
+		resp, err := suite.client.ListBooks(ctx, req)
+		suite.Require().NoError(err)
+		for _, book := range resp.GetBooks() {
+			titles = append(titles, book.GetTitle())
+		}
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+	assert.Equal(suite.T(), []string{"Book 1", "Book 2", "Book 3", "Book 4", "Book 5"}, titles)
+}
+
+func (suite *GRPCAPITestSuite) TestAuthenticationAndTenant() {
+	_, err := suite.client.CreateBook(context.Background(), &booksv1.CreateBookRequest{Book: &booksv1.Book{Title: "Dune", Author: "Frank Herbert"}})
+	assert.Equal(suite.T(), codes.Unauthenticated, status.Code(err))
+
+	bad := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "bk_invalid")
+	_, err = suite.client.GetBook(bad, &booksv1.GetBookRequest{Id: 1})
+	assert.Equal(suite.T(), codes.Unauthenticated, status.Code(err))
+
+	reader := suite.withKey("", models.ScopeBooksRead)
+	_, err = suite.client.DeleteBook(reader, &booksv1.DeleteBookRequest{Id: 1})
+	assert.Equal(suite.T(), codes.PermissionDenied, status.Code(err))
+
+	unknown := metadata.AppendToOutgoingContext(context.Background(), "x-tenant", "initech")
+	_, err = suite.client.GetBook(unknown, &booksv1.GetBookRequest{Id: 1})
+	assert.Equal(suite.T(), codes.NotFound, status.Code(err))
+}
+
+func (suite *GRPCAPITestSuite) TestRateLimits() {
+	server, conn := suite.serve(ratelimit.Config{
+		Default: ratelimit.Unlimited,
+		Groups: map[string]ratelimit.Limit{
+			"books":   {Requests: 2, Period: time.Minute},
+			"clients": {Requests: 6, Period: time.Minute},
+		},
+	})
+	defer server.Stop()
+	defer conn.Close()
+	client := booksv1.NewBookServiceClient(conn)
+
+	// Each principal has its own bucket in the books group
+	reader := suite.withKey("", models.ScopeBooksRead)
+	for i := 0; i < 2; i++ {
+		_, err := client.GetBook(reader, &booksv1.GetBookRequest{Id: 999})
+		assert.Equal(suite.T(), codes.NotFound, status.Code(err))
+	}
+	var header metadata.MD
+	_, err := client.GetBook(reader, &booksv1.GetBookRequest{Id: 999}, grpc.Header(&header))
+	assert.Equal(suite.T(), codes.ResourceExhausted, status.Code(err))
+	assert.Equal(suite.T(), []string{"30"}, header.Get("retry-after"))
+
+	other := suite.withKey("", models.ScopeBooksRead)
+	_, err = client.GetBook(other, &booksv1.GetBookRequest{Id: 999})
+	assert.Equal(suite.T(), codes.NotFound, status.Code(err))
+
+	// Streams are limited too
+	watch, err := client.WatchBooks(reader, &booksv1.WatchBooksRequest{})
+	suite.Require().NoError(err)
+	_, err = watch.Recv()
+	assert.Equal(suite.T(), codes.ResourceExhausted, status.Code(err))
+
+	// The client address is limited before credentials are checked
+	bad := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "bk_invalid")
+	_, err = client.GetBook(bad, &booksv1.GetBookRequest{Id: 1})
+	assert.Equal(suite.T(), codes.Unauthenticated, status.Code(err))
+	_, err = client.GetBook(bad, &booksv1.GetBookRequest{Id: 1})
+	assert.Equal(suite.T(), codes.ResourceExhausted, status.Code(err))
+
+	// Health checks are not limited
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *GRPCAPITestSuite) TestWatchBooks() {
+	ctx, cancel := context.WithTimeout(suite.withKey("", models.ScopeBooksRead, models.ScopeBooksWrite), 5*time.Second)
+	defer cancel()
+
+	watch, err := suite.client.WatchBooks(ctx, &booksv1.WatchBooksRequest{Author: "Frank Herbert", Types: []string{"book.created"}})
+	suite.Require().NoError(err)
+	// Headers arrive once the server has subscribed
+	_, err = watch.Header()
+	suite.Require().NoError(err)
+
+	for _, book := range []*booksv1.Book{{Title: "Emma", Author: "Jane Austen"}, {Title: "Dune", Author: "Frank Herbert"}} {
+		_, err := suite.client.CreateBook(ctx, &booksv1.CreateBookRequest{Book: book})
+		suite.Require().NoError(err)
+	}
+	_, err = suite.dispatcher.Dispatch(context.Background())
+	suite.Require().NoError(err)
+
+	resp, err := watch.Recv()
+	suite.Require().NoError(err)
+	event := resp.GetEvent()
+	suite.Require().NotNil(event)
+	assert.Equal(suite.T(), "book.created", event.GetType())
+	assert.Equal(suite.T(), "Dune", event.GetBook().GetTitle())
+	assert.NotZero(suite.T(), event.GetId())
+
+	// Resuming after an unknown event starts over with a reset
+	resumed, err := suite.client.WatchBooks(ctx, &booksv1.WatchBooksRequest{LastEventId: 999})
+	suite.Require().NoError(err)
+	resp, err = resumed.Recv()
+	suite.Require().NoError(err)
+	assert.True(suite.T(), resp.GetReset_())
+
+	invalid, err := suite.client.WatchBooks(ctx, &booksv1.WatchBooksRequest{Types: []string{"book.burned"}})
+	suite.Require().NoError(err)
+	_, err = invalid.Recv()
+	assert.Equal(suite.T(), codes.InvalidArgument, status.Code(err))
+}
+
+func (suite *GRPCAPITestSuite) TestHealthAndReflection() {
+	health := healthpb.NewHealthClient(suite.conn)
+	for _, service := range []string{"", "books.v1.BookService"} {
+		resp, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+
+	info, err := reflectionpb.NewServerReflectionClient(suite.conn).ServerReflectionInfo(context.Background())
+	suite.Require().NoError(err)
+	defer info.CloseSend()
+	suite.Require().NoError(info.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := info.Recv()
+	suite.Require().NoError(err)
+	var services []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	assert.Contains(suite.T(), services, "books.v1.BookService")
+	assert.Contains(suite.T(), services, "grpc.health.v1.Health")
+}
+
+func (suite *GRPCAPITestSuite) TestGateway() {
+	issued, err := suite.keyService.IssueKey(context.Background(), "ci", "", []models.Scope{models.ScopeBooksRead, models.ScopeBooksWrite}, nil)
+	suite.Require().NoError(err)
+
+	req, _ := http.NewRequest("POST", "/v1/books", strings.NewReader(`{"title":"Dune","author":"Frank Herbert","color":"COLOR_RED"}`))
+	req.Header.Set(middleware.APIKeyHeader, issued.Key)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var created map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(suite.T(), "COLOR_RED", created["color"])
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/v1/books/%v", created["id"]), nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"title":"Dune"`)
+
+	// Scopes and domain errors map to HTTP statuses
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/v1/books/%v", created["id"]), nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	req, _ = http.NewRequest("GET", "/v1/books/999", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *GRPCAPITestSuite) TestMultiplexedOnHTTPPort() {
+	httpServer := httptest.NewUnstartedServer(suite.server.Multiplex(suite.router))
+	httpServer.Config.Protocols = new(http.Protocols)
+	httpServer.Config.Protocols.SetHTTP1(true)
+	httpServer.Config.Protocols.SetUnencryptedHTTP2(true)
+	httpServer.Start()
+	defer httpServer.Close()
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(httpServer.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	suite.Require().NoError(err)
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	_, err = booksv1.NewBookServiceClient(conn).GetBook(context.Background(), &booksv1.GetBookRequest{Id: 999})
+	assert.Equal(suite.T(), codes.NotFound, status.Code(err))
+
+	// Everything else still reaches the router
+	httpResp, err := http.Get(httpServer.URL + "/health")
+	suite.Require().NoError(err)
+	httpResp.Body.Close()
+	assert.Equal(suite.T(), http.StatusOK, httpResp.StatusCode)
+}
@@ -0,0 +1,265 @@
+package integration
+
+import (
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type HoldAPITestSuite struct {
+	suite.Suite
+	db          *gorm.DB
+	router      *gin.Engine
+	holdService service.HoldService
+	book        models.Book
+	copy        models.Copy
+}
+
+func (suite *HoldAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+
+	bookRepo := repository.NewBookRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+	holdRepo := repository.NewHoldRepository(db)
+	unitOfWork := repository.NewUnitOfWork(db, nil, slog.Default())
+	policy := service.DefaultCirculationPolicy()
+
+	suite.holdService = service.NewHoldService(holdRepo, unitOfWork, policy, slog.Default())
+	bookController := controller.NewBookController(service.NewBookService(bookRepo, unitOfWork, authz.AllowAll(), slog.Default()))
+	loanController := controller.NewLoanController(service.NewLoanService(loanRepo, unitOfWork, policy, slog.Default()))
+	holdController := controller.NewHoldController(suite.holdService)
+	copyController := controller.NewCopyController(service.NewCopyService(repository.NewCopyRepository(db), loanRepo, bookRepo, unitOfWork, policy, slog.Default()))
+	memberController := controller.NewMemberController(service.NewMemberService(repository.NewMemberRepository(db), loanRepo, repository.NewFineRepository(db), unitOfWork, policy, slog.Default()))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	router.GET("/books/:id", bookController.GetBook)
+	router.POST("/books/:id/copies", copyController.CreateCopy)
+	router.POST("/books/:id/holds", holdController.PlaceHold)
+	router.GET("/members/:id/holds", holdController.ListMemberHolds)
+	router.DELETE("/members/:id", memberController.DeleteMember)
+	router.DELETE("/holds/:id", holdController.CancelHold)
+	router.DELETE("/copies/:id", copyController.DeleteCopy)
+	router.POST("/copies/:id/checkout", loanController.Checkout)
+	router.POST("/copies/:id/return", loanController.Return)
+
+	suite.book = models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412}
+	db.Create(&suite.book)
+	suite.copy = models.Copy{BookID: suite.book.ID, Barcode: "B-001", Branch: "Main", Condition: models.ConditionGood}
+	db.Create(&suite.copy)
+	for i := 1; i <= 3; i++ {
+		db.Create(&models.Member{Name: fmt.Sprintf("Member %d", i), Email: fmt.Sprintf("member%d@example.com", i), CardNumber: fmt.Sprintf("C-%03d", i)})
+	}
+
+	suite.db = db
+	suite.router = router
+}
+
+func (suite *HoldAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func (suite *HoldAPITestSuite) request(method, path string, payload interface{}) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *HoldAPITestSuite) placeHold(memberID uint) models.Hold {
+	w := suite.request("POST", fmt.Sprintf("/books/%d/holds", suite.book.ID), controller.PlaceHoldRequest{MemberID: memberID})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var hold models.Hold
+	json.Unmarshal(w.Body.Bytes(), &hold)
+	return hold
+}
+
+func (suite *HoldAPITestSuite) memberHolds(memberID uint) []models.Hold {
+	w := suite.request("GET", fmt.Sprintf("/members/%d/holds", memberID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var holds []models.Hold
+	json.Unmarshal(w.Body.Bytes(), &holds)
+	return holds
+}
+
+func (suite *HoldAPITestSuite) checkout(memberID uint) int {
+	w := suite.request("POST", fmt.Sprintf("/copies/%d/checkout", suite.copy.ID), controller.CheckoutRequest{MemberID: memberID})
+	return w.Code
+}
+
+func (suite *HoldAPITestSuite) TestHoldRequiresAllCopiesOut() {
+	w := suite.request("POST", fmt.Sprintf("/books/%d/holds", suite.book.ID), controller.PlaceHoldRequest{MemberID: 2})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *HoldAPITestSuite) TestReturnPromotesHoldsInOrder() {
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(1))
+
+	second := suite.placeHold(2)
+	third := suite.placeHold(3)
+	assert.Equal(suite.T(), models.HoldWaiting, second.Status)
+
+	// Member 2 cannot queue twice
+	w := suite.request("POST", fmt.Sprintf("/books/%d/holds", suite.book.ID), controller.PlaceHoldRequest{MemberID: 2})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = suite.request("POST", fmt.Sprintf("/copies/%d/return", suite.copy.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	holds := suite.memberHolds(2)
+	assert.Equal(suite.T(), models.HoldReady, holds[0].Status)
+	assert.Equal(suite.T(), suite.copy.ID, *holds[0].CopyID)
+	assert.Equal(suite.T(), models.HoldWaiting, suite.memberHolds(3)[0].Status)
+
+	// The copy is set aside, so it no longer counts as available
+	w = suite.request("GET", fmt.Sprintf("/books/%d", suite.book.ID), nil)
+	var book models.Book
+	json.Unmarshal(w.Body.Bytes(), &book)
+	assert.Equal(suite.T(), models.BookAvailability{Total: 1, OnHold: 1, Waiting: 1}, *book.Availability)
+
+	assert.Equal(suite.T(), http.StatusConflict, suite.checkout(3))
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(2))
+	assert.Equal(suite.T(), models.HoldFulfilled, suite.memberHolds(2)[0].Status)
+
+	// Cancelling a waiting hold leaves the queue empty
+	w = suite.request("DELETE", fmt.Sprintf("/holds/%d", third.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.request("DELETE", fmt.Sprintf("/holds/%d", third.ID), nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *HoldAPITestSuite) TestCancelReadyHoldPassesCopyOn() {
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(1))
+	second := suite.placeHold(2)
+	suite.placeHold(3)
+
+	suite.request("POST", fmt.Sprintf("/copies/%d/return", suite.copy.ID), nil)
+
+	w := suite.request("DELETE", fmt.Sprintf("/holds/%d", second.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	assert.Equal(suite.T(), models.HoldCancelled, suite.memberHolds(2)[0].Status)
+	assert.Equal(suite.T(), models.HoldReady, suite.memberHolds(3)[0].Status)
+}
+
+func (suite *HoldAPITestSuite) TestCopySetAsideCannotBeDeleted() {
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(1))
+	suite.placeHold(2)
+	w := suite.request("POST", fmt.Sprintf("/copies/%d/return", suite.copy.ID), nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	w = suite.request("DELETE", fmt.Sprintf("/copies/%d", suite.copy.ID), nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "copy is reserved for a hold")
+
+	// Once the hold is picked up and the copy returned, it can go
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(2))
+	w = suite.request("POST", fmt.Sprintf("/copies/%d/return", suite.copy.ID), nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	w = suite.request("DELETE", fmt.Sprintf("/copies/%d", suite.copy.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *HoldAPITestSuite) TestDeletingMemberCancelsHolds() {
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(1))
+	suite.placeHold(2)
+	suite.placeHold(3)
+	w := suite.request("POST", fmt.Sprintf("/copies/%d/return", suite.copy.ID), nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	suite.Require().Equal(models.HoldReady, suite.memberHolds(2)[0].Status)
+
+	// The copy set aside for the deleted member goes to the next in line
+	w = suite.request("DELETE", "/members/2", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), models.HoldCancelled, suite.memberHolds(2)[0].Status)
+	holds := suite.memberHolds(3)
+	assert.Equal(suite.T(), models.HoldReady, holds[0].Status)
+	assert.Equal(suite.T(), suite.copy.ID, *holds[0].CopyID)
+
+	// Waiting holds are cancelled too and never promoted
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(3))
+	suite.placeHold(1)
+	w = suite.request("DELETE", "/members/1", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), models.HoldCancelled, suite.memberHolds(1)[0].Status)
+	w = suite.request("POST", fmt.Sprintf("/copies/%d/return", suite.copy.ID), nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Equal(suite.T(), models.HoldCancelled, suite.memberHolds(1)[0].Status)
+}
+
+func (suite *HoldAPITestSuite) TestExpiredPickupWindowPassesCopyOn() {
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(1))
+	second := suite.placeHold(2)
+	suite.placeHold(3)
+
+	suite.request("POST", fmt.Sprintf("/copies/%d/return", suite.copy.ID), nil)
+	suite.db.Model(&models.Hold{}).Where("id = ?", second.ID).Update("expires_at", time.Now().Add(-time.Minute))
+
+	expired, err := suite.holdService.ExpireHolds(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, expired)
+
+	assert.Equal(suite.T(), models.HoldExpired, suite.memberHolds(2)[0].Status)
+	assert.Equal(suite.T(), models.HoldReady, suite.memberHolds(3)[0].Status)
+}
+
+func (suite *HoldAPITestSuite) TestNewCopyGoesToHoldQueue() {
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(1))
+	suite.placeHold(2)
+
+	w := suite.request("POST", fmt.Sprintf("/books/%d/copies", suite.book.ID), models.Copy{Barcode: "B-002", Branch: "Main"})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var created models.Copy
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	holds := suite.memberHolds(2)
+	assert.Equal(suite.T(), models.HoldReady, holds[0].Status)
+	assert.Equal(suite.T(), created.ID, *holds[0].CopyID)
+}
+
+func (suite *HoldAPITestSuite) TestWalkInCheckoutCannotJumpQueue() {
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(1))
+	suite.placeHold(2)
+
+	// The loan ends without a return promoting the queue
+	suite.db.Model(&models.Loan{}).Where("copy_id = ?", suite.copy.ID).Update("returned_at", time.Now())
+
+	assert.Equal(suite.T(), http.StatusConflict, suite.checkout(3))
+	holds := suite.memberHolds(2)
+	assert.Equal(suite.T(), models.HoldReady, holds[0].Status)
+	assert.Equal(suite.T(), suite.copy.ID, *holds[0].CopyID)
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(2))
+}
+
+func TestHoldAPITestSuite(t *testing.T) {
+	suite.Run(t, new(HoldAPITestSuite))
+}
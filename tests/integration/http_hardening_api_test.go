@@ -0,0 +1,182 @@
+package integration
+
+import (
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/middleware"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type HTTPHardeningAPITestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	router *gin.Engine
+	key    string
+}
+
+func (suite *HTTPHardeningAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+
+	keyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewTenantRepository(db), slog.Default())
+	issued, err := keyService.IssueKey(context.Background(), "frontend", "", []models.Scope{models.ScopeBooksRead, models.ScopeBooksWrite}, nil)
+	suite.NoError(err)
+
+	authenticator := middleware.NewAuthenticator(keyService, nil, false)
+	bookController := controller.NewBookController(service.NewBookService(repository.NewBookRepository(db), repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), slog.Default()))
+
+	cors := middleware.DefaultCORSConfig()
+	cors.AllowedOrigins = []string{"https://app.example.com"}
+	cors.MaxAge = time.Hour
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.Use(
+		middleware.SecurityHeaders(middleware.DefaultSecurityHeadersConfig()),
+		middleware.CORS(cors),
+		middleware.MaxBodySize(1024),
+	)
+	router.GET("/swagger/*any", func(c *gin.Context) {
+		c.String(http.StatusOK, "swagger")
+	})
+
+	api := router.Group("", authenticator.Authenticate())
+	api.POST("/books", authenticator.Require(models.ScopeBooksWrite), bookController.CreateBook)
+	api.GET("/books", authenticator.Require(models.ScopeBooksRead), bookController.ListBooks)
+
+	suite.db = db
+	suite.router = router
+	suite.key = issued.Key
+}
+
+func (suite *HTTPHardeningAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func (suite *HTTPHardeningAPITestSuite) request(req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *HTTPHardeningAPITestSuite) preflight(origin, method, headers string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("OPTIONS", "/books", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return suite.request(req)
+}
+
+func (suite *HTTPHardeningAPITestSuite) TestPreflight() {
+	// Preflights carry no credentials and must not be authenticated
+	w := suite.preflight("https://app.example.com", "POST", "content-type, x-api-key")
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.Equal(suite.T(), "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(suite.T(), w.Header().Get("Access-Control-Allow-Methods"), "POST")
+	assert.Contains(suite.T(), w.Header().Get("Access-Control-Allow-Headers"), middleware.APIKeyHeader)
+	assert.Equal(suite.T(), "3600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(suite.T(), w.Header().Get("Access-Control-Allow-Credentials"))
+
+	w = suite.preflight("https://evil.example.com", "POST", "")
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	assert.Empty(suite.T(), w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = suite.preflight("https://app.example.com", "PATCH", "")
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.preflight("https://app.example.com", "GET", "X-Debug")
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *HTTPHardeningAPITestSuite) TestCrossOriginRequest() {
+	req, _ := http.NewRequest("GET", "/books", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set(middleware.APIKeyHeader, suite.key)
+	w := suite.request(req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(suite.T(), w.Header().Get("Access-Control-Expose-Headers"), "RateLimit-Remaining")
+	assert.Contains(suite.T(), w.Header().Values("Vary"), "Origin")
+
+	// Other origins are served, but the browser keeps the response from them
+	req, _ = http.NewRequest("GET", "/books", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set(middleware.APIKeyHeader, suite.key)
+	w = suite.request(req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Empty(suite.T(), w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func (suite *HTTPHardeningAPITestSuite) TestSecurityHeaders() {
+	req, _ := http.NewRequest("GET", "/books", nil)
+	w := suite.request(req)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	assert.Equal(suite.T(), "max-age=31536000", w.Header().Get("Strict-Transport-Security"))
+	assert.Equal(suite.T(), "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(suite.T(), "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(suite.T(), "default-src 'none'; frame-ancestors 'none'", w.Header().Get("Content-Security-Policy"))
+
+	req, _ = http.NewRequest("GET", "/swagger/index.html", nil)
+	w = suite.request(req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Header().Get("Content-Security-Policy"), "default-src 'self'")
+	assert.Equal(suite.T(), "nosniff", w.Header().Get("X-Content-Type-Options"))
+}
+
+func (suite *HTTPHardeningAPITestSuite) TestBodyTooLarge() {
+	payload := `{"title":"` + strings.Repeat("a", 2048) + `","author":"Someone","pages":1}`
+
+	// Announced through Content-Length
+	req, _ := http.NewRequest("POST", "/books", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.APIKeyHeader, suite.key)
+	w := suite.request(req)
+	assert.Equal(suite.T(), http.StatusRequestEntityTooLarge, w.Code)
+
+	// Streamed without a length, so only noticed while decoding
+	req, _ = http.NewRequest("POST", "/books", io.NopCloser(bytes.NewBufferString(payload)))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.APIKeyHeader, suite.key)
+	w = suite.request(req)
+	assert.Equal(suite.T(), http.StatusRequestEntityTooLarge, w.Code)
+
+	var count int64
+	suite.db.Model(&models.Book{}).Count(&count)
+	assert.Zero(suite.T(), count)
+}
+
+func (suite *HTTPHardeningAPITestSuite) TestUnknownBookField() {
+	req, _ := http.NewRequest("POST", "/books", bytes.NewBufferString(`{"title":"Dune","author":"Frank Herbert","pages":412,"isbn":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.APIKeyHeader, suite.key)
+	w := suite.request(req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "isbn")
+}
+
+func TestHTTPHardeningAPITestSuite(t *testing.T) {
+	suite.Run(t, new(HTTPHardeningAPITestSuite))
+}
@@ -0,0 +1,191 @@
+package integration
+
+import (
+	"books-api/app/controller"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type MemberAPITestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	router *gin.Engine
+	copy   models.Copy
+}
+
+func (suite *MemberAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+
+	loanRepo := repository.NewLoanRepository(db)
+	memberRepo := repository.NewMemberRepository(db)
+	fineRepo := repository.NewFineRepository(db)
+	policy := service.DefaultCirculationPolicy()
+
+	memberController := controller.NewMemberController(service.NewMemberService(memberRepo, loanRepo, fineRepo, repository.NewUnitOfWork(db, nil, slog.Default()), policy, slog.Default()))
+	loanController := controller.NewLoanController(service.NewLoanService(loanRepo, repository.NewUnitOfWork(db, nil, slog.Default()), policy, slog.Default()))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	router.POST("/members", memberController.CreateMember)
+	router.GET("/members", memberController.ListMembers)
+	router.GET("/members/:id", memberController.GetMember)
+	router.PUT("/members/:id", memberController.UpdateMember)
+	router.DELETE("/members/:id", memberController.DeleteMember)
+	router.POST("/members/:id/suspend", memberController.SuspendMember)
+	router.POST("/members/:id/reinstate", memberController.ReinstateMember)
+	router.GET("/members/:id/loans", memberController.ListMemberLoans)
+	router.GET("/members/:id/fines", memberController.ListMemberFines)
+	router.POST("/fines/:id/pay", memberController.PayFine)
+	router.POST("/copies/:id/checkout", loanController.Checkout)
+	router.POST("/copies/:id/return", loanController.Return)
+
+	book := models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412}
+	db.Create(&book)
+	suite.copy = models.Copy{BookID: book.ID, Barcode: "B-001", Branch: "Main", Condition: models.ConditionGood}
+	db.Create(&suite.copy)
+
+	suite.db = db
+	suite.router = router
+}
+
+func (suite *MemberAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func (suite *MemberAPITestSuite) request(method, path string, payload interface{}) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *MemberAPITestSuite) createMember(name, email, card string) models.Member {
+	w := suite.request("POST", "/members", models.Member{Name: name, Email: email, CardNumber: card})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var member models.Member
+	json.Unmarshal(w.Body.Bytes(), &member)
+	return member
+}
+
+func (suite *MemberAPITestSuite) checkout(memberID uint) int {
+	w := suite.request("POST", fmt.Sprintf("/copies/%d/checkout", suite.copy.ID), controller.CheckoutRequest{MemberID: memberID})
+	return w.Code
+}
+
+func (suite *MemberAPITestSuite) TestCreateAndSearchMembers() {
+	ada := suite.createMember("Ada Lovelace", "ada@example.com", "C-001")
+	assert.Equal(suite.T(), models.MemberActive, ada.Status)
+	suite.createMember("Alan Turing", "alan@example.com", "C-002")
+
+	w := suite.request("POST", "/members", models.Member{Name: "Imposter", Email: "ADA@example.com", CardNumber: "C-003"})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = suite.request("GET", "/members?q=lovelace", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var members []models.Member
+	json.Unmarshal(w.Body.Bytes(), &members)
+	assert.Len(suite.T(), members, 1)
+	assert.Equal(suite.T(), ada.ID, members[0].ID)
+
+	w = suite.request("GET", "/members?status=banned", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request("PUT", fmt.Sprintf("/members/%d", ada.ID), models.Member{Email: "alan@example.com"})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = suite.request("GET", "/members/999", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *MemberAPITestSuite) TestSuspendedMemberCannotBorrow() {
+	member := suite.createMember("Ada Lovelace", "ada@example.com", "C-001")
+
+	w := suite.request("POST", fmt.Sprintf("/members/%d/suspend", member.ID), controller.SuspendMemberRequest{Reason: "lost books"})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), http.StatusConflict, suite.checkout(member.ID))
+	assert.Equal(suite.T(), http.StatusNotFound, suite.checkout(999))
+
+	w = suite.request("POST", fmt.Sprintf("/members/%d/reinstate", member.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(member.ID))
+}
+
+func (suite *MemberAPITestSuite) TestOverdueReturnChargesFine() {
+	member := suite.createMember("Ada Lovelace", "ada@example.com", "C-001")
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(member.ID))
+
+	w := suite.request("DELETE", fmt.Sprintf("/members/%d", member.ID), nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	// Backdate the loan so that it is returned during its 50th day late
+	suite.db.Model(&models.Loan{}).Where("member_id = ?", member.ID).
+		Update("due_at", time.Now().Add(-50*24*time.Hour+time.Hour))
+
+	w = suite.request("GET", fmt.Sprintf("/members/%d/loans", member.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var loans []models.Loan
+	json.Unmarshal(w.Body.Bytes(), &loans)
+	suite.Require().Len(loans, 1)
+	assert.True(suite.T(), loans[0].Overdue)
+
+	w = suite.request("POST", fmt.Sprintf("/copies/%d/return", suite.copy.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request("GET", fmt.Sprintf("/members/%d/fines", member.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var fines []models.Fine
+	json.Unmarshal(w.Body.Bytes(), &fines)
+	suite.Require().Len(fines, 1)
+	assert.Equal(suite.T(), 50, fines[0].DaysOverdue)
+	assert.Equal(suite.T(), int64(1250), fines[0].AmountCents)
+
+	// Fines above the policy threshold block further checkouts until paid
+	assert.Equal(suite.T(), http.StatusConflict, suite.checkout(member.ID))
+
+	w = suite.request("POST", fmt.Sprintf("/fines/%d/pay", fines[0].ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.request("POST", fmt.Sprintf("/fines/%d/pay", fines[0].ID), nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	assert.Equal(suite.T(), http.StatusCreated, suite.checkout(member.ID))
+}
+
+func (suite *MemberAPITestSuite) TestDeleteMember() {
+	member := suite.createMember("Ada Lovelace", "ada@example.com", "C-001")
+
+	w := suite.request("DELETE", fmt.Sprintf("/members/%d", member.ID), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request("GET", fmt.Sprintf("/members/%d", member.ID), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestMemberAPITestSuite(t *testing.T) {
+	suite.Run(t, new(MemberAPITestSuite))
+}
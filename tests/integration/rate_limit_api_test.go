@@ -0,0 +1,184 @@
+package integration
+
+import (
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/middleware"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/ratelimit"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// failingStore is a rate limit store that is down
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+type RateLimitAPITestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	keyService service.APIKeyService
+}
+
+func (suite *RateLimitAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+
+	suite.db = db
+	suite.keyService = service.NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewTenantRepository(db), slog.Default())
+}
+
+func (suite *RateLimitAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// router limits /books to three requests, leaves /tags unlimited and lets
+// each client address make twenty requests
+func (suite *RateLimitAPITestSuite) router(store ratelimit.Store) *gin.Engine {
+	authenticator := middleware.NewAuthenticator(suite.keyService, nil, true)
+	limiter := middleware.NewRateLimiter(store, ratelimit.Config{
+		Default: ratelimit.Limit{Requests: 3, Period: time.Minute},
+		Groups: map[string]ratelimit.Limit{
+			"tags":    ratelimit.Unlimited,
+			"clients": {Requests: 20, Period: time.Minute},
+		},
+	}, slog.Default())
+	bookController := controller.NewBookController(service.NewBookService(repository.NewBookRepository(suite.db), repository.NewUnitOfWork(suite.db, nil, slog.Default()), authz.AllowAll(), slog.Default()))
+	tagController := controller.NewTagController(service.NewTagService(repository.NewTagRepository(suite.db), repository.NewBookRepository(suite.db), repository.NewUnitOfWork(suite.db, nil, slog.Default()), authz.AllowAll(), slog.Default()))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.SetTrustedProxies(nil)
+
+	api := router.Group("", limiter.LimitClients("clients"), authenticator.Authenticate())
+	api.GET("/books", limiter.Limit("books"), authenticator.Require(models.ScopeBooksRead), bookController.ListBooks)
+	api.GET("/tags", limiter.Limit("tags"), authenticator.Require(models.ScopeBooksRead), tagController.ListTags)
+	return router
+}
+
+func (suite *RateLimitAPITestSuite) request(router *gin.Engine, path, remoteAddr, key string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.RemoteAddr = remoteAddr
+	if key != "" {
+		req.Header.Set(middleware.APIKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *RateLimitAPITestSuite) TestLimitsPerCaller() {
+	router := suite.router(ratelimit.NewMemoryStore())
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		w := suite.request(router, "/books", "192.0.2.1:1234", "")
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.Equal(suite.T(), "3", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(suite.T(), strconv.Itoa(remaining), w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(suite.T(), "3;w=60", w.Header().Get("RateLimit-Policy"))
+	}
+
+	w := suite.request(router, "/books", "192.0.2.1:5678", "")
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+	assert.Equal(suite.T(), "20", w.Header().Get("Retry-After"))
+	assert.Equal(suite.T(), "60", w.Header().Get("RateLimit-Reset"))
+
+	// Forwarding headers from untrusted peers do not pick a fresh bucket
+	req, _ := http.NewRequest("GET", "/books", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+
+	// Other addresses and keys have their own buckets, even from the same address
+	w = suite.request(router, "/books", "192.0.2.2:1234", "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	issued, err := suite.keyService.IssueKey(context.Background(), "script", "", []models.Scope{models.ScopeBooksRead}, nil)
+	suite.Require().NoError(err)
+	for i := 0; i < 3; i++ {
+		w = suite.request(router, "/books", "192.0.2.1:1234", issued.Key)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+	}
+	w = suite.request(router, "/books", "192.0.2.3:1234", issued.Key)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+
+	// Groups are limited separately and may be unlimited
+	for i := 0; i < 5; i++ {
+		w = suite.request(router, "/tags", "192.0.2.1:1234", "")
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.Empty(suite.T(), w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func (suite *RateLimitAPITestSuite) TestPolicyRoundsWindowUp() {
+	limiter := middleware.NewRateLimiter(ratelimit.NewMemoryStore(), ratelimit.Config{
+		Default: ratelimit.Limit{Requests: 2, Period: 1500 * time.Millisecond},
+	}, slog.Default())
+	router := gin.New()
+	router.GET("/books", limiter.Limit("books"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := suite.request(router, "/books", "192.0.2.1:1234", "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "2;w=2", w.Header().Get("RateLimit-Policy"))
+}
+
+func (suite *RateLimitAPITestSuite) TestLimitsClientsBeforeAuthentication() {
+	router := suite.router(ratelimit.NewMemoryStore())
+
+	// Invalid keys are rejected without ever reaching a per caller bucket
+	for i := 0; i < 20; i++ {
+		w := suite.request(router, "/books", "192.0.2.1:1234", "invalid-key-"+strconv.Itoa(i))
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	}
+	w := suite.request(router, "/books", "192.0.2.1:1234", "invalid-key")
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+	assert.Equal(suite.T(), "20", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(suite.T(), "3", w.Header().Get("Retry-After"))
+
+	// A valid key from the same address is throttled as well
+	issued, err := suite.keyService.IssueKey(context.Background(), "script", "", []models.Scope{models.ScopeBooksRead}, nil)
+	suite.Require().NoError(err)
+	w = suite.request(router, "/books", "192.0.2.1:1234", issued.Key)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+
+	// Other addresses are not affected
+	w = suite.request(router, "/books", "192.0.2.2:1234", issued.Key)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *RateLimitAPITestSuite) TestStoreFailureAllowsRequests() {
+	router := suite.router(failingStore{})
+
+	for i := 0; i < 5; i++ {
+		w := suite.request(router, "/books", "192.0.2.1:1234", "")
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+	}
+}
+
+func TestRateLimitAPITestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitAPITestSuite))
+}
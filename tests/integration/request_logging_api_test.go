@@ -0,0 +1,180 @@
+package integration
+
+import (
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/logging"
+	"books-api/app/middleware"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type RequestLoggingAPITestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	router     *gin.Engine
+	logs       *bytes.Buffer
+	keyService service.APIKeyService
+	key        *models.IssuedAPIKey
+}
+
+func (suite *RequestLoggingAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+
+	suite.logs = &bytes.Buffer{}
+	switches := logging.NewSwitches(logging.Config{Level: slog.LevelInfo}, logging.SQLConfig{})
+	logger := logging.New(suite.logs, logging.Config{Format: "json", Level: switches.Level()})
+
+	keyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewTenantRepository(db), slog.Default())
+	suite.key, err = keyService.IssueKey(context.Background(), "logging", "", []models.Scope{models.ScopeBooksRead, models.ScopeBooksWrite}, nil)
+	suite.NoError(err)
+
+	authenticator := middleware.NewAuthenticator(keyService, nil, true)
+	bookController := controller.NewBookController(service.NewBookService(repository.NewBookRepository(db), repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), logger))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.RequestLogger(logger), middleware.Recovery(logger))
+	api := router.Group("", authenticator.Authenticate())
+	api.POST("/books", authenticator.Require(models.ScopeBooksWrite), bookController.CreateBook)
+	api.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	loggingController := controller.NewLoggingController(switches, logger)
+	api.GET("/admin/logging", authenticator.Require(models.ScopeAdmin), loggingController.GetLoggingSettings)
+	api.PUT("/admin/logging", authenticator.Require(models.ScopeAdmin), loggingController.UpdateLoggingSettings)
+
+	suite.db = db
+	suite.router = router
+	suite.keyService = keyService
+}
+
+func (suite *RequestLoggingAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// records returns the JSON log records written so far
+func (suite *RequestLoggingAPITestSuite) records() []map[string]interface{} {
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(suite.logs.Bytes()))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		suite.Require().NoError(json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func (suite *RequestLoggingAPITestSuite) createBook(requestID string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412})
+	req, _ := http.NewRequest("POST", "/books", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.APIKeyHeader, suite.key.Key)
+	if requestID != "" {
+		req.Header.Set(middleware.RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *RequestLoggingAPITestSuite) TestRequestIDIsPropagated() {
+	w := suite.createBook("upstream-42")
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Equal(suite.T(), "upstream-42", w.Header().Get(middleware.RequestIDHeader))
+
+	records := suite.records()
+	suite.Require().NotEmpty(records)
+	for _, record := range records {
+		assert.Equal(suite.T(), "upstream-42", record["request_id"], record["msg"])
+		assert.Equal(suite.T(), fmt.Sprintf("api_key:%d", suite.key.ID), record["principal"], record["msg"])
+	}
+
+	access := records[len(records)-1]
+	assert.Equal(suite.T(), "request completed", access["msg"])
+	assert.Equal(suite.T(), "/books", access["route"])
+	assert.Equal(suite.T(), float64(http.StatusCreated), access["status"])
+
+	// The plaintext key never reaches the log
+	assert.NotContains(suite.T(), suite.logs.String(), suite.key.Key)
+}
+
+func (suite *RequestLoggingAPITestSuite) TestRequestIDIsGenerated() {
+	w := suite.createBook("")
+	generated := w.Header().Get(middleware.RequestIDHeader)
+	assert.Len(suite.T(), generated, 32)
+
+	// IDs that could forge log lines are replaced
+	w = suite.createBook("bad id\nlevel=ERROR")
+	assert.NotEqual(suite.T(), "bad id\nlevel=ERROR", w.Header().Get(middleware.RequestIDHeader))
+	assert.NotEqual(suite.T(), generated, w.Header().Get(middleware.RequestIDHeader))
+}
+
+func (suite *RequestLoggingAPITestSuite) TestPanicIsLogged() {
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+
+	records := suite.records()
+	suite.Require().Len(records, 2)
+	assert.Equal(suite.T(), "panic recovered", records[0]["msg"])
+	assert.Equal(suite.T(), "boom", records[0]["panic"])
+	assert.Equal(suite.T(), "ERROR", records[1]["level"])
+	assert.Equal(suite.T(), records[0]["request_id"], records[1]["request_id"])
+}
+
+func (suite *RequestLoggingAPITestSuite) TestAdminSwitchesLogging() {
+	admin, err := suite.keyService.IssueKey(context.Background(), "ops", "", []models.Scope{models.ScopeAdmin}, nil)
+	suite.Require().NoError(err)
+
+	call := func(method, key, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/admin/logging", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.APIKeyHeader, key)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call("PUT", suite.key.Key, `{"level":"debug"}`)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = call("GET", admin.Key, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.JSONEq(suite.T(), `{"level":"info","sql_statements":false,"slow_query_threshold":"0s"}`, w.Body.String())
+
+	w = call("PUT", admin.Key, `{"level":"debug","sql_statements":true,"slow_query_threshold":"100ms"}`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.JSONEq(suite.T(), `{"level":"debug","sql_statements":true,"slow_query_threshold":"100ms"}`, w.Body.String())
+
+	w = call("PUT", admin.Key, `{"level":"verbose"}`)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = call("GET", admin.Key, "")
+	assert.Contains(suite.T(), w.Body.String(), `"level":"debug"`)
+}
+
+func TestRequestLoggingAPITestSuite(t *testing.T) {
+	suite.Run(t, new(RequestLoggingAPITestSuite))
+}
@@ -0,0 +1,230 @@
+package integration
+
+import (
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/events"
+	"books-api/app/middleware"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"books-api/app/stream"
+	"books-api/app/tenancy"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// sseMessage is one message of an event stream
+type sseMessage struct {
+	id    string
+	event string
+	data  string
+}
+
+type StreamAPITestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	server     *httptest.Server
+	broker     *stream.Broker
+	dispatcher *events.Dispatcher
+}
+
+func (suite *StreamAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+	suite.NoError(tenancy.RegisterCallbacks(db))
+
+	bookRepo := repository.NewBookRepository(db)
+	tenantService := service.NewTenantService(repository.NewTenantRepository(db), bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), slog.Default())
+	keyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewTenantRepository(db), slog.Default())
+	authenticator := middleware.NewAuthenticator(keyService, nil, true)
+	tenantResolver := middleware.NewTenantResolver(tenantService, "", models.DefaultTenantSlug)
+
+	config := stream.DefaultConfig()
+	config.Heartbeat = 50 * time.Millisecond
+	suite.broker = stream.NewBroker(config)
+	subscribers := events.NewSubscribers()
+	subscribers.Subscribe(suite.broker.Publish)
+	suite.dispatcher = events.NewDispatcher(repository.NewOutboxRepository(db), []events.Sink{subscribers}, events.DefaultConfig(), slog.Default())
+
+	bookController := controller.NewBookController(service.NewBookService(bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), slog.Default()))
+	feedService := service.NewBookFeedService(suite.broker, authz.AllowAll(), slog.Default())
+	streamController := controller.NewStreamController(feedService, config.Heartbeat, func(string) bool { return false })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	// Mirrors setupRoutes
+	api := router.Group("", authenticator.Authenticate(), tenantResolver.Resolve())
+	read := authenticator.Require(models.ScopeBooksRead)
+	api.POST("/books", bookController.CreateBook)
+	api.DELETE("/books/:id", bookController.DeleteBook)
+	api.GET("/books/stream", read, streamController.StreamBooks)
+	api.GET("/books/stream/ws", read, streamController.StreamBooksWebSocket)
+
+	suite.db = db
+	suite.server = httptest.NewServer(router)
+}
+
+func (suite *StreamAPITestSuite) TearDownTest() {
+	suite.broker.Close()
+	suite.server.Close()
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func TestStreamAPITestSuite(t *testing.T) {
+	suite.Run(t, new(StreamAPITestSuite))
+}
+
+// createBook creates a book and delivers its event to the broker
+func (suite *StreamAPITestSuite) createBook(title, author string) models.Book {
+	body, _ := json.Marshal(models.Book{Title: title, Author: author})
+	resp, err := http.Post(suite.server.URL+"/books", "application/json", bytes.NewReader(body))
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	suite.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	var book models.Book
+	json.NewDecoder(resp.Body).Decode(&book)
+	suite.dispatch()
+	return book
+}
+
+func (suite *StreamAPITestSuite) dispatch() {
+	_, err := suite.dispatcher.Dispatch(context.Background())
+	suite.Require().NoError(err)
+}
+
+// openStream connects to the SSE endpoint and waits until it is subscribed
+func (suite *StreamAPITestSuite) openStream(query string, lastEventID string) (*bufio.Reader, func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	req, _ := http.NewRequestWithContext(ctx, "GET", suite.server.URL+"/books/stream"+query, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	suite.Equal("retry: 3000\n", suite.readLine(reader))
+	suite.Equal("\n", suite.readLine(reader))
+	return reader, func() {
+		cancel()
+		resp.Body.Close()
+	}
+}
+
+func (suite *StreamAPITestSuite) readLine(reader *bufio.Reader) string {
+	line, err := reader.ReadString('\n')
+	suite.Require().NoError(err)
+	return line
+}
+
+// next reads the next message, skipping heartbeat comments
+func (suite *StreamAPITestSuite) next(reader *bufio.Reader) sseMessage {
+	var message sseMessage
+	for {
+		line := strings.TrimSuffix(suite.readLine(reader), "\n")
+		switch {
+		case line == "" && message.event != "":
+			return message
+		case strings.HasPrefix(line, "id:"):
+			message.id = line[len("id:"):]
+		case strings.HasPrefix(line, "event:"):
+			message.event = line[len("event:"):]
+		case strings.HasPrefix(line, "data:"):
+			message.data = line[len("data:"):]
+		}
+	}
+}
+
+func (suite *StreamAPITestSuite) TestServerSentEventsWithFilterAndResume() {
+	reader, closeStream := suite.openStream("?author=frank%20herbert", "")
+	suite.createBook("Emma", "Jane Austen")
+	dune := suite.createBook("Dune", "Frank Herbert")
+
+	message := suite.next(reader)
+	assert.Equal(suite.T(), "book.created", message.event)
+	var event events.Event
+	suite.Require().NoError(json.Unmarshal([]byte(message.data), &event))
+	assert.Equal(suite.T(), dune.ID, event.BookID)
+	assert.Equal(suite.T(), fmt.Sprint(event.ID), message.id)
+	closeStream()
+
+	// Changes made while disconnected are replayed after Last-Event-ID
+	suite.createBook("Children of Dune", "Frank Herbert")
+	reader, closeStream = suite.openStream("?author=frank%20herbert", message.id)
+	defer closeStream()
+	replayed := suite.next(reader)
+	assert.Contains(suite.T(), replayed.data, "Children of Dune")
+
+	suite.createBook("Dune Messiah", "Frank Herbert")
+	assert.Contains(suite.T(), suite.next(reader).data, "Dune Messiah")
+}
+
+func (suite *StreamAPITestSuite) TestServerSentEventsResetAfterUnknownEvent() {
+	reader, closeStream := suite.openStream("", "999")
+	defer closeStream()
+
+	message := suite.next(reader)
+	assert.Equal(suite.T(), "stream.reset", message.event)
+}
+
+func (suite *StreamAPITestSuite) TestWebSocketWithTypeFilter() {
+	url := "ws" + strings.TrimPrefix(suite.server.URL, "http") + "/books/stream/ws?type=book.deleted"
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	suite.Require().NoError(err)
+	defer conn.Close()
+	assert.Equal(suite.T(), http.StatusSwitchingProtocols, resp.StatusCode)
+
+	book := suite.createBook("Dune", "Frank Herbert")
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/books/%d", suite.server.URL, book.ID), nil)
+	deleted, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	deleted.Body.Close()
+	suite.dispatch()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event events.Event
+	suite.Require().NoError(conn.ReadJSON(&event))
+	assert.Equal(suite.T(), events.BookDeleted, event.Type)
+	assert.Equal(suite.T(), book.ID, event.BookID)
+
+	// Shutting down closes the connection as going away
+	suite.broker.Close()
+	_, _, err = conn.ReadMessage()
+	assert.True(suite.T(), websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+}
+
+func (suite *StreamAPITestSuite) TestRejectsInvalidFiltersAndForeignOrigins() {
+	resp, err := http.Get(suite.server.URL + "/books/stream?type=book.burned")
+	suite.Require().NoError(err)
+	resp.Body.Close()
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.StatusCode)
+
+	url := "ws" + strings.TrimPrefix(suite.server.URL, "http") + "/books/stream/ws"
+	_, resp, err = websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.example"}})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusForbidden, resp.StatusCode)
+}
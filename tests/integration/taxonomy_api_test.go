@@ -0,0 +1,193 @@
+package integration
+
+import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type TaxonomyAPITestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	router *gin.Engine
+}
+
+func (suite *TaxonomyAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+
+	bookRepo := repository.NewBookRepository(db)
+	unitOfWork := repository.NewUnitOfWork(db, nil, slog.Default())
+	bookController := controller.NewBookController(service.NewBookService(bookRepo, unitOfWork, authz.AllowAll(), slog.Default()))
+	tagController := controller.NewTagController(service.NewTagService(repository.NewTagRepository(db), bookRepo, unitOfWork, authz.AllowAll(), slog.Default()))
+	categoryController := controller.NewCategoryController(service.NewCategoryService(repository.NewCategoryRepository(db), bookRepo, unitOfWork, authz.AllowAll(), slog.Default()))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	router.GET("/books", bookController.ListBooks)
+	router.PUT("/books/:id/tags", tagController.SetBookTags)
+	router.PUT("/books/:id/categories", categoryController.SetBookCategories)
+	router.GET("/tags", tagController.ListTags)
+	router.POST("/tags", tagController.CreateTag)
+	router.POST("/tags/:id/merge", tagController.MergeTag)
+	router.POST("/categories", categoryController.CreateCategory)
+	router.PUT("/categories/:id", categoryController.UpdateCategory)
+	router.DELETE("/categories/:id", categoryController.DeleteCategory)
+
+	suite.db = db
+	suite.router = router
+}
+
+func (suite *TaxonomyAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func (suite *TaxonomyAPITestSuite) request(method, path string, payload interface{}) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *TaxonomyAPITestSuite) createCategory(name string, parentID *uint) models.Category {
+	w := suite.request("POST", "/categories", models.Category{Name: name, ParentID: parentID})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var category models.Category
+	json.Unmarshal(w.Body.Bytes(), &category)
+	return category
+}
+
+func (suite *TaxonomyAPITestSuite) TestFilterBooksByTag() {
+	dune := models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412}
+	emma := models.Book{Title: "Emma", Author: "Jane Austen", Pages: 474}
+	suite.db.Create(&dune)
+	suite.db.Create(&emma)
+
+	w := suite.request("PUT", fmt.Sprintf("/books/%d/tags", dune.ID), controller.BookTagsRequest{Tags: []string{" Science  Fiction", "Classic"}})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.request("PUT", fmt.Sprintf("/books/%d/tags", emma.ID), controller.BookTagsRequest{Tags: []string{"classic"}})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request("GET", "/books?tag=SCIENCE%20FICTION", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var books []models.Book
+	json.Unmarshal(w.Body.Bytes(), &books)
+	assert.Len(suite.T(), books, 1)
+	assert.Equal(suite.T(), "Dune", books[0].Title)
+
+	w = suite.request("GET", "/tags", nil)
+	var counts []models.TagCount
+	json.Unmarshal(w.Body.Bytes(), &counts)
+	assert.Equal(suite.T(), []string{"classic", "science fiction"}, []string{counts[0].Name, counts[1].Name})
+	assert.Equal(suite.T(), int64(2), counts[0].BookCount)
+}
+
+func (suite *TaxonomyAPITestSuite) TestTaggingRequiresBookUpdate() {
+	dune := models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412, CreatedBy: "alice"}
+	suite.db.Create(&dune)
+	space := suite.createCategory("Space", nil)
+
+	// Editors may only update, and so tag, the books they created
+	bookRepo := repository.NewBookRepository(suite.db)
+	authorizer := authz.NewAuthorizer(authz.DefaultPolicy(), slog.Default())
+	unitOfWork := repository.NewUnitOfWork(suite.db, nil, slog.Default())
+	tagController := controller.NewTagController(service.NewTagService(repository.NewTagRepository(suite.db), bookRepo, unitOfWork, authorizer, slog.Default()))
+	categoryController := controller.NewCategoryController(service.NewCategoryService(repository.NewCategoryRepository(suite.db), bookRepo, unitOfWork, authorizer, slog.Default()))
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		principal := &auth.Principal{Subject: c.GetHeader("X-Subject"), Roles: []string{"editor"}}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+	})
+	router.PUT("/books/:id/tags", tagController.SetBookTags)
+	router.PUT("/books/:id/categories", categoryController.SetBookCategories)
+
+	put := func(subject, path string, payload interface{}) int {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("PUT", path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Subject", subject)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tagsPath := fmt.Sprintf("/books/%d/tags", dune.ID)
+	categoriesPath := fmt.Sprintf("/books/%d/categories", dune.ID)
+	assert.Equal(suite.T(), http.StatusForbidden, put("eve", tagsPath, controller.BookTagsRequest{Tags: []string{"classic"}}))
+	assert.Equal(suite.T(), http.StatusForbidden, put("eve", categoriesPath, controller.BookCategoriesRequest{CategoryIDs: []uint{space.ID}}))
+
+	var tags int64
+	suite.db.Model(&models.Tag{}).Count(&tags)
+	assert.Zero(suite.T(), tags)
+
+	assert.Equal(suite.T(), http.StatusOK, put("alice", tagsPath, controller.BookTagsRequest{Tags: []string{"classic"}}))
+	assert.Equal(suite.T(), http.StatusOK, put("alice", categoriesPath, controller.BookCategoriesRequest{CategoryIDs: []uint{space.ID}}))
+}
+
+func (suite *TaxonomyAPITestSuite) TestCreateTag_DuplicateConflict() {
+	w := suite.request("POST", "/tags", models.Tag{Name: "Fantasy"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	w = suite.request("POST", "/tags", models.Tag{Name: " fantasy "})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *TaxonomyAPITestSuite) TestFilterBooksByCategoryIncludesDescendants() {
+	fiction := suite.createCategory("Fiction", nil)
+	scifi := suite.createCategory("Science Fiction", &fiction.ID)
+
+	dune := models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412}
+	suite.db.Create(&dune)
+	w := suite.request("PUT", fmt.Sprintf("/books/%d/categories", dune.ID), controller.BookCategoriesRequest{CategoryIDs: []uint{scifi.ID}})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request("GET", fmt.Sprintf("/books?category=%d", fiction.ID), nil)
+	var books []models.Book
+	json.Unmarshal(w.Body.Bytes(), &books)
+	assert.Len(suite.T(), books, 1)
+
+	w = suite.request("GET", "/books?category=abc", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TaxonomyAPITestSuite) TestCategoryCannotMoveBelowDescendant() {
+	fiction := suite.createCategory("Fiction", nil)
+	scifi := suite.createCategory("Science Fiction", &fiction.ID)
+
+	w := suite.request("PUT", fmt.Sprintf("/categories/%d", fiction.ID), models.Category{ParentID: &scifi.ID})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request("DELETE", fmt.Sprintf("/categories/%d", fiction.ID), nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func TestTaxonomyAPITestSuite(t *testing.T) {
+	suite.Run(t, new(TaxonomyAPITestSuite))
+}
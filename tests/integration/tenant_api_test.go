@@ -0,0 +1,400 @@
+package integration
+
+import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/middleware"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"books-api/app/tenancy"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type TenantAPITestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	router     *gin.Engine
+	keyService service.APIKeyService
+	adminKey   string
+	secret     []byte
+}
+
+func (suite *TenantAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+	suite.NoError(tenancy.RegisterCallbacks(db))
+
+	bookRepo := repository.NewBookRepository(db)
+	tenantRepo := repository.NewTenantRepository(db)
+	suite.keyService = service.NewAPIKeyService(repository.NewAPIKeyRepository(db), tenantRepo, slog.Default())
+	tenantService := service.NewTenantService(tenantRepo, bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), slog.Default())
+
+	suite.secret = []byte("gateway-secret")
+	verifier := auth.NewJWTVerifier(auth.JWTConfig{Issuer: "gateway", Audience: "books-api", Algorithms: []string{"HS256"}}, auth.NewHMACKeySource(suite.secret))
+	authenticator := middleware.NewAuthenticator(suite.keyService, verifier, true)
+	tenantResolver := middleware.NewTenantResolver(tenantService, "books.example.com", models.DefaultTenantSlug)
+
+	bookController := controller.NewBookController(service.NewBookService(bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), slog.Default()))
+	tenantController := controller.NewTenantController(tenantService)
+	keyController := controller.NewAPIKeyController(suite.keyService)
+
+	copyRepo := repository.NewCopyRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+	memberRepo := repository.NewMemberRepository(db)
+	fineRepo := repository.NewFineRepository(db)
+	copyController := controller.NewCopyController(service.NewCopyService(copyRepo, loanRepo, bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), service.DefaultCirculationPolicy(), slog.Default()))
+	loanController := controller.NewLoanController(service.NewLoanService(loanRepo, repository.NewUnitOfWork(db, nil, slog.Default()), service.DefaultCirculationPolicy(), slog.Default()))
+	memberController := controller.NewMemberController(service.NewMemberService(memberRepo, loanRepo, fineRepo, repository.NewUnitOfWork(db, nil, slog.Default()), service.DefaultCirculationPolicy(), slog.Default()))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	// Mirrors setupRoutes: caller and tenant are resolved for every API route
+	api := router.Group("", authenticator.Authenticate(), tenantResolver.Resolve())
+	read := authenticator.Require(models.ScopeBooksRead)
+	write := authenticator.Require(models.ScopeBooksWrite)
+	api.GET("/books", read, bookController.ListBooks)
+	api.POST("/books", write, bookController.CreateBook)
+	api.GET("/books/:id", read, bookController.GetBook)
+	api.PUT("/books/:id", write, bookController.UpdateBook)
+	api.DELETE("/books/:id", write, bookController.DeleteBook)
+	api.POST("/books/:id/copies", write, copyController.CreateCopy)
+	api.GET("/books/:id/copies", read, copyController.ListBookCopies)
+	api.GET("/copies/:id", read, copyController.GetCopy)
+	api.PUT("/copies/:id", write, copyController.UpdateCopy)
+	api.DELETE("/copies/:id", write, copyController.DeleteCopy)
+	api.POST("/copies/:id/checkout", write, loanController.Checkout)
+	api.POST("/copies/:id/return", write, loanController.Return)
+	api.POST("/copies/:id/renew", write, loanController.Renew)
+	api.POST("/members", write, memberController.CreateMember)
+	api.GET("/members", write, memberController.ListMembers)
+	api.GET("/members/:id", write, memberController.GetMember)
+	api.PUT("/members/:id", write, memberController.UpdateMember)
+	api.DELETE("/members/:id", write, memberController.DeleteMember)
+	api.GET("/members/:id/loans", write, memberController.ListMemberLoans)
+
+	admin := api.Group("/admin", authenticator.Require(models.ScopeAdmin), authenticator.RequirePlatform())
+	admin.POST("/api-keys", keyController.IssueAPIKey)
+	admin.POST("/tenants", tenantController.CreateTenant)
+	admin.GET("/tenants", tenantController.ListTenants)
+	admin.GET("/tenants/:id", tenantController.GetTenant)
+	admin.PUT("/tenants/:id", tenantController.UpdateTenant)
+	admin.DELETE("/tenants/:id", tenantController.DeleteTenant)
+
+	issued, err := suite.keyService.IssueKey(context.Background(), "ops", "", []models.Scope{models.ScopeAdmin}, nil)
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.router = router
+	suite.adminKey = issued.Key
+}
+
+func (suite *TenantAPITestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// request calls the API; headers are set as given, e.g. X-API-Key and X-Tenant
+func (suite *TenantAPITestSuite) request(method, path string, headers map[string]string, payload interface{}) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		if name == "Host" {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *TenantAPITestSuite) createTenant(slug string, maxBooks int) models.Tenant {
+	w := suite.request("POST", "/admin/tenants", map[string]string{middleware.APIKeyHeader: suite.adminKey},
+		models.Tenant{Slug: slug, Name: slug, MaxBooks: maxBooks})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var tenant models.Tenant
+	json.Unmarshal(w.Body.Bytes(), &tenant)
+	return tenant
+}
+
+func (suite *TenantAPITestSuite) tenantKey(tenant string) string {
+	issued, err := suite.keyService.IssueKey(context.Background(), tenant+" staff", tenant, []models.Scope{models.ScopeBooksRead, models.ScopeBooksWrite}, nil)
+	suite.Require().NoError(err)
+	return issued.Key
+}
+
+func (suite *TenantAPITestSuite) createBook(headers map[string]string, title string) *httptest.ResponseRecorder {
+	return suite.request("POST", "/books", headers, models.Book{Title: title, Author: "Author"})
+}
+
+func (suite *TenantAPITestSuite) TestCataloguesAreIsolated() {
+	suite.createTenant("acme", 0)
+	suite.createTenant("globex", 0)
+	acme := map[string]string{middleware.APIKeyHeader: suite.tenantKey("acme")}
+	globex := map[string]string{middleware.APIKeyHeader: suite.tenantKey("globex")}
+
+	w := suite.createBook(acme, "Dune")
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	var dune models.Book
+	json.Unmarshal(w.Body.Bytes(), &dune)
+	path := fmt.Sprintf("/books/%d", dune.ID)
+
+	w = suite.createBook(globex, "Emma")
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	w = suite.request("GET", "/books", globex, nil)
+	var books []models.Book
+	json.Unmarshal(w.Body.Bytes(), &books)
+	assert.Len(suite.T(), books, 1)
+	assert.Equal(suite.T(), "Emma", books[0].Title)
+
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		w = suite.request(method, path, globex, models.Book{Title: "Hijacked"})
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code, method)
+	}
+	w = suite.request("GET", path, acme, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"title":"Dune"`)
+
+	// A bound key cannot switch tenant with the header
+	acme[middleware.TenantHeader] = "globex"
+	w = suite.request("GET", "/books", acme, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	// Anonymous readers pick the catalogue by header or subdomain, and get
+	// the default tenant otherwise
+	w = suite.request("GET", path, map[string]string{middleware.TenantHeader: "acme"}, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.request("GET", path, map[string]string{"Host": "acme.books.example.com:8080"}, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.request("GET", path, map[string]string{"Host": "globex.books.example.com"}, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = suite.request("GET", path, nil, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = suite.request("GET", "/books", map[string]string{middleware.TenantHeader: "initech"}, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TenantAPITestSuite) TestCopiesAreIsolated() {
+	suite.createTenant("acme", 0)
+	suite.createTenant("globex", 0)
+	acme := map[string]string{middleware.APIKeyHeader: suite.tenantKey("acme")}
+	globex := map[string]string{middleware.APIKeyHeader: suite.tenantKey("globex")}
+
+	w := suite.createBook(acme, "Dune")
+	var dune models.Book
+	json.Unmarshal(w.Body.Bytes(), &dune)
+	w = suite.request("POST", fmt.Sprintf("/books/%d/copies", dune.ID), acme, models.Copy{Barcode: "B-1", Branch: "Main"})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var bookCopy models.Copy
+	json.Unmarshal(w.Body.Bytes(), &bookCopy)
+	path := fmt.Sprintf("/copies/%d", bookCopy.ID)
+
+	w = suite.request("POST", "/members", globex, models.Member{Name: "Eve", Email: "eve@example.com", CardNumber: "C-1"})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var eve models.Member
+	json.Unmarshal(w.Body.Bytes(), &eve)
+
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		w = suite.request(method, path, globex, models.Copy{Branch: "Hijacked"})
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code, method)
+	}
+	for _, action := range []string{"return", "renew"} {
+		w = suite.request("POST", path+"/"+action, globex, nil)
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code, action)
+	}
+	w = suite.request("POST", path+"/checkout", globex, map[string]uint{"member_id": eve.ID})
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// The barcode is only taken within acme
+	w = suite.createBook(globex, "Emma")
+	var emma models.Book
+	json.Unmarshal(w.Body.Bytes(), &emma)
+	w = suite.request("POST", fmt.Sprintf("/books/%d/copies", emma.ID), globex, models.Copy{Barcode: "B-1", Branch: "Main"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	w = suite.request("GET", path, acme, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"branch":"Main"`)
+}
+
+func (suite *TenantAPITestSuite) TestMembersAreIsolated() {
+	suite.createTenant("acme", 0)
+	suite.createTenant("globex", 0)
+	acme := map[string]string{middleware.APIKeyHeader: suite.tenantKey("acme")}
+	globex := map[string]string{middleware.APIKeyHeader: suite.tenantKey("globex")}
+
+	w := suite.request("POST", "/members", acme, models.Member{Name: "Ada", Email: "ada@example.com", CardNumber: "C-1"})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var ada models.Member
+	json.Unmarshal(w.Body.Bytes(), &ada)
+	path := fmt.Sprintf("/members/%d", ada.ID)
+
+	w = suite.request("GET", "/members", globex, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.JSONEq(suite.T(), `[]`, w.Body.String())
+
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		w = suite.request(method, path, globex, models.Member{Name: "Hijacked"})
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code, method)
+	}
+	w = suite.request("GET", path+"/loans", globex, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// Email and card number are only taken within acme
+	w = suite.request("POST", "/members", globex, models.Member{Name: "Ada", Email: "ada@example.com", CardNumber: "C-1"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	w = suite.request("GET", path, acme, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"name":"Ada"`)
+}
+
+func (suite *TenantAPITestSuite) TestTokenTenantClaim() {
+	suite.createTenant("acme", 0)
+
+	token := func(tenant string) map[string]string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iss": "gateway", "aud": "books-api", "sub": "alice", "exp": time.Now().Add(time.Hour).Unix(),
+			"scope": "books:read books:write", "tenant": tenant,
+		}).SignedString(suite.secret)
+		suite.Require().NoError(err)
+		return map[string]string{"Authorization": "Bearer " + signed}
+	}
+
+	w := suite.createBook(token("acme"), "Dune")
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	var book models.Book
+	json.Unmarshal(w.Body.Bytes(), &book)
+	assert.NotEqual(suite.T(), uint(models.DefaultTenantID), book.TenantID)
+
+	w = suite.request("GET", "/books", map[string]string{"Host": "acme.books.example.com"}, nil)
+	assert.Contains(suite.T(), w.Body.String(), "Dune")
+	w = suite.request("GET", "/books", nil, nil)
+	assert.NotContains(suite.T(), w.Body.String(), "Dune")
+
+	headers := token("acme")
+	headers["Host"] = "globex.books.example.com"
+	w = suite.request("GET", "/books", headers, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *TenantAPITestSuite) TestBookQuota() {
+	tenant := suite.createTenant("acme", 1)
+	acme := map[string]string{middleware.APIKeyHeader: suite.tenantKey("acme")}
+	admin := map[string]string{middleware.APIKeyHeader: suite.adminKey}
+
+	w := suite.createBook(acme, "Dune")
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	w = suite.createBook(acme, "Emma")
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "book quota exceeded")
+
+	// Other tenants are not affected
+	w = suite.createBook(admin, "Emma")
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	path := fmt.Sprintf("/admin/tenants/%d", tenant.ID)
+	w = suite.request("GET", path, admin, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &tenant)
+	assert.Equal(suite.T(), &models.TenantUsage{Books: 1}, tenant.Usage)
+
+	w = suite.request("PUT", path, admin, models.Tenant{MaxBooks: 2})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.createBook(acme, "Emma")
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	w = suite.request("DELETE", path, admin, nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *TenantAPITestSuite) TestTenantAdministration() {
+	admin := map[string]string{middleware.APIKeyHeader: suite.adminKey}
+
+	w := suite.request("POST", "/admin/tenants", admin, models.Tenant{Slug: "Not A Slug", Name: "Acme"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	tenant := suite.createTenant("acme", 0)
+	w = suite.request("POST", "/admin/tenants", admin, models.Tenant{Slug: "acme", Name: "Acme again"})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = suite.request("GET", "/admin/tenants", admin, nil)
+	var tenants []models.Tenant
+	json.Unmarshal(w.Body.Bytes(), &tenants)
+	assert.Len(suite.T(), tenants, 2)
+
+	// Keys bound to a tenant cannot administer the platform, even with admin scope
+	issued, err := suite.keyService.IssueKey(context.Background(), "acme admin", "acme", []models.Scope{models.ScopeAdmin}, nil)
+	suite.Require().NoError(err)
+	w = suite.request("GET", "/admin/tenants", map[string]string{middleware.APIKeyHeader: issued.Key}, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.request("POST", "/admin/api-keys", admin,
+		controller.IssueAPIKeyRequest{Name: "ci", Scopes: []models.Scope{models.ScopeBooksWrite}, Tenant: "initech"})
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.request("DELETE", fmt.Sprintf("/admin/tenants/%d", models.DefaultTenantID), admin, nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	w = suite.request("DELETE", fmt.Sprintf("/admin/tenants/%d", tenant.ID), admin, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.request("GET", fmt.Sprintf("/admin/tenants/%d", tenant.ID), admin, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TenantAPITestSuite) TestDeletedTenantKeysDoNotCarryOver() {
+	admin := map[string]string{middleware.APIKeyHeader: suite.adminKey}
+	tenant := suite.createTenant("acme", 0)
+	acme := map[string]string{middleware.APIKeyHeader: suite.tenantKey("acme")}
+
+	// Members keep the tenant from being deleted
+	w := suite.request("POST", "/members", acme, models.Member{Name: "Ada", Email: "ada@example.com", CardNumber: "C-1"})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	var member models.Member
+	json.Unmarshal(w.Body.Bytes(), &member)
+	w = suite.request("DELETE", fmt.Sprintf("/admin/tenants/%d", tenant.ID), admin, nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "tenant has members")
+
+	w = suite.request("DELETE", fmt.Sprintf("/members/%d", member.ID), acme, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = suite.request("DELETE", fmt.Sprintf("/admin/tenants/%d", tenant.ID), admin, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	// The old key cannot act for a new tenant with the same slug
+	suite.createTenant("acme", 0)
+	w = suite.request("GET", "/books", acme, nil)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	w = suite.request("GET", "/books", map[string]string{middleware.APIKeyHeader: suite.tenantKey("acme")}, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func TestTenantAPITestSuite(t *testing.T) {
+	suite.Run(t, new(TenantAPITestSuite))
+}
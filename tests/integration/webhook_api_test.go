@@ -0,0 +1,291 @@
+package integration
+
+import (
+	"books-api/app/authz"
+	"books-api/app/controller"
+	"books-api/app/events"
+	"books-api/app/middleware"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/service"
+	"books-api/app/tenancy"
+	"books-api/app/webhook"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// receivedDelivery is a request the test receiver got
+type receivedDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+type WebhookAPITestSuite struct {
+	suite.Suite
+	db         *gorm.DB
+	router     *gin.Engine
+	keyService service.APIKeyService
+	dispatcher *events.Dispatcher
+	deliverer  *webhook.Deliverer
+	receiver   *httptest.Server
+
+	mu       sync.Mutex
+	received []receivedDelivery
+	status   int
+}
+
+func (suite *WebhookAPITestSuite) SetupTest() {
+	db := openTestDB(suite.T())
+
+	err := migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	suite.NoError(err)
+	suite.NoError(tenancy.RegisterCallbacks(db))
+
+	bookRepo := repository.NewBookRepository(db)
+	tenantRepo := repository.NewTenantRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	deliveryRepo := repository.NewWebhookDeliveryRepository(db)
+	suite.keyService = service.NewAPIKeyService(repository.NewAPIKeyRepository(db), tenantRepo, slog.Default())
+	tenantService := service.NewTenantService(tenantRepo, bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), slog.Default())
+
+	authenticator := middleware.NewAuthenticator(suite.keyService, nil, false)
+	tenantResolver := middleware.NewTenantResolver(tenantService, "", models.DefaultTenantSlug)
+	bookController := controller.NewBookController(service.NewBookService(bookRepo, repository.NewUnitOfWork(db, nil, slog.Default()), authz.AllowAll(), slog.Default()))
+	webhookController := controller.NewWebhookController(service.NewWebhookService(webhookRepo, deliveryRepo, slog.Default()))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	// Mirrors setupRoutes
+	api := router.Group("", authenticator.Authenticate(), tenantResolver.Resolve())
+	api.POST("/books", authenticator.Require(models.ScopeBooksWrite), bookController.CreateBook)
+	api.PUT("/books/:id", authenticator.Require(models.ScopeBooksWrite), bookController.UpdateBook)
+	webhooks := api.Group("/webhooks", authenticator.Require(models.ScopeWebhooks))
+	webhooks.POST("", webhookController.CreateWebhook)
+	webhooks.GET("", webhookController.ListWebhooks)
+	webhooks.GET("/:id", webhookController.GetWebhook)
+	webhooks.PUT("/:id", webhookController.UpdateWebhook)
+	webhooks.DELETE("/:id", webhookController.DeleteWebhook)
+	webhooks.GET("/:id/deliveries", webhookController.ListDeliveries)
+	webhooks.GET("/:id/deliveries/:deliveryId", webhookController.GetDelivery)
+	webhooks.POST("/:id/deliveries/:deliveryId/redeliver", webhookController.Redeliver)
+
+	suite.status = http.StatusOK
+	suite.received = nil
+	suite.receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		suite.mu.Lock()
+		suite.received = append(suite.received, receivedDelivery{header: r.Header, body: body})
+		status := suite.status
+		suite.mu.Unlock()
+		w.WriteHeader(status)
+		fmt.Fprint(w, "thanks")
+	}))
+
+	webhookConfig := webhook.DefaultConfig()
+	webhookConfig.AllowPrivateTargets = true
+	webhookConfig.MaxAttempts = 2
+	webhookConfig.RetryBase = time.Millisecond
+	webhookConfig.RetryMax = time.Millisecond
+	suite.deliverer = webhook.NewDeliverer(webhookRepo, deliveryRepo, webhookConfig, slog.Default())
+	suite.dispatcher = events.NewDispatcher(repository.NewOutboxRepository(db),
+		[]events.Sink{webhook.NewSink(webhookRepo, deliveryRepo)}, events.DefaultConfig(), slog.Default())
+
+	for _, slug := range []string{"acme", "globex"} {
+		suite.NoError(tenantRepo.Create(context.Background(), &models.Tenant{Slug: slug, Name: slug}))
+	}
+
+	suite.db = db
+	suite.router = router
+}
+
+func (suite *WebhookAPITestSuite) TearDownTest() {
+	suite.receiver.Close()
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func TestWebhookAPITestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookAPITestSuite))
+}
+
+func (suite *WebhookAPITestSuite) request(method, path, key string, payload interface{}) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	if payload != nil {
+		json.NewEncoder(&body).Encode(payload)
+	}
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.APIKeyHeader, key)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *WebhookAPITestSuite) tenantKey(tenant string, scopes ...models.Scope) string {
+	issued, err := suite.keyService.IssueKey(context.Background(), tenant+" integration", tenant, scopes, nil)
+	suite.Require().NoError(err)
+	return issued.Key
+}
+
+func (suite *WebhookAPITestSuite) createWebhook(key string, payload map[string]interface{}) models.WebhookWithSecret {
+	w := suite.request("POST", "/webhooks", key, payload)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var created models.WebhookWithSecret
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
+	return created
+}
+
+// deliver moves the outbox into the webhook queue and makes the due
+// deliveries
+func (suite *WebhookAPITestSuite) deliver() {
+	ctx := context.Background()
+	_, err := suite.dispatcher.Dispatch(ctx)
+	suite.Require().NoError(err)
+	_, err = suite.deliverer.Deliver(ctx)
+	suite.Require().NoError(err)
+}
+
+func (suite *WebhookAPITestSuite) TestSignedDeliveryOfBookEvents() {
+	key := suite.tenantKey("acme", models.ScopeBooksWrite, models.ScopeWebhooks)
+	created := suite.createWebhook(key, map[string]interface{}{"url": suite.receiver.URL, "events": []string{"book.created"}})
+	assert.True(suite.T(), len(created.Secret) > 16)
+
+	w := suite.request("POST", "/books", key, models.Book{Title: "Dune", Author: "Frank Herbert"})
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var book models.Book
+	json.Unmarshal(w.Body.Bytes(), &book)
+	// Updates are filtered out
+	w = suite.request("PUT", fmt.Sprintf("/books/%d", book.ID), key, models.Book{Title: "Dune Messiah"})
+	suite.Require().Equal(http.StatusOK, w.Code)
+	suite.deliver()
+
+	suite.Require().Len(suite.received, 1)
+	received := suite.received[0]
+	assert.Equal(suite.T(), "book.created", received.header.Get(webhook.HeaderEvent))
+	assert.NoError(suite.T(), webhook.Verify(created.Secret, received.header.Get(webhook.HeaderTimestamp),
+		received.header.Get(webhook.HeaderSignature), received.body, time.Now(), 5*time.Minute))
+	var event events.Event
+	suite.Require().NoError(json.Unmarshal(received.body, &event))
+	assert.Equal(suite.T(), book.ID, event.BookID)
+	assert.Equal(suite.T(), "Dune", event.Book.Title)
+
+	// The delivery log shows the attempt, and the secret is never listed
+	w = suite.request("GET", fmt.Sprintf("/webhooks/%d/deliveries", created.ID), key, nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var deliveries []models.WebhookDelivery
+	json.Unmarshal(w.Body.Bytes(), &deliveries)
+	suite.Require().Len(deliveries, 1)
+	assert.Equal(suite.T(), models.WebhookDeliverySucceeded, deliveries[0].Status)
+	assert.Equal(suite.T(), http.StatusOK, deliveries[0].ResponseStatus)
+	assert.Equal(suite.T(), "thanks", deliveries[0].ResponseBody)
+	assert.Equal(suite.T(), received.header.Get(webhook.HeaderID), fmt.Sprint(deliveries[0].ID))
+
+	w = suite.request("GET", fmt.Sprintf("/webhooks/%d", created.ID), key, nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), created.Secret)
+}
+
+func (suite *WebhookAPITestSuite) TestFailingWebhookIsDisabledAndRedelivered() {
+	key := suite.tenantKey("acme", models.ScopeBooksWrite, models.ScopeWebhooks)
+	created := suite.createWebhook(key, map[string]interface{}{"url": suite.receiver.URL})
+	suite.status = http.StatusInternalServerError
+
+	w := suite.request("POST", "/books", key, models.Book{Title: "Dune", Author: "Frank Herbert"})
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	suite.deliver()
+	time.Sleep(2 * time.Millisecond)
+	suite.deliver()
+	suite.Require().Len(suite.received, 2)
+
+	w = suite.request("GET", fmt.Sprintf("/webhooks/%d", created.ID), key, nil)
+	var disabled models.Webhook
+	json.Unmarshal(w.Body.Bytes(), &disabled)
+	suite.Require().NotNil(disabled.DisabledAt)
+	assert.Contains(suite.T(), disabled.DisabledReason, "failed 2 times")
+
+	w = suite.request("GET", fmt.Sprintf("/webhooks/%d/deliveries", created.ID), key, nil)
+	var deliveries []models.WebhookDelivery
+	json.Unmarshal(w.Body.Bytes(), &deliveries)
+	suite.Require().Len(deliveries, 1)
+	assert.Equal(suite.T(), models.WebhookDeliveryFailed, deliveries[0].Status)
+	assert.Equal(suite.T(), "webhook answered 500 Internal Server Error", deliveries[0].Error)
+	redeliver := fmt.Sprintf("/webhooks/%d/deliveries/%d/redeliver", created.ID, deliveries[0].ID)
+
+	// A disabled webhook has to be enabled before redelivering
+	w = suite.request("POST", redeliver, key, nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	w = suite.request("PUT", fmt.Sprintf("/webhooks/%d", created.ID), key, map[string]interface{}{"active": true})
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), "secret")
+
+	suite.status = http.StatusNoContent
+	w = suite.request("POST", redeliver, key, nil)
+	suite.Require().Equal(http.StatusAccepted, w.Code)
+	suite.deliver()
+	suite.Require().Len(suite.received, 3)
+	assert.Equal(suite.T(), suite.received[0].body, suite.received[2].body)
+
+	w = suite.request("GET", redeliver[:len(redeliver)-len("/redeliver")], key, nil)
+	var delivery models.WebhookDelivery
+	json.Unmarshal(w.Body.Bytes(), &delivery)
+	assert.Equal(suite.T(), models.WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(suite.T(), 1, delivery.Attempts)
+}
+
+func (suite *WebhookAPITestSuite) TestWebhooksAreTenantScoped() {
+	acme := suite.tenantKey("acme", models.ScopeBooksWrite, models.ScopeWebhooks)
+	globex := suite.tenantKey("globex", models.ScopeBooksWrite, models.ScopeWebhooks)
+	created := suite.createWebhook(acme, map[string]interface{}{"url": suite.receiver.URL})
+
+	w := suite.request("GET", fmt.Sprintf("/webhooks/%d", created.ID), globex, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = suite.request("GET", "/webhooks", globex, nil)
+	assert.JSONEq(suite.T(), "[]", w.Body.String())
+	w = suite.request("DELETE", fmt.Sprintf("/webhooks/%d", created.ID), globex, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// Books of another tenant are not delivered
+	w = suite.request("POST", "/books", globex, models.Book{Title: "Emma", Author: "Jane Austen"})
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	suite.deliver()
+	assert.Empty(suite.T(), suite.received)
+}
+
+func (suite *WebhookAPITestSuite) TestValidationAndScope() {
+	key := suite.tenantKey("acme", models.ScopeWebhooks)
+
+	w := suite.request("POST", "/webhooks", key, map[string]interface{}{"url": "ftp://partner.example"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = suite.request("POST", "/webhooks", key, map[string]interface{}{"url": suite.receiver.URL, "events": []string{"book.burned"}})
+	assert.JSONEq(suite.T(), `{"error":"invalid event type: book.burned"}`, w.Body.String())
+	w = suite.request("POST", "/webhooks", key, map[string]interface{}{"url": suite.receiver.URL, "secret": "short"})
+	assert.JSONEq(suite.T(), `{"error":"secret must be at least 16 characters"}`, w.Body.String())
+
+	created := suite.createWebhook(key, map[string]interface{}{"url": suite.receiver.URL, "secret": "a-secret-of-my-own"})
+	assert.Equal(suite.T(), "a-secret-of-my-own", created.Secret)
+	w = suite.request("PUT", fmt.Sprintf("/webhooks/%d", created.ID), key, map[string]interface{}{"rotate_secret": true})
+	var rotated models.WebhookWithSecret
+	json.Unmarshal(w.Body.Bytes(), &rotated)
+	assert.NotEqual(suite.T(), created.Secret, rotated.Secret)
+	assert.NotEmpty(suite.T(), rotated.Secret)
+
+	w = suite.request("GET", "/webhooks", suite.tenantKey("acme", models.ScopeBooksWrite), nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,165 @@
+package logging_test
+
+import (
+	"books-api/app/logging"
+	"books-api/app/models"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type sqlLogFixture struct {
+	db       *gorm.DB
+	switches *logging.Switches
+	buf      *bytes.Buffer
+}
+
+func newSQLLogFixture(t *testing.T, sql logging.SQLConfig) *sqlLogFixture {
+	buf := &bytes.Buffer{}
+	switches := logging.NewSwitches(logging.Config{Format: "json", Level: slog.LevelDebug}, sql)
+	logger := logging.New(buf, logging.Config{Format: "json", Level: switches.Level()})
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logging.NewSQLLogger(logger, switches, sql.RedactParams)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Tag{}))
+	buf.Reset()
+
+	return &sqlLogFixture{db: db, switches: switches, buf: buf}
+}
+
+func (f *sqlLogFixture) records(t *testing.T) []map[string]interface{} {
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(f.buf.Bytes()))
+	for scanner.Scan() {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	f.buf.Reset()
+	return records
+}
+
+func TestSQLLogger_StatementsCanBeSwitched(t *testing.T) {
+	f := newSQLLogFixture(t, logging.SQLConfig{RedactParams: true})
+
+	f.db.Create(&models.Tag{Name: "classics"})
+	assert.Empty(t, f.records(t))
+
+	enabled := true
+	_, err := f.switches.Apply(logging.SettingsUpdate{SQLStatements: &enabled})
+	require.NoError(t, err)
+
+	ctx := logging.WithRequestID(context.Background(), "req-7")
+	var tag models.Tag
+	f.db.WithContext(ctx).Where("name = ?", "classics").First(&tag)
+
+	records := f.records(t)
+	require.Len(t, records, 1)
+	assert.Equal(t, "DEBUG", records[0]["level"])
+	assert.Equal(t, "SQL query", records[0]["msg"])
+	assert.Equal(t, "req-7", records[0]["request_id"])
+	assert.Equal(t, float64(1), records[0]["rows"])
+	assert.Contains(t, records[0]["caller"], "sql_logger_test.go")
+	assert.Contains(t, records[0]["sql"], "name = ?")
+	assert.NotContains(t, records[0]["sql"], "classics")
+}
+
+func TestSQLLogger_ParamsCanBeLogged(t *testing.T) {
+	f := newSQLLogFixture(t, logging.SQLConfig{Statements: true})
+
+	var tag models.Tag
+	f.db.Where("name = ?", "classics").Find(&tag)
+
+	records := f.records(t)
+	require.Len(t, records, 1)
+	assert.Contains(t, records[0]["sql"], "name = \"classics\"")
+}
+
+func TestSQLLogger_SlowQueries(t *testing.T) {
+	f := newSQLLogFixture(t, logging.SQLConfig{SlowThreshold: time.Nanosecond, RedactParams: true})
+
+	f.db.Create(&models.Tag{Name: "classics"})
+
+	records := f.records(t)
+	require.Len(t, records, 1)
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, "Slow SQL query", records[0]["msg"])
+	assert.Equal(t, float64(1), records[0]["rows"])
+	assert.Contains(t, records[0], "duration")
+	assert.Contains(t, records[0], "threshold")
+
+	// Slow queries are still reported when the level hides statements
+	info := "info"
+	_, err := f.switches.Apply(logging.SettingsUpdate{Level: &info})
+	require.NoError(t, err)
+	f.db.Create(&models.Tag{Name: "poetry"})
+	assert.Len(t, f.records(t), 1)
+}
+
+func TestSQLLogger_FailedQueries(t *testing.T) {
+	f := newSQLLogFixture(t, logging.SQLConfig{RedactParams: true})
+
+	// Missing records are not failures
+	var tag models.Tag
+	f.db.First(&tag, 42)
+	assert.Empty(t, f.records(t))
+
+	f.db.Create(&models.Tag{Name: "classics"})
+	f.records(t)
+	f.db.Create(&models.Tag{Name: "classics"})
+
+	records := f.records(t)
+	require.Len(t, records, 1)
+	assert.Equal(t, "ERROR", records[0]["level"])
+	assert.Contains(t, records[0]["error"], "UNIQUE")
+}
+
+func TestSwitches_Apply(t *testing.T) {
+	switches := logging.NewSwitches(logging.Config{Level: slog.LevelInfo}, logging.SQLConfig{SlowThreshold: 200 * time.Millisecond})
+	assert.Equal(t, logging.Settings{Level: "info", SlowQueryThreshold: "200ms"}, switches.Settings())
+
+	level, threshold, enabled := "debug", "50ms", true
+	settings, err := switches.Apply(logging.SettingsUpdate{Level: &level, SQLStatements: &enabled, SlowQueryThreshold: &threshold})
+	require.NoError(t, err)
+	assert.Equal(t, logging.Settings{Level: "debug", SQLStatements: true, SlowQueryThreshold: "50ms"}, settings)
+	assert.Equal(t, slog.LevelDebug, switches.Level().Level())
+
+	// Invalid updates change nothing
+	bad, disabled := "-1s", false
+	_, err = switches.Apply(logging.SettingsUpdate{SQLStatements: &disabled, SlowQueryThreshold: &bad})
+	assert.Error(t, err)
+	assert.True(t, switches.SQLStatements())
+
+	loud := "loud"
+	_, err = switches.Apply(logging.SettingsUpdate{Level: &loud})
+	assert.EqualError(t, err, "invalid level: loud")
+}
+
+func TestLoadSQLConfig(t *testing.T) {
+	env := func(values map[string]string) func(string) (string, bool) {
+		return func(name string) (string, bool) {
+			value, ok := values[name]
+			return value, ok
+		}
+	}
+
+	config, err := logging.LoadSQLConfig(env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, logging.SQLConfig{SlowThreshold: logging.DefaultSlowThreshold, RedactParams: true}, config)
+
+	config, err = logging.LoadSQLConfig(env(map[string]string{"SQL_LOG_STATEMENTS": "true", "SQL_SLOW_THRESHOLD": "0", "SQL_REDACT_PARAMS": "false"}))
+	require.NoError(t, err)
+	assert.Equal(t, logging.SQLConfig{Statements: true}, config)
+
+	_, err = logging.LoadSQLConfig(env(map[string]string{"SQL_SLOW_THRESHOLD": "slow"}))
+	assert.Error(t, err)
+}
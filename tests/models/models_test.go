@@ -106,4 +106,20 @@ func TestBook_Update_PartialUpdate(t *testing.T) {
 	assert.Equal(t, "Original Author", original.Author)
 	assert.Equal(t, 100, original.Pages) // Should not change (0 is ignored)
 	assert.Equal(t, &red, original.Color) // Should not change
-}
\ No newline at end of file
+}
+
+func TestNormalizeTagName(t *testing.T) {
+	assert.Equal(t, "science fiction", models.NormalizeTagName("  Science \t FICTION "))
+	assert.Equal(t, "", models.NormalizeTagName("   "))
+}
+
+func TestAPIKey_HasScope(t *testing.T) {
+	writer := &models.APIKey{Scopes: []models.Scope{models.ScopeBooksWrite}}
+	assert.True(t, writer.HasScope(models.ScopeBooksWrite))
+	assert.False(t, writer.HasScope(models.ScopeBooksRead))
+	assert.False(t, writer.HasScope(models.ScopeAdmin))
+
+	admin := &models.APIKey{Scopes: []models.Scope{models.ScopeAdmin}}
+	assert.True(t, admin.HasScope(models.ScopeBooksRead))
+	assert.True(t, admin.HasScope(models.ScopeBooksWrite))
+}
@@ -0,0 +1,83 @@
+package ratelimit_test
+
+import (
+	"books-api/app/ratelimit"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 2, Period: time.Second, Burst: 3}
+	now := time.Now()
+	ctx := context.Background()
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		result, err := store.Take(ctx, "alice", limit, now)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result, _ := store.Take(ctx, "alice", limit, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, result.Reset)
+
+	// Other callers have their own bucket
+	result, _ = store.Take(ctx, "bob", limit, now)
+	assert.True(t, result.Allowed)
+
+	// Two tokens a second come back
+	result, _ = store.Take(ctx, "alice", limit, now.Add(500*time.Millisecond))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// The bucket never holds more than its burst
+	result, _ = store.Take(ctx, "alice", limit, now.Add(time.Hour))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ratelimit.ParseLimit("300/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 300, Period: time.Minute}, limit)
+	assert.Equal(t, 300, limit.Capacity())
+
+	limit, err = ratelimit.ParseLimit(" 10/1s,burst=50 ")
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 10, Period: time.Second, Burst: 50}, limit)
+	assert.Equal(t, "10/1s,burst=50", limit.String())
+
+	limit, err = ratelimit.ParseLimit("off")
+	assert.NoError(t, err)
+	assert.True(t, limit.IsUnlimited())
+
+	for _, value := range []string{"", "300", "0/1m", "10/never", "10/1m,size=5", "10/1m,burst=0"} {
+		_, err := ratelimit.ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	env := map[string]string{"RATE_LIMIT": "100/1m", "RATE_LIMIT_ADMIN": "off"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	config, err := ratelimit.LoadConfig(lookup, ratelimit.Limit{Requests: 300, Period: time.Minute}, "books", "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 100, Period: time.Minute}, config.For("books"))
+	assert.True(t, config.For("admin").IsUnlimited())
+
+	env["RATE_LIMIT_BOOKS"] = "lots"
+	_, err = ratelimit.LoadConfig(lookup, ratelimit.Unlimited, "books")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "RATE_LIMIT_BOOKS")
+}
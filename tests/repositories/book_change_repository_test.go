@@ -0,0 +1,141 @@
+package repositories_test
+
+import (
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/tenancy"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookChangeRepository_ReturnsLatestWritesAndTombstones(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	books := repository.NewBookRepository(db)
+	tags := repository.NewTagRepository(db)
+	changes := repository.NewBookChangeRepository(db)
+
+	dune := &models.Book{Title: "Dune", Author: "Frank Herbert"}
+	emma := &models.Book{Title: "Emma", Author: "Jane Austen"}
+	require.NoError(t, books.Create(ctx, dune))
+	require.NoError(t, books.Create(ctx, emma))
+	assert.Less(t, dune.ChangeSeq, emma.ChangeSeq)
+
+	dune.Pages = 412
+	require.NoError(t, books.Update(ctx, dune))
+	require.NoError(t, books.Delete(ctx, emma.ID))
+	tag := &models.Tag{Name: "sci-fi"}
+	require.NoError(t, tags.Create(context.Background(), tag))
+	require.NoError(t, books.ReplaceTags(ctx, dune, []models.Tag{*tag}))
+	// Renaming the tag changes the books carrying it
+	tag.Name = "science fiction"
+	require.NoError(t, tags.Update(context.Background(), tag))
+
+	all, err := changes.GetSince(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, models.BookRemoved, all[0].Type)
+	assert.Equal(t, emma.ID, all[0].BookID)
+	assert.Nil(t, all[0].Book)
+	assert.Equal(t, models.BookUpserted, all[1].Type)
+	assert.Equal(t, 412, all[1].Book.Pages)
+	assert.Equal(t, "science fiction", all[1].Book.Tags[0].Name)
+	assert.Less(t, all[0].Seq, all[1].Seq)
+
+	since, err := changes.GetSince(ctx, all[0].Seq, 10)
+	require.NoError(t, err)
+	require.Len(t, since, 1)
+	assert.Equal(t, dune.ID, since[0].BookID)
+
+	limited, err := changes.GetSince(ctx, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+	assert.Equal(t, all[0].Seq, limited[0].Seq)
+
+	// Reviews change the rating of the book
+	require.NoError(t, repository.NewReviewRepository(db).Create(context.Background(), &models.Review{BookID: dune.ID, Reviewer: "ana", Rating: 5, Status: models.ReviewApproved}))
+	rated, err := changes.GetSince(ctx, all[1].Seq, 10)
+	require.NoError(t, err)
+	require.Len(t, rated, 1)
+	assert.Equal(t, 5.0, rated[0].Book.AverageRating)
+}
+
+func TestBookChangeRepository_ScopesChangesToTenant(t *testing.T) {
+	db := setupTestDB(t)
+	tenantRepo := repository.NewTenantRepository(db)
+	acme := &models.Tenant{Slug: "acme", Name: "Acme"}
+	globex := &models.Tenant{Slug: "globex", Name: "Globex"}
+	require.NoError(t, tenantRepo.Create(context.Background(), acme))
+	require.NoError(t, tenantRepo.Create(context.Background(), globex))
+	acmeCtx := tenancy.WithTenant(context.Background(), acme)
+	globexCtx := tenancy.WithTenant(context.Background(), globex)
+
+	books := repository.NewBookRepository(db)
+	book := &models.Book{Title: "Dune", Author: "Frank Herbert"}
+	require.NoError(t, books.Create(acmeCtx, book))
+	require.NoError(t, books.Create(acmeCtx, &models.Book{Title: "Emma", Author: "Jane Austen"}))
+	require.NoError(t, books.Delete(acmeCtx, book.ID))
+
+	changes := repository.NewBookChangeRepository(db)
+	acmeChanges, err := changes.GetSince(acmeCtx, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, acmeChanges, 2)
+	globexChanges, err := changes.GetSince(globexCtx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, globexChanges)
+}
+
+func TestBookChangeRepository_PurgeTombstones(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	books := repository.NewBookRepository(db)
+	changes := repository.NewBookChangeRepository(db)
+
+	book := &models.Book{Title: "Dune", Author: "Frank Herbert"}
+	require.NoError(t, books.Create(ctx, book))
+	require.NoError(t, books.Delete(ctx, book.ID))
+	deleted, err := changes.GetSince(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+
+	purged, err := changes.PurgeTombstones(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = changes.PurgeTombstones(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	seq, err := changes.GetPurgedSeq(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, deleted[0].Seq, seq)
+
+	remaining, err := changes.GetSince(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+func TestMigrations_NumberExistingBooks(t *testing.T) {
+	db := setupTestDB(t)
+	books := repository.NewBookRepository(db)
+	book := &models.Book{Title: "Dune", Author: "Frank Herbert"}
+	require.NoError(t, books.Create(context.Background(), book))
+
+	// A book from before change sequences existed
+	require.NoError(t, db.Model(&models.Book{}).Where("id = ?", book.ID).UpdateColumn("change_seq", 0).Error)
+	require.NoError(t, migrations.NewMigrationManager(slog.Default()).RunMigrations(db))
+
+	changes, err := repository.NewBookChangeRepository(db).GetSince(context.Background(), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Greater(t, changes[0].Seq, book.ChangeSeq)
+
+	next := &models.Book{Title: "Emma", Author: "Jane Austen"}
+	require.NoError(t, books.Create(context.Background(), next))
+	assert.Greater(t, next.ChangeSeq, changes[0].Seq)
+}
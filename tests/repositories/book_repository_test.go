@@ -1,8 +1,12 @@
 package repositories_test
 
 import (
+	"books-api/app/migrations"
 	"books-api/app/repository"
 	"books-api/app/models"
+	"books-api/app/tenancy"
+	"context"
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,7 +20,11 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	assert.NoError(t, err)
 
 	// Run migrations
-	err = db.AutoMigrate(&models.Book{})
+	err = migrations.NewMigrationManager(slog.Default()).RunMigrations(db)
+	assert.NoError(t, err)
+
+	// Scope queries by tenant as the application does
+	err = tenancy.RegisterCallbacks(db)
 	assert.NoError(t, err)
 
 	return db
@@ -34,7 +42,7 @@ func TestBookRepository_Create(t *testing.T) {
 		Color:  &color,
 	}
 
-	err := repo.Create(book)
+	err := repo.Create(context.Background(), book)
 	assert.NoError(t, err)
 	assert.NotZero(t, book.ID)
 }
@@ -51,11 +59,11 @@ func TestBookRepository_GetByID(t *testing.T) {
 		Pages:  200,
 		Color:  &color,
 	}
-	err := repo.Create(book)
+	err := repo.Create(context.Background(), book)
 	assert.NoError(t, err)
 
 	// Retrieve it
-	retrieved, err := repo.GetByID(book.ID)
+	retrieved, err := repo.GetByID(context.Background(), book.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, book.Title, retrieved.Title)
 	assert.Equal(t, book.Author, retrieved.Author)
@@ -66,7 +74,7 @@ func TestBookRepository_GetByID_NotFound(t *testing.T) {
 	db := setupTestDB(t)
 	repo := repository.NewBookRepository(db)
 
-	_, err := repo.GetByID(999)
+	_, err := repo.GetByID(context.Background(), 999)
 	assert.Error(t, err)
 }
 
@@ -82,12 +90,12 @@ func TestBookRepository_GetAll(t *testing.T) {
 	}
 
 	for _, book := range books {
-		err := repo.Create(book)
+		err := repo.Create(context.Background(), book)
 		assert.NoError(t, err)
 	}
 
 	// Retrieve all
-	allBooks, err := repo.GetAll()
+	allBooks, err := repo.GetAll(context.Background(), models.BookFilter{})
 	assert.NoError(t, err)
 	assert.Len(t, allBooks, 3)
 }
@@ -104,17 +112,17 @@ func TestBookRepository_Update(t *testing.T) {
 		Pages:  100,
 		Color:  &color,
 	}
-	err := repo.Create(book)
+	err := repo.Create(context.Background(), book)
 	assert.NoError(t, err)
 
 	// Update it
 	book.Title = "Updated Title"
 	book.Pages = 150
-	err = repo.Update(book)
+	err = repo.Update(context.Background(), book)
 	assert.NoError(t, err)
 
 	// Verify update
-	updated, err := repo.GetByID(book.ID)
+	updated, err := repo.GetByID(context.Background(), book.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Title", updated.Title)
 	assert.Equal(t, 150, updated.Pages)
@@ -130,14 +138,40 @@ func TestBookRepository_Delete(t *testing.T) {
 		Author: "Test Author",
 		Pages:  100,
 	}
-	err := repo.Create(book)
+	err := repo.Create(context.Background(), book)
 	assert.NoError(t, err)
 
 	// Delete it
-	err = repo.Delete(book.ID)
+	err = repo.Delete(context.Background(), book.ID)
 	assert.NoError(t, err)
 
 	// Verify deletion
-	_, err = repo.GetByID(book.ID)
+	_, err = repo.GetByID(context.Background(), book.ID)
 	assert.Error(t, err)
 }
+
+func TestBookRepository_GetAll_Paged(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewBookRepository(db)
+
+	for _, title := range []string{"Ulysses", "Dune", "Emma"} {
+		assert.NoError(t, repo.Create(context.Background(), &models.Book{Title: title, Author: "Someone"}))
+	}
+
+	page, err := repo.GetAll(context.Background(), models.BookFilter{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.Equal(t, "Ulysses", page[0].Title)
+
+	page, err = repo.GetAll(context.Background(), models.BookFilter{Limit: 2, Offset: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "Emma", page[0].Title)
+
+	// The sort field comes first
+	page, err = repo.GetAll(context.Background(), models.BookFilter{Sort: "title", Limit: 2, Offset: 1})
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.Equal(t, "Emma", page[0].Title)
+	assert.Equal(t, "Ulysses", page[1].Title)
+}
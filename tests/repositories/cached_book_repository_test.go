@@ -0,0 +1,223 @@
+package repositories_test
+
+import (
+	"books-api/app/cache"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/tenancy"
+	"books-api/tests/repositories/mocks"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newCachedBookRepository() (*repository.CachedBookRepository, *mocks.MockBookRepository) {
+	books := new(mocks.MockBookRepository)
+	return repository.NewCachedBookRepository(books, cache.NewMemoryStore(10), time.Minute), books
+}
+
+func TestCachedBookRepository_GetByIDServesFromCache(t *testing.T) {
+	cached, books := newCachedBookRepository()
+	books.On("GetByID", uint(1)).Return(&models.Book{ID: 1, Title: "Dune", TenantID: 1}, nil).Once()
+	ctx := context.Background()
+
+	first, err := cached.GetByID(ctx, 1)
+	require.NoError(t, err)
+	second, err := cached.GetByID(ctx, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Dune", second.Title)
+	assert.NotSame(t, first, second, "callers get their own copy")
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 1, Loads: 1}, cached.Stats())
+	books.AssertExpectations(t)
+}
+
+func TestCachedBookRepository_ErrorsAreNotCached(t *testing.T) {
+	cached, books := newCachedBookRepository()
+	books.On("GetByID", uint(1)).Return(nil, gorm.ErrRecordNotFound).Twice()
+
+	for i := 0; i < 2; i++ {
+		_, err := cached.GetByID(context.Background(), 1)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	}
+	books.AssertExpectations(t)
+}
+
+func TestCachedBookRepository_WritesInvalidate(t *testing.T) {
+	ctx := context.Background()
+	book := &models.Book{ID: 1, Title: "Dune", TenantID: 1}
+	writes := map[string]struct {
+		expect func(books *mocks.MockBookRepository)
+		write  func(cached *repository.CachedBookRepository) error
+	}{
+		"Update": {
+			expect: func(books *mocks.MockBookRepository) { books.On("Update", book).Return(nil) },
+			write:  func(cached *repository.CachedBookRepository) error { return cached.Update(ctx, book) },
+		},
+		"Delete": {
+			expect: func(books *mocks.MockBookRepository) { books.On("Delete", uint(1)).Return(nil) },
+			write:  func(cached *repository.CachedBookRepository) error { return cached.Delete(ctx, 1) },
+		},
+		"ReplaceTags": {
+			expect: func(books *mocks.MockBookRepository) { books.On("ReplaceTags", book, []models.Tag(nil)).Return(nil) },
+			write:  func(cached *repository.CachedBookRepository) error { return cached.ReplaceTags(ctx, book, nil) },
+		},
+		"ReplaceCategories": {
+			expect: func(books *mocks.MockBookRepository) {
+				books.On("ReplaceCategories", book, []models.Category(nil)).Return(nil)
+			},
+			write: func(cached *repository.CachedBookRepository) error { return cached.ReplaceCategories(ctx, book, nil) },
+		},
+	}
+
+	for name, tc := range writes {
+		t.Run(name, func(t *testing.T) {
+			cached, books := newCachedBookRepository()
+			books.On("GetByID", uint(1)).Return(book, nil).Twice()
+			tc.expect(books)
+
+			_, err := cached.GetByID(ctx, 1)
+			require.NoError(t, err)
+			require.NoError(t, tc.write(cached))
+			_, err = cached.GetByID(ctx, 1)
+			require.NoError(t, err)
+
+			assert.Equal(t, cache.Stats{Misses: 2, Loads: 2}, cached.Stats())
+			books.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCachedBookRepository_WriteDuringLoadIsNotCachedStale(t *testing.T) {
+	cached, books := newCachedBookRepository()
+	ctx := context.Background()
+	book := &models.Book{ID: 1, Title: "Dune Messiah", TenantID: 1}
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	books.On("GetByID", uint(1)).
+		Run(func(mock.Arguments) {
+			close(loading)
+			<-release
+		}).
+		Return(&models.Book{ID: 1, Title: "Dune", TenantID: 1}, nil).Once()
+	books.On("Update", book).Return(nil).Once()
+	books.On("GetByID", uint(1)).Return(book, nil).Once()
+
+	// The book is updated after the load read it but before it is cached
+	loaded := make(chan error)
+	go func() {
+		_, err := cached.GetByID(ctx, 1)
+		loaded <- err
+	}()
+	<-loading
+	require.NoError(t, cached.Update(ctx, book))
+	close(release)
+	require.NoError(t, <-loaded)
+
+	stored, err := cached.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", stored.Title)
+	assert.Equal(t, cache.Stats{Misses: 2, Loads: 2}, cached.Stats())
+	books.AssertExpectations(t)
+}
+
+func TestCachedBookRepository_FailedWriteKeepsEntry(t *testing.T) {
+	cached, books := newCachedBookRepository()
+	ctx := context.Background()
+	book := &models.Book{ID: 1, Title: "Dune", TenantID: 1}
+	books.On("GetByID", uint(1)).Return(book, nil).Once()
+	books.On("Update", book).Return(gorm.ErrInvalidTransaction)
+
+	_, err := cached.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Error(t, cached.Update(ctx, book))
+	_, err = cached.GetByID(ctx, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1), cached.Stats().Hits)
+	books.AssertExpectations(t)
+}
+
+func TestCachedBookRepository_KeepsTenantScope(t *testing.T) {
+	cached, books := newCachedBookRepository()
+	books.On("GetByID", uint(1)).Return(&models.Book{ID: 1, Title: "Dune", TenantID: 1}, nil).Once()
+	owner := tenancy.WithTenant(context.Background(), &models.Tenant{ID: 1})
+	other := tenancy.WithTenant(context.Background(), &models.Tenant{ID: 2})
+
+	_, err := cached.GetByID(owner, 1)
+	require.NoError(t, err)
+
+	_, err = cached.GetByID(other, 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	book, err := cached.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune", book.Title)
+	books.AssertExpectations(t)
+}
+
+func TestCachedBookRepository_ConcurrentMissesShareOneLoad(t *testing.T) {
+	cached, books := newCachedBookRepository()
+	release := make(chan struct{})
+	books.On("GetByID", uint(1)).
+		Run(func(mock.Arguments) { <-release }).
+		Return(&models.Book{ID: 1, Title: "Dune", TenantID: 1}, nil).Once()
+
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cached.GetByID(context.Background(), 1)
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool { return cached.Stats().Misses == callers }, time.Second, time.Millisecond)
+	// Let the last callers reach the load in progress
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, cache.Stats{Misses: callers, Loads: 1}, cached.Stats())
+	books.AssertExpectations(t)
+}
+
+func TestCachedBookRepository_CancelledCallerDoesNotFailOthers(t *testing.T) {
+	cached, books := newCachedBookRepository()
+	release := make(chan struct{})
+	books.On("GetByID", uint(1)).
+		Run(func(mock.Arguments) { <-release }).
+		Return(&models.Book{ID: 1, Title: "Dune", TenantID: 1}, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := cached.GetByID(ctx, 1)
+		cancelled <- err
+	}()
+	require.Eventually(t, func() bool { return cached.Stats().Loads == 1 }, time.Second, time.Millisecond)
+	waiting := make(chan error)
+	go func() {
+		_, err := cached.GetByID(context.Background(), 1)
+		waiting <- err
+	}()
+	require.Eventually(t, func() bool { return cached.Stats().Misses == 2 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+	close(release)
+	assert.NoError(t, <-waiting)
+	books.AssertExpectations(t)
+}
@@ -0,0 +1,86 @@
+package repositories_test
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoldRepository_PromoteNext_FIFO(t *testing.T) {
+	db := setupTestDB(t)
+	holdRepo := repository.NewHoldRepository(db)
+
+	first := &models.Hold{BookID: 1, MemberID: 1, Status: models.HoldWaiting}
+	second := &models.Hold{BookID: 1, MemberID: 2, Status: models.HoldWaiting}
+	assert.NoError(t, holdRepo.Create(context.Background(), first))
+	assert.NoError(t, holdRepo.Create(context.Background(), second))
+
+	now := time.Now()
+	promoted, err := holdRepo.PromoteNext(context.Background(), 1, 10, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, promoted.ID)
+	assert.Equal(t, models.HoldReady, promoted.Status)
+	assert.Equal(t, uint(10), *promoted.CopyID)
+
+	promoted, err = holdRepo.PromoteNext(context.Background(), 1, 11, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, second.ID, promoted.ID)
+
+	promoted, err = holdRepo.PromoteNext(context.Background(), 1, 12, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Nil(t, promoted)
+}
+
+func TestHoldRepository_PromoteNext_ConcurrentReturns(t *testing.T) {
+	db := setupTestDB(t)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	holdRepo := repository.NewHoldRepository(db)
+
+	assert.NoError(t, holdRepo.Create(context.Background(), &models.Hold{BookID: 1, MemberID: 1, Status: models.HoldWaiting}))
+
+	var wg sync.WaitGroup
+	promoted := make(chan *models.Hold, 8)
+	for copyID := uint(1); copyID <= 8; copyID++ {
+		wg.Add(1)
+		go func(copyID uint) {
+			defer wg.Done()
+			now := time.Now()
+			hold, err := holdRepo.PromoteNext(context.Background(), 1, copyID, now, now.Add(time.Hour))
+			assert.NoError(t, err)
+			if hold != nil {
+				promoted <- hold
+			}
+		}(copyID)
+	}
+	wg.Wait()
+	close(promoted)
+
+	assert.Len(t, promoted, 1)
+}
+
+func TestHoldRepository_UpdateIfStatus(t *testing.T) {
+	db := setupTestDB(t)
+	holdRepo := repository.NewHoldRepository(db)
+
+	hold := &models.Hold{BookID: 1, MemberID: 1, Status: models.HoldWaiting}
+	assert.NoError(t, holdRepo.Create(context.Background(), hold))
+
+	hold.Status = models.HoldCancelled
+	saved, err := holdRepo.UpdateIfStatus(context.Background(), hold, models.HoldWaiting)
+	assert.NoError(t, err)
+	assert.True(t, saved)
+
+	hold.Status = models.HoldExpired
+	saved, err = holdRepo.UpdateIfStatus(context.Background(), hold, models.HoldReady)
+	assert.NoError(t, err)
+	assert.False(t, saved)
+
+	stored, _ := holdRepo.GetByID(context.Background(), hold.ID)
+	assert.Equal(t, models.HoldCancelled, stored.Status)
+}
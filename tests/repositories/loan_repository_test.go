@@ -0,0 +1,75 @@
+package repositories_test
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoanRepository_OneActiveLoanPerCopy(t *testing.T) {
+	db := setupTestDB(t)
+	loanRepo := repository.NewLoanRepository(db)
+
+	now := time.Now()
+	first := &models.Loan{CopyID: 1, BookID: 1, MemberID: 1, CheckedOutAt: now, DueAt: now.Add(time.Hour)}
+	assert.NoError(t, loanRepo.Create(context.Background(), first))
+
+	second := &models.Loan{CopyID: 1, BookID: 1, MemberID: 2, CheckedOutAt: now, DueAt: now.Add(time.Hour)}
+	assert.Error(t, loanRepo.Create(context.Background(), second))
+
+	// Once returned, the copy can be lent again
+	first.ReturnedAt = &now
+	assert.NoError(t, loanRepo.Update(context.Background(), first))
+	second.ID = 0
+	assert.NoError(t, loanRepo.Create(context.Background(), second))
+
+	count, err := loanRepo.CountActiveByMember(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestLoanRepository_GetOverdue(t *testing.T) {
+	db := setupTestDB(t)
+	loanRepo := repository.NewLoanRepository(db)
+
+	now := time.Now()
+	returned := now.Add(-time.Hour)
+	loans := []*models.Loan{
+		{CopyID: 1, BookID: 1, MemberID: 1, CheckedOutAt: now, DueAt: now.Add(-2 * time.Hour)},
+		{CopyID: 2, BookID: 1, MemberID: 1, CheckedOutAt: now, DueAt: now.Add(2 * time.Hour)},
+		{CopyID: 3, BookID: 1, MemberID: 1, CheckedOutAt: now, DueAt: now.Add(-2 * time.Hour), ReturnedAt: &returned},
+	}
+	for _, loan := range loans {
+		assert.NoError(t, loanRepo.Create(context.Background(), loan))
+	}
+
+	overdue, err := loanRepo.GetOverdue(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Len(t, overdue, 1)
+	assert.Equal(t, uint(1), overdue[0].CopyID)
+}
+
+func TestLoanRepository_MarkReturnedOnce(t *testing.T) {
+	db := setupTestDB(t)
+	loanRepo := repository.NewLoanRepository(db)
+
+	now := time.Now()
+	loan := &models.Loan{CopyID: 1, BookID: 1, MemberID: 1, CheckedOutAt: now, DueAt: now.Add(time.Hour)}
+	assert.NoError(t, loanRepo.Create(context.Background(), loan))
+
+	stale := *loan
+	returned, err := loanRepo.MarkReturned(context.Background(), loan, now)
+	assert.NoError(t, err)
+	assert.True(t, returned)
+	assert.NotNil(t, loan.ReturnedAt)
+
+	// A second return of the same loan changes nothing
+	returned, err = loanRepo.MarkReturned(context.Background(), &stale, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, returned)
+	assert.Nil(t, stale.ReturnedAt)
+}
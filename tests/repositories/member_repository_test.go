@@ -0,0 +1,54 @@
+package repositories_test
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemberRepository_Search(t *testing.T) {
+	db := setupTestDB(t)
+	memberRepo := repository.NewMemberRepository(db)
+
+	assert.NoError(t, memberRepo.Create(context.Background(), &models.Member{Name: "Ada Lovelace", Email: "ada@example.com", CardNumber: "C-001"}))
+	assert.NoError(t, memberRepo.Create(context.Background(), &models.Member{Name: "Alan Turing", Email: "alan@example.com", CardNumber: "C-002"}))
+	assert.NoError(t, memberRepo.Create(context.Background(), &models.Member{Name: "Grace Hopper", Email: "grace@example.com", CardNumber: "C-003", Status: models.MemberSuspended}))
+
+	members, err := memberRepo.Search(context.Background(), models.MemberFilter{Query: "AL"})
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+	assert.Equal(t, "Alan Turing", members[0].Name)
+
+	members, err = memberRepo.Search(context.Background(), models.MemberFilter{Query: "c-00"})
+	assert.NoError(t, err)
+	assert.Len(t, members, 3)
+	assert.Equal(t, "Ada Lovelace", members[0].Name)
+
+	members, err = memberRepo.Search(context.Background(), models.MemberFilter{Status: models.MemberSuspended})
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+	assert.Equal(t, "Grace Hopper", members[0].Name)
+}
+
+func TestFineRepository_SumUnpaidByMember(t *testing.T) {
+	db := setupTestDB(t)
+	fineRepo := repository.NewFineRepository(db)
+
+	total, err := fineRepo.SumUnpaidByMember(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	paidAt := time.Now()
+	assert.NoError(t, fineRepo.Create(context.Background(), &models.Fine{MemberID: 1, LoanID: 1, AmountCents: 100, DaysOverdue: 4}))
+	assert.NoError(t, fineRepo.Create(context.Background(), &models.Fine{MemberID: 1, LoanID: 2, AmountCents: 50, DaysOverdue: 2}))
+	assert.NoError(t, fineRepo.Create(context.Background(), &models.Fine{MemberID: 1, LoanID: 3, AmountCents: 75, DaysOverdue: 3, PaidAt: &paidAt}))
+	assert.NoError(t, fineRepo.Create(context.Background(), &models.Fine{MemberID: 2, LoanID: 4, AmountCents: 25, DaysOverdue: 1}))
+
+	total, err = fineRepo.SumUnpaidByMember(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), total)
+}
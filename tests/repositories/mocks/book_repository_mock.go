@@ -2,20 +2,23 @@ package mocks
 
 import (
 	"books-api/app/models"
+	"context"
 	"github.com/stretchr/testify/mock"
 )
 
-// MockBookRepository is a mock implementation of BookRepository interface
+// MockBookRepository is a mock implementation of BookRepository interface.
+// The context is not recorded; expectations match on the remaining
+// arguments.
 type MockBookRepository struct {
 	mock.Mock
 }
 
-func (m *MockBookRepository) Create(book *models.Book) error {
+func (m *MockBookRepository) Create(ctx context.Context, book *models.Book) error {
 	args := m.Called(book)
 	return args.Error(0)
 }
 
-func (m *MockBookRepository) GetByID(id uint) (*models.Book, error) {
+func (m *MockBookRepository) GetByID(ctx context.Context, id uint) (*models.Book, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -23,17 +26,40 @@ func (m *MockBookRepository) GetByID(id uint) (*models.Book, error) {
 	return args.Get(0).(*models.Book), args.Error(1)
 }
 
-func (m *MockBookRepository) GetAll() ([]models.Book, error) {
-	args := m.Called()
+func (m *MockBookRepository) GetAll(ctx context.Context, filter models.BookFilter) ([]models.Book, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]models.Book), args.Error(1)
 }
 
-func (m *MockBookRepository) Update(book *models.Book) error {
+func (m *MockBookRepository) Count(ctx context.Context) (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockBookRepository) Update(ctx context.Context, book *models.Book) error {
 	args := m.Called(book)
 	return args.Error(0)
 }
 
-func (m *MockBookRepository) Delete(id uint) error {
+func (m *MockBookRepository) Delete(ctx context.Context, id uint) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+
+func (m *MockBookRepository) ReplaceTags(ctx context.Context, book *models.Book, tags []models.Tag) error {
+	args := m.Called(book, tags)
+	return args.Error(0)
+}
+
+func (m *MockBookRepository) ReplaceCategories(ctx context.Context, book *models.Book, categories []models.Category) error {
+	args := m.Called(book, categories)
+	return args.Error(0)
+}
+
+func (m *MockBookRepository) GetAvailability(ctx context.Context, bookID uint) (*models.BookAvailability, error) {
+	args := m.Called(bookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BookAvailability), args.Error(1)
+}
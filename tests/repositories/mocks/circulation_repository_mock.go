@@ -0,0 +1,164 @@
+package mocks
+
+import (
+	"books-api/app/models"
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCopyRepository is a mock implementation of CopyRepository interface.
+// The context is not recorded.
+type MockCopyRepository struct {
+	mock.Mock
+}
+
+func (m *MockCopyRepository) Create(ctx context.Context, bookCopy *models.Copy) error {
+	args := m.Called(bookCopy)
+	return args.Error(0)
+}
+
+func (m *MockCopyRepository) GetByID(ctx context.Context, id uint) (*models.Copy, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Copy), args.Error(1)
+}
+
+func (m *MockCopyRepository) GetByBarcode(ctx context.Context, barcode string) (*models.Copy, error) {
+	args := m.Called(barcode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Copy), args.Error(1)
+}
+
+func (m *MockCopyRepository) GetByBook(ctx context.Context, bookID uint) ([]models.Copy, error) {
+	args := m.Called(bookID)
+	return args.Get(0).([]models.Copy), args.Error(1)
+}
+
+func (m *MockCopyRepository) Update(ctx context.Context, bookCopy *models.Copy) error {
+	args := m.Called(bookCopy)
+	return args.Error(0)
+}
+
+func (m *MockCopyRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// MockLoanRepository is a mock implementation of LoanRepository interface.
+// The context is not recorded.
+type MockLoanRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoanRepository) Create(ctx context.Context, loan *models.Loan) error {
+	args := m.Called(loan)
+	return args.Error(0)
+}
+
+func (m *MockLoanRepository) GetByID(ctx context.Context, id uint) (*models.Loan, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Loan), args.Error(1)
+}
+
+func (m *MockLoanRepository) GetActiveByCopy(ctx context.Context, copyID uint) (*models.Loan, error) {
+	args := m.Called(copyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Loan), args.Error(1)
+}
+
+func (m *MockLoanRepository) CountActiveByMember(ctx context.Context, memberID uint) (int64, error) {
+	args := m.Called(memberID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoanRepository) GetByMember(ctx context.Context, memberID uint) ([]models.Loan, error) {
+	args := m.Called(memberID)
+	return args.Get(0).([]models.Loan), args.Error(1)
+}
+
+func (m *MockLoanRepository) GetOverdue(ctx context.Context, now time.Time) ([]models.Loan, error) {
+	args := m.Called(now)
+	return args.Get(0).([]models.Loan), args.Error(1)
+}
+
+func (m *MockLoanRepository) Update(ctx context.Context, loan *models.Loan) error {
+	args := m.Called(loan)
+	return args.Error(0)
+}
+
+func (m *MockLoanRepository) MarkReturned(ctx context.Context, loan *models.Loan, returnedAt time.Time) (bool, error) {
+	args := m.Called(loan, returnedAt)
+	if args.Bool(0) {
+		loan.ReturnedAt = &returnedAt
+	}
+	return args.Bool(0), args.Error(1)
+}
+
+// MockHoldRepository is a mock implementation of HoldRepository interface.
+// The context is not recorded.
+type MockHoldRepository struct {
+	mock.Mock
+}
+
+func (m *MockHoldRepository) Create(ctx context.Context, hold *models.Hold) error {
+	args := m.Called(hold)
+	return args.Error(0)
+}
+
+func (m *MockHoldRepository) GetByID(ctx context.Context, id uint) (*models.Hold, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) GetActiveByMemberAndBook(ctx context.Context, memberID, bookID uint) (*models.Hold, error) {
+	args := m.Called(memberID, bookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) GetByMember(ctx context.Context, memberID uint) ([]models.Hold, error) {
+	args := m.Called(memberID)
+	return args.Get(0).([]models.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) GetReadyByCopy(ctx context.Context, copyID uint) (*models.Hold, error) {
+	args := m.Called(copyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) GetExpiredReady(ctx context.Context, now time.Time) ([]models.Hold, error) {
+	args := m.Called(now)
+	return args.Get(0).([]models.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) PromoteNext(ctx context.Context, bookID, copyID uint, readyAt, expiresAt time.Time) (*models.Hold, error) {
+	args := m.Called(bookID, copyID, readyAt, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Hold), args.Error(1)
+}
+
+func (m *MockHoldRepository) UpdateIfStatus(ctx context.Context, hold *models.Hold, expected models.HoldStatus) (bool, error) {
+	args := m.Called(hold, expected)
+	return args.Bool(0), args.Error(1)
+}
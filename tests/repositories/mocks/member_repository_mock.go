@@ -0,0 +1,92 @@
+package mocks
+
+import (
+	"books-api/app/models"
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMemberRepository is a mock implementation of MemberRepository interface.
+// The context is not recorded.
+type MockMemberRepository struct {
+	mock.Mock
+}
+
+func (m *MockMemberRepository) Create(ctx context.Context, member *models.Member) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepository) GetByID(ctx context.Context, id uint) (*models.Member, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+func (m *MockMemberRepository) GetByEmail(ctx context.Context, email string) (*models.Member, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+func (m *MockMemberRepository) GetByCardNumber(ctx context.Context, cardNumber string) (*models.Member, error) {
+	args := m.Called(cardNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+func (m *MockMemberRepository) Search(ctx context.Context, filter models.MemberFilter) ([]models.Member, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Member), args.Error(1)
+}
+
+func (m *MockMemberRepository) Update(ctx context.Context, member *models.Member) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// MockFineRepository is a mock implementation of FineRepository interface.
+// The context is not recorded.
+type MockFineRepository struct {
+	mock.Mock
+}
+
+func (m *MockFineRepository) Create(ctx context.Context, fine *models.Fine) error {
+	args := m.Called(fine)
+	return args.Error(0)
+}
+
+func (m *MockFineRepository) GetByID(ctx context.Context, id uint) (*models.Fine, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Fine), args.Error(1)
+}
+
+func (m *MockFineRepository) GetByMember(ctx context.Context, memberID uint) ([]models.Fine, error) {
+	args := m.Called(memberID)
+	return args.Get(0).([]models.Fine), args.Error(1)
+}
+
+func (m *MockFineRepository) SumUnpaidByMember(ctx context.Context, memberID uint) (int64, error) {
+	args := m.Called(memberID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockFineRepository) Update(ctx context.Context, fine *models.Fine) error {
+	args := m.Called(fine)
+	return args.Error(0)
+}
@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"books-api/app/models"
+	"context"
+	"time"
+)
+
+// MockOutboxRepository records the events written to it. Pending events are
+// the recorded ones that are still pending.
+type MockOutboxRepository struct {
+	Events []models.OutboxEvent
+}
+
+func (m *MockOutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	event.ID = uint(len(m.Events) + 1)
+	m.Events = append(m.Events, *event)
+	return nil
+}
+
+func (m *MockOutboxRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var pending []models.OutboxEvent
+	for _, event := range m.Events {
+		if event.Status == models.OutboxPending && !event.NextAttemptAt.After(now) && len(pending) < limit {
+			pending = append(pending, event)
+		}
+	}
+	return pending, nil
+}
+
+func (m *MockOutboxRepository) Update(ctx context.Context, event *models.OutboxEvent) error {
+	m.Events[event.ID-1] = *event
+	return nil
+}
+
+func (m *MockOutboxRepository) DeleteDeliveredBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,54 @@
+package mocks
+
+import (
+	"books-api/app/models"
+	"context"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockReviewRepository is a mock implementation of ReviewRepository interface.
+// The context is not recorded.
+type MockReviewRepository struct {
+	mock.Mock
+}
+
+func (m *MockReviewRepository) Create(ctx context.Context, review *models.Review) error {
+	args := m.Called(review)
+	return args.Error(0)
+}
+
+func (m *MockReviewRepository) GetByID(ctx context.Context, id uint) (*models.Review, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Review), args.Error(1)
+}
+
+func (m *MockReviewRepository) GetByBookAndReviewer(ctx context.Context, bookID uint, reviewer string) (*models.Review, error) {
+	args := m.Called(bookID, reviewer)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Review), args.Error(1)
+}
+
+func (m *MockReviewRepository) GetByBook(ctx context.Context, bookID uint, status *models.ReviewStatus) ([]models.Review, error) {
+	args := m.Called(bookID, status)
+	return args.Get(0).([]models.Review), args.Error(1)
+}
+
+func (m *MockReviewRepository) GetApprovedByBooks(ctx context.Context, bookIDs []uint) ([]models.Review, error) {
+	args := m.Called(bookIDs)
+	return args.Get(0).([]models.Review), args.Error(1)
+}
+
+func (m *MockReviewRepository) Update(ctx context.Context, review *models.Review) error {
+	args := m.Called(review)
+	return args.Error(0)
+}
+
+func (m *MockReviewRepository) Delete(ctx context.Context, review *models.Review) error {
+	args := m.Called(review)
+	return args.Error(0)
+}
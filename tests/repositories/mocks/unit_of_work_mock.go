@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"books-api/app/repository"
+	"context"
+)
+
+// MockUnitOfWork runs units of work against mock repositories, without a
+// transaction. Repositories left nil must not be used by the code under
+// test, except for the outbox, which records the events of the units of
+// work that committed.
+type MockUnitOfWork struct {
+	BookRepo    *MockBookRepository
+	TagRepo     *MockTagRepository
+	ReviewRepo  *MockReviewRepository
+	CopyRepo    *MockCopyRepository
+	LoanRepo    *MockLoanRepository
+	HoldRepo    *MockHoldRepository
+	MemberRepo  *MockMemberRepository
+	FineRepo    *MockFineRepository
+	APIKeyRepo  *MockAPIKeyRepository
+	TenantRepo  *MockTenantRepository
+	OutboxRepo  MockOutboxRepository
+	Commits     int
+	RolledBacks int
+}
+
+// Do calls fn with the mock repositories and counts whether it would have
+// committed
+func (u *MockUnitOfWork) Do(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	recorded := len(u.OutboxRepo.Events)
+	if err := fn(u); err != nil {
+		u.OutboxRepo.Events = u.OutboxRepo.Events[:recorded]
+		u.RolledBacks++
+		return err
+	}
+	u.Commits++
+	return nil
+}
+
+func (u *MockUnitOfWork) Books() repository.BookRepository          { return u.BookRepo }
+func (u *MockUnitOfWork) Tags() repository.TagRepository            { return u.TagRepo }
+func (u *MockUnitOfWork) Categories() repository.CategoryRepository { return nil }
+func (u *MockUnitOfWork) Reviews() repository.ReviewRepository      { return u.ReviewRepo }
+func (u *MockUnitOfWork) Copies() repository.CopyRepository         { return u.CopyRepo }
+func (u *MockUnitOfWork) Loans() repository.LoanRepository          { return u.LoanRepo }
+func (u *MockUnitOfWork) Holds() repository.HoldRepository          { return u.HoldRepo }
+func (u *MockUnitOfWork) Members() repository.MemberRepository      { return u.MemberRepo }
+func (u *MockUnitOfWork) Fines() repository.FineRepository          { return u.FineRepo }
+func (u *MockUnitOfWork) APIKeys() repository.APIKeyRepository      { return u.APIKeyRepo }
+func (u *MockUnitOfWork) Tenants() repository.TenantRepository      { return u.TenantRepo }
+func (u *MockUnitOfWork) Outbox() repository.OutboxRepository       { return &u.OutboxRepo }
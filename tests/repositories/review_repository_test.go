@@ -0,0 +1,105 @@
+package repositories_test
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewRepository_MaintainsBookRating(t *testing.T) {
+	db := setupTestDB(t)
+	bookRepo := repository.NewBookRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+
+	book := &models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412}
+	assert.NoError(t, bookRepo.Create(context.Background(), book))
+
+	alice := &models.Review{BookID: book.ID, Reviewer: "alice", Rating: 5, Status: models.ReviewApproved}
+	bob := &models.Review{BookID: book.ID, Reviewer: "bob", Rating: 2, Status: models.ReviewApproved}
+	carol := &models.Review{BookID: book.ID, Reviewer: "carol", Rating: 1, Status: models.ReviewPending}
+	for _, review := range []*models.Review{alice, bob, carol} {
+		assert.NoError(t, reviewRepo.Create(context.Background(), review))
+	}
+
+	rated, err := bookRepo.GetByID(context.Background(), book.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rated.RatingCount)
+	assert.InDelta(t, 3.5, rated.AverageRating, 0.001)
+
+	carol.Status = models.ReviewApproved
+	assert.NoError(t, reviewRepo.Update(context.Background(), carol))
+	rated, _ = bookRepo.GetByID(context.Background(), book.ID)
+	assert.Equal(t, 3, rated.RatingCount)
+	assert.InDelta(t, 8.0/3.0, rated.AverageRating, 0.001)
+
+	assert.NoError(t, reviewRepo.Delete(context.Background(), alice))
+	assert.NoError(t, reviewRepo.Delete(context.Background(), bob))
+	assert.NoError(t, reviewRepo.Delete(context.Background(), carol))
+	rated, _ = bookRepo.GetByID(context.Background(), book.ID)
+	assert.Equal(t, 0, rated.RatingCount)
+	assert.Zero(t, rated.AverageRating)
+}
+
+func TestReviewRepository_UniquePerReviewerAndBook(t *testing.T) {
+	db := setupTestDB(t)
+	bookRepo := repository.NewBookRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+
+	book := &models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412}
+	assert.NoError(t, bookRepo.Create(context.Background(), book))
+
+	assert.NoError(t, reviewRepo.Create(context.Background(), &models.Review{BookID: book.ID, Reviewer: "alice", Rating: 5}))
+	assert.Error(t, reviewRepo.Create(context.Background(), &models.Review{BookID: book.ID, Reviewer: "alice", Rating: 3}))
+}
+
+func TestBookRepository_GetAll_SortByRating(t *testing.T) {
+	db := setupTestDB(t)
+	bookRepo := repository.NewBookRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+
+	ratings := map[string]int{"Low": 1, "High": 5, "Mid": 3}
+	for title, rating := range ratings {
+		book := &models.Book{Title: title, Author: "Author", Pages: 100}
+		assert.NoError(t, bookRepo.Create(context.Background(), book))
+		assert.NoError(t, reviewRepo.Create(context.Background(), &models.Review{BookID: book.ID, Reviewer: "alice", Rating: rating, Status: models.ReviewApproved}))
+	}
+
+	books, err := bookRepo.GetAll(context.Background(), models.BookFilter{Sort: "-rating"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"High", "Mid", "Low"}, []string{books[0].Title, books[1].Title, books[2].Title})
+}
+
+func TestReviewRepository_GetApprovedByBooks(t *testing.T) {
+	db := setupTestDB(t)
+	bookRepo := repository.NewBookRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+
+	dune := &models.Book{Title: "Dune", Author: "Frank Herbert"}
+	emma := &models.Book{Title: "Emma", Author: "Jane Austen"}
+	other := &models.Book{Title: "Ulysses", Author: "James Joyce"}
+	for _, book := range []*models.Book{dune, emma, other} {
+		assert.NoError(t, bookRepo.Create(context.Background(), book))
+	}
+	for _, review := range []*models.Review{
+		{BookID: dune.ID, Reviewer: "alice", Rating: 5, Status: models.ReviewApproved},
+		{BookID: emma.ID, Reviewer: "alice", Rating: 4, Status: models.ReviewApproved},
+		{BookID: dune.ID, Reviewer: "bob", Rating: 3, Status: models.ReviewApproved},
+		{BookID: dune.ID, Reviewer: "carol", Rating: 1, Status: models.ReviewPending},
+		{BookID: other.ID, Reviewer: "dave", Rating: 2, Status: models.ReviewApproved},
+	} {
+		assert.NoError(t, reviewRepo.Create(context.Background(), review))
+	}
+
+	reviews, err := reviewRepo.GetApprovedByBooks(context.Background(), []uint{dune.ID, emma.ID})
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 3)
+	// Newest first
+	assert.Equal(t, "bob", reviews[0].Reviewer)
+	for _, review := range reviews {
+		assert.Equal(t, models.ReviewApproved, review.Status)
+		assert.NotEqual(t, other.ID, review.BookID)
+	}
+}
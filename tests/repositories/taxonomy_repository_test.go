@@ -0,0 +1,103 @@
+package repositories_test
+
+import (
+	"books-api/app/models"
+	"books-api/app/repository"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagRepository_GetAllWithCounts(t *testing.T) {
+	db := setupTestDB(t)
+	bookRepo := repository.NewBookRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+
+	classic := &models.Tag{Name: "classic"}
+	unused := &models.Tag{Name: "unused"}
+	assert.NoError(t, tagRepo.Create(context.Background(), classic))
+	assert.NoError(t, tagRepo.Create(context.Background(), unused))
+
+	for _, title := range []string{"Book 1", "Book 2"} {
+		book := &models.Book{Title: title, Author: "Author", Pages: 100}
+		assert.NoError(t, bookRepo.Create(context.Background(), book))
+		assert.NoError(t, bookRepo.ReplaceTags(context.Background(), book, []models.Tag{*classic}))
+	}
+
+	counts, err := tagRepo.GetAllWithCounts(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TagCount{
+		{ID: classic.ID, Name: "classic", BookCount: 2},
+		{ID: unused.ID, Name: "unused", BookCount: 0},
+	}, counts)
+}
+
+func TestTagRepository_Merge(t *testing.T) {
+	db := setupTestDB(t)
+	bookRepo := repository.NewBookRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+
+	scifi := &models.Tag{Name: "scifi"}
+	scienceFiction := &models.Tag{Name: "science fiction"}
+	assert.NoError(t, tagRepo.Create(context.Background(), scifi))
+	assert.NoError(t, tagRepo.Create(context.Background(), scienceFiction))
+
+	both := &models.Book{Title: "Both", Author: "Author", Pages: 100}
+	onlySource := &models.Book{Title: "Only source", Author: "Author", Pages: 100}
+	assert.NoError(t, bookRepo.Create(context.Background(), both))
+	assert.NoError(t, bookRepo.Create(context.Background(), onlySource))
+	assert.NoError(t, bookRepo.ReplaceTags(context.Background(), both, []models.Tag{*scifi, *scienceFiction}))
+	assert.NoError(t, bookRepo.ReplaceTags(context.Background(), onlySource, []models.Tag{*scifi}))
+
+	assert.NoError(t, tagRepo.Merge(context.Background(), scifi.ID, scienceFiction.ID))
+
+	_, err := tagRepo.GetByID(context.Background(), scifi.ID)
+	assert.Error(t, err)
+
+	books, err := bookRepo.GetAll(context.Background(), models.BookFilter{Tag: "Science Fiction"})
+	assert.NoError(t, err)
+	assert.Len(t, books, 2)
+	for _, book := range books {
+		assert.Len(t, book.Tags, 1)
+	}
+}
+
+func TestBookRepository_GetAll_CategoryIncludesDescendants(t *testing.T) {
+	db := setupTestDB(t)
+	bookRepo := repository.NewBookRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+
+	fiction := &models.Category{Name: "Fiction"}
+	assert.NoError(t, categoryRepo.Create(context.Background(), fiction))
+	scifi := &models.Category{Name: "Science Fiction", ParentID: &fiction.ID}
+	assert.NoError(t, categoryRepo.Create(context.Background(), scifi))
+	cyberpunk := &models.Category{Name: "Cyberpunk", ParentID: &scifi.ID}
+	assert.NoError(t, categoryRepo.Create(context.Background(), cyberpunk))
+	history := &models.Category{Name: "History"}
+	assert.NoError(t, categoryRepo.Create(context.Background(), history))
+
+	placements := map[string]*models.Category{
+		"Neuromancer": cyberpunk,
+		"Dune":        scifi,
+		"SPQR":        history,
+	}
+	for title, category := range placements {
+		book := &models.Book{Title: title, Author: "Author", Pages: 100}
+		assert.NoError(t, bookRepo.Create(context.Background(), book))
+		assert.NoError(t, bookRepo.ReplaceCategories(context.Background(), book, []models.Category{*category}))
+	}
+
+	ids, err := categoryRepo.GetDescendantIDs(context.Background(), fiction.ID)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uint{fiction.ID, scifi.ID, cyberpunk.ID}, ids)
+
+	books, err := bookRepo.GetAll(context.Background(), models.BookFilter{CategoryID: &fiction.ID})
+	assert.NoError(t, err)
+	assert.Len(t, books, 2)
+
+	books, err = bookRepo.GetAll(context.Background(), models.BookFilter{CategoryID: &cyberpunk.ID})
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, "Neuromancer", books[0].Title)
+}
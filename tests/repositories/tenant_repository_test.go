@@ -0,0 +1,77 @@
+package repositories_test
+
+import (
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/tenancy"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantRepository_DefaultTenantIsSeeded(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewTenantRepository(db)
+
+	tenant, err := repo.GetBySlug(context.Background(), models.DefaultTenantSlug)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(models.DefaultTenantID), tenant.ID)
+
+	// Migrations can run again without duplicating it
+	assert.NoError(t, migrations.NewMigrationManager(slog.Default()).RunMigrations(db))
+	tenants, err := repo.GetAll(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, tenants, 1)
+}
+
+func TestBookRepository_ScopesQueriesToTenant(t *testing.T) {
+	db := setupTestDB(t)
+	tenantRepo := repository.NewTenantRepository(db)
+	bookRepo := repository.NewBookRepository(db)
+
+	acme := &models.Tenant{Slug: "acme", Name: "Acme"}
+	globex := &models.Tenant{Slug: "globex", Name: "Globex"}
+	assert.NoError(t, tenantRepo.Create(context.Background(), acme))
+	assert.NoError(t, tenantRepo.Create(context.Background(), globex))
+	acmeCtx := tenancy.WithTenant(context.Background(), acme)
+	globexCtx := tenancy.WithTenant(context.Background(), globex)
+
+	// The client cannot pick the tenant of a new book
+	dune := &models.Book{Title: "Dune", Author: "Frank Herbert", TenantID: globex.ID}
+	assert.NoError(t, bookRepo.Create(acmeCtx, dune))
+	assert.Equal(t, acme.ID, dune.TenantID)
+	assert.NoError(t, bookRepo.Create(globexCtx, &models.Book{Title: "Emma", Author: "Jane Austen"}))
+
+	_, err := bookRepo.GetByID(acmeCtx, dune.ID)
+	assert.NoError(t, err)
+	_, err = bookRepo.GetByID(globexCtx, dune.ID)
+	assert.Error(t, err)
+
+	books, err := bookRepo.GetAll(globexCtx, models.BookFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, "Emma", books[0].Title)
+
+	count, err := bookRepo.Count(acmeCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Writes from another tenant do not reach the book, nor create a copy of it
+	dune.Title = "Hijacked"
+	assert.NoError(t, bookRepo.Update(globexCtx, dune))
+	assert.NoError(t, bookRepo.Delete(globexCtx, dune.ID))
+	stored, err := bookRepo.GetByID(acmeCtx, dune.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Dune", stored.Title)
+	count, err = bookRepo.Count(globexCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Without a tenant in the context nothing is filtered
+	count, err = bookRepo.Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
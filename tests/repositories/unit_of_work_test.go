@@ -0,0 +1,250 @@
+package repositories_test
+
+import (
+	"books-api/app/cache"
+	"books-api/app/database"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func createBook(t *testing.T, db *gorm.DB, title string) *models.Book {
+	book := &models.Book{Title: title, Author: "Frank Herbert", Pages: 100}
+	require.NoError(t, repository.NewBookRepository(db).Create(context.Background(), book))
+	return book
+}
+
+func TestUnitOfWork_CommitsOnSuccess(t *testing.T) {
+	db := setupTestDB(t)
+	book := createBook(t, db, "Dune")
+	ctx := context.Background()
+
+	err := repository.NewUnitOfWork(db, nil, slog.Default()).Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Tags().Create(context.Background(), &models.Tag{Name: "classics"}); err != nil {
+			return err
+		}
+		book.Title = "Dune Messiah"
+		return repos.Books().Update(ctx, book)
+	})
+	require.NoError(t, err)
+
+	stored, err := repository.NewBookRepository(db).GetByID(ctx, book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", stored.Title)
+	_, err = repository.NewTagRepository(db).GetByName(context.Background(), "classics")
+	assert.NoError(t, err)
+}
+
+func TestUnitOfWork_RollsBackOnError(t *testing.T) {
+	db := setupTestDB(t)
+	book := createBook(t, db, "Dune")
+	ctx := context.Background()
+	failure := errors.New("failure")
+
+	err := repository.NewUnitOfWork(db, nil, slog.Default()).Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Tags().Create(context.Background(), &models.Tag{Name: "classics"}); err != nil {
+			return err
+		}
+		book.Title = "Dune Messiah"
+		if err := repos.Books().Update(ctx, book); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	stored, err := repository.NewBookRepository(db).GetByID(ctx, book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune", stored.Title)
+	_, err = repository.NewTagRepository(db).GetByName(context.Background(), "classics")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestUnitOfWork_RollsBackOnPanic(t *testing.T) {
+	db := setupTestDB(t)
+	book := createBook(t, db, "Dune")
+	ctx := context.Background()
+
+	assert.Panics(t, func() {
+		repository.NewUnitOfWork(db, nil, slog.Default()).Do(ctx, func(repos repository.Repositories) error {
+			if err := repos.Books().Delete(ctx, book.ID); err != nil {
+				return err
+			}
+			panic("failure")
+		})
+	})
+
+	_, err := repository.NewBookRepository(db).GetByID(ctx, book.ID)
+	assert.NoError(t, err)
+}
+
+func TestUnitOfWork_InvalidatesCachedBooksAfterCommit(t *testing.T) {
+	db := setupTestDB(t)
+	book := createBook(t, db, "Dune")
+	ctx := context.Background()
+	cached := repository.NewCachedBookRepository(repository.NewBookRepository(db), cache.NewMemoryStore(10), time.Minute)
+	unitOfWork := repository.NewUnitOfWork(db, cached, slog.Default())
+
+	_, err := cached.GetByID(ctx, book.ID)
+	require.NoError(t, err)
+
+	// A rolled back change leaves the cached book valid
+	err = unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		book.Title = "Children of Dune"
+		if err := repos.Books().Update(ctx, book); err != nil {
+			return err
+		}
+		return errors.New("failure")
+	})
+	require.Error(t, err)
+	stored, err := cached.GetByID(ctx, book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune", stored.Title)
+
+	err = unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		book.Title = "Dune Messiah"
+		return repos.Books().Update(ctx, book)
+	})
+	require.NoError(t, err)
+	stored, err = cached.GetByID(ctx, book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", stored.Title)
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 2, Loads: 2}, cached.Stats())
+}
+
+func TestUnitOfWork_InvalidatesBooksOfChangedTags(t *testing.T) {
+	db := setupTestDB(t)
+	book := createBook(t, db, "Dune")
+	ctx := context.Background()
+	tag := &models.Tag{Name: "scifi"}
+	require.NoError(t, repository.NewTagRepository(db).Create(ctx, tag))
+	require.NoError(t, repository.NewBookRepository(db).ReplaceTags(ctx, book, []models.Tag{*tag}))
+	cached := repository.NewCachedBookRepository(repository.NewBookRepository(db), cache.NewMemoryStore(10), time.Minute)
+	unitOfWork := repository.NewUnitOfWork(db, cached, slog.Default())
+
+	_, err := cached.GetByID(ctx, book.ID)
+	require.NoError(t, err)
+
+	err = unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		tag.Name = "science fiction"
+		return repos.Tags().Update(ctx, tag)
+	})
+	require.NoError(t, err)
+	stored, err := cached.GetByID(ctx, book.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Tags, 1)
+	assert.Equal(t, "science fiction", stored.Tags[0].Name)
+}
+
+func TestUnitOfWork_InvalidatesBooksOfChangedReviews(t *testing.T) {
+	db := setupTestDB(t)
+	book := createBook(t, db, "Dune")
+	ctx := context.Background()
+	cached := repository.NewCachedBookRepository(repository.NewBookRepository(db), cache.NewMemoryStore(10), time.Minute)
+	unitOfWork := repository.NewUnitOfWork(db, cached, slog.Default())
+
+	_, err := cached.GetByID(ctx, book.ID)
+	require.NoError(t, err)
+
+	// Approving a review refreshes the rating of its book
+	review := &models.Review{BookID: book.ID, Reviewer: "alice", Rating: 4, Status: models.ReviewApproved}
+	err = unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		return repos.Reviews().Create(ctx, review)
+	})
+	require.NoError(t, err)
+	stored, err := cached.GetByID(ctx, book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, stored.AverageRating)
+	assert.Equal(t, 1, stored.RatingCount)
+
+	err = unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		return repos.Reviews().Delete(ctx, review)
+	})
+	require.NoError(t, err)
+	stored, err = cached.GetByID(ctx, book.ID)
+	require.NoError(t, err)
+	assert.Zero(t, stored.RatingCount)
+}
+
+// failingDeleteStore is a cache whose entries cannot be dropped
+type failingDeleteStore struct {
+	cache.Store
+}
+
+func (s failingDeleteStore) Delete(ctx context.Context, key string) error {
+	return errors.New("cache unavailable")
+}
+
+func TestUnitOfWork_LogsFailedInvalidationAfterCommit(t *testing.T) {
+	db := setupTestDB(t)
+	book := createBook(t, db, "Dune")
+	ctx := context.Background()
+	var logs bytes.Buffer
+	cached := repository.NewCachedBookRepository(repository.NewBookRepository(db), failingDeleteStore{cache.NewMemoryStore(10)}, time.Minute)
+	unitOfWork := repository.NewUnitOfWork(db, cached, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	// The committed change stands even though its book stays cached
+	err := unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		book.Title = "Dune Messiah"
+		return repos.Books().Update(ctx, book)
+	})
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "Failed to invalidate cached book after commit")
+
+	stored, err := repository.NewBookRepository(db).GetByID(ctx, book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Dune Messiah", stored.Title)
+}
+
+func TestUnitOfWork_ConcurrentReadModifyWrite(t *testing.T) {
+	db, err := database.Open(database.Config{
+		Driver: "sqlite",
+		DSN:    filepath.Join(t.TempDir(), "books.db"),
+		SQLite: database.DefaultSQLiteConfig(),
+	}, &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, migrations.NewMigrationManager(slog.Default()).RunMigrations(db))
+	book := createBook(t, db, "Dune")
+	unitOfWork := repository.NewUnitOfWork(db, nil, slog.Default())
+
+	// Without the transaction, increments read the same page count and
+	// overwrite each other
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- unitOfWork.Do(context.Background(), func(repos repository.Repositories) error {
+				stored, err := repos.Books().GetByID(context.Background(), book.ID)
+				if err != nil {
+					return err
+				}
+				stored.Pages++
+				return repos.Books().Update(context.Background(), stored)
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	stored, err := repository.NewBookRepository(db).GetByID(context.Background(), book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 120, stored.Pages)
+}
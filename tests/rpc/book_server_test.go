@@ -0,0 +1,177 @@
+package rpc_test
+
+import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/models"
+	"books-api/app/rpc"
+	"books-api/app/rpc/booksv1"
+	"books-api/tests/services/mocks"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newBookServer creates a server that, like the authenticator with
+// anonymous reads, lets callers without credentials read if anonymousReads
+func newBookServer(anonymousReads bool) (*rpc.BookServer, *mocks.MockBookService) {
+	bookService := new(mocks.MockBookService)
+	server := rpc.NewBookServer(bookService, nil, func(scope models.Scope) bool {
+		return anonymousReads && scope == models.ScopeBooksRead
+	})
+	return server, bookService
+}
+
+func caller(scopes ...models.Scope) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "ci", Scopes: scopes})
+}
+
+func books(ids ...uint) []models.Book {
+	var list []models.Book
+	for _, id := range ids {
+		list = append(list, models.Book{ID: id, Title: "Book"})
+	}
+	return list
+}
+
+func TestBookServer_GetBook(t *testing.T) {
+	server, bookService := newBookServer(true)
+	red := models.Red
+	parent := uint(3)
+	bookService.On("GetBookByID", mock.Anything, uint(7)).Return(&models.Book{
+		ID: 7, Title: "Dune", Author: "Frank Herbert", Pages: 412, Color: &red,
+		AverageRating: 4.5, RatingCount: 2,
+		Tags:       []models.Tag{{ID: 1, Name: "classic"}},
+		Categories: []models.Category{{ID: 4, Name: "Space", ParentID: &parent}},
+	}, nil)
+
+	book, err := server.GetBook(context.Background(), &booksv1.GetBookRequest{Id: 7})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), book.GetId())
+	assert.Equal(t, "Dune", book.GetTitle())
+	assert.Equal(t, int32(412), book.GetPages())
+	assert.Equal(t, booksv1.Color_COLOR_RED, book.GetColor())
+	assert.Equal(t, 4.5, book.GetAverageRating())
+	assert.Equal(t, "classic", book.GetTags()[0].GetName())
+	assert.Equal(t, uint32(3), book.GetCategories()[0].GetParentId())
+}
+
+func TestBookServer_MapsErrorsToStatusCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{errors.New("book not found"), codes.NotFound},
+		{errors.New("failed to get book: disk I/O error"), codes.Internal},
+		{errors.New("book quota exceeded"), codes.PermissionDenied},
+		{&authz.ForbiddenError{Subject: "ci", Action: authz.Action("books.delete")}, codes.PermissionDenied},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{errors.New("title is required"), codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		server, bookService := newBookServer(true)
+		bookService.On("GetBookByID", mock.Anything, uint(1)).Return(nil, tt.err)
+
+		_, err := server.GetBook(context.Background(), &booksv1.GetBookRequest{Id: 1})
+		assert.Equal(t, tt.code, status.Code(err), tt.err.Error())
+	}
+}
+
+func TestBookServer_RequiresScopes(t *testing.T) {
+	server, _ := newBookServer(false)
+
+	_, err := server.GetBook(context.Background(), &booksv1.GetBookRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = server.DeleteBook(caller(models.ScopeBooksRead), &booksv1.DeleteBookRequest{Id: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "missing scope books:write", status.Convert(err).Message())
+
+	// Anonymous reads never extend to writes
+	server, _ = newBookServer(true)
+	_, err = server.CreateBook(context.Background(), &booksv1.CreateBookRequest{Book: &booksv1.Book{Title: "Dune"}})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestBookServer_ListBooksPaginates(t *testing.T) {
+	server, bookService := newBookServer(true)
+	categoryID := uint(4)
+	bookService.On("GetAllBooks", mock.Anything, models.BookFilter{Tag: "classic", CategoryID: &categoryID, Sort: "title", Limit: 3}).
+		Return(books(1, 2, 3), nil).Once()
+
+	resp, err := server.ListBooks(context.Background(), &booksv1.ListBooksRequest{PageSize: 2, Tag: "classic", CategoryId: 4, OrderBy: "title"})
+	require.NoError(t, err)
+	assert.Len(t, resp.GetBooks(), 2)
+	require.NotEmpty(t, resp.GetNextPageToken())
+
+	bookService.On("GetAllBooks", mock.Anything, models.BookFilter{Tag: "classic", CategoryID: &categoryID, Sort: "title", Limit: 3, Offset: 2}).
+		Return(books(3), nil).Once()
+
+	resp, err = server.ListBooks(context.Background(), &booksv1.ListBooksRequest{
+		PageSize: 2, PageToken: resp.GetNextPageToken(), Tag: "classic", CategoryId: 4, OrderBy: "title",
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.GetBooks(), 1)
+	assert.Empty(t, resp.GetNextPageToken())
+}
+
+func TestBookServer_ListBooksDefaultsAndValidation(t *testing.T) {
+	server, bookService := newBookServer(true)
+	bookService.On("GetAllBooks", mock.Anything, models.BookFilter{Limit: rpc.DefaultPageSize + 1}).Return(books(1), nil)
+
+	resp, err := server.ListBooks(context.Background(), &booksv1.ListBooksRequest{})
+	require.NoError(t, err)
+	assert.Len(t, resp.GetBooks(), 1)
+
+	for _, req := range []*booksv1.ListBooksRequest{
+		{PageSize: -1},
+		{PageSize: rpc.MaxPageSize + 1},
+		{PageToken: "not a token"},
+	} {
+		_, err := server.ListBooks(context.Background(), req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), req.String())
+	}
+}
+
+func TestBookServer_CreateAndUpdateBook(t *testing.T) {
+	server, bookService := newBookServer(false)
+	ctx := caller(models.ScopeBooksWrite)
+	blue := models.Blue
+
+	bookService.On("CreateBook", mock.Anything, &models.Book{Title: "Dune", Author: "Frank Herbert", Pages: 412, Color: &blue}).
+		Run(func(args mock.Arguments) { args.Get(1).(*models.Book).ID = 9 }).
+		Return(nil)
+	created, err := server.CreateBook(ctx, &booksv1.CreateBookRequest{Book: &booksv1.Book{
+		Title: "Dune", Author: "Frank Herbert", Pages: 412, Color: booksv1.Color_COLOR_BLUE,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(9), created.GetId())
+
+	bookService.On("UpdateBook", mock.Anything, uint(9), models.Book{Pages: 500}).
+		Return(&models.Book{ID: 9, Title: "Dune", Pages: 500}, nil)
+	updated, err := server.UpdateBook(ctx, &booksv1.UpdateBookRequest{Id: 9, Book: &booksv1.Book{Pages: 500}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(500), updated.GetPages())
+
+	_, err = server.CreateBook(ctx, &booksv1.CreateBookRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.UpdateBook(ctx, &booksv1.UpdateBookRequest{Id: 9, Book: &booksv1.Book{Color: booksv1.Color(42)}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestBookServer_DeleteBook(t *testing.T) {
+	server, bookService := newBookServer(false)
+	bookService.On("DeleteBook", mock.Anything, uint(3)).Return(nil)
+	bookService.On("DeleteBook", mock.Anything, uint(4)).Return(errors.New("book not found"))
+
+	_, err := server.DeleteBook(caller(models.ScopeBooksWrite), &booksv1.DeleteBookRequest{Id: 3})
+	assert.NoError(t, err)
+	_, err = server.DeleteBook(caller(models.ScopeBooksWrite), &booksv1.DeleteBookRequest{Id: 4})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,43 @@
+package rpc_test
+
+import (
+	"books-api/app/rpc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	config, err := rpc.LoadConfig(lookup(nil))
+	require.NoError(t, err)
+	assert.Equal(t, rpc.Config{Reflection: true}, config)
+	assert.False(t, config.Enabled())
+
+	config, err = rpc.LoadConfig(lookup(map[string]string{
+		"GRPC_PORT":       "9090",
+		"GRPC_MULTIPLEX":  "true",
+		"GRPC_REFLECTION": "false",
+		"GRPC_GATEWAY":    "true",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, rpc.Config{Addr: ":9090", Multiplex: true, Gateway: true}, config)
+	assert.True(t, config.Enabled())
+
+	for _, env := range []map[string]string{
+		{"GRPC_PORT": "0"},
+		{"GRPC_PORT": "grpc"},
+		{"GRPC_MULTIPLEX": "maybe"},
+		{"GRPC_REFLECTION": "off"},
+	} {
+		_, err := rpc.LoadConfig(lookup(env))
+		assert.Error(t, err, env)
+	}
+}
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
@@ -0,0 +1,255 @@
+package server_test
+
+import (
+	"books-api/app/server"
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoHandler answers with the protocol and client certificate subject
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	subject := "none"
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		subject = r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+	io.WriteString(w, r.Proto+" "+subject)
+})
+
+// start serves srv on local sockets until the test ends
+func start(t *testing.T, srv *server.Server, quic, redirect bool) server.Listeners {
+	var listeners server.Listeners
+	var err error
+	listeners.Main, err = net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if quic {
+		listeners.QUIC, err = net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+	}
+	if redirect {
+		listeners.Redirect, err = net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- srv.Serve(ctx, listeners) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+	return listeners
+}
+
+func get(t *testing.T, client *http.Client, url string) (*http.Response, string) {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestCertReloader_PicksUpRenewedCertificate(t *testing.T) {
+	ca := newCA(t, "Test CA")
+	dir := t.TempDir()
+	certFile, keyFile := newServerCert(t, ca, 1).write(t, dir)
+
+	reloader, err := server.NewCertReloader(certFile, keyFile, slog.Default())
+	require.NoError(t, err)
+
+	cert, _ := reloader.GetCertificate(nil)
+	assert.Equal(t, int64(1), cert.Leaf.SerialNumber.Int64())
+
+	reloaded, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	newServerCert(t, ca, 2).write(t, dir)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+
+	reloaded, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	cert, _ = reloader.GetCertificate(nil)
+	assert.Equal(t, int64(2), cert.Leaf.SerialNumber.Int64())
+}
+
+func TestCertReloader_KeepsCertificateWhenRenewalIsBroken(t *testing.T) {
+	ca := newCA(t, "Test CA")
+	dir := t.TempDir()
+	certFile, _ := newServerCert(t, ca, 1).write(t, dir)
+	keyFile := filepath.Join(dir, "key.pem")
+
+	reloader, err := server.NewCertReloader(certFile, keyFile, slog.Default())
+	require.NoError(t, err)
+
+	// The certificate was replaced but its key not yet
+	require.NoError(t, os.WriteFile(certFile, newServerCert(t, ca, 2).certPEM, 0o600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+
+	_, err = reloader.Reload()
+	assert.Error(t, err)
+	cert, _ := reloader.GetCertificate(nil)
+	assert.Equal(t, int64(1), cert.Leaf.SerialNumber.Int64())
+}
+
+func TestServer_MutualTLS(t *testing.T) {
+	ca := newCA(t, "Test CA")
+	clientCA := newCA(t, "Client CA")
+	dir := t.TempDir()
+	certFile, keyFile := newServerCert(t, ca, 1).write(t, dir)
+	clientCAFile := filepath.Join(dir, "client-ca.pem")
+	require.NoError(t, os.WriteFile(clientCAFile, clientCA.certPEM, 0o600))
+
+	srv, err := server.New(server.Config{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ClientCAFile:   clientCAFile,
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+		ReloadInterval: time.Minute,
+	}, echoHandler, slog.Default())
+	require.NoError(t, err)
+	listeners := start(t, srv, false, false)
+	url := "https://" + listeners.Main.Addr().String()
+
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.pool()}}}
+	_, err = anonymous.Get(url)
+	assert.Error(t, err)
+
+	// Certificates from another CA are refused as well
+	stranger := newClientCert(t, newCA(t, "Other CA"), "stranger")
+	foreign := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      ca.pool(),
+		Certificates: []tls.Certificate{stranger.tlsCertificate(t)},
+	}}}
+	_, err = foreign.Get(url)
+	assert.Error(t, err)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs:      ca.pool(),
+			Certificates: []tls.Certificate{newClientCert(t, clientCA, "inventory-sync").tlsCertificate(t)},
+		},
+		ForceAttemptHTTP2: true,
+	}}
+	resp, body := get(t, client, url)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "HTTP/2.0 inventory-sync", body)
+}
+
+func TestServer_HTTP3(t *testing.T) {
+	ca := newCA(t, "Test CA")
+	certFile, keyFile := newServerCert(t, ca, 1).write(t, t.TempDir())
+
+	srv, err := server.New(server.Config{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		HTTP3:          true,
+		ReloadInterval: time.Minute,
+	}, echoHandler, slog.Default())
+	require.NoError(t, err)
+	listeners := start(t, srv, true, false)
+
+	// TCP responses advertise the QUIC endpoint
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.pool()}}}
+	_, port, _ := net.SplitHostPort(listeners.QUIC.LocalAddr().String())
+	resp, _ := get(t, client, "https://"+listeners.Main.Addr().String())
+	assert.Contains(t, resp.Header.Get("Alt-Svc"), `h3=":`+port+`"`)
+
+	transport := &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.pool()}}
+	defer transport.Close()
+	resp, body := get(t, &http.Client{Transport: transport, Timeout: 5 * time.Second}, "https://"+listeners.QUIC.LocalAddr().String())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "HTTP/3.0 none", body)
+}
+
+func TestServer_RedirectsToHTTPS(t *testing.T) {
+	ca := newCA(t, "Test CA")
+	certFile, keyFile := newServerCert(t, ca, 1).write(t, t.TempDir())
+
+	srv, err := server.New(server.Config{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		RedirectAddr:   "127.0.0.1:0",
+		ReloadInterval: time.Minute,
+	}, echoHandler, slog.Default())
+	require.NoError(t, err)
+	listeners := start(t, srv, false, true)
+	_, port, _ := net.SplitHostPort(listeners.Main.Addr().String())
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, _ := get(t, client, "http://"+listeners.Redirect.Addr().String()+"/books?page=2")
+	assert.Equal(t, http.StatusPermanentRedirect, resp.StatusCode)
+	assert.Equal(t, "https://127.0.0.1:"+port+"/books?page=2", resp.Header.Get("Location"))
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		host, port, location string
+	}{
+		{"books.example.com", "443", "https://books.example.com/books/1"},
+		{"books.example.com:80", "443", "https://books.example.com/books/1"},
+		{"books.example.com:8080", "8443", "https://books.example.com:8443/books/1"},
+		{"[::1]:80", "443", "https://[::1]/books/1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/books/1", nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		server.RedirectHandler(tt.port).ServeHTTP(w, req)
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, tt.location, w.Header().Get("Location"))
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	env := func(values map[string]string) func(string) (string, bool) {
+		return func(name string) (string, bool) {
+			value, ok := values[name]
+			return value, ok
+		}
+	}
+
+	config, err := server.LoadConfig(env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", config.Addr)
+	assert.False(t, config.TLSEnabled())
+
+	config, err = server.LoadConfig(env(map[string]string{
+		"PORT":               "8443",
+		"TLS_CERT_FILE":      "cert.pem",
+		"TLS_KEY_FILE":       "key.pem",
+		"TLS_CLIENT_CA_FILE": "ca.pem",
+		"HTTP3":              "true",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, ":8443", config.Addr)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, config.ClientAuth)
+	assert.True(t, config.HTTP3)
+
+	for _, values := range []map[string]string{
+		{"TLS_CERT_FILE": "cert.pem"},
+		{"HTTP3": "true"},
+		{"HTTP_REDIRECT_ADDR": ":80"},
+		{"TLS_CERT_FILE": "cert.pem", "TLS_KEY_FILE": "key.pem", "TLS_CLIENT_CA_FILE": "ca.pem", "TLS_CLIENT_AUTH": "maybe"},
+		{"TLS_CERT_FILE": "cert.pem", "TLS_KEY_FILE": "key.pem", "TLS_RELOAD_INTERVAL": "soon"},
+	} {
+		_, err := server.LoadConfig(env(values))
+		assert.Error(t, err, values)
+	}
+}
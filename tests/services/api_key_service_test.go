@@ -0,0 +1,117 @@
+package services_test
+
+import (
+	"books-api/app/models"
+	"books-api/app/service"
+	"books-api/tests/repositories/mocks"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyService_IssueKey_StoresHashOnly(t *testing.T) {
+	keyRepo := new(mocks.MockAPIKeyRepository)
+	svc := service.NewAPIKeyService(keyRepo, new(mocks.MockTenantRepository), slog.Default())
+
+	var stored *models.APIKey
+	keyRepo.On("Create", mock.AnythingOfType("*models.APIKey")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*models.APIKey)
+	}).Return(nil)
+
+	issued, err := svc.IssueKey(context.Background(), " ci ", "", []models.Scope{models.ScopeBooksWrite, models.ScopeBooksWrite}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(issued.Key, "bk_"))
+	assert.Equal(t, "ci", stored.Name)
+	assert.Equal(t, []models.Scope{models.ScopeBooksWrite}, stored.Scopes)
+	assert.Equal(t, service.HashAPIKey(issued.Key), stored.KeyHash)
+	assert.NotContains(t, stored.KeyHash, issued.Key)
+	assert.True(t, strings.HasPrefix(issued.Key, stored.Prefix))
+}
+
+func TestAPIKeyService_IssueKey_Validation(t *testing.T) {
+	keyRepo := new(mocks.MockAPIKeyRepository)
+	svc := service.NewAPIKeyService(keyRepo, new(mocks.MockTenantRepository), slog.Default())
+
+	_, err := svc.IssueKey(context.Background(), "ci", "", []models.Scope{"books:delete"}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, "invalid scope: books:delete", err.Error())
+
+	_, err = svc.IssueKey(context.Background(), "ci", "", nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, "at least one scope is required", err.Error())
+
+	past := time.Now().Add(-time.Hour)
+	_, err = svc.IssueKey(context.Background(), "ci", "", []models.Scope{models.ScopeBooksRead}, &past)
+	assert.Error(t, err)
+	assert.Equal(t, "expiry must be in the future", err.Error())
+	keyRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestAPIKeyService_IssueKey_BindsTenant(t *testing.T) {
+	keyRepo := new(mocks.MockAPIKeyRepository)
+	tenantRepo := new(mocks.MockTenantRepository)
+	svc := service.NewAPIKeyService(keyRepo, tenantRepo, slog.Default())
+
+	tenantRepo.On("GetBySlug", "acme").Return(&models.Tenant{ID: 2, Slug: "acme"}, nil)
+	tenantRepo.On("GetBySlug", "nobody").Return(nil, errors.New("record not found"))
+	keyRepo.On("Create", mock.AnythingOfType("*models.APIKey")).Return(nil)
+
+	issued, err := svc.IssueKey(context.Background(), "ci", " Acme ", []models.Scope{models.ScopeBooksWrite}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", issued.Tenant)
+
+	_, err = svc.IssueKey(context.Background(), "ci", "nobody", []models.Scope{models.ScopeBooksWrite}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, "tenant not found", err.Error())
+	keyRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	keyRepo := new(mocks.MockAPIKeyRepository)
+	svc := service.NewAPIKeyService(keyRepo, new(mocks.MockTenantRepository), slog.Default())
+
+	expired := time.Now().Add(-time.Minute)
+	revoked := time.Now().Add(-time.Hour)
+	keyRepo.On("GetByHash", service.HashAPIKey("bk_good")).Return(&models.APIKey{ID: 1}, nil)
+	keyRepo.On("GetByHash", service.HashAPIKey("bk_expired")).Return(&models.APIKey{ID: 2, ExpiresAt: &expired}, nil)
+	keyRepo.On("GetByHash", service.HashAPIKey("bk_revoked")).Return(&models.APIKey{ID: 3, RevokedAt: &revoked}, nil)
+	keyRepo.On("GetByHash", service.HashAPIKey("bk_unknown")).Return(nil, notFound)
+	keyRepo.On("TouchLastUsed", uint(1), mock.AnythingOfType("time.Time")).Return(nil)
+
+	key, err := svc.Authenticate(context.Background(), "bk_good")
+	assert.NoError(t, err)
+	assert.NotNil(t, key.LastUsedAt)
+
+	_, err = svc.Authenticate(context.Background(), "bk_expired")
+	assert.Equal(t, "API key expired", err.Error())
+	_, err = svc.Authenticate(context.Background(), "bk_revoked")
+	assert.Equal(t, "API key revoked", err.Error())
+	_, err = svc.Authenticate(context.Background(), "bk_unknown")
+	assert.Equal(t, "invalid API key", err.Error())
+	_, err = svc.Authenticate(context.Background(), "no-prefix")
+	assert.Equal(t, "invalid API key", err.Error())
+	keyRepo.AssertNumberOfCalls(t, "TouchLastUsed", 1)
+}
+
+func TestAPIKeyService_RevokeKey(t *testing.T) {
+	keyRepo := new(mocks.MockAPIKeyRepository)
+	svc := service.NewAPIKeyService(keyRepo, new(mocks.MockTenantRepository), slog.Default())
+
+	key := &models.APIKey{ID: 1}
+	keyRepo.On("GetByID", uint(1)).Return(key, nil)
+	keyRepo.On("Update", key).Return(nil)
+
+	revoked, err := svc.RevokeKey(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+
+	_, err = svc.RevokeKey(context.Background(), 1)
+	assert.Error(t, err)
+	assert.Equal(t, "API key already revoked", err.Error())
+}
@@ -1,10 +1,15 @@
 package services_test
 
 import (
+	"books-api/app/auth"
+	"books-api/app/authz"
 	"books-api/app/service"
+	"books-api/app/tenancy"
 	"books-api/tests/repositories/mocks"
 	"books-api/app/models"
+	"context"
 	"errors"
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,7 +18,7 @@ import (
 
 func TestBookService_CreateBook_Success(t *testing.T) {
 	mockRepo := new(mocks.MockBookRepository)
-	svc := service.NewBookService(mockRepo)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.AllowAll(), slog.Default())
 
 	color := models.Red
 	book := &models.Book{
@@ -25,14 +30,14 @@ func TestBookService_CreateBook_Success(t *testing.T) {
 
 	mockRepo.On("Create", book).Return(nil)
 
-	err := svc.CreateBook(book)
+	err := svc.CreateBook(context.Background(), book)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestBookService_CreateBook_InvalidColor(t *testing.T) {
 	mockRepo := new(mocks.MockBookRepository)
-	svc := service.NewBookService(mockRepo)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.AllowAll(), slog.Default())
 
 	invalidColor := models.Color("Purple")
 	book := &models.Book{
@@ -42,7 +47,7 @@ func TestBookService_CreateBook_InvalidColor(t *testing.T) {
 		Color:  &invalidColor,
 	}
 
-	err := svc.CreateBook(book)
+	err := svc.CreateBook(context.Background(), book)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid color")
 	mockRepo.AssertNotCalled(t, "Create")
@@ -50,7 +55,7 @@ func TestBookService_CreateBook_InvalidColor(t *testing.T) {
 
 func TestBookService_GetBookByID_Success(t *testing.T) {
 	mockRepo := new(mocks.MockBookRepository)
-	svc := service.NewBookService(mockRepo)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.AllowAll(), slog.Default())
 
 	expectedBook := &models.Book{
 		ID:     1,
@@ -60,8 +65,9 @@ func TestBookService_GetBookByID_Success(t *testing.T) {
 	}
 
 	mockRepo.On("GetByID", uint(1)).Return(expectedBook, nil)
+	mockRepo.On("GetAvailability", uint(1)).Return(&models.BookAvailability{Total: 2, Available: 1, OnLoan: 1}, nil)
 
-	book, err := svc.GetBookByID(1)
+	book, err := svc.GetBookByID(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedBook, book)
 	mockRepo.AssertExpectations(t)
@@ -69,11 +75,11 @@ func TestBookService_GetBookByID_Success(t *testing.T) {
 
 func TestBookService_GetBookByID_NotFound(t *testing.T) {
 	mockRepo := new(mocks.MockBookRepository)
-	svc := service.NewBookService(mockRepo)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.AllowAll(), slog.Default())
 
 	mockRepo.On("GetByID", uint(999)).Return(nil, errors.New("record not found"))
 
-	book, err := svc.GetBookByID(999)
+	book, err := svc.GetBookByID(context.Background(), 999)
 	assert.Error(t, err)
 	assert.Nil(t, book)
 	assert.Contains(t, err.Error(), "book not found")
@@ -82,16 +88,16 @@ func TestBookService_GetBookByID_NotFound(t *testing.T) {
 
 func TestBookService_GetAllBooks(t *testing.T) {
 	mockRepo := new(mocks.MockBookRepository)
-	svc := service.NewBookService(mockRepo)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.AllowAll(), slog.Default())
 
 	expectedBooks := []models.Book{
 		{ID: 1, Title: "Book 1", Author: "Author 1", Pages: 100},
 		{ID: 2, Title: "Book 2", Author: "Author 2", Pages: 200},
 	}
 
-	mockRepo.On("GetAll").Return(expectedBooks, nil)
+	mockRepo.On("GetAll", models.BookFilter{}).Return(expectedBooks, nil)
 
-	books, err := svc.GetAllBooks()
+	books, err := svc.GetAllBooks(context.Background(), models.BookFilter{})
 	assert.NoError(t, err)
 	assert.Len(t, books, 2)
 	mockRepo.AssertExpectations(t)
@@ -99,7 +105,8 @@ func TestBookService_GetAllBooks(t *testing.T) {
 
 func TestBookService_UpdateBook_Success(t *testing.T) {
 	mockRepo := new(mocks.MockBookRepository)
-	svc := service.NewBookService(mockRepo)
+	unitOfWork := &mocks.MockUnitOfWork{BookRepo: mockRepo}
+	svc := service.NewBookService(mockRepo, unitOfWork, authz.AllowAll(), slog.Default())
 
 	existingBook := &models.Book{
 		ID:     1,
@@ -116,16 +123,21 @@ func TestBookService_UpdateBook_Success(t *testing.T) {
 	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
 	mockRepo.On("Update", mock.AnythingOfType("*models.Book")).Return(nil)
 
-	book, err := svc.UpdateBook(1, updateData)
+	book, err := svc.UpdateBook(context.Background(), 1, updateData)
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Title", book.Title)
 	assert.Equal(t, 150, book.Pages)
 	mockRepo.AssertExpectations(t)
+	assert.Equal(t, 1, unitOfWork.Commits)
+	if assert.Len(t, unitOfWork.OutboxRepo.Events, 1) {
+		assert.Equal(t, "book.updated", unitOfWork.OutboxRepo.Events[0].Type)
+	}
 }
 
 func TestBookService_UpdateBook_InvalidColor(t *testing.T) {
 	mockRepo := new(mocks.MockBookRepository)
-	svc := service.NewBookService(mockRepo)
+	unitOfWork := &mocks.MockUnitOfWork{BookRepo: mockRepo}
+	svc := service.NewBookService(mockRepo, unitOfWork, authz.AllowAll(), slog.Default())
 
 	existingBook := &models.Book{
 		ID:     1,
@@ -141,16 +153,18 @@ func TestBookService_UpdateBook_InvalidColor(t *testing.T) {
 
 	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
 
-	book, err := svc.UpdateBook(1, updateData)
+	book, err := svc.UpdateBook(context.Background(), 1, updateData)
 	assert.Error(t, err)
 	assert.Nil(t, book)
 	assert.Contains(t, err.Error(), "invalid color")
 	mockRepo.AssertNotCalled(t, "Update")
+	assert.Equal(t, 1, unitOfWork.RolledBacks)
+	assert.Empty(t, unitOfWork.OutboxRepo.Events)
 }
 
 func TestBookService_DeleteBook_Success(t *testing.T) {
 	mockRepo := new(mocks.MockBookRepository)
-	svc := service.NewBookService(mockRepo)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.AllowAll(), slog.Default())
 
 	existingBook := &models.Book{
 		ID:     1,
@@ -160,21 +174,96 @@ func TestBookService_DeleteBook_Success(t *testing.T) {
 	}
 
 	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
+	mockRepo.On("GetAvailability", uint(1)).Return(&models.BookAvailability{}, nil)
 	mockRepo.On("Delete", uint(1)).Return(nil)
 
-	err := svc.DeleteBook(1)
+	err := svc.DeleteBook(context.Background(), 1)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestBookService_DeleteBook_NotFound(t *testing.T) {
 	mockRepo := new(mocks.MockBookRepository)
-	svc := service.NewBookService(mockRepo)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.AllowAll(), slog.Default())
 
 	mockRepo.On("GetByID", uint(999)).Return(nil, errors.New("record not found"))
 
-	err := svc.DeleteBook(999)
+	err := svc.DeleteBook(context.Background(), 999)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "book not found")
 	mockRepo.AssertNotCalled(t, "Delete")
 }
+
+func TestBookService_DeleteBook_HasCopies(t *testing.T) {
+	mockRepo := new(mocks.MockBookRepository)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.AllowAll(), slog.Default())
+
+	mockRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+	mockRepo.On("GetAvailability", uint(1)).Return(&models.BookAvailability{Total: 1, Available: 1}, nil)
+
+	err := svc.DeleteBook(context.Background(), 1)
+	assert.Error(t, err)
+	assert.Equal(t, "book has copies", err.Error())
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestBookService_CreateBook_RecordsOwner(t *testing.T) {
+	mockRepo := new(mocks.MockBookRepository)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.NewAuthorizer(authz.DefaultPolicy(), slog.Default()), slog.Default())
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "eve", Roles: []string{"editor"}})
+	book := &models.Book{Title: "Test Book", Author: "Test Author", CreatedBy: "mallory"}
+	mockRepo.On("Create", book).Return(nil)
+
+	err := svc.CreateBook(ctx, book)
+	assert.NoError(t, err)
+	assert.Equal(t, "eve", book.CreatedBy)
+}
+
+func TestBookService_UpdateBook_EditorForbiddenOnOthersBook(t *testing.T) {
+	mockRepo := new(mocks.MockBookRepository)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.NewAuthorizer(authz.DefaultPolicy(), slog.Default()), slog.Default())
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "eve", Roles: []string{"editor"}})
+	mockRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1, Title: "Theirs", CreatedBy: "mallory"}, nil)
+
+	book, err := svc.UpdateBook(ctx, 1, models.Book{Title: "Mine now"})
+	assert.Nil(t, book)
+	assert.True(t, authz.IsForbidden(err))
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	err = svc.DeleteBook(ctx, 1)
+	assert.True(t, authz.IsForbidden(err))
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestBookService_CreateBook_AnonymousForbidden(t *testing.T) {
+	mockRepo := new(mocks.MockBookRepository)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.NewAuthorizer(authz.DefaultPolicy(), slog.Default()), slog.Default())
+
+	err := svc.CreateBook(context.Background(), &models.Book{Title: "Test Book"})
+	assert.True(t, authz.IsForbidden(err))
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBookService_CreateBook_TenantQuota(t *testing.T) {
+	mockRepo := new(mocks.MockBookRepository)
+	svc := service.NewBookService(mockRepo, &mocks.MockUnitOfWork{BookRepo: mockRepo}, authz.AllowAll(), slog.Default())
+
+	tenant := &models.Tenant{ID: 2, Slug: "acme", MaxBooks: 2}
+	ctx := tenancy.WithTenant(context.Background(), tenant)
+
+	book := &models.Book{Title: "Test Book", Author: "Test Author", TenantID: 7}
+	mockRepo.On("Count").Return(int64(1), nil).Once()
+	mockRepo.On("Create", book).Return(nil)
+
+	err := svc.CreateBook(ctx, book)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), book.TenantID)
+
+	mockRepo.On("Count").Return(int64(2), nil).Once()
+	err = svc.CreateBook(ctx, &models.Book{Title: "One Too Many"})
+	assert.Error(t, err)
+	assert.Equal(t, "book quota exceeded", err.Error())
+	mockRepo.AssertNumberOfCalls(t, "Create", 1)
+}
@@ -0,0 +1,344 @@
+package services_test
+
+import (
+	"books-api/app/models"
+	"books-api/app/service"
+	"books-api/tests/repositories/mocks"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var notFound = errors.New("record not found")
+
+func newLoanServiceWithMembers() (service.LoanService, *mocks.MockLoanRepository, *mocks.MockCopyRepository, *mocks.MockHoldRepository, *mocks.MockMemberRepository, *mocks.MockFineRepository) {
+	loanRepo := new(mocks.MockLoanRepository)
+	copyRepo := new(mocks.MockCopyRepository)
+	holdRepo := new(mocks.MockHoldRepository)
+	memberRepo := new(mocks.MockMemberRepository)
+	fineRepo := new(mocks.MockFineRepository)
+	policy := service.CirculationPolicy{
+		MaxLoansPerMember:   2,
+		LoanPeriod:          7 * 24 * time.Hour,
+		MaxRenewals:         1,
+		HoldPickupWindow:    24 * time.Hour,
+		FinePerDayCents:     25,
+		MaxUnpaidFinesCents: 500,
+	}
+	// Circulation locks the book of the copy
+	bookRepo := new(mocks.MockBookRepository)
+	bookRepo.On("GetByID", mock.Anything).Return(&models.Book{ID: 3}, nil)
+	unitOfWork := &mocks.MockUnitOfWork{
+		BookRepo:   bookRepo,
+		CopyRepo:   copyRepo,
+		LoanRepo:   loanRepo,
+		HoldRepo:   holdRepo,
+		MemberRepo: memberRepo,
+		FineRepo:   fineRepo,
+	}
+	svc := service.NewLoanService(loanRepo, unitOfWork, policy, slog.Default())
+	return svc, loanRepo, copyRepo, holdRepo, memberRepo, fineRepo
+}
+
+// newLoanService builds a loan service whose members are all active and fine-free
+func newLoanService() (service.LoanService, *mocks.MockLoanRepository, *mocks.MockCopyRepository, *mocks.MockHoldRepository) {
+	svc, loanRepo, copyRepo, holdRepo, memberRepo, fineRepo := newLoanServiceWithMembers()
+	memberRepo.On("GetByID", mock.Anything).Return(&models.Member{Status: models.MemberActive}, nil)
+	fineRepo.On("SumUnpaidByMember", mock.Anything).Return(int64(0), nil)
+	fineRepo.On("Create", mock.AnythingOfType("*models.Fine")).Return(nil)
+	return svc, loanRepo, copyRepo, holdRepo
+}
+
+func TestLoanService_Checkout_Success(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo := newLoanService()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("PromoteNext", uint(3), uint(10), mock.Anything, mock.Anything).Return(nil, nil)
+	loanRepo.On("CountActiveByMember", uint(7)).Return(int64(1), nil)
+	loanRepo.On("Create", mock.AnythingOfType("*models.Loan")).Return(nil)
+
+	before := time.Now()
+	loan, err := svc.Checkout(context.Background(), 10, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), loan.BookID)
+	assert.Equal(t, uint(7), loan.MemberID)
+	assert.WithinDuration(t, before.Add(7*24*time.Hour), loan.DueAt, time.Minute)
+	loanRepo.AssertExpectations(t)
+}
+
+func TestLoanService_Checkout_AlreadyCheckedOut(t *testing.T) {
+	svc, loanRepo, copyRepo, _ := newLoanService()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(&models.Loan{ID: 1, CopyID: 10}, nil)
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.Error(t, err)
+	assert.Equal(t, "copy is already checked out", err.Error())
+	loanRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestLoanService_Checkout_LoanLimit(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo := newLoanService()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("PromoteNext", uint(3), uint(10), mock.Anything, mock.Anything).Return(nil, nil)
+	loanRepo.On("CountActiveByMember", uint(7)).Return(int64(2), nil)
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.Error(t, err)
+	assert.Equal(t, "member has reached the loan limit", err.Error())
+	loanRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestLoanService_Checkout_ConcurrentCheckoutLoses(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo := newLoanService()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound).Once()
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("PromoteNext", uint(3), uint(10), mock.Anything, mock.Anything).Return(nil, nil)
+	loanRepo.On("CountActiveByMember", uint(7)).Return(int64(0), nil)
+	loanRepo.On("Create", mock.AnythingOfType("*models.Loan")).Return(errors.New("UNIQUE constraint failed"))
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(&models.Loan{ID: 1, CopyID: 10}, nil).Once()
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.Error(t, err)
+	assert.Equal(t, "copy is already checked out", err.Error())
+}
+
+func TestLoanService_Return_MarksOverdue(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo := newLoanService()
+
+	loan := &models.Loan{ID: 1, CopyID: 10, DueAt: time.Now().Add(-time.Hour)}
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(loan, nil)
+	loanRepo.On("MarkReturned", loan, mock.AnythingOfType("time.Time")).Return(true, nil)
+	holdRepo.On("PromoteNext", uint(0), uint(10), mock.Anything, mock.Anything).Return(nil, nil)
+
+	returned, err := svc.Return(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.NotNil(t, returned.ReturnedAt)
+	assert.True(t, returned.Overdue)
+}
+
+func TestLoanService_Return_NotCheckedOut(t *testing.T) {
+	svc, loanRepo, copyRepo, _ := newLoanService()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+
+	_, err := svc.Return(context.Background(), 10)
+	assert.Error(t, err)
+	assert.Equal(t, "copy is not checked out", err.Error())
+}
+
+func TestLoanService_Renew_Limits(t *testing.T) {
+	svc, loanRepo, copyRepo, _ := newLoanService()
+
+	due := time.Now().Add(24 * time.Hour)
+	loan := &models.Loan{ID: 1, CopyID: 10, DueAt: due}
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(loan, nil)
+	loanRepo.On("Update", loan).Return(nil)
+
+	renewed, err := svc.Renew(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, renewed.Renewals)
+	assert.Equal(t, due.Add(7*24*time.Hour), renewed.DueAt)
+
+	_, err = svc.Renew(context.Background(), 10)
+	assert.Error(t, err)
+	assert.Equal(t, "loan has reached the renewal limit", err.Error())
+}
+
+func TestLoanService_Renew_Overdue(t *testing.T) {
+	svc, loanRepo, copyRepo, _ := newLoanService()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(&models.Loan{ID: 1, CopyID: 10, DueAt: time.Now().Add(-time.Hour)}, nil)
+
+	_, err := svc.Renew(context.Background(), 10)
+	assert.Error(t, err)
+	assert.Equal(t, "overdue loans cannot be renewed", err.Error())
+	loanRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestLoanService_Checkout_ReservedForAnotherMember(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo := newLoanService()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(&models.Hold{ID: 4, MemberID: 8, Status: models.HoldReady}, nil)
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.Error(t, err)
+	assert.Equal(t, "copy is reserved for another member", err.Error())
+	loanRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestLoanService_Checkout_FulfillsHold(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo := newLoanService()
+
+	hold := &models.Hold{ID: 4, MemberID: 7, Status: models.HoldReady}
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(hold, nil)
+	loanRepo.On("CountActiveByMember", uint(7)).Return(int64(0), nil)
+	loanRepo.On("Create", mock.AnythingOfType("*models.Loan")).Return(nil)
+	holdRepo.On("UpdateIfStatus", hold, models.HoldReady).Return(true, nil)
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, models.HoldFulfilled, hold.Status)
+	holdRepo.AssertExpectations(t)
+}
+
+func TestLoanService_Return_PromotesNextHold(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo := newLoanService()
+
+	loan := &models.Loan{ID: 1, CopyID: 10, BookID: 3, DueAt: time.Now().Add(time.Hour)}
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(loan, nil)
+	loanRepo.On("MarkReturned", loan, mock.AnythingOfType("time.Time")).Return(true, nil)
+	holdRepo.On("PromoteNext", uint(3), uint(10), mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).
+		Return(&models.Hold{ID: 4, MemberID: 8, Status: models.HoldReady}, nil)
+
+	_, err := svc.Return(context.Background(), 10)
+	assert.NoError(t, err)
+	holdRepo.AssertExpectations(t)
+}
+
+func TestLoanService_Checkout_SuspendedMember(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo, memberRepo, _ := newLoanServiceWithMembers()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("PromoteNext", uint(3), uint(10), mock.Anything, mock.Anything).Return(nil, nil)
+	memberRepo.On("GetByID", uint(7)).Return(&models.Member{ID: 7, Status: models.MemberSuspended}, nil)
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.Error(t, err)
+	assert.Equal(t, "member is suspended", err.Error())
+	loanRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestLoanService_Checkout_UnknownMember(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo, memberRepo, _ := newLoanServiceWithMembers()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("PromoteNext", uint(3), uint(10), mock.Anything, mock.Anything).Return(nil, nil)
+	memberRepo.On("GetByID", uint(7)).Return(nil, notFound)
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.Error(t, err)
+	assert.Equal(t, "member not found", err.Error())
+}
+
+func TestLoanService_Checkout_UnpaidFines(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo, memberRepo, fineRepo := newLoanServiceWithMembers()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("PromoteNext", uint(3), uint(10), mock.Anything, mock.Anything).Return(nil, nil)
+	memberRepo.On("GetByID", uint(7)).Return(&models.Member{ID: 7, Status: models.MemberActive}, nil)
+	fineRepo.On("SumUnpaidByMember", uint(7)).Return(int64(500), nil)
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.Error(t, err)
+	assert.Equal(t, "member has unpaid fines", err.Error())
+	loanRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestLoanService_Checkout_MemberLoanLimitOverride(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo, memberRepo, fineRepo := newLoanServiceWithMembers()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("PromoteNext", uint(3), uint(10), mock.Anything, mock.Anything).Return(nil, nil)
+	memberRepo.On("GetByID", uint(7)).Return(&models.Member{ID: 7, Status: models.MemberActive, MaxLoans: 4}, nil)
+	fineRepo.On("SumUnpaidByMember", uint(7)).Return(int64(0), nil)
+	loanRepo.On("CountActiveByMember", uint(7)).Return(int64(3), nil)
+	loanRepo.On("Create", mock.AnythingOfType("*models.Loan")).Return(nil)
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.NoError(t, err)
+	loanRepo.AssertExpectations(t)
+}
+
+func TestLoanService_Return_ChargesFine(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo, _, fineRepo := newLoanServiceWithMembers()
+
+	loan := &models.Loan{ID: 1, CopyID: 10, BookID: 3, MemberID: 7, DueAt: time.Now().Add(-49 * time.Hour)}
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(loan, nil)
+	loanRepo.On("MarkReturned", loan, mock.AnythingOfType("time.Time")).Return(true, nil)
+	holdRepo.On("PromoteNext", uint(3), uint(10), mock.Anything, mock.Anything).Return(nil, nil)
+	fineRepo.On("Create", mock.MatchedBy(func(fine *models.Fine) bool {
+		return fine.MemberID == 7 && fine.LoanID == 1 && fine.DaysOverdue == 3 && fine.AmountCents == 75
+	})).Return(nil)
+
+	_, err := svc.Return(context.Background(), 10)
+	assert.NoError(t, err)
+	fineRepo.AssertExpectations(t)
+}
+
+func TestLoanService_Checkout_WalkInGoesToHoldQueue(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo := newLoanService()
+
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("PromoteNext", uint(3), uint(10), mock.Anything, mock.Anything).
+		Return(&models.Hold{ID: 4, MemberID: 8, Status: models.HoldReady}, nil)
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.Error(t, err)
+	assert.Equal(t, "copy is reserved for another member", err.Error())
+	loanRepo.AssertNotCalled(t, "Create", mock.Anything)
+	holdRepo.AssertExpectations(t)
+}
+
+func TestLoanService_Checkout_HoldUpdateFails(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo := newLoanService()
+
+	hold := &models.Hold{ID: 4, MemberID: 7, Status: models.HoldReady}
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(nil, notFound)
+	holdRepo.On("GetReadyByCopy", uint(10)).Return(hold, nil)
+	loanRepo.On("CountActiveByMember", uint(7)).Return(int64(0), nil)
+	loanRepo.On("Create", mock.AnythingOfType("*models.Loan")).Return(nil)
+	holdRepo.On("UpdateIfStatus", hold, models.HoldReady).Return(false, errors.New("database is locked"))
+
+	_, err := svc.Checkout(context.Background(), 10, 7)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to check out copy")
+}
+
+func TestLoanService_Return_ConcurrentReturnLoses(t *testing.T) {
+	svc, loanRepo, copyRepo, holdRepo := newLoanService()
+
+	loan := &models.Loan{ID: 1, CopyID: 10, BookID: 3, DueAt: time.Now().Add(-time.Hour)}
+	copyRepo.On("GetByID", uint(10)).Return(&models.Copy{ID: 10, BookID: 3}, nil)
+	loanRepo.On("GetActiveByCopy", uint(10)).Return(loan, nil)
+	loanRepo.On("MarkReturned", loan, mock.AnythingOfType("time.Time")).Return(false, nil)
+
+	_, err := svc.Return(context.Background(), 10)
+	assert.Error(t, err)
+	assert.Equal(t, "copy is not checked out", err.Error())
+	holdRepo.AssertNotCalled(t, "PromoteNext", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,112 @@
+package services_test
+
+import (
+	"books-api/app/models"
+	"books-api/app/service"
+	"books-api/tests/repositories/mocks"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newMemberService() (service.MemberService, *mocks.MockMemberRepository, *mocks.MockLoanRepository, *mocks.MockFineRepository) {
+	memberRepo := new(mocks.MockMemberRepository)
+	loanRepo := new(mocks.MockLoanRepository)
+	fineRepo := new(mocks.MockFineRepository)
+	unitOfWork := &mocks.MockUnitOfWork{MemberRepo: memberRepo, LoanRepo: loanRepo, FineRepo: fineRepo}
+	return service.NewMemberService(memberRepo, loanRepo, fineRepo, unitOfWork, service.DefaultCirculationPolicy(), slog.Default()), memberRepo, loanRepo, fineRepo
+}
+
+func TestMemberService_CreateMember_Success(t *testing.T) {
+	svc, memberRepo, _, _ := newMemberService()
+
+	member := &models.Member{Name: " Ada Lovelace ", Email: "Ada@Example.com", CardNumber: "C-001", Status: models.MemberSuspended}
+	memberRepo.On("GetByEmail", "ada@example.com").Return(nil, notFound)
+	memberRepo.On("GetByCardNumber", "C-001").Return(nil, notFound)
+	memberRepo.On("Create", member).Return(nil)
+
+	err := svc.CreateMember(context.Background(), member)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", member.Name)
+	assert.Equal(t, "ada@example.com", member.Email)
+	assert.Equal(t, models.MemberActive, member.Status)
+	memberRepo.AssertExpectations(t)
+}
+
+func TestMemberService_CreateMember_InvalidEmail(t *testing.T) {
+	svc, memberRepo, _, _ := newMemberService()
+
+	err := svc.CreateMember(context.Background(), &models.Member{Name: "Ada", Email: "not-an-email", CardNumber: "C-001"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid email")
+	memberRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestMemberService_CreateMember_DuplicateCard(t *testing.T) {
+	svc, memberRepo, _, _ := newMemberService()
+
+	memberRepo.On("GetByEmail", "ada@example.com").Return(nil, notFound)
+	memberRepo.On("GetByCardNumber", "C-001").Return(&models.Member{ID: 4, CardNumber: "C-001"}, nil)
+
+	err := svc.CreateMember(context.Background(), &models.Member{Name: "Ada", Email: "ada@example.com", CardNumber: "C-001"})
+	assert.Error(t, err)
+	assert.Equal(t, "card number already exists", err.Error())
+	memberRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestMemberService_SuspendAndReinstate(t *testing.T) {
+	svc, memberRepo, _, _ := newMemberService()
+
+	member := &models.Member{ID: 1, Name: "Ada", Status: models.MemberActive}
+	memberRepo.On("GetByID", uint(1)).Return(member, nil)
+	memberRepo.On("Update", member).Return(nil)
+
+	_, err := svc.SuspendMember(context.Background(), 1, "  ")
+	assert.Error(t, err)
+	assert.Equal(t, "reason is required", err.Error())
+
+	suspended, err := svc.SuspendMember(context.Background(), 1, "lost books")
+	assert.NoError(t, err)
+	assert.Equal(t, models.MemberSuspended, suspended.Status)
+	assert.Equal(t, "lost books", suspended.SuspendedReason)
+
+	_, err = svc.SuspendMember(context.Background(), 1, "again")
+	assert.Equal(t, "member is already suspended", err.Error())
+
+	reinstated, err := svc.ReinstateMember(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.MemberActive, reinstated.Status)
+	assert.Empty(t, reinstated.SuspendedReason)
+}
+
+func TestMemberService_DeleteMember_ActiveLoans(t *testing.T) {
+	svc, memberRepo, loanRepo, _ := newMemberService()
+
+	memberRepo.On("GetByID", uint(1)).Return(&models.Member{ID: 1}, nil)
+	loanRepo.On("CountActiveByMember", uint(1)).Return(int64(1), nil)
+
+	err := svc.DeleteMember(context.Background(), 1)
+	assert.Error(t, err)
+	assert.Equal(t, "member has active loans", err.Error())
+	memberRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestMemberService_PayFine(t *testing.T) {
+	svc, _, _, fineRepo := newMemberService()
+
+	fine := &models.Fine{ID: 3, MemberID: 1, AmountCents: 75}
+	fineRepo.On("GetByID", uint(3)).Return(fine, nil)
+	fineRepo.On("Update", fine).Return(nil)
+
+	paid, err := svc.PayFine(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.NotNil(t, paid.PaidAt)
+
+	_, err = svc.PayFine(context.Background(), 3)
+	assert.Error(t, err)
+	assert.Equal(t, "fine already paid", err.Error())
+	fineRepo.AssertNumberOfCalls(t, "Update", 1)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"books-api/app/models"
+	"context"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -10,33 +11,33 @@ type MockBookService struct {
 	mock.Mock
 }
 
-func (m *MockBookService) CreateBook(book *models.Book) error {
-	args := m.Called(book)
+func (m *MockBookService) CreateBook(ctx context.Context, book *models.Book) error {
+	args := m.Called(ctx, book)
 	return args.Error(0)
 }
 
-func (m *MockBookService) GetBookByID(id uint) (*models.Book, error) {
-	args := m.Called(id)
+func (m *MockBookService) GetBookByID(ctx context.Context, id uint) (*models.Book, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Book), args.Error(1)
 }
 
-func (m *MockBookService) GetAllBooks() ([]models.Book, error) {
-	args := m.Called()
+func (m *MockBookService) GetAllBooks(ctx context.Context, filter models.BookFilter) ([]models.Book, error) {
+	args := m.Called(ctx, filter)
 	return args.Get(0).([]models.Book), args.Error(1)
 }
 
-func (m *MockBookService) UpdateBook(id uint, updateData models.Book) (*models.Book, error) {
-	args := m.Called(id, updateData)
+func (m *MockBookService) UpdateBook(ctx context.Context, id uint, updateData models.Book) (*models.Book, error) {
+	args := m.Called(ctx, id, updateData)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Book), args.Error(1)
 }
 
-func (m *MockBookService) DeleteBook(id uint) error {
-	args := m.Called(id)
+func (m *MockBookService) DeleteBook(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
@@ -0,0 +1,235 @@
+package services_test
+
+import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/models"
+	"books-api/app/service"
+	"books-api/tests/repositories/mocks"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newReviewService builds a review service enforcing the default policy
+func newReviewService() (service.ReviewService, *mocks.MockReviewRepository, *mocks.MockBookRepository) {
+	mockReviewRepo := new(mocks.MockReviewRepository)
+	mockBookRepo := new(mocks.MockBookRepository)
+	unitOfWork := &mocks.MockUnitOfWork{BookRepo: mockBookRepo, ReviewRepo: mockReviewRepo}
+	svc := service.NewReviewService(mockReviewRepo, mockBookRepo, unitOfWork, authz.NewAuthorizer(authz.DefaultPolicy(), slog.Default()), slog.Default())
+	return svc, mockReviewRepo, mockBookRepo
+}
+
+func reader(subject string, roles ...string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Roles: roles})
+}
+
+func TestReviewService_CreateReview_Success(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	review := &models.Review{Reviewer: "mallory", Rating: 4, Text: "Great", Status: models.ReviewApproved}
+
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+	mockReviewRepo.On("GetByBookAndReviewer", uint(1), "alice").Return(nil, errors.New("record not found"))
+	mockReviewRepo.On("Create", review).Return(nil)
+
+	err := svc.CreateReview(reader("alice"), 1, review)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), review.BookID)
+	assert.Equal(t, "alice", review.Reviewer)
+	assert.Equal(t, models.ReviewPending, review.Status)
+	mockReviewRepo.AssertExpectations(t)
+}
+
+func TestReviewService_CreateReview_Anonymous(t *testing.T) {
+	svc, mockReviewRepo, _ := newReviewService()
+
+	err := svc.CreateReview(context.Background(), 1, &models.Review{Reviewer: "alice", Rating: 5})
+	assert.True(t, authz.IsForbidden(err))
+	mockReviewRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestReviewService_CreateReview_RatingOutOfRange(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+
+	for _, rating := range []int{0, 6, -1} {
+		err := svc.CreateReview(reader("alice"), 1, &models.Review{Rating: rating})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "rating must be between")
+	}
+	mockReviewRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestReviewService_CreateReview_OnePerReviewer(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+	mockReviewRepo.On("GetByBookAndReviewer", uint(1), "alice").Return(&models.Review{ID: 3}, nil)
+
+	err := svc.CreateReview(reader("alice"), 1, &models.Review{Rating: 5})
+	assert.Error(t, err)
+	assert.Equal(t, "review already exists", err.Error())
+	mockReviewRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestReviewService_GetReview_WrongBook(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+	mockReviewRepo.On("GetByID", uint(7)).Return(&models.Review{ID: 7, BookID: 2, Status: models.ReviewApproved}, nil)
+
+	_, err := svc.GetReview(context.Background(), 1, 7)
+	assert.Error(t, err)
+	assert.Equal(t, "review not found", err.Error())
+}
+
+func TestReviewService_GetReview_BookOfOtherTenant(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	// The book repository hides books of other tenants
+	mockBookRepo.On("GetByID", uint(1)).Return(nil, errors.New("record not found"))
+
+	_, err := svc.GetReview(context.Background(), 1, 7)
+	assert.Error(t, err)
+	assert.Equal(t, "book not found", err.Error())
+	mockReviewRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+}
+
+func TestReviewService_GetReview_PendingOnlyForModerators(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+	mockReviewRepo.On("GetByID", uint(7)).Return(&models.Review{ID: 7, BookID: 1, Reviewer: "alice", Status: models.ReviewPending}, nil)
+
+	for _, ctx := range []context.Context{context.Background(), reader("alice"), reader("eve", "editor")} {
+		_, err := svc.GetReview(ctx, 1, 7)
+		assert.Error(t, err)
+		assert.Equal(t, "review not found", err.Error())
+	}
+
+	review, err := svc.GetReview(reader("lee", "librarian"), 1, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), review.ID)
+}
+
+func TestReviewService_GetBookReviews_OtherStatusesOnlyForModerators(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	approved := models.ReviewApproved
+	pending := models.ReviewPending
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+	mockReviewRepo.On("GetByBook", uint(1), &approved).Return([]models.Review{{ID: 1}}, nil)
+	mockReviewRepo.On("GetByBook", uint(1), &pending).Return([]models.Review{{ID: 2}}, nil)
+
+	reviews, err := svc.GetBookReviews(context.Background(), 1, &approved)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 1)
+
+	_, err = svc.GetBookReviews(context.Background(), 1, &pending)
+	assert.True(t, authz.IsForbidden(err))
+	_, err = svc.GetBookReviews(reader("alice"), 1, nil)
+	assert.True(t, authz.IsForbidden(err))
+
+	reviews, err = svc.GetBookReviews(reader("lee", "librarian"), 1, &pending)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 1)
+}
+
+func TestReviewService_UpdateReview_ReturnsToModeration(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+	review := &models.Review{ID: 7, BookID: 1, Reviewer: "alice", Rating: 2, Status: models.ReviewApproved}
+	mockReviewRepo.On("GetByID", uint(7)).Return(review, nil)
+	mockReviewRepo.On("Update", review).Return(nil)
+
+	updated, err := svc.UpdateReview(reader("alice"), 1, 7, models.Review{Rating: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, updated.Rating)
+	assert.Equal(t, models.ReviewPending, updated.Status)
+}
+
+func TestReviewService_UpdateReview_OnlyByReviewer(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+	mockReviewRepo.On("GetByID", uint(7)).Return(&models.Review{ID: 7, BookID: 1, Reviewer: "alice", Rating: 2}, nil)
+
+	for _, ctx := range []context.Context{context.Background(), reader("mallory"), reader("lee", "librarian")} {
+		_, err := svc.UpdateReview(ctx, 1, 7, models.Review{Rating: 1})
+		assert.True(t, authz.IsForbidden(err))
+	}
+	mockReviewRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestReviewService_DeleteReview_ReviewerOrModerator(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	review := &models.Review{ID: 7, BookID: 1, Reviewer: "alice"}
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+	mockReviewRepo.On("GetByID", uint(7)).Return(review, nil)
+	mockReviewRepo.On("Delete", review).Return(nil)
+
+	assert.True(t, authz.IsForbidden(svc.DeleteReview(reader("mallory"), 1, 7)))
+	assert.NoError(t, svc.DeleteReview(reader("alice"), 1, 7))
+	assert.NoError(t, svc.DeleteReview(reader("lee", "librarian"), 1, 7))
+}
+
+func TestReviewService_ModerateReview_RequiresModerator(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	review := &models.Review{ID: 7, BookID: 1, Reviewer: "alice", Status: models.ReviewPending}
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1}, nil)
+	mockReviewRepo.On("GetByID", uint(7)).Return(review, nil)
+	mockReviewRepo.On("Update", review).Return(nil)
+
+	_, err := svc.ModerateReview(reader("alice"), 1, 7, models.ReviewApproved)
+	assert.True(t, authz.IsForbidden(err))
+	mockReviewRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	moderated, err := svc.ModerateReview(reader("lee", "librarian"), 1, 7, models.ReviewApproved)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReviewApproved, moderated.Status)
+}
+
+func TestReviewService_ModerateReview_InvalidStatus(t *testing.T) {
+	svc, mockReviewRepo, _ := newReviewService()
+
+	_, err := svc.ModerateReview(reader("lee", "librarian"), 1, 7, models.ReviewStatus("hidden"))
+	assert.Error(t, err)
+	assert.False(t, authz.IsForbidden(err))
+	mockReviewRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestReviewService_GetApprovedReviews_GroupsByBook(t *testing.T) {
+	svc, mockReviewRepo, mockBookRepo := newReviewService()
+
+	mockReviewRepo.On("GetApprovedByBooks", []uint{1, 2, 3}).Return([]models.Review{
+		{ID: 3, BookID: 2, Reviewer: "carol"},
+		{ID: 2, BookID: 1, Reviewer: "bob"},
+		{ID: 1, BookID: 1, Reviewer: "alice"},
+	}, nil)
+
+	reviews, err := svc.GetApprovedReviews(context.Background(), []uint{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Len(t, reviews[1], 2)
+	assert.Equal(t, "bob", reviews[1][0].Reviewer)
+	assert.Len(t, reviews[2], 1)
+	assert.Empty(t, reviews[3])
+	mockBookRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestReviewService_GetApprovedReviews_NoBooks(t *testing.T) {
+	svc, mockReviewRepo, _ := newReviewService()
+
+	reviews, err := svc.GetApprovedReviews(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, reviews)
+	mockReviewRepo.AssertNotCalled(t, "GetApprovedByBooks", mock.Anything)
+}
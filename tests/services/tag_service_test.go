@@ -0,0 +1,127 @@
+package services_test
+
+import (
+	"books-api/app/auth"
+	"books-api/app/authz"
+	"books-api/app/models"
+	"books-api/app/service"
+	"books-api/tests/repositories/mocks"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTagService_CreateTag_NormalizesName(t *testing.T) {
+	mockTagRepo := new(mocks.MockTagRepository)
+	svc := service.NewTagService(mockTagRepo, new(mocks.MockBookRepository), &mocks.MockUnitOfWork{TagRepo: mockTagRepo}, authz.AllowAll(), slog.Default())
+
+	tag := &models.Tag{Name: "  Science   FICTION "}
+
+	mockTagRepo.On("GetByName", "science fiction").Return(nil, errors.New("record not found"))
+	mockTagRepo.On("Create", tag).Return(nil)
+
+	err := svc.CreateTag(context.Background(), tag)
+	assert.NoError(t, err)
+	assert.Equal(t, "science fiction", tag.Name)
+	mockTagRepo.AssertExpectations(t)
+}
+
+func TestTagService_CreateTag_Duplicate(t *testing.T) {
+	mockTagRepo := new(mocks.MockTagRepository)
+	svc := service.NewTagService(mockTagRepo, new(mocks.MockBookRepository), &mocks.MockUnitOfWork{TagRepo: mockTagRepo}, authz.AllowAll(), slog.Default())
+
+	mockTagRepo.On("GetByName", "fantasy").Return(&models.Tag{ID: 1, Name: "fantasy"}, nil)
+
+	err := svc.CreateTag(context.Background(), &models.Tag{Name: "Fantasy"})
+	assert.Error(t, err)
+	assert.Equal(t, "tag already exists", err.Error())
+	mockTagRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestTagService_UpdateTag_RenameOntoExisting(t *testing.T) {
+	mockTagRepo := new(mocks.MockTagRepository)
+	svc := service.NewTagService(mockTagRepo, new(mocks.MockBookRepository), &mocks.MockUnitOfWork{TagRepo: mockTagRepo}, authz.AllowAll(), slog.Default())
+
+	mockTagRepo.On("GetByID", uint(1)).Return(&models.Tag{ID: 1, Name: "scifi"}, nil)
+	mockTagRepo.On("GetByName", "science fiction").Return(&models.Tag{ID: 2, Name: "science fiction"}, nil)
+
+	_, err := svc.UpdateTag(context.Background(), 1, models.Tag{Name: "Science Fiction"})
+	assert.Error(t, err)
+	assert.Equal(t, "tag already exists", err.Error())
+	mockTagRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestTagService_MergeTags_Success(t *testing.T) {
+	mockTagRepo := new(mocks.MockTagRepository)
+	svc := service.NewTagService(mockTagRepo, new(mocks.MockBookRepository), &mocks.MockUnitOfWork{TagRepo: mockTagRepo}, authz.AllowAll(), slog.Default())
+
+	target := &models.Tag{ID: 2, Name: "science fiction"}
+	mockTagRepo.On("GetByID", uint(1)).Return(&models.Tag{ID: 1, Name: "scifi"}, nil)
+	mockTagRepo.On("GetByID", uint(2)).Return(target, nil)
+	mockTagRepo.On("Merge", uint(1), uint(2)).Return(nil)
+
+	merged, err := svc.MergeTags(context.Background(), 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, target, merged)
+	mockTagRepo.AssertExpectations(t)
+}
+
+func TestTagService_MergeTags_Self(t *testing.T) {
+	mockTagRepo := new(mocks.MockTagRepository)
+	svc := service.NewTagService(mockTagRepo, new(mocks.MockBookRepository), &mocks.MockUnitOfWork{TagRepo: mockTagRepo}, authz.AllowAll(), slog.Default())
+
+	_, err := svc.MergeTags(context.Background(), 1, 1)
+	assert.Error(t, err)
+	mockTagRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything)
+}
+
+func TestTagService_SetBookTags_CreatesMissingAndDeduplicates(t *testing.T) {
+	mockTagRepo := new(mocks.MockTagRepository)
+	mockBookRepo := new(mocks.MockBookRepository)
+	svc := service.NewTagService(mockTagRepo, mockBookRepo, &mocks.MockUnitOfWork{BookRepo: mockBookRepo, TagRepo: mockTagRepo}, authz.AllowAll(), slog.Default())
+
+	book := &models.Book{ID: 1, Title: "Dune"}
+	existing := &models.Tag{ID: 5, Name: "classic"}
+
+	mockBookRepo.On("GetByID", uint(1)).Return(book, nil)
+	mockTagRepo.On("GetByName", "classic").Return(existing, nil)
+	mockTagRepo.On("GetByName", "desert planet").Return(nil, errors.New("record not found"))
+	mockTagRepo.On("Create", &models.Tag{Name: "desert planet"}).Return(nil)
+	mockBookRepo.On("ReplaceTags", book, mock.AnythingOfType("[]models.Tag")).Return(nil)
+
+	updated, err := svc.SetBookTags(context.Background(), 1, []string{"Classic", "desert  planet", "CLASSIC", " "})
+	assert.NoError(t, err)
+	assert.Len(t, updated.Tags, 2)
+	mockTagRepo.AssertNumberOfCalls(t, "Create", 1)
+	mockBookRepo.AssertExpectations(t)
+}
+
+func TestTagService_SetBookTags_BookNotFound(t *testing.T) {
+	mockTagRepo := new(mocks.MockTagRepository)
+	mockBookRepo := new(mocks.MockBookRepository)
+	svc := service.NewTagService(mockTagRepo, mockBookRepo, &mocks.MockUnitOfWork{BookRepo: mockBookRepo, TagRepo: mockTagRepo}, authz.AllowAll(), slog.Default())
+
+	mockBookRepo.On("GetByID", uint(999)).Return(nil, errors.New("record not found"))
+
+	_, err := svc.SetBookTags(context.Background(), 999, []string{"classic"})
+	assert.Error(t, err)
+	assert.Equal(t, "book not found", err.Error())
+}
+
+func TestTagService_SetBookTags_Forbidden(t *testing.T) {
+	mockTagRepo := new(mocks.MockTagRepository)
+	mockBookRepo := new(mocks.MockBookRepository)
+	svc := service.NewTagService(mockTagRepo, mockBookRepo, &mocks.MockUnitOfWork{BookRepo: mockBookRepo, TagRepo: mockTagRepo}, authz.NewAuthorizer(authz.DefaultPolicy(), slog.Default()), slog.Default())
+
+	mockBookRepo.On("GetByID", uint(1)).Return(&models.Book{ID: 1, CreatedBy: "alice"}, nil)
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "eve", Roles: []string{"editor"}})
+	_, err := svc.SetBookTags(ctx, 1, []string{"classic"})
+	assert.True(t, authz.IsForbidden(err))
+	mockTagRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockBookRepo.AssertNotCalled(t, "ReplaceTags", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,101 @@
+package services_test
+
+import (
+	"books-api/app/models"
+	"books-api/app/service"
+	"books-api/tests/repositories/mocks"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTenantService_CreateTenant_Validation(t *testing.T) {
+	tenantRepo := new(mocks.MockTenantRepository)
+	svc := service.NewTenantService(tenantRepo, new(mocks.MockBookRepository), &mocks.MockUnitOfWork{TenantRepo: tenantRepo}, slog.Default())
+
+	tenantRepo.On("GetBySlug", "acme").Return(&models.Tenant{ID: 2, Slug: "acme"}, nil)
+
+	for _, slug := range []string{"", "-acme", "acme.example", "acme_library"} {
+		err := svc.CreateTenant(context.Background(), &models.Tenant{Slug: slug, Name: "Acme"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid slug")
+	}
+
+	err := svc.CreateTenant(context.Background(), &models.Tenant{Slug: "globex", Name: "Globex", MaxBooks: -1})
+	assert.Error(t, err)
+	assert.Equal(t, "max books cannot be negative", err.Error())
+
+	err = svc.CreateTenant(context.Background(), &models.Tenant{Slug: " ACME ", Name: "Acme"})
+	assert.Error(t, err)
+	assert.Equal(t, "slug already exists", err.Error())
+	tenantRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestTenantService_GetTenantByID_IncludesUsage(t *testing.T) {
+	tenantRepo := new(mocks.MockTenantRepository)
+	bookRepo := new(mocks.MockBookRepository)
+	svc := service.NewTenantService(tenantRepo, bookRepo, &mocks.MockUnitOfWork{TenantRepo: tenantRepo}, slog.Default())
+
+	tenantRepo.On("GetByID", uint(2)).Return(&models.Tenant{ID: 2, Slug: "acme", MaxBooks: 10}, nil)
+	bookRepo.On("Count").Return(int64(4), nil)
+
+	tenant, err := svc.GetTenantByID(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.TenantUsage{Books: 4}, tenant.Usage)
+}
+
+func TestTenantService_DeleteTenant(t *testing.T) {
+	tenantRepo := new(mocks.MockTenantRepository)
+	bookRepo := new(mocks.MockBookRepository)
+	svc := service.NewTenantService(tenantRepo, bookRepo, &mocks.MockUnitOfWork{TenantRepo: tenantRepo}, slog.Default())
+
+	tenantRepo.On("GetByID", uint(models.DefaultTenantID)).Return(&models.Tenant{ID: models.DefaultTenantID, Slug: models.DefaultTenantSlug}, nil)
+	tenantRepo.On("GetByID", uint(2)).Return(&models.Tenant{ID: 2, Slug: "acme"}, nil)
+	tenantRepo.On("GetByID", uint(9)).Return(nil, errors.New("record not found"))
+	bookRepo.On("Count").Return(int64(3), nil)
+
+	err := svc.DeleteTenant(context.Background(), models.DefaultTenantID)
+	assert.Error(t, err)
+	assert.Equal(t, "default tenant cannot be deleted", err.Error())
+
+	err = svc.DeleteTenant(context.Background(), 2)
+	assert.Error(t, err)
+	assert.Equal(t, "tenant has books", err.Error())
+
+	err = svc.DeleteTenant(context.Background(), 9)
+	assert.Error(t, err)
+	assert.Equal(t, "tenant not found", err.Error())
+	tenantRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestTenantService_DeleteTenant_RefusesOwnedDataAndRevokesKeys(t *testing.T) {
+	tenantRepo := new(mocks.MockTenantRepository)
+	bookRepo := new(mocks.MockBookRepository)
+	apiKeyRepo := new(mocks.MockAPIKeyRepository)
+	unitOfWork := &mocks.MockUnitOfWork{TenantRepo: tenantRepo, APIKeyRepo: apiKeyRepo}
+	svc := service.NewTenantService(tenantRepo, bookRepo, unitOfWork, slog.Default())
+
+	tenantRepo.On("GetByID", uint(2)).Return(&models.Tenant{ID: 2, Slug: "acme"}, nil)
+	tenantRepo.On("GetByID", uint(3)).Return(&models.Tenant{ID: 3, Slug: "globex"}, nil)
+	bookRepo.On("Count").Return(int64(0), nil)
+	tenantRepo.On("Owned", uint(2)).Return([]string{"members", "webhooks"}, nil)
+	tenantRepo.On("Owned", uint(3)).Return(nil, nil)
+	apiKeyRepo.On("RevokeByTenant", "globex", mock.Anything).Return(int64(2), nil)
+	tenantRepo.On("Delete", uint(3)).Return(nil)
+
+	err := svc.DeleteTenant(context.Background(), 2)
+	assert.Error(t, err)
+	assert.Equal(t, "tenant has members", err.Error())
+	apiKeyRepo.AssertNotCalled(t, "RevokeByTenant", "acme", mock.Anything)
+	tenantRepo.AssertNotCalled(t, "Delete", uint(2))
+
+	err = svc.DeleteTenant(context.Background(), 3)
+	assert.NoError(t, err)
+	apiKeyRepo.AssertCalled(t, "RevokeByTenant", "globex", mock.Anything)
+	tenantRepo.AssertCalled(t, "Delete", uint(3))
+	assert.Equal(t, 1, unitOfWork.Commits)
+}
@@ -0,0 +1,148 @@
+package stream_test
+
+import (
+	"books-api/app/events"
+	"books-api/app/models"
+	"books-api/app/stream"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bookEvent(id uint, eventType events.Type, book models.Book) events.Event {
+	return events.Event{ID: id, Type: eventType, TenantID: book.TenantID, BookID: book.ID, Book: &book, OccurredAt: time.Now()}
+}
+
+func publish(t *testing.T, broker *stream.Broker, events ...events.Event) {
+	for _, event := range events {
+		require.NoError(t, broker.Publish(context.Background(), event))
+	}
+}
+
+func receive(t *testing.T, subscription *stream.Subscription) []uint {
+	var ids []uint
+	for {
+		select {
+		case event, open := <-subscription.Events():
+			if !open {
+				return ids
+			}
+			ids = append(ids, event.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestBroker_FiltersEvents(t *testing.T) {
+	broker := stream.NewBroker(stream.DefaultConfig())
+	red := models.Red
+	herbert := models.Book{ID: 1, Author: "Frank Herbert", TenantID: 1, Color: &red}
+	austen := models.Book{ID: 2, Author: "Jane Austen", TenantID: 1}
+	otherTenant := models.Book{ID: 3, Author: "Frank Herbert", TenantID: 2}
+
+	byAuthor, err := broker.Subscribe(stream.Filter{TenantID: 1, Author: "frank herbert"}, 0)
+	require.NoError(t, err)
+	byColor, err := broker.Subscribe(stream.Filter{Color: models.Red}, 0)
+	require.NoError(t, err)
+	deletes, err := broker.Subscribe(stream.Filter{Types: []events.Type{events.BookDeleted}}, 0)
+	require.NoError(t, err)
+
+	publish(t, broker,
+		bookEvent(1, events.BookCreated, herbert),
+		bookEvent(2, events.BookCreated, austen),
+		bookEvent(3, events.BookCreated, otherTenant),
+		bookEvent(4, events.BookDeleted, austen),
+	)
+
+	assert.Equal(t, []uint{1}, receive(t, byAuthor))
+	assert.Equal(t, []uint{1}, receive(t, byColor))
+	assert.Equal(t, []uint{4}, receive(t, deletes))
+}
+
+func TestBroker_ResumesFromBuffer(t *testing.T) {
+	config := stream.DefaultConfig()
+	config.BufferSize = 3
+	broker := stream.NewBroker(config)
+	book := models.Book{ID: 1, TenantID: 1}
+	for id := uint(1); id <= 5; id++ {
+		publish(t, broker, bookEvent(id, events.BookUpdated, book))
+	}
+	// The dispatcher may deliver an event again
+	publish(t, broker, bookEvent(5, events.BookUpdated, book))
+
+	resumed, err := broker.Subscribe(stream.Filter{}, 3)
+	require.NoError(t, err)
+	assert.False(t, resumed.Reset)
+	require.Len(t, resumed.Replay, 2)
+	assert.Equal(t, uint(4), resumed.Replay[0].ID)
+	assert.Equal(t, uint(5), resumed.Replay[1].ID)
+
+	// Event 1 has left the buffer, so events since then may be missing
+	tooOld, err := broker.Subscribe(stream.Filter{}, 1)
+	require.NoError(t, err)
+	assert.True(t, tooOld.Reset)
+	assert.Empty(t, tooOld.Replay)
+
+	publish(t, broker, bookEvent(6, events.BookUpdated, book))
+	assert.Equal(t, []uint{6}, receive(t, resumed))
+}
+
+func TestBroker_DisconnectsSlowClients(t *testing.T) {
+	config := stream.DefaultConfig()
+	config.ClientBuffer = 2
+	broker := stream.NewBroker(config)
+	book := models.Book{ID: 1, TenantID: 1}
+
+	slow, err := broker.Subscribe(stream.Filter{}, 0)
+	require.NoError(t, err)
+	for id := uint(1); id <= 3; id++ {
+		publish(t, broker, bookEvent(id, events.BookUpdated, book))
+	}
+
+	// The queued events are still delivered before the end
+	assert.Equal(t, []uint{1, 2}, receive(t, slow))
+	assert.ErrorIs(t, slow.Err(), stream.ErrLagged)
+	assert.Zero(t, broker.Clients())
+
+	// It resumes after the last event it received
+	resumed, err := broker.Subscribe(stream.Filter{}, 2)
+	require.NoError(t, err)
+	require.Len(t, resumed.Replay, 1)
+	assert.Equal(t, uint(3), resumed.Replay[0].ID)
+}
+
+func TestBroker_Close(t *testing.T) {
+	broker := stream.NewBroker(stream.DefaultConfig())
+	subscription, err := broker.Subscribe(stream.Filter{}, 0)
+	require.NoError(t, err)
+
+	broker.Close()
+	_, open := <-subscription.Events()
+	assert.False(t, open)
+	assert.ErrorIs(t, subscription.Err(), stream.ErrClosed)
+
+	_, err = broker.Subscribe(stream.Filter{}, 0)
+	assert.ErrorIs(t, err, stream.ErrClosed)
+}
+
+func TestLoadConfig(t *testing.T) {
+	lookup := func(values map[string]string) func(string) (string, bool) {
+		return func(name string) (string, bool) {
+			value, ok := values[name]
+			return value, ok
+		}
+	}
+
+	config, err := stream.LoadConfig(lookup(map[string]string{"STREAM_BUFFER_SIZE": "50", "STREAM_HEARTBEAT": "5s"}))
+	require.NoError(t, err)
+	assert.Equal(t, 50, config.BufferSize)
+	assert.Equal(t, 64, config.ClientBuffer)
+	assert.Equal(t, 5*time.Second, config.Heartbeat)
+
+	_, err = stream.LoadConfig(lookup(map[string]string{"STREAM_CLIENT_BUFFER": "none"}))
+	assert.EqualError(t, err, "STREAM_CLIENT_BUFFER: must be a positive number")
+}
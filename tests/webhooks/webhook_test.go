@@ -0,0 +1,236 @@
+package webhooks_test
+
+import (
+	"books-api/app/events"
+	"books-api/app/migrations"
+	"books-api/app/models"
+	"books-api/app/repository"
+	"books-api/app/webhook"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, migrations.NewMigrationManager(slog.Default()).RunMigrations(db))
+	return db
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	signedAt := time.Unix(1760000000, 0)
+	signature := webhook.Sign("whsec_test", signedAt, body)
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", signature)
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+
+	assert.NoError(t, webhook.Verify("whsec_test", timestamp, signature, body, signedAt.Add(time.Minute), 5*time.Minute))
+	assert.EqualError(t, webhook.Verify("whsec_other", timestamp, signature, body, signedAt, 5*time.Minute), "signature mismatch")
+	assert.EqualError(t, webhook.Verify("whsec_test", timestamp, signature, []byte(`{"id":2}`), signedAt, 5*time.Minute), "signature mismatch")
+	assert.EqualError(t, webhook.Verify("whsec_test", timestamp, signature, body, signedAt.Add(time.Hour), 5*time.Minute), "timestamp outside tolerance")
+	// The timestamp is part of what is signed
+	assert.EqualError(t, webhook.Verify("whsec_test", strconv.FormatInt(signedAt.Unix()+1, 10), signature, body, signedAt, 5*time.Minute), "signature mismatch")
+}
+
+func TestSink_QueuesMatchingWebhooksOnce(t *testing.T) {
+	db := setupTestDB(t)
+	webhooks := repository.NewWebhookRepository(db)
+	deliveries := repository.NewWebhookDeliveryRepository(db)
+	ctx := context.Background()
+
+	disabledAt := time.Now()
+	all := &models.Webhook{URL: "https://a.example", Secret: "secret", TenantID: 1}
+	deletesOnly := &models.Webhook{URL: "https://b.example", Events: []string{"book.deleted"}, Secret: "secret", TenantID: 1}
+	disabled := &models.Webhook{URL: "https://c.example", Secret: "secret", TenantID: 1, DisabledAt: &disabledAt}
+	otherTenant := &models.Webhook{URL: "https://d.example", Secret: "secret", TenantID: 2}
+	for _, hook := range []*models.Webhook{all, deletesOnly, disabled, otherTenant} {
+		require.NoError(t, webhooks.Create(ctx, hook))
+	}
+
+	sink := webhook.NewSink(webhooks, deliveries)
+	event := events.NewBookCreated(&models.Book{ID: 7, Title: "Dune", TenantID: 1})
+	event.ID = 42
+	require.NoError(t, sink.Deliver(ctx, event))
+	// The dispatcher may hand over an event again
+	require.NoError(t, sink.Deliver(ctx, event))
+
+	var queued []models.WebhookDelivery
+	require.NoError(t, db.Find(&queued).Error)
+	require.Len(t, queued, 1)
+	assert.Equal(t, all.ID, queued[0].WebhookID)
+	assert.Equal(t, uint(42), queued[0].EventID)
+	assert.Equal(t, "book.created", queued[0].EventType)
+	assert.Contains(t, queued[0].Payload, `"title":"Dune"`)
+}
+
+func TestDeliverer_RetriesWithBackoff(t *testing.T) {
+	db := setupTestDB(t)
+	webhooks := repository.NewWebhookRepository(db)
+	deliveries := repository.NewWebhookDeliveryRepository(db)
+	ctx := context.Background()
+
+	attempts := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	hook := &models.Webhook{URL: receiver.URL, Secret: "secret", TenantID: 1}
+	require.NoError(t, webhooks.Create(ctx, hook))
+	require.NoError(t, deliveries.CreateMissing(ctx, []models.WebhookDelivery{
+		{WebhookID: hook.ID, EventID: 1, EventType: "book.created", Payload: "{}", NextAttemptAt: time.Now(), TenantID: 1},
+		{WebhookID: hook.ID, EventID: 2, EventType: "book.updated", Payload: "{}", NextAttemptAt: time.Now(), TenantID: 1},
+	}))
+
+	config := webhook.DefaultConfig()
+	config.AllowPrivateTargets = true
+	deliverer := webhook.NewDeliverer(webhooks, deliveries, config, slog.Default())
+
+	succeeded, err := deliverer.Deliver(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, succeeded)
+	// The second delivery waits for the next poll after the first failed
+	assert.Equal(t, 1, attempts)
+
+	first, err := deliveries.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryPending, first.Status)
+	assert.Equal(t, 1, first.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, first.ResponseStatus)
+	assert.WithinDuration(t, time.Now().Add(config.RetryBase), first.NextAttemptAt, 5*time.Second)
+
+	stored, err := webhooks.GetByID(ctx, hook.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.IsActive())
+}
+
+func TestDeliverer_RefusesInternalTargets(t *testing.T) {
+	db := setupTestDB(t)
+	webhooks := repository.NewWebhookRepository(db)
+	deliveries := repository.NewWebhookDeliveryRepository(db)
+	ctx := context.Background()
+
+	called := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	hook := &models.Webhook{URL: receiver.URL + "/hooks/T0-token", Secret: "secret", TenantID: 1}
+	require.NoError(t, webhooks.Create(ctx, hook))
+	require.NoError(t, deliveries.CreateMissing(ctx, []models.WebhookDelivery{
+		{WebhookID: hook.ID, EventID: 1, EventType: "book.created", Payload: "{}", NextAttemptAt: time.Now(), TenantID: 1},
+	}))
+
+	deliverer := webhook.NewDeliverer(webhooks, deliveries, webhook.DefaultConfig(), slog.Default())
+	_, err := deliverer.Deliver(ctx)
+	require.NoError(t, err)
+	assert.False(t, called)
+
+	delivery, err := deliveries.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Contains(t, delivery.Error, "refusing to connect to internal address 127.0.0.1")
+	assert.NotContains(t, delivery.Error, "T0-token")
+}
+
+func TestDeliverer_DisableKeepsConcurrentChanges(t *testing.T) {
+	db := setupTestDB(t)
+	webhooks := repository.NewWebhookRepository(db)
+	deliveries := repository.NewWebhookDeliveryRepository(db)
+	ctx := context.Background()
+
+	var hook *models.Webhook
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The webhook is edited while its last attempt is in flight
+		edited := *hook
+		edited.Events = []string{"book.deleted"}
+		require.NoError(t, webhooks.Update(ctx, &edited))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	hook = &models.Webhook{URL: receiver.URL, Secret: "secret", TenantID: 1}
+	require.NoError(t, webhooks.Create(ctx, hook))
+	require.NoError(t, deliveries.CreateMissing(ctx, []models.WebhookDelivery{
+		{WebhookID: hook.ID, EventID: 1, EventType: "book.created", Payload: "{}", NextAttemptAt: time.Now(), TenantID: 1},
+	}))
+
+	config := webhook.DefaultConfig()
+	config.AllowPrivateTargets = true
+	config.MaxAttempts = 1
+	_, err := webhook.NewDeliverer(webhooks, deliveries, config, slog.Default()).Deliver(ctx)
+	require.NoError(t, err)
+
+	stored, err := webhooks.GetByID(ctx, hook.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.IsActive())
+	assert.Contains(t, stored.DisabledReason, "delivery 1 failed 1 times")
+	assert.Equal(t, []string{"book.deleted"}, stored.Events)
+}
+
+func TestDeliverer_DeletedWebhookStaysDeleted(t *testing.T) {
+	db := setupTestDB(t)
+	webhooks := repository.NewWebhookRepository(db)
+	deliveries := repository.NewWebhookDeliveryRepository(db)
+	ctx := context.Background()
+
+	var hook *models.Webhook
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The webhook is deleted while its last attempt is in flight
+		require.NoError(t, webhooks.Delete(ctx, hook.ID))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	hook = &models.Webhook{URL: receiver.URL, Secret: "secret", TenantID: 1}
+	require.NoError(t, webhooks.Create(ctx, hook))
+	require.NoError(t, deliveries.CreateMissing(ctx, []models.WebhookDelivery{
+		{WebhookID: hook.ID, EventID: 1, EventType: "book.created", Payload: "{}", NextAttemptAt: time.Now(), TenantID: 1},
+	}))
+
+	config := webhook.DefaultConfig()
+	config.AllowPrivateTargets = true
+	config.MaxAttempts = 1
+	_, err := webhook.NewDeliverer(webhooks, deliveries, config, slog.Default()).Deliver(ctx)
+	require.NoError(t, err)
+
+	var webhookCount, deliveryCount int64
+	require.NoError(t, db.Model(&models.Webhook{}).Count(&webhookCount).Error)
+	require.NoError(t, db.Model(&models.WebhookDelivery{}).Count(&deliveryCount).Error)
+	assert.Zero(t, webhookCount)
+	assert.Zero(t, deliveryCount)
+}
+
+func TestLoadConfig(t *testing.T) {
+	lookup := func(values map[string]string) func(string) (string, bool) {
+		return func(name string) (string, bool) {
+			value, ok := values[name]
+			return value, ok
+		}
+	}
+
+	config, err := webhook.LoadConfig(lookup(map[string]string{
+		"WEBHOOK_MAX_ATTEMPTS":          "3",
+		"WEBHOOK_TIMEOUT":               "2s",
+		"WEBHOOK_ALLOW_PRIVATE_TARGETS": "true",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 3, config.MaxAttempts)
+	assert.Equal(t, 2*time.Second, config.Timeout)
+	assert.True(t, config.AllowPrivateTargets)
+
+	_, err = webhook.LoadConfig(lookup(map[string]string{"WEBHOOK_MAX_ATTEMPTS": "0"}))
+	assert.EqualError(t, err, "WEBHOOK_MAX_ATTEMPTS: must be a positive number")
+}